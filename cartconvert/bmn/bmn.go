@@ -8,7 +8,8 @@
 // and here specifically of the Bundesmeldenetz, the former federal cartographic datum
 // of Austria. The Bundesmeldenetz is already widely replaced by UTM coordinates but much legacy
 // data is still encoded in BMN coordinates. Unlike UTM, the BMN uses the Bessel reference ellipsoid
-// and uses lat0 at Hierro (canary islands), which makes transformations tedious.
+// and counts longitudes from the prime meridian of Ferro (Hierro, canary islands), which makes
+// transformations tedious.
 // For more information see
 //
 // [DE]: http://www.topsoft.at/pstrainer/entwicklung/algorithm/karto/oek/austria_oek.htm#bmn
@@ -22,6 +23,48 @@ import (
 	"strings"
 )
 
+// Grid shift from MGI to WGS84, eg. the NTv2 file AT_GIS_GRID.gsb published by the BEV. If set, BMN
+// coordinates are transformed by the grid instead of by the transformation of cartconvert.MGIDatum.
+// Create it by NewGridShift to fall back to that transformation outside the grid.
+var DefaultGridShift *cartconvert.GridShift
+
+// Returns the grid shift of the NTv2 grid from MGI to WGS84, which falls back to the transformation
+// of cartconvert.MGIDatum for coordinates outside the grid
+func NewGridShift(grid *cartconvert.NTv2) *cartconvert.GridShift {
+	return cartconvert.NewGridShift(grid, cartconvert.MGIDatum.ToWGS84)
+}
+
+// Transform gc of the MGI datum into WGS84 by DefaultGridShift if set, otherwise by the transformation of
+// cartconvert.MGIDatum, which is reported as cartconvert.ShiftFallback
+func mgiToWGS84(gc *cartconvert.PolarCoord) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, error) {
+	if DefaultGridShift == nil {
+		pc, err := cartconvert.TransformDatum(gc, cartconvert.MGIDatum, cartconvert.WGS84Datum)
+		return pc, cartconvert.ShiftFallback, err
+	}
+
+	pc, method, err := DefaultGridShift.Shift(gc)
+	if err != nil {
+		return nil, method, err
+	}
+	pc.El, pc.Datum = cartconvert.WGS84Ellipsoid, cartconvert.WGS84Datum
+	return pc, method, nil
+}
+
+// Transform gc of WGS84 into the MGI datum, the inverse of mgiToWGS84
+func wgs84ToMGI(gc *cartconvert.PolarCoord) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, error) {
+	if DefaultGridShift == nil {
+		pc, err := cartconvert.TransformDatum(gc, cartconvert.WGS84Datum, cartconvert.MGIDatum)
+		return pc, cartconvert.ShiftFallback, err
+	}
+
+	pc, method, err := DefaultGridShift.InverseShift(gc)
+	if err != nil {
+		return nil, method, err
+	}
+	pc.El, pc.Datum = cartconvert.Bessel1841MGIEllipsoid, cartconvert.MGIDatum
+	return pc, method, nil
+}
+
 // Meridian Coordinates of the Bundesmeldenetz, three values describing false easting and false northing.
 // The meridian specification of BMN plays the same role as the zone specifier of UTM.
 type BMNMeridian byte
@@ -49,12 +92,53 @@ func (bm BMNMeridian) String() (rep string) {
 	return
 }
 
+// Longitude of the central meridian east of Ferro and the false easting of the meridian stripe.
+// Returns cartconvert.ErrRange if the meridian stripe is not set.
+func (bm BMNMeridian) ferroParameters() (ferro, fe float64, err error) {
+	switch bm {
+	case BMNM28:
+		return 28, 150000, nil
+	case BMNM31:
+		return 31, 450000, nil
+	case BMNM34:
+		return 34, 750000, nil
+	}
+	return 0, 0, cartconvert.ErrRange
+}
+
+// Longitude of the central meridian east of Greenwich and the false easting of the meridian stripe.
+// Returns cartconvert.ErrRange if the meridian stripe is not set.
+func (bm BMNMeridian) parameters() (long0, fe float64, err error) {
+	ferro, fe, err := bm.ferroParameters()
+	if err != nil {
+		return 0, 0, err
+	}
+	return cartconvert.ConvertPrimeMeridian(ferro, cartconvert.FerroMeridian, cartconvert.GreenwichMeridian), fe, nil
+}
+
+// The coordinate reference system of the meridian stripe on the datum MGI, whose longitudes refer to
+// Greenwich, as EPSG publishes MGI / M28, MGI / M31 and MGI / M34 with the central meridians 10°20', 13°20'
+// and 16°20' east of Greenwich. Returns cartconvert.ErrRange if the meridian stripe is not set.
+func (bm BMNMeridian) CRS() (*cartconvert.CRS, error) {
+	long0, fe, err := bm.parameters()
+	if err != nil {
+		return nil, err
+	}
+	return &cartconvert.CRS{
+		Name:       cartconvert.MGIDatum.Name + " / " + bm.String(),
+		Datum:      cartconvert.MGIDatum,
+		Projection: &cartconvert.Projection{Method: cartconvert.TransverseMercator, LongO: long0, Scale: 1, FE: fe, FN: -5000000},
+	}, nil
+}
+
 // A BMN coordinate is specified by right-value (easting), height-value (northing)
-// and the meridian stripe, 28°, 31° or 34° West of Hierro
+// and the meridian stripe, 28°, 31° or 34° east of Ferro (Hierro). RelHeight is the ellipsoidal height above the
+// Bessel ellipsoid of the MGI datum.
 type BMNCoord struct {
 	Right, Height, RelHeight float64
 	Meridian                 BMNMeridian
 	El                       *cartconvert.Ellipsoid
+	Datum                    *cartconvert.Datum
 }
 
 // Canonical representation of a BMN-value
@@ -86,7 +170,7 @@ func (bc *BMNCoord) String() (fs string) {
 }
 
 // Parses a string representation of a BMN-Coordinate into a struct holding a BMN coordinate value.
-// The reference ellipsoid of BMN coordinates is always the Bessel ellipsoid.
+// The reference ellipsoid of BMN coordinates is always the Bessel ellipsoid, the datum MGI.
 func ABMNToStruct(bmncoord string) (*BMNCoord, error) {
 
 	compact := strings.ToUpper(strings.TrimSpace(bmncoord))
@@ -132,7 +216,7 @@ L1:
 			height, err = strconv.ParseFloat(heights, 64)
 			if err == nil {
 
-				return &BMNCoord{Right: right, Height: height, Meridian: meridian, El: cartconvert.Bessel1841MGIEllipsoid}, nil
+				return &BMNCoord{Right: right, Height: height, Meridian: meridian, El: cartconvert.Bessel1841MGIEllipsoid, Datum: cartconvert.MGIDatum}, nil
 			}
 		}
 	}
@@ -141,82 +225,97 @@ L1:
 }
 
 // Transform a BMN coordinate value to a WGS84 based latitude and longitude coordinate. Function returns
-// cartconvert.ErrRange, if the meridian stripe of the bmn-coordinate is not set
+// cartconvert.ErrRange, if the meridian stripe of the bmn-coordinate is not set or if the coordinate
+// lies outside the grid of DefaultGridShift, which has no fallback transformation
 func BMNToWGS84LatLong(bmncoord *BMNCoord) (*cartconvert.PolarCoord, error) {
+	pc, _, err := BMNToWGS84LatLongShift(bmncoord)
+	return pc, err
+}
 
-	var long0, fe float64
+// Like BMNToWGS84LatLong, but additionally reports whether the coordinate has been shifted by the grid of
+// DefaultGridShift or by the fallback transformation, which is the transformation of cartconvert.MGIDatum
+// if DefaultGridShift is not set.
+func BMNToWGS84LatLongShift(bmncoord *BMNCoord) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, error) {
+	return bmnToWGS84LatLong(bmncoord, cartconvert.DefaultTMAlgorithm)
+}
 
-	switch bmncoord.Meridian {
-	case BMNM28:
-		long0 = 10.0 + 20.0/60.0
-		fe = 150000
-	case BMNM31:
-		long0 = 13.0 + 20.0/60.0
-		fe = 450000
-	case BMNM34:
-		long0 = 16.0 + 20.0/60.0
-		fe = 750000
-	default:
-		return nil, cartconvert.ErrRange
+// Like BMNToWGS84LatLong, but projects by the transverse mercator algorithm alg.
+func BMNToWGS84LatLongAlgorithm(bmncoord *BMNCoord, alg cartconvert.TMAlgorithm) (*cartconvert.PolarCoord, error) {
+	pc, _, err := bmnToWGS84LatLong(bmncoord, alg)
+	return pc, err
+}
+
+func bmnToWGS84LatLong(bmncoord *BMNCoord, alg cartconvert.TMAlgorithm) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, error) {
+
+	long0, fe, err := bmncoord.Meridian.parameters()
+	if err != nil {
+		return nil, cartconvert.ShiftFallback, err
 	}
 
-	gc := cartconvert.InverseTransverseMercator(
-		&cartconvert.GeoPoint{Y: bmncoord.Height, X: bmncoord.Right, El: bmncoord.El},
+	gc := alg.InverseTransverseMercator(
+		&cartconvert.GeoPoint{Y: bmncoord.Height, X: bmncoord.Right, H: bmncoord.RelHeight, El: bmncoord.El},
 		0,
 		long0,
 		1,
 		fe,
 		-5000000)
 
-	cart := cartconvert.PolarToCartesian(gc)
-	pt := cartconvert.HelmertWGS84ToMGI.InverseTransform(&cartconvert.Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
-
-	return cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.WGS84Ellipsoid}), nil
+	return mgiToWGS84(gc)
 }
 
 // Transform a latitude / longitude coordinate datum into a BMN coordinate. Function returns
-// cartconvert.ErrRange, if the meridian stripe of the bmn-coordinate is not set.
+// cartconvert.ErrRange, if the meridian stripe of the bmn-coordinate is not set or if the coordinate
+// lies outside the grid of DefaultGridShift, which has no fallback transformation.
 //
 // Important: The reference ellipsoid of the originating coordinate system will be assumed
 // to be the WGS84Ellipsoid and will be set thereupon, regardless of the actually set reference ellipsoid.
 func WGS84LatLongToBMN(gc *cartconvert.PolarCoord, meridian BMNMeridian) (*BMNCoord, error) {
+	bmncoord, _, err := WGS84LatLongToBMNShift(gc, meridian)
+	return bmncoord, err
+}
+
+// Like WGS84LatLongToBMN, but additionally reports whether the coordinate has been shifted by the grid of
+// DefaultGridShift or by the fallback transformation, which is the transformation of cartconvert.MGIDatum
+// if DefaultGridShift is not set.
+func WGS84LatLongToBMNShift(gc *cartconvert.PolarCoord, meridian BMNMeridian) (*BMNCoord, cartconvert.ShiftMethod, error) {
+	return wgs84LatLongToBMN(gc, meridian, cartconvert.DefaultTMAlgorithm)
+}
+
+// Like WGS84LatLongToBMN, but projects by the transverse mercator algorithm alg.
+func WGS84LatLongToBMNAlgorithm(gc *cartconvert.PolarCoord, meridian BMNMeridian, alg cartconvert.TMAlgorithm) (*BMNCoord, error) {
+	bmncoord, _, err := wgs84LatLongToBMN(gc, meridian, alg)
+	return bmncoord, err
+}
 
-	var long0, fe float64
+func wgs84LatLongToBMN(gc *cartconvert.PolarCoord, meridian BMNMeridian, alg cartconvert.TMAlgorithm) (*BMNCoord, cartconvert.ShiftMethod, error) {
 
 	// This sets the Ellipsoid to WGS84, regardless of the actual value set
 	gc.El = cartconvert.WGS84Ellipsoid
 
-	cart := cartconvert.PolarToCartesian(gc)
-	pt := cartconvert.HelmertWGS84ToMGI.Transform(&cartconvert.Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
-	polar := cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.Bessel1841MGIEllipsoid})
+	polar, method, err := wgs84ToMGI(gc)
+	if err != nil {
+		return nil, method, err
+	}
 
-	// Determine meridian stripe based on longitude
+	// Determine meridian stripe based on longitude, the stripes extending 1°30' to either side
 	if meridian == BMNZoneDet {
+		ferro := cartconvert.ConvertPrimeMeridian(polar.Longitude, cartconvert.GreenwichMeridian, cartconvert.FerroMeridian)
 		switch {
-		case 11.0+0.5/6*10 >= polar.Longitude && polar.Longitude >= 8.0+0.5/6*10:
+		case 29.5 >= ferro && ferro >= 26.5:
 			meridian = BMNM28
-		case 14.0+0.5/6*10 >= polar.Longitude && polar.Longitude >= 11.0+0.5/6*10:
+		case 32.5 >= ferro && ferro >= 29.5:
 			meridian = BMNM31
-		case 17.0+0.5/6*10 >= polar.Longitude && polar.Longitude >= 14.0+0.5/6*10:
+		case 35.5 >= ferro && ferro >= 32.5:
 			meridian = BMNM34
 		}
 	}
 
-	switch meridian {
-	case BMNM28:
-		long0 = 10.0 + 20.0/60.0
-		fe = 150000
-	case BMNM31:
-		long0 = 13.0 + 20.0/60.0
-		fe = 450000
-	case BMNM34:
-		long0 = 16.0 + 20.0/60.0
-		fe = 750000
-	default:
-		return nil, cartconvert.ErrRange
+	long0, fe, err := meridian.parameters()
+	if err != nil {
+		return nil, method, err
 	}
 
-	gp := cartconvert.DirectTransverseMercator(
+	gp := alg.DirectTransverseMercator(
 		polar,
 		0,
 		long0,
@@ -224,9 +323,50 @@ func WGS84LatLongToBMN(gc *cartconvert.PolarCoord, meridian BMNMeridian) (*BMNCo
 		fe,
 		-5000000)
 
-	return &BMNCoord{Meridian: meridian, Height: gp.Y, Right: gp.X, El: gp.El}, nil
+	return &BMNCoord{Meridian: meridian, Height: gp.Y, Right: gp.X, RelHeight: gp.H, El: gp.El, Datum: cartconvert.MGIDatum}, method, nil
 }
 
 func NewBMNCoord(Meridian BMNMeridian, Right, Height, RelHeight float64) *BMNCoord {
-	return &BMNCoord{Right: Right, Height: Height, RelHeight: RelHeight, Meridian: Meridian, El: cartconvert.Bessel1841MGIEllipsoid}
+	return &BMNCoord{Right: Right, Height: Height, RelHeight: RelHeight, Meridian: Meridian, El: cartconvert.Bessel1841MGIEllipsoid, Datum: cartconvert.MGIDatum}
+}
+
+// The Bundesmeldenetz as a cartconvert.CoordinateSystem
+type bmnSystem struct{}
+
+func (bmnSystem) Name() string        { return "bmn" }
+func (bmnSystem) Description() string { return "AT:Bundesmeldenetz" }
+
+func (bmnSystem) Parse(coord string) (fmt.Stringer, error) {
+	return ABMNToStruct(coord)
+}
+
+func (bmnSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*BMNCoord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (bmnSystem) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	bmncoord, ok := coord.(*BMNCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return BMNToWGS84LatLong(bmncoord)
+}
+
+func (bmnSystem) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return WGS84LatLongToBMN(pc, BMNZoneDet)
+}
+
+func (bmnSystem) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	bmncoord, ok := coord.(*BMNCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return bmncoord.Meridian.CRS()
+}
+
+func init() {
+	cartconvert.RegisterCoordinateSystem(bmnSystem{})
 }
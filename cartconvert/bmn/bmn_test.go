@@ -8,6 +8,8 @@ package bmn
 import (
 	"fmt"
 	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -56,18 +58,16 @@ func bMNStringToStructHelper(coord string) (bmncoord *BMNCoord) {
 	return
 }
 
+// The fundamental point of MGI on the Hermannskogel, 48°16'15.29" N 33°57'41.06" E of Ferro, projected
+// to M34 and transformed into WGS84 by EPSG:1618
 var bMNToWGS84LatLongTests = []bMNToWGS84LatLongTest{
 	{
-		NewBMNCoord(BMNM28, 592270.0, 272290, 0),
-		&cartconvert.PolarCoord{Latitude: 47.439212, Longitude: 16.197434},
-	},
-	{ // TODO: Ist das möglich??
-		bMNStringToStructHelper("M34 592269 272290"),
-		&cartconvert.PolarCoord{Latitude: 47.570299, Longitude: 14.236188},
+		NewBMNCoord(BMNM34, 747135.34, 348006.28, 0),
+		&cartconvert.PolarCoord{Latitude: 48.270403, Longitude: 16.293540},
 	},
 	{
-		bMNStringToStructHelper("M34 703168 374510"),
-		&cartconvert.PolarCoord{Latitude: 48.507001, Longitude: 15.698748},
+		bMNStringToStructHelper("M34 747135 348006"),
+		&cartconvert.PolarCoord{Latitude: 48.270403, Longitude: 16.293540},
 	},
 }
 
@@ -86,6 +86,12 @@ func TestBMNToWGS84LatLong(t *testing.T) {
 		if !latlongequal(test.out, out) {
 			t.Error("BMNToWGS84LatLong")
 		}
+
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			if out, err := BMNToWGS84LatLongAlgorithm(test.in, alg); err != nil || !latlongequal(test.out, out) {
+				t.Errorf("BMNToWGS84LatLongAlgorithm [%s]: expected %s, got %v (%v)", alg, test.out, out, err)
+			}
+		}
 	}
 }
 
@@ -103,15 +109,15 @@ type wGS84LatLongToBMNTest struct {
 var wGS84LatLongToBMNTests = []wGS84LatLongToBMNTest{
 	{
 		wGS84LatLongToBMNParam{
-			gc:       &cartconvert.PolarCoord{Latitude: 47.570299, Longitude: 14.236188, El: cartconvert.WGS84Ellipsoid},
+			gc:       &cartconvert.PolarCoord{Latitude: 48.270403, Longitude: 16.293540, El: cartconvert.WGS84Ellipsoid},
 			meridian: BMNM34},
-		NewBMNCoord(BMNM34, 592269, 272290.05, 0),
+		NewBMNCoord(BMNM34, 747135, 348006, 0),
 	},
 	{
 		wGS84LatLongToBMNParam{
-			gc:       &cartconvert.PolarCoord{Latitude: 48.507001, Longitude: 15.698748, El: cartconvert.WGS84Ellipsoid},
+			gc:       &cartconvert.PolarCoord{Latitude: 48.270403, Longitude: 16.293540, El: cartconvert.WGS84Ellipsoid},
 			meridian: BMNZoneDet},
-		NewBMNCoord(BMNM34, 703168, 374510, 0),
+		NewBMNCoord(BMNM34, 747135, 348006, 0),
 	},
 }
 
@@ -121,5 +127,166 @@ func TestWGS84LatLongToBMN(t *testing.T) {
 		if !bmnequal(test.out, out) {
 			t.Errorf("WGS84LatLongToBMN [%d]: expected %s, got %s", index, test.out, out)
 		}
+
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			if out, err := WGS84LatLongToBMNAlgorithm(test.in.gc, test.in.meridian, alg); err != nil || !bmnequal(test.out, out) {
+				t.Errorf("WGS84LatLongToBMNAlgorithm [%d, %s]: expected %s, got %v (%v)", index, alg, test.out, out, err)
+			}
+		}
+	}
+}
+
+// ## Datum
+func TestBMNDatum(t *testing.T) {
+	in := NewBMNCoord(BMNM34, 592270, 272290, 0)
+	if in.Datum != cartconvert.MGIDatum {
+		t.Errorf("NewBMNCoord: expected the datum %s, got %s", cartconvert.MGIDatum, in.Datum)
+	}
+
+	out, err := BMNToWGS84LatLong(in)
+	if err != nil || out.Datum != cartconvert.WGS84Datum {
+		t.Errorf("BMNToWGS84LatLong: expected the datum %s, got %v (%v)", cartconvert.WGS84Datum, out, err)
+	}
+
+	// the generic datum transformation yields the same coordinate as the BMN conversion
+	gc := cartconvert.DefaultTMAlgorithm.InverseTransverseMercator(
+		&cartconvert.GeoPoint{X: in.Right, Y: in.Height, El: cartconvert.Bessel1841MGIEllipsoid},
+		0, 16.0+20.0/60.0, 1, 750000, -5000000)
+	if expected, err := cartconvert.TransformDatum(gc, cartconvert.MGIDatum, cartconvert.WGS84Datum); err != nil || !latlongequal(expected, out) {
+		t.Errorf("TransformDatum: expected %s, got %s (%v)", out, expected, err)
+	}
+
+	bmncoord, err := WGS84LatLongToBMN(out, BMNM34)
+	if err != nil || bmncoord.Datum != cartconvert.MGIDatum || !bmnequal(in, bmncoord) {
+		t.Errorf("WGS84LatLongToBMN: expected %s in %s, got %v (%v)", in, cartconvert.MGIDatum, bmncoord, err)
+	}
+}
+
+// ## Coordinate system registry
+func TestBMNCoordinateSystem(t *testing.T) {
+	cs, ok := cartconvert.LookupCoordinateSystem("bmn")
+	if !ok {
+		t.Fatal("BMNCoordinateSystem: bmn is not registered")
+	}
+
+	coord, err := cs.Parse("M34 747135 348006")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := cs.ToLatLong(coord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := bMNToWGS84LatLongTests[1].out; !latlongequal(expected, out) {
+		t.Errorf("BMNCoordinateSystem: expected %s, got %s", expected, out)
+	}
+}
+
+// ## DefaultGridShift
+func TestDefaultGridShift(t *testing.T) {
+
+	// a grid without shift from 47°N to 48°N and 15°E to 16°E
+	grid, err := cartconvert.LoadNTv2File("testdata/mgi.gsb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	DefaultGridShift = NewGridShift(grid)
+	defer func() { DefaultGridShift = nil }()
+
+	// outside the grid, transformed by the fallback helmert parameters
+	for index, test := range bMNToWGS84LatLongTests {
+		out, method, err := BMNToWGS84LatLongShift(test.in)
+		if err != nil || method != cartconvert.ShiftFallback || !latlongequal(test.out, out) {
+			t.Errorf("BMNToWGS84LatLongShift [%d]: expected %s by %s, got %s by %s (%v)", index, test.out, cartconvert.ShiftFallback, out, method, err)
+		}
+	}
+	for index, test := range wGS84LatLongToBMNTests {
+		out, method, err := WGS84LatLongToBMNShift(test.in.gc, test.in.meridian)
+		if err != nil || method != cartconvert.ShiftFallback || !bmnequal(test.out, out) {
+			t.Errorf("WGS84LatLongToBMNShift [%d]: expected %s by %s, got %s by %s (%v)", index, test.out, cartconvert.ShiftFallback, out, method, err)
+		}
+	}
+
+	// inside the grid, MGI equals WGS84 latitude and longitude
+	gc := &cartconvert.PolarCoord{Latitude: 47.5, Longitude: 15.5, El: cartconvert.WGS84Ellipsoid}
+	gp := cartconvert.DefaultTMAlgorithm.DirectTransverseMercator(
+		&cartconvert.PolarCoord{Latitude: 47.5, Longitude: 15.5, El: cartconvert.Bessel1841MGIEllipsoid},
+		0, 16.0+20.0/60.0, 1, 750000, -5000000)
+
+	bmncoord, method, err := WGS84LatLongToBMNShift(gc, BMNZoneDet)
+	if err != nil || method != cartconvert.ShiftGrid || bmncoord.Meridian != BMNM34 || math.Abs(bmncoord.Right-gp.X) > 0.001 || math.Abs(bmncoord.Height-gp.Y) > 0.001 {
+		t.Errorf("WGS84LatLongToBMNShift: expected M34 %f %f by %s, got %s by %s (%v)", gp.X, gp.Y, cartconvert.ShiftGrid, bmncoord, method, err)
+	}
+
+	out, method, err := BMNToWGS84LatLongShift(bmncoord)
+	if err != nil || method != cartconvert.ShiftGrid || math.Abs(out.Latitude-47.5) > 1e-8 || math.Abs(out.Longitude-15.5) > 1e-8 || out.El != cartconvert.WGS84Ellipsoid || out.Datum != cartconvert.WGS84Datum {
+		t.Errorf("BMNToWGS84LatLongShift: expected %s by %s, got %s by %s (%v)", gc, cartconvert.ShiftGrid, out, method, err)
+	}
+
+	// no fallback outside the grid
+	DefaultGridShift = cartconvert.NewGridShift(grid, nil)
+
+	if _, err := BMNToWGS84LatLong(bMNToWGS84LatLongTests[0].in); err != cartconvert.ErrRange {
+		t.Errorf("BMNToWGS84LatLong: expected %s outside the grid, got %v", cartconvert.ErrRange, err)
+	}
+	if _, err := WGS84LatLongToBMN(wGS84LatLongToBMNTests[0].in.gc, BMNM34); err != cartconvert.ErrRange {
+		t.Errorf("WGS84LatLongToBMN: expected %s outside the grid, got %v", cartconvert.ErrRange, err)
+	}
+
+	// without grid, the transformation of MGI counts as fallback
+	DefaultGridShift = nil
+
+	if _, method, err := BMNToWGS84LatLongShift(bmncoord); err != nil || method != cartconvert.ShiftFallback {
+		t.Errorf("BMNToWGS84LatLongShift: expected %s, got %s (%v)", cartconvert.ShiftFallback, method, err)
+	}
+}
+
+// ## 3D round trip
+func TestBMNHeight(t *testing.T) {
+
+	in := &cartconvert.PolarCoord{Latitude: 47.570299, Longitude: 14.236188, Height: 512.3, El: cartconvert.WGS84Ellipsoid}
+
+	bmncoord, err := WGS84LatLongToBMN(in, BMNZoneDet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the height above the Bessel ellipsoid differs by the datum shift
+	if math.Abs(bmncoord.RelHeight-in.Height) < 1 {
+		t.Errorf("WGS84LatLongToBMN: Expected the height to be shifted, got %f", bmncoord.RelHeight)
+	}
+
+	out, err := BMNToWGS84LatLong(bmncoord)
+	if err != nil || !latlongequal(in, out) || math.Abs(out.Height-in.Height) > 0.001 {
+		t.Errorf("BMNToWGS84LatLong: Expected %s at %fm, got %s at %fm (%v)", in, in.Height, out, out.Height, err)
+	}
+}
+
+// ## Coordinate reference system
+func TestBMNCRS(t *testing.T) {
+	for index, test := range bMNToWGS84LatLongTests {
+		crs, err := test.in.Meridian.CRS()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the coordinate reference system yields the same coordinate as the BMN conversion
+		out, err := crs.ToWGS84(&cartconvert.GeoPoint{X: test.in.Right, Y: test.in.Height})
+		if err != nil || !latlongequal(test.out, out) {
+			t.Errorf("CRS.ToWGS84 [%d]: expected %s, got %v (%v)", index, test.out, out, err)
+		}
+	}
+
+	crs, _ := BMNM31.CRS()
+	wkt, err := crs.WKT()
+	if err != nil || !strings.HasPrefix(wkt, `PROJCS["MGI / M31",GEOGCS["MGI",`) || !strings.Contains(wkt, `PRIMEM["Greenwich",0]`) || !strings.Contains(wkt, `PARAMETER["central_meridian",13.3333333333333]`) {
+		t.Errorf("CRS.WKT: expected MGI / M31, got %s (%v)", wkt, err)
+	}
+
+	if _, err := BMNZoneDet.CRS(); err != cartconvert.ErrRange {
+		t.Errorf("CRS: expected %s, got %v", cartconvert.ErrRange, err)
 	}
 }
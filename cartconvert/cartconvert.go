@@ -39,7 +39,15 @@ var (
 	GRS80Ellipsoid         = NewEllipsoid(6378137, 6356752.31414, "GRS80")
 	WGS84Ellipsoid         = NewEllipsoid(6378137, 6356752.31425, "WGS84")
 	Airy1830Ellipsoid      = NewEllipsoid(6377563.396, 6356256.909, "Airy1830")
-	DefaultEllipsoid       = WGS84Ellipsoid
+	AiryModifiedEllipsoid  = NewEllipsoid(6377340.189, 6356034.447, "AiryModified")
+	Clarke1866Ellipsoid    = NewEllipsoid(6378206.4, 6356583.8, "Clarke1866")
+	// NTF
+	Clarke1880IGNEllipsoid = NewEllipsoid(6378249.2, 6356515.0, "Clarke1880IGN")
+	// ED50
+	International1924Ellipsoid = NewEllipsoid(6378388, 6356911.946128, "International1924")
+	// Pulkovo 1942, S-42
+	Krassowsky1940Ellipsoid = NewEllipsoid(6378245, 6356863.018773, "Krassowsky1940")
+	DefaultEllipsoid        = WGS84Ellipsoid
 )
 
 type Ellipsoid struct {
@@ -47,10 +55,13 @@ type Ellipsoid struct {
 	CommonName string
 }
 
-// Holds latitude, longitude and ellipsoidal height, relative to El, the reference ellipsoid
+// Holds latitude, longitude and ellipsoidal height, relative to El, the reference ellipsoid.
+// Datum is the geodetic datum of the coordinate, if known; Its ellipsoid equals El. The longitude
+// counts from the prime meridian of the datum, Greenwich if the datum is not known.
 type PolarCoord struct {
 	Latitude, Longitude, Height float64
 	El                          *Ellipsoid
+	Datum                       *Datum
 }
 
 // specifier for the string representation of a polar coordinate
@@ -169,6 +180,42 @@ type CartPoint struct {
 	El      *Ellipsoid
 }
 
+// An area bounded by two parallels and two meridians in decimal degrees, relative to the
+// reference ellipsoid El. If West is larger than East, the area crosses the antimeridian.
+type BoundingBox struct {
+	South, West, North, East float64
+	El                       *Ellipsoid
+}
+
+// Centre of the bounding box
+func (bb *BoundingBox) Center() *PolarCoord {
+	east := bb.East
+	if bb.West > east {
+		east += 360
+	}
+	long := (bb.West + east) / 2
+	if long >= 180 {
+		long -= 360
+	}
+	return &PolarCoord{Latitude: (bb.South + bb.North) / 2, Longitude: long, El: bb.El}
+}
+
+// Reports whether the point pc lies within the bounding box, including its borders
+func (bb *BoundingBox) Contains(pc *PolarCoord) bool {
+	if pc.Latitude < bb.South || pc.Latitude > bb.North {
+		return false
+	}
+	if bb.West > bb.East {
+		return pc.Longitude >= bb.West || pc.Longitude <= bb.East
+	}
+	return pc.Longitude >= bb.West && pc.Longitude <= bb.East
+}
+
+// Canonical representation of a bounding box
+func (bb *BoundingBox) String() string {
+	return fmt.Sprintf("south: %s°, west: %s°, north: %s°, east: %s°", f64toa(bb.South, 6), f64toa(bb.West, 6), f64toa(bb.North, 6), f64toa(bb.East, 6))
+}
+
 func degtorad(deg float64) float64 {
 	return math.Pi * deg / 180
 }
@@ -395,7 +442,59 @@ func ADegMMSSToPolar(Northing, Easting string, Height float64, El *Ellipsoid) (*
 	return nil, err
 }
 
+// The function accepts a longitude literal of the format of ADegMMSSToNum, which may be followed by the name
+// of the prime meridian the longitude counts from, as found on historical maps and surveys. Returns the
+// longitude and the prime meridian, or nil if the literal names none. For the names understood see
+// LookupPrimeMeridian.
+//
+// [E|W|+|-]ddd°[dd'[dd'']] [Greenwich|Ferro|Hierro|Paris]
+func ADegMMSSToLongitude(DegMMSS string) (float64, *PrimeMeridian, error) {
+
+	var pm *PrimeMeridian
+
+	literal := strings.TrimSpace(DegMMSS)
+	if index := strings.LastIndexAny(literal, " \t"); index >= 0 {
+		if meridian, ok := LookupPrimeMeridian(literal[index+1:]); ok {
+			pm = meridian
+			literal = literal[:index]
+		}
+	}
+
+	long, err := ADegMMSSToNum(literal)
+	if err != nil {
+		return 0, nil, err
+	}
+	return long, pm, nil
+}
+
+// Function accepts latitude and longitude as Deg°MM'SS'' and the height at the bearing relative to the datum and
+// returns a polar coordinate of the datum. The longitude may name its prime meridian as accepted by
+// ADegMMSSToLongitude, eg. "E 34°2'30'' Ferro", and is converted to the prime meridian of the datum; Without
+// a name, it counts from the prime meridian of the datum. If the datum is nil, WGS84Datum will be set.
+func ADegMMSSToPolarDatum(Northing, Easting string, Height float64, datum *Datum) (*PolarCoord, error) {
+
+	if datum == nil {
+		datum = WGS84Datum
+	}
+
+	northing, err := ADegMMSSToNum(Northing)
+	if err != nil {
+		return nil, err
+	}
+
+	easting, pm, err := ADegMMSSToLongitude(Easting)
+	if err != nil {
+		return nil, err
+	}
+	if pm != nil {
+		easting = ConvertPrimeMeridian(easting, pm, datum.PrimeMeridian)
+	}
+
+	return &PolarCoord{Latitude: northing, Longitude: easting, Height: Height, El: datum.El, Datum: datum}, nil
+}
+
 // Convert polar coordinates to Cartesian. The polar coordinates must be in decimal degrees.
+// The longitude refers to the prime meridian of gc, the X axis of the result to Greenwich.
 // The reference ellipsoid is copied verbatim to the result.
 // Inspired by http://www.movable-type.co.uk/scripts/latlong-convert-coords.html
 func PolarToCartesian(gc *PolarCoord) *CartPoint {
@@ -405,7 +504,7 @@ func PolarToCartesian(gc *PolarCoord) *CartPoint {
 	el := gc.El
 
 	lat := degtorad(gc.Latitude)
-	long := degtorad(gc.Longitude)
+	long := degtorad(gc.Longitude + gc.PrimeMeridian().Longitude)
 
 	esq := (el.a*el.a - el.b*el.b) / (el.a * el.a)
 
@@ -422,7 +521,7 @@ func PolarToCartesian(gc *PolarCoord) *CartPoint {
 
 // Convert Cartesian coordinates to polar.
 // The reference ellipsoid is copied verbatim to the result.
-// The resulting polar coordinates are in decimal degrees.
+// The resulting polar coordinates are in decimal degrees, the longitude east of Greenwich.
 // Inspired by http://www.movable-type.co.uk/scripts/latlong-convert-coords.html
 func CartesianToPolar(pt *CartPoint) *PolarCoord {
 
@@ -436,7 +535,8 @@ func CartesianToPolar(pt *CartPoint) *PolarCoord {
 	lat := math.Atan2(pt.Z, p*(1-esq))
 	lat0 := 2.0 * math.Pi
 
-	precision := 4.0 / el.a
+	// iterate until the change of latitude is below 0.1mm on the ellipsoid
+	precision := 0.0001 / el.a
 	var v float64
 	for math.Abs(lat-lat0) > precision {
 		v = el.a / math.Sqrt(1-esq*math.Pow(math.Sin(lat), 2))
@@ -469,6 +569,8 @@ func CartesianToPolar(pt *CartPoint) *PolarCoord {
 //
 // Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
 // pp. 48 - 51
+//
+// See DirectTransverseMercatorKrueger for the Krüger n-series to sixth order.
 func DirectTransverseMercator(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
 
 	var pt GeoPoint
@@ -531,6 +633,7 @@ func DirectTransverseMercator(gc *PolarCoord, latO, longO, scale, fe, fn float64
 	pt.Y = fn + scale*(B*xi-SO)
 
 	pt.El = el
+	pt.H = gc.Height
 
 	return &pt
 }
@@ -550,6 +653,8 @@ func DirectTransverseMercator(gc *PolarCoord, latO, longO, scale, fe, fn float64
 // pp. 48 - 51
 //
 // More accurate, iterative but slower algorithmic implementation
+//
+// See InverseTransverseMercatorKrueger for the Krüger n-series to sixth order.
 func InverseTransverseMercator(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
 
 	var gc PolarCoord
@@ -624,6 +729,7 @@ func InverseTransverseMercator(pt *GeoPoint, latO, longO, scale, fe, fn float64)
 	gc.Longitude = radtodeg(longOrad + math.Asin(math.Tanh(eta0i)/math.Cos(bi)))
 
 	gc.El = el
+	gc.Height = pt.H
 
 	return &gc
 }
@@ -700,6 +806,11 @@ L1:
 //
 // Inspired by http://www.gpsy.com/gpsinfo/geotoutm/gantz/LatLong-UTMconversion.cpp.txt
 func UTMToLatLong(coord *UTMCoord) (*PolarCoord, error) {
+	return UTMToLatLongAlgorithm(coord, DefaultTMAlgorithm)
+}
+
+// Like UTMToLatLong, but projects by the transverse mercator algorithm alg.
+func UTMToLatLongAlgorithm(coord *UTMCoord, alg TMAlgorithm) (*PolarCoord, error) {
 
 	zonelength := len(coord.Zone)
 	utmLetter := int8(coord.Zone[zonelength-1:][0])
@@ -719,7 +830,7 @@ func UTMToLatLong(coord *UTMCoord) (*PolarCoord, error) {
 		pt.El = DefaultEllipsoid
 	}
 
-	gc := InverseTransverseMercator(pt, 0, (float64(zonenumber)-1)*6-180+3, 0.9996, 500000, 0)
+	gc := alg.InverseTransverseMercator(pt, 0, (float64(zonenumber)-1)*6-180+3, 0.9996, 500000, 0)
 
 	return gc, nil
 }
@@ -727,12 +838,25 @@ func UTMToLatLong(coord *UTMCoord) (*PolarCoord, error) {
 // Convert from 3D polar to UTM 2D projection. If the polar coordinates do not contain a
 // reference ellipsoid, the WGS84Ellipsoid is assumed and copied to the resulting UTM coordinates.
 //
+// Returns ErrRange if the latitude is outside the UTM limits of 84°N and 80°S. Use LatLongToUPS
+// for the polar regions.
+//
 // Inspired by http://www.gpsy.com/gpsinfo/geotoutm/gantz/LatLong-UTMconversion.cpp.txt
-func LatLongToUTM(gcin *PolarCoord) *UTMCoord {
+func LatLongToUTM(gcin *PolarCoord) (*UTMCoord, error) {
+	return LatLongToUTMAlgorithm(gcin, DefaultTMAlgorithm)
+}
+
+// Like LatLongToUTM, but projects by the transverse mercator algorithm alg.
+func LatLongToUTMAlgorithm(gcin *PolarCoord, alg TMAlgorithm) (*UTMCoord, error) {
 
 	var utm UTMCoord
 
 	gc := *gcin // Make a copy as we might set the ellipsoid and we will not alter the input values
+
+	letter := utmLetterDesignator(gc.Latitude)
+	if letter == 'Z' {
+		return nil, ErrRange
+	}
 	zonenumber := uint((gc.Longitude+180)/6) + 1
 
 	if gc.Latitude >= 56.0 && gc.Latitude < 64.0 && gc.Longitude >= 3.0 && gc.Longitude < 12.0 {
@@ -756,9 +880,9 @@ func LatLongToUTM(gcin *PolarCoord) *UTMCoord {
 		gc.El = DefaultEllipsoid
 	}
 
-	pt := DirectTransverseMercator(&gc, 0, (float64(zonenumber)-1)*6-180+3, 0.9996, 500000, 0)
+	pt := alg.DirectTransverseMercator(&gc, 0, (float64(zonenumber)-1)*6-180+3, 0.9996, 500000, 0)
 
-	utm.Zone = strconv.FormatUint(uint64(zonenumber), 10) + string(utmLetterDesignator(gc.Latitude))
+	utm.Zone = strconv.FormatUint(uint64(zonenumber), 10) + string(letter)
 	utm.Northing = pt.Y
 	utm.Easting = pt.X
 
@@ -768,7 +892,7 @@ func LatLongToUTM(gcin *PolarCoord) *UTMCoord {
 
 	utm.El = pt.El
 
-	return &utm
+	return &utm, nil
 }
 
 // This routine determines the correct UTM letter designator for the given latitude
@@ -832,17 +956,14 @@ var Base32GeohashCode = []byte("0123456789bcdefghjkmnpqrstuvwxyz")
 // - https://github.com/kungfoo/geohsh-java/blob/master/src/main/java/ch/hsr/geohash/GeoHash.java
 // - https://github.com/broady/gogeohash/blob/master/geohash.go
 
-// Return latitude & longitude from a geohash-encoded string.
+// Return the cell of a geohash-encoded string as a bounding box.
 // If the reference ellipsoid is nil, the default Ellipsoid will be returned.
 // If the string is not a geohash, err will be set to ERRRANGE.
-func GeoHashToLatLong(geohash string, el *Ellipsoid) (*PolarCoord, error) {
+func GeoHashToBoundingBox(geohash string, el *Ellipsoid) (*BoundingBox, error) {
 
 	latrange := [2]float64{-90, 90}
 	longrange := [2]float64{-180, 180}
 
-	errlat := latrange[1]
-	errlong := longrange[1]
-
 	bytehash := []byte(geohash)
 	even := true
 
@@ -861,10 +982,8 @@ func GeoHashToLatLong(geohash string, el *Ellipsoid) (*PolarCoord, error) {
 
 			if even {
 				longrange[index] = (longrange[0] + longrange[1]) / 2.0
-				errlong /= 2
 			} else {
 				latrange[index] = (latrange[0] + latrange[1]) / 2.0
-				errlat /= 2
 			}
 			even = !even
 		}
@@ -874,10 +993,26 @@ func GeoHashToLatLong(geohash string, el *Ellipsoid) (*PolarCoord, error) {
 		el = DefaultEllipsoid
 	}
 
+	return &BoundingBox{South: latrange[0], West: longrange[0], North: latrange[1], East: longrange[1], El: el}, nil
+}
+
+// Return latitude & longitude from a geohash-encoded string, the centre of the geohash cell rounded
+// to the precision of the geohash. If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+// If the string is not a geohash, err will be set to ERRRANGE.
+func GeoHashToLatLong(geohash string, el *Ellipsoid) (*PolarCoord, error) {
+
+	bb, err := GeoHashToBoundingBox(geohash, el)
+	if err != nil {
+		return nil, err
+	}
+
+	errlat := (bb.North - bb.South) / 2
+	errlong := (bb.East - bb.West) / 2
+
 	return &PolarCoord{
-			Latitude:  round((latrange[0]+latrange[1])/2.0, int(math.Max(1.0, -round(math.Log10(errlat), 0))-1)),
-			Longitude: round((longrange[0]+longrange[1])/2.0, int(math.Max(1.0, -round(math.Log10(errlong), 0))-1)),
-			El:        el},
+			Latitude:  round((bb.South+bb.North)/2.0, int(math.Max(1.0, -round(math.Log10(errlat), 0))-1)),
+			Longitude: round((bb.West+bb.East)/2.0, int(math.Max(1.0, -round(math.Log10(errlong), 0))-1)),
+			El:        bb.El},
 		nil
 }
 
@@ -1000,11 +1135,6 @@ func NewEllipsoid(a, b float64, CommonName string) *Ellipsoid {
 
 // ## Helmert transformation
 
-type transformer struct {
-	dx, dy, dz, dM, drx, dry, drz float64
-	datum                         string
-}
-
 // A generic Cartesian point to represent a 3D datum; used by the helmert-transformation
 type Point3D struct {
 	X, Y, Z float64
@@ -1012,73 +1142,17 @@ type Point3D struct {
 
 // A set of 3D datum transformations for the helmert transformation
 var (
-	// http://de.wikipedia.org/wiki/Datum_Austria
-	HelmertWGS84ToMGI    = NewHelmertTransformer(-577.326, -90.129, -463.919, -2.4232, 5.1366, 1.4742, 5.2970, "WGS84toMGI")
+	// Reverse of EPSG:1618 "MGI to WGS 84 (3)" by the BEV, position vector: 577.326m, 90.129m, 463.919m,
+	// 5.137", 1.474", 5.297", 2.4232ppm; http://de.wikipedia.org/wiki/Datum_Austria
+	HelmertWGS84ToMGI = NewHelmertTransformer(-577.326, -90.129, -463.919, -2.4232, -5.137, -1.474, -5.297, "WGS84toMGI")
+	// Reverse of EPSG:1314 "OSGB36 to WGS 84 (6)", position vector: 446.448m, -125.157m, 542.060m,
+	// 0.1502", 0.2470", 0.8421", -20.4894ppm; as published by Ordnance Survey in "A guide to coordinate
+	// systems in Great Britain"
 	HelmertWGS84ToOSGB36 = NewHelmertTransformer(-446.448, 125.157, -542.060, 20.4894, -0.1502, -0.2470, -0.8421, "WGS84toOSGB36")
-	// "Granit87" parameters
-	HelmertLV03ToWGS84Granit87 = NewHelmertTransformer(660.077, 13.551, 369.3444, 5.66, 2.2356, 1.6047, 2.6451, "LV03toWGS84")
+	// Reverse of EPSG:1641 "TM65 to WGS 84 (2)" by Ordnance Survey Ireland, position vector: 482.530m,
+	// -130.596m, 564.557m, -1.042", -0.214", -0.631", 8.150ppm
+	HelmertWGS84ToIreland65 = NewHelmertTransformer(-482.530, 130.596, -564.557, -8.150, 1.042, 0.214, 0.631, "WGS84toIreland65")
+	// "Granit87" parameters of swisstopo, coordinate frame: 660.077m, 13.551m, 369.344m, 5.66ppm and
+	// rotations of 2.484cc, 1.783cc, 2.939cc (centesimal seconds of 0.324" each)
+	HelmertLV03ToWGS84Granit87 = NewHelmert(660.077, 13.551, 369.344, 5.66, 0.804816, 0.577692, 0.952236, CoordinateFrame, "LV03toWGS84")
 )
-
-// Method to perform the Helmert transformation on a generic 3D datum and return a new datum.
-// Instances of helmert transformations might be created by calls to NewHelmertTransformer
-//
-// Inspired by http://www.movable-type.co.uk/scripts/latlong-convert-coords.html
-func (hp *transformer) Transform(ip *Point3D) *Point3D {
-
-	var tp Point3D
-
-	s := 1 + hp.dM/1e6
-
-	rx := degtorad(hp.drx / 3600)
-	ry := degtorad(hp.dry / 3600)
-	rz := degtorad(hp.drz / 3600)
-
-	tp.X = hp.dx + s*ip.X - rz*ip.Y + ry*ip.Z
-	tp.Y = hp.dy + rx*ip.X + s*ip.Y - rx*ip.Z
-	tp.Z = hp.dz - ry*ip.X + rx*ip.Y + s*ip.Z
-
-	return &tp
-}
-
-// Method to perform the inverse helmert transformation on a generic 3D datum and return a new datum.
-// The inverse might be easily computed by inverting all helmert parameters. The inversion is moderately
-// accurate if the points of the orginal datum (x0, y0, z0) do not substantially diverge.
-// Instances of helmert transformations might be created by calls to NewHelmertTransformer
-func (hp *transformer) InverseTransform(pt *Point3D) *Point3D {
-
-	var ihp transformer
-
-	ihp.dM = -1 * hp.dM
-	ihp.drx = -1 * hp.drx
-	ihp.dry = -1 * hp.dry
-	ihp.drz = -1 * hp.drz
-	ihp.dx = -1 * hp.dx
-	ihp.dy = -1 * hp.dy
-	ihp.dz = -1 * hp.dz
-
-	return ihp.Transform(pt)
-}
-
-// Returns a canoncial representation of the helmert parameters
-func (tp *transformer) String() string {
-	return fmt.Sprintf("Helmert[%s](dx,dy,dz,dM,drx, dry,drz): (%f, %f, %f, %f, %f, %f, %f)", tp.datum, tp.dx, tp.dy, tp.dz, tp.dM, tp.drx, tp.dry, tp.drz)
-}
-
-// Get the well known text (WKT) for the helmert transformation as defined in
-// http://www.geoapi.org/2.0/javadoc/org/opengis/referencing/doc-files/WKT.html
-func (tp *transformer) WellKnownString() string {
-	return fmt.Sprintf("TOWGS84[\"%f\", \"%f\", \"%f\", \"%f\", \"%f\", \"%f\", \"%f\"]", tp.dx, tp.dy, tp.dz, tp.dM, tp.drx, tp.dry, tp.drz)
-}
-
-// Create a new instance of helmert parameters.
-//
-//	dx, dy, dz: delta of coordinate origin in meters
-//	drx, dry, drz: delta of coordinate bearing in rads
-//	dM:  scale correction to be made to the position vector in the source coordinate reference
-//		system, in parts of per million
-//
-// Attention: Unlike the other functions dealing with bearings and coordinates in this package,
-// the angular helmert parameters have to be specified in rad [-pi;pi]
-func NewHelmertTransformer(dx, dy, dz, dM, drx, dry, drz float64, datum string) *transformer {
-	return &transformer{dx: dx, dy: dy, dz: dz, dM: dM, drx: drx, dry: dry, drz: drz, datum: datum}
-}
@@ -7,7 +7,6 @@ package cartconvert
 
 import (
 	"fmt"
-	"math"
 	"testing"
 )
 
@@ -185,13 +184,26 @@ func utmabrequal(utm1, utm2 *UTMCoord) bool {
 
 func TestLatLongToUTM(t *testing.T) {
 	for _, test := range aLatLongToUTMTests {
-		out := LatLongToUTM(test.in)
+		out, err := LatLongToUTM(test.in)
+
+		if err != nil {
+			t.Error(err)
+		}
+
 		if !utmabrequal(test.out, out) {
 			t.Errorf("LatLongToUTM")
 		}
 	}
 }
 
+func TestLatLongToUTMRange(t *testing.T) {
+	for _, pc := range []*PolarCoord{{Latitude: 84.5, Longitude: 10}, {Latitude: -80.5, Longitude: 10}} {
+		if _, err := LatLongToUTM(pc); err != ErrRange {
+			t.Errorf("LatLongToUTM: expected %s for %s, got %v", ErrRange, pc, err)
+		}
+	}
+}
+
 // ## AUTMToStruct
 type aUTMToStructTestParam struct {
 	utmcoord string
@@ -310,6 +322,77 @@ func TestADegMMSSToPolar(t *testing.T) {
 	}
 }
 
+// ## ADegMMSSToLongitude
+type aDegMMSSToLongitudeTest struct {
+	in  string
+	out float64
+	pm  *PrimeMeridian
+}
+
+var aDegMMSSToLongitudeTests = []aDegMMSSToLongitudeTest{
+	{"E 34°2'30'' Ferro", 34.041667, FerroMeridian},
+	{" 31° hierro ", 31, FerroMeridian},
+	{"E0°30' Paris", 0.5, ParisMeridian},
+	{"W 1°30'  Greenwich", -1.5, GreenwichMeridian},
+	{"E18° 25' 0.08''", 18.416689, nil},
+}
+
+func TestADegMMSSToLongitude(t *testing.T) {
+	for index, test := range aDegMMSSToLongitudeTests {
+		out, pm, err := ADegMMSSToLongitude(test.in)
+		if err != nil || !floatequal(test.out, out) || pm != test.pm {
+			t.Errorf("ADegMMSSToLongitude [%d]: expected %f %v, got %f %v (%v)", index, test.out, test.pm, out, pm, err)
+		}
+	}
+
+	for _, in := range []string{"E 34° Rome", "Ferro"} {
+		if _, _, err := ADegMMSSToLongitude(in); err == nil {
+			t.Errorf("ADegMMSSToLongitude: expected an error for %q", in)
+		}
+	}
+}
+
+// ## ADegMMSSToPolarDatum
+type aDegMMSSToPolarDatumTest struct {
+	easting string
+	datum   *Datum
+	out     float64
+}
+
+var aDegMMSSToPolarDatumTests = []aDegMMSSToPolarDatumTest{
+	// longitudes of archive data east of Ferro, converted to Greenwich
+	{"E 34°0'0'' Ferro", MGIDatum, 16.333333},
+	{"E 34°0'0'' Ferro", MGIFerroDatum, 34},
+	// without a prime meridian, the longitude counts from the one of the datum
+	{"E 34°", MGIFerroDatum, 34},
+	{"E 16°20'", MGIDatum, 16.333333},
+	{"E 0°", NTFParisDatum, 0},
+	{"E 2°20'14.025'' Greenwich", NTFParisDatum, 0},
+	{"E 34°0'0'' Ferro", nil, 16.333333},
+}
+
+func TestADegMMSSToPolarDatum(t *testing.T) {
+	for index, test := range aDegMMSSToPolarDatumTests {
+		out, err := ADegMMSSToPolarDatum("N 48°12'", test.easting, 200, test.datum)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		datum := test.datum
+		if datum == nil {
+			datum = WGS84Datum
+		}
+		if !floatequal(48.2, out.Latitude) || !floatequal(test.out, out.Longitude) || out.Height != 200 || out.Datum != datum || out.El != datum.El {
+			t.Errorf("ADegMMSSToPolarDatum [%d]: expected lat 48.2 long %f in %s, got %s in %s", index, test.out, datum, out, out.Datum)
+		}
+	}
+
+	if _, err := ADegMMSSToPolarDatum("N 48°12'", "E 34 Ferro", 0, MGIDatum); err == nil {
+		t.Error("ADegMMSSToPolarDatum: expected an error")
+	}
+}
+
 // ## PolarToCartesian
 type polarToCartesianTest struct {
 	in  *PolarCoord
@@ -321,6 +404,11 @@ var polarToCartesianTests = []polarToCartesianTest{
 		&PolarCoord{Latitude: 47.567, Longitude: 14.243, El: WGS84Ellipsoid},
 		&CartPoint{Y: 1060748.224300, X: 4178845.984047, Z: 4684527.101880},
 	},
+	// the same point with the longitude east of Ferro
+	{
+		&PolarCoord{Latitude: 47.567, Longitude: 14.243 + 17 + 40.0/60, El: WGS84Ellipsoid, Datum: NewDatum("WGS84 (Ferro)", WGS84Ellipsoid, FerroMeridian, nil)},
+		&CartPoint{Y: 1060748.224300, X: 4178845.984047, Z: 4684527.101880},
+	},
 }
 
 func cartequal(cp1, cp2 *CartPoint) bool {
@@ -372,7 +460,7 @@ type helmertTest struct {
 var helmertTests = []helmertTest{
 	{
 		&Point3D{Y: 4178845.984047, X: 1060748.2243, Z: 4684527.10188},
-		&Point3D{Y: 4178655.486121, X: 1060094.493596, Z: 4684148.315584},
+		&Point3D{Y: 4178835.155600, X: 1060242.166557, Z: 4683955.338185},
 	},
 }
 
@@ -383,12 +471,6 @@ func point3dequal(p1, p2 *Point3D) bool {
 	return pt1 == pt2
 }
 
-// the inversion of a helmert is not totaly accurate; let's assume an accuracy of 2 meters
-// (may even be more)
-func helmertfuzzyequal(p1, p2 *Point3D) bool {
-	return math.Sqrt(math.Pow(p1.X-p2.X, 2)+math.Pow(p1.Y-p2.Y, 2)+math.Pow(p1.Z-p2.Z, 2)) < 2.0
-}
-
 func TestHelmertTransform(t *testing.T) {
 	for index, test := range helmertTests {
 		out := HelmertWGS84ToMGI.Transform(test.in)
@@ -402,7 +484,7 @@ func TestHelmertTransform(t *testing.T) {
 func TestHelmertInverseTransform(t *testing.T) {
 	for index, test := range helmertTests {
 		out := HelmertWGS84ToMGI.InverseTransform(test.out)
-		if !helmertfuzzyequal(test.in, out) {
+		if !point3dequal(test.in, out) {
 			t.Errorf("HelmertInverseTransform [%d]: expected (%f %f %f), got (%f %f %f)",
 				index, test.in.X, test.in.Y, test.in.Z, out.X, out.Y, out.Z)
 		}
@@ -478,3 +560,46 @@ func TestLatLongToString(t *testing.T) {
 		}
 	}
 }
+
+// ## Heights of projections
+func TestProjectionHeight(t *testing.T) {
+
+	gc := &PolarCoord{Latitude: 47.5, Longitude: 13.25, Height: 1234.5, El: Bessel1841Ellipsoid}
+
+	projections := map[string]struct {
+		direct  func(gc *PolarCoord) *GeoPoint
+		inverse func(pt *GeoPoint) *PolarCoord
+	}{
+		"TransverseMercator": {
+			func(gc *PolarCoord) *GeoPoint { return DirectTransverseMercator(gc, 0, 13, 1, 0, 0) },
+			func(pt *GeoPoint) *PolarCoord { return InverseTransverseMercator(pt, 0, 13, 1, 0, 0) }},
+		"TransverseMercatorKrueger": {
+			func(gc *PolarCoord) *GeoPoint { return DirectTransverseMercatorKrueger(gc, 0, 13, 1, 0, 0) },
+			func(pt *GeoPoint) *PolarCoord { return InverseTransverseMercatorKrueger(pt, 0, 13, 1, 0, 0) }},
+		"LambertConformalConic2SP": {
+			func(gc *PolarCoord) *GeoPoint { return DirectLambertConformalConic2SP(gc, 47.5, 13.3, 46, 49, 0, 0) },
+			func(pt *GeoPoint) *PolarCoord { return InverseLambertConformalConic2SP(pt, 47.5, 13.3, 46, 49, 0, 0) }},
+		"ObliqueStereographic": {
+			func(gc *PolarCoord) *GeoPoint { return DirectObliqueStereographic(gc, 47, 13, 1, 0, 0) },
+			func(pt *GeoPoint) *PolarCoord { return InverseObliqueStereographic(pt, 47, 13, 1, 0, 0) }},
+		"SwissObliqueMercator": {
+			func(gc *PolarCoord) *GeoPoint { return DirectSwissObliqueMercator(gc, 47, 13, 1, 0, 0) },
+			func(pt *GeoPoint) *PolarCoord { return InverseSwissObliqueMercator(pt, 47, 13, 1, 0, 0) }},
+	}
+
+	for name, projection := range projections {
+		pt := projection.direct(gc)
+		if pt.H != gc.Height {
+			t.Errorf("Direct%s: Expected height %f, got %f", name, gc.Height, pt.H)
+		}
+		if out := projection.inverse(pt); out.Height != gc.Height {
+			t.Errorf("Inverse%s: Expected height %f, got %f", name, gc.Height, out.Height)
+		}
+	}
+
+	polar := &PolarCoord{Latitude: 85, Longitude: 13.25, Height: 1234.5, El: WGS84Ellipsoid}
+	pt := DirectPolarStereographic(polar, 90, 0, 0.994, 2000000, 2000000)
+	if out := InversePolarStereographic(pt, 90, 0, 0.994, 2000000, 2000000); pt.H != polar.Height || out.Height != polar.Height {
+		t.Errorf("PolarStereographic: Expected height %f, got %f, %f", polar.Height, pt.H, out.Height)
+	}
+}
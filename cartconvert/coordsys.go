@@ -0,0 +1,281 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrCoordType is returned by a CoordinateSystem when it is handed a coordinate
+// value it did not create.
+var ErrCoordType = errors.New("coordinate does not belong to coordinate system")
+
+// A CoordinateSystem bundles parsing, formatting and conversion from and to
+// latitude / longitude of a single coordinate representation, eg. UTM, geohash or
+// the Bundesmeldenetz. Coordinate values are handed around as fmt.Stringer; the
+// String method of a value yields its canonical representation.
+//
+// Coordinate systems register themselves by calling RegisterCoordinateSystem,
+// typically from within the init function of their package.
+type CoordinateSystem interface {
+	// Name is the short, unique and lower case identifier of the coordinate system, eg. "utm"
+	Name() string
+	// Description is a human readable description, eg. "AT:Bundesmeldenetz"
+	Description() string
+	// Parse a literal into a coordinate value of this coordinate system
+	Parse(coord string) (fmt.Stringer, error)
+	// Format a coordinate value of this coordinate system
+	Format(coord fmt.Stringer) (string, error)
+	// Convert a coordinate value of this coordinate system to latitude and longitude
+	ToLatLong(coord fmt.Stringer) (*PolarCoord, error)
+	// Convert latitude and longitude into a coordinate value of this coordinate system
+	FromLatLong(pc *PolarCoord) (fmt.Stringer, error)
+}
+
+var (
+	coordSystemsMu sync.RWMutex
+	coordSystems   = make(map[string]CoordinateSystem)
+)
+
+// RegisterCoordinateSystem makes a coordinate system available by its name.
+// If RegisterCoordinateSystem is called twice with the same name or if cs is nil, it panics.
+func RegisterCoordinateSystem(cs CoordinateSystem) {
+	if cs == nil {
+		panic("cartconvert: RegisterCoordinateSystem coordinate system is nil")
+	}
+
+	name := strings.ToLower(cs.Name())
+
+	coordSystemsMu.Lock()
+	defer coordSystemsMu.Unlock()
+
+	if _, dup := coordSystems[name]; dup {
+		panic("cartconvert: RegisterCoordinateSystem called twice for " + name)
+	}
+	coordSystems[name] = cs
+}
+
+// LookupCoordinateSystem returns the coordinate system registered as name. The lookup is case insensitive.
+func LookupCoordinateSystem(name string) (cs CoordinateSystem, ok bool) {
+	coordSystemsMu.RLock()
+	defer coordSystemsMu.RUnlock()
+
+	cs, ok = coordSystems[strings.ToLower(name)]
+	return
+}
+
+// CoordinateSystems returns the sorted names of all registered coordinate systems.
+func CoordinateSystems() []string {
+	coordSystemsMu.RLock()
+	defer coordSystemsMu.RUnlock()
+
+	names := make([]string, 0, len(coordSystems))
+	for name := range coordSystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ## Coordinate systems of this package
+
+// Latitude and longitude, formatted according to format
+type latLongSystem struct {
+	name, description string
+	format            LatLongFormat
+}
+
+func (cs latLongSystem) Name() string        { return cs.name }
+func (cs latLongSystem) Description() string { return cs.description }
+
+// Parses a literal of the form "LAT, LONG". Both bearings may either be given in
+// degrees, minutes and seconds or as decimal degrees, see ADegMMSSToNum and ADegCommaToNum.
+func (cs latLongSystem) Parse(coord string) (fmt.Stringer, error) {
+
+	index := strings.Index(coord, ",")
+	if index == -1 {
+		return nil, CartographyError{Coord: coord, Err: ErrSyntax}
+	}
+
+	var bearings [2]float64
+	for i, bearing := range []string{coord[:index], coord[index+len(","):]} {
+		val, err := ADegMMSSToNum(bearing)
+		if err != nil {
+			if val, err = ADegCommaToNum(bearing); err != nil {
+				return nil, err
+			}
+		}
+		bearings[i] = val
+	}
+	return &PolarCoord{Latitude: bearings[0], Longitude: bearings[1], El: DefaultEllipsoid}, nil
+}
+
+func (cs latLongSystem) Format(coord fmt.Stringer) (string, error) {
+	pc, ok := coord.(*PolarCoord)
+	if !ok {
+		return "", ErrCoordType
+	}
+	lat, long := LatLongToString(pc, cs.format)
+	return lat + ", " + long, nil
+}
+
+func (cs latLongSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	pc, ok := coord.(*PolarCoord)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return pc, nil
+}
+
+func (cs latLongSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	return pc, nil
+}
+
+// Geographic coordinates of the datum of coord, WGS84 if the datum is not known
+func (cs latLongSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	if pc, ok := coord.(*PolarCoord); ok && pc.Datum != nil {
+		geog, _, _ := wktNames(pc.Datum)
+		return &CRS{Name: geog, Datum: pc.Datum}, nil
+	}
+	return WGS84CRS, nil
+}
+
+// UTM coordinates. Latitudes outside the UTM limits are represented as UPS coordinates.
+type utmSystem struct{}
+
+func (utmSystem) Name() string        { return "utm" }
+func (utmSystem) Description() string { return "UTM" }
+
+func (utmSystem) Parse(coord string) (fmt.Stringer, error) {
+	if ups, err := AUPSToStruct(coord, nil); err == nil {
+		return ups, nil
+	}
+	return AUTMToStruct(coord, nil)
+}
+
+func (utmSystem) Format(coord fmt.Stringer) (string, error) {
+	switch coord.(type) {
+	case *UTMCoord, *UPSCoord:
+		return coord.String(), nil
+	}
+	return "", ErrCoordType
+}
+
+func (utmSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	switch val := coord.(type) {
+	case *UTMCoord:
+		return UTMToLatLong(val)
+	case *UPSCoord:
+		return UPSToLatLong(val)
+	}
+	return nil, ErrCoordType
+}
+
+func (utmSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	if utm, err := LatLongToUTM(pc); err == nil {
+		return utm, nil
+	}
+	return LatLongToUPS(pc)
+}
+
+func (utmSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	switch val := coord.(type) {
+	case *UTMCoord:
+		return utmCRS(val.Zone, val.El)
+	case *UPSCoord:
+		return upsCRS(val.Zone, val.El)
+	}
+	return nil, ErrCoordType
+}
+
+// UPS coordinates
+type upsSystem struct{}
+
+func (upsSystem) Name() string        { return "ups" }
+func (upsSystem) Description() string { return "UPS" }
+
+func (upsSystem) Parse(coord string) (fmt.Stringer, error) {
+	return AUPSToStruct(coord, nil)
+}
+
+func (upsSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*UPSCoord); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (upsSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	ups, ok := coord.(*UPSCoord)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return UPSToLatLong(ups)
+}
+
+func (upsSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	return LatLongToUPS(pc)
+}
+
+func (upsSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	ups, ok := coord.(*UPSCoord)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return upsCRS(ups.Zone, ups.El)
+}
+
+// A geohash literal
+type geoHash string
+
+func (gh geoHash) String() string { return string(gh) }
+
+type geoHashSystem struct{}
+
+func (geoHashSystem) Name() string        { return "geohash" }
+func (geoHashSystem) Description() string { return "Geohash" }
+
+func (geoHashSystem) Parse(coord string) (fmt.Stringer, error) {
+	gh := strings.TrimSpace(coord)
+	if _, err := GeoHashToLatLong(gh, nil); err != nil {
+		return nil, err
+	}
+	return geoHash(gh), nil
+}
+
+func (geoHashSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(geoHash); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (geoHashSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	gh, ok := coord.(geoHash)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return GeoHashToLatLong(string(gh), nil)
+}
+
+func (geoHashSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	return geoHash(LatLongToGeoHash(pc)), nil
+}
+
+func (geoHashSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return WGS84CRS, nil
+}
+
+func init() {
+	RegisterCoordinateSystem(latLongSystem{name: "latlongdeg", description: "Latitude, Longitude in degrees, minutes and seconds", format: LLFdms})
+	RegisterCoordinateSystem(latLongSystem{name: "latlongcomma", description: "Latitude, Longitude in decimal degrees", format: LLFdeg})
+	RegisterCoordinateSystem(utmSystem{})
+	RegisterCoordinateSystem(upsSystem{})
+	RegisterCoordinateSystem(geoHashSystem{})
+}
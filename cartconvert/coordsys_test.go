@@ -0,0 +1,99 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the coordinate system registry of the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+func TestCoordinateSystems(t *testing.T) {
+	for _, name := range []string{"latlongdeg", "latlongcomma", "utm", "ups", "geohash", "webmercator", "tile", "quadkey", "pluscode", "maidenhead", "gars"} {
+		cs, ok := LookupCoordinateSystem(name)
+		if !ok {
+			t.Errorf("LookupCoordinateSystem: %s is not registered", name)
+			continue
+		}
+		if cs.Name() != name {
+			t.Errorf("LookupCoordinateSystem: expected %s, got %s", name, cs.Name())
+		}
+	}
+
+	if _, ok := LookupCoordinateSystem("UTM"); !ok {
+		t.Error("LookupCoordinateSystem: lookup is not case insensitive")
+	}
+
+	if _, ok := LookupCoordinateSystem("#unknown"); ok {
+		t.Error("LookupCoordinateSystem: found unregistered coordinate system")
+	}
+}
+
+// ## Parse, ToLatLong, FromLatLong and Format of the registered coordinate systems
+type coordinateSystemTest struct {
+	name     string
+	in       string
+	latlong  *PolarCoord
+	formated string
+}
+
+var coordinateSystemTests = []coordinateSystemTest{
+	{"utm", " 17T   630084.31    4833438.548 ", &PolarCoord{Latitude: 43.642567, Longitude: -79.387139}, "17T 630084 4833439"},
+	{"utm", "Z 2170960.056 1530291.107", &PolarCoord{Latitude: 85.5, Longitude: 20}, "Z 2170960 1530291"},
+	{"ups", "a 2000000 2000000", &PolarCoord{Latitude: -90, Longitude: 0}, "B 2000000 2000000"},
+	{"webmercator", "-11169055.58 2800000.00", &PolarCoord{Latitude: 24.381787, Longitude: -100.333333}, "-11169055.58 2800000.00"},
+	{"tile", "0/0/0", &PolarCoord{Latitude: 0, Longitude: 0}, "18/131072/131072"},
+	{"quadkey", "213", &PolarCoord{Latitude: -55.776573, Longitude: -22.5}, "213300000000000000"},
+	{"pluscode", " 8fvc9g8f+6x", &PolarCoord{Latitude: 47.365562, Longitude: 8.524937}, "8FVC9G8F+6X"},
+	{"maidenhead", "jn88DD", &PolarCoord{Latitude: 48.145833, Longitude: 16.291667}, "JN88dd"},
+	{"gars", "006ag39", &PolarCoord{Latitude: -86.958333, Longitude: -177.291667}, "006AG39"},
+	{"geohash", "u4pruydqqvj", &PolarCoord{Latitude: 57.64911, Longitude: 10.40744}, "u4pruydqqvj"},
+	{"latlongcomma", "S 33.922667°, E 18.416689°", &PolarCoord{Latitude: -33.922667, Longitude: 18.416689}, "-33.922667, 18.416689"},
+	{"latlongdeg", "S33° 55' 21.6'', E18° 25' 0.08''", &PolarCoord{Latitude: -33.922667, Longitude: 18.416689}, "S 33°55'21.6'', E 18°25'0.08''"},
+}
+
+func TestCoordinateSystemConversion(t *testing.T) {
+	for index, test := range coordinateSystemTests {
+		cs, _ := LookupCoordinateSystem(test.name)
+
+		coord, err := cs.Parse(test.in)
+		if err != nil {
+			t.Errorf("%s.Parse [%d]: %s", test.name, index, err)
+			continue
+		}
+
+		out, err := cs.ToLatLong(coord)
+		if err != nil {
+			t.Errorf("%s.ToLatLong [%d]: %s", test.name, index, err)
+			continue
+		}
+		if !latlongequal(test.latlong, out) {
+			t.Errorf("%s.ToLatLong [%d]: expected %s, got %s", test.name, index, test.latlong, out)
+		}
+
+		coord, err = cs.FromLatLong(out)
+		if err != nil {
+			t.Errorf("%s.FromLatLong [%d]: %s", test.name, index, err)
+			continue
+		}
+
+		formated, err := cs.Format(coord)
+		if err != nil {
+			t.Errorf("%s.Format [%d]: %s", test.name, index, err)
+		}
+		if formated != test.formated {
+			t.Errorf("%s.Format [%d]: expected %s, got %s", test.name, index, test.formated, formated)
+		}
+	}
+}
+
+func TestCoordinateSystemCoordType(t *testing.T) {
+	utm, _ := LookupCoordinateSystem("utm")
+	gh, _ := LookupCoordinateSystem("geohash")
+
+	coord, _ := gh.Parse("ezs42")
+	if _, err := utm.ToLatLong(coord); err != ErrCoordType {
+		t.Errorf("ToLatLong: expected %s, got %v", ErrCoordType, err)
+	}
+}
@@ -0,0 +1,440 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned when a coordinate reference system uses a projection method, a parameter or
+// a unit, which is not implemented by this package.
+var ErrUnsupported = errors.New("unsupported coordinate reference system")
+
+// ## Coordinate reference systems
+
+// A projection method implemented by this package
+type ProjectionMethod byte
+
+const (
+	// Transverse Mercator, projected by the Algorithm of the projection
+	TransverseMercator ProjectionMethod = iota
+	// Lambert conformal conic with one standard parallel
+	LambertConformalConic1SP
+	// Lambert conformal conic with two standard parallels
+	LambertConformalConic2SP
+	// Oblique stereographic via the conformal sphere
+	ObliqueStereographic
+	// Swiss oblique mercator, the Hotine oblique mercator with an azimuth of 90° at the projection centre
+	SwissObliqueMercator
+	// Polar stereographic with the natural origin at one of the poles
+	PolarStereographic
+	// Web Mercator, also known as Popular Visualisation Pseudo Mercator
+	WebMercator
+)
+
+func (pm ProjectionMethod) String() string {
+	switch pm {
+	case TransverseMercator:
+		return "TransverseMercator"
+	case LambertConformalConic1SP:
+		return "LambertConformalConic1SP"
+	case LambertConformalConic2SP:
+		return "LambertConformalConic2SP"
+	case ObliqueStereographic:
+		return "ObliqueStereographic"
+	case SwissObliqueMercator:
+		return "SwissObliqueMercator"
+	case PolarStereographic:
+		return "PolarStereographic"
+	case WebMercator:
+		return "WebMercator"
+	}
+	return "ProjectionMethod(" + strconv.Itoa(int(pm)) + ")"
+}
+
+// The parameters of a map projection. Latitudes and longitudes are given in decimal degrees, the longitudes
+// east of the prime meridian of the datum; False easting and northing in meters.
+type Projection struct {
+	Method ProjectionMethod
+	// natural origin, the false origin of LambertConformalConic2SP or the projection centre of SwissObliqueMercator
+	LatO, LongO float64
+	// first and second standard parallel of LambertConformalConic2SP
+	Lat1, Lat2 float64
+	// scale factor at the natural origin, not used by LambertConformalConic2SP
+	Scale  float64
+	FE, FN float64
+	// algorithm of TransverseMercator, the zero value is TMRedfearn
+	Algorithm TMAlgorithm
+}
+
+// Returns ErrRange if a latitude is beyond the poles, the scale is not positive or the standard parallels of a
+// Lambert conic projection yield no cone, and ErrUnsupported if the parameters can not be projected by the
+// method
+func (p *Projection) check() error {
+	for _, lat := range []float64{p.LatO, p.Lat1, p.Lat2} {
+		if !(math.Abs(lat) <= 90) {
+			return ErrRange
+		}
+	}
+
+	switch p.Method {
+	case LambertConformalConic1SP:
+		// the cone constant is the sine of the standard parallel
+		if p.LatO == 0 || math.Abs(p.LatO) == 90 {
+			return ErrRange
+		}
+	case LambertConformalConic2SP:
+		// parallels symmetric to the equator yield a cone constant of zero
+		if p.Lat1 == -p.Lat2 || math.Abs(p.Lat1) == 90 || math.Abs(p.Lat2) == 90 {
+			return ErrRange
+		}
+		return nil
+	}
+	if !(p.Scale > 0) {
+		return ErrRange
+	}
+
+	switch p.Method {
+	case TransverseMercator, LambertConformalConic1SP, LambertConformalConic2SP, ObliqueStereographic, SwissObliqueMercator:
+		return nil
+	case PolarStereographic:
+		if math.Abs(p.LatO) == 90 {
+			return nil
+		}
+	case WebMercator:
+		// the web mercator of this package has no parameters
+		if p.LatO == 0 && p.LongO == 0 && p.Scale == 1 && p.FE == 0 && p.FN == 0 {
+			return nil
+		}
+	}
+	return ErrUnsupported
+}
+
+// Canonical representation of the projection parameters
+func (p *Projection) String() string {
+	return fmt.Sprintf("%s(latO: %f, longO: %f, lat1: %f, lat2: %f, scale: %f, fe: %f, fn: %f)",
+		p.Method, p.LatO, p.LongO, p.Lat1, p.Lat2, p.Scale, p.FE, p.FN)
+}
+
+// A coordinate reference system (CRS): the datum and, for a projected CRS, the map projection. A CRS without
+// projection is geographic, its coordinates are the latitude and longitude of the datum.
+//
+// A CRS can be read from and written to well known text (WKT), see ParseWKT, CRS.WKT and CRS.WKT2, and to PROJ
+// strings, see ParseProj4 and CRS.Proj4.
+type CRS struct {
+	Name       string
+	Datum      *Datum
+	Projection *Projection
+}
+
+// The geographic coordinate reference system of the WGS84 datum
+var WGS84CRS = &CRS{Name: "WGS 84", Datum: WGS84Datum}
+
+// Canonical representation of a coordinate reference system
+func (crs *CRS) String() string {
+	return crs.Name
+}
+
+// Reports whether the CRS is geographic, ie. without a map projection
+func (crs *CRS) IsGeographic() bool {
+	return crs.Projection == nil
+}
+
+// Project latitude, longitude and ellipsoidal height of the datum of the CRS onto the plane. The coordinates
+// of a geographic CRS are the longitude as X and the latitude as Y. The longitude of gc counts from the
+// prime meridian of the datum.
+//
+// Returns ErrUnsupported if the parameters can not be projected by the projection method.
+func (crs *CRS) Direct(gc *PolarCoord) (*GeoPoint, error) {
+
+	pc := &PolarCoord{Latitude: gc.Latitude, Longitude: gc.Longitude, Height: gc.Height, El: crs.Datum.El}
+
+	p := crs.Projection
+	if p == nil {
+		return &GeoPoint{X: pc.Longitude, Y: pc.Latitude, H: pc.Height, El: pc.El}, nil
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+
+	switch p.Method {
+	case TransverseMercator:
+		return p.Algorithm.DirectTransverseMercator(pc, p.LatO, p.LongO, p.Scale, p.FE, p.FN), nil
+	case LambertConformalConic1SP:
+		return DirectLambertConformalConic1SP(pc, p.LatO, p.LongO, p.Scale, p.FE, p.FN), nil
+	case LambertConformalConic2SP:
+		return DirectLambertConformalConic2SP(pc, p.LatO, p.LongO, p.Lat1, p.Lat2, p.FE, p.FN), nil
+	case ObliqueStereographic:
+		return DirectObliqueStereographic(pc, p.LatO, p.LongO, p.Scale, p.FE, p.FN), nil
+	case SwissObliqueMercator:
+		return DirectSwissObliqueMercator(pc, p.LatO, p.LongO, p.Scale, p.FE, p.FN), nil
+	case PolarStereographic:
+		return DirectPolarStereographic(pc, p.LatO, p.LongO, p.Scale, p.FE, p.FN), nil
+	}
+	return DirectWebMercator(pc), nil
+}
+
+// Inverse projection of the plane coordinates pt onto latitude, longitude and ellipsoidal height of the datum
+// of the CRS. The longitude of the result counts from the prime meridian of the datum.
+//
+// Returns ErrUnsupported if the parameters can not be projected by the projection method.
+func (crs *CRS) Inverse(pt *GeoPoint) (*PolarCoord, error) {
+
+	gp := &GeoPoint{X: pt.X, Y: pt.Y, H: pt.H, El: crs.Datum.El}
+
+	var pc *PolarCoord
+
+	p := crs.Projection
+	if p == nil {
+		pc = &PolarCoord{Latitude: gp.Y, Longitude: gp.X, Height: gp.H, El: gp.El}
+	} else {
+		if err := p.check(); err != nil {
+			return nil, err
+		}
+
+		switch p.Method {
+		case TransverseMercator:
+			pc = p.Algorithm.InverseTransverseMercator(gp, p.LatO, p.LongO, p.Scale, p.FE, p.FN)
+		case LambertConformalConic1SP:
+			pc = InverseLambertConformalConic1SP(gp, p.LatO, p.LongO, p.Scale, p.FE, p.FN)
+		case LambertConformalConic2SP:
+			pc = InverseLambertConformalConic2SP(gp, p.LatO, p.LongO, p.Lat1, p.Lat2, p.FE, p.FN)
+		case ObliqueStereographic:
+			pc = InverseObliqueStereographic(gp, p.LatO, p.LongO, p.Scale, p.FE, p.FN)
+		case SwissObliqueMercator:
+			pc = InverseSwissObliqueMercator(gp, p.LatO, p.LongO, p.Scale, p.FE, p.FN)
+		case PolarStereographic:
+			pc = InversePolarStereographic(gp, p.LatO, p.LongO, p.Scale, p.FE, p.FN)
+		default:
+			pc = InverseWebMercator(gp)
+		}
+	}
+
+	pc.Datum = crs.Datum
+	return pc, nil
+}
+
+// Convert the coordinates pt of the CRS into WGS84 latitude, longitude and ellipsoidal height
+func (crs *CRS) ToWGS84(pt *GeoPoint) (*PolarCoord, error) {
+	pc, err := crs.Inverse(pt)
+	if err != nil {
+		return nil, err
+	}
+	return TransformDatum(pc, crs.Datum, WGS84Datum)
+}
+
+// Convert WGS84 latitude, longitude and ellipsoidal height into coordinates of the CRS
+func (crs *CRS) FromWGS84(gc *PolarCoord) (*GeoPoint, error) {
+	pc, err := TransformDatum(gc, WGS84Datum, crs.Datum)
+	if err != nil {
+		return nil, err
+	}
+	return crs.Direct(pc)
+}
+
+// A CoordinateSystem, whose coordinate values refer to a coordinate reference system, eg. to publish the
+// definition of the coordinate system as WKT. Implemented by all coordinate systems of cartconvert and its
+// subpackages.
+type CRSCoordinateSystem interface {
+	CoordinateSystem
+	// The coordinate reference system of the coordinate value coord. coord selects the zone of zoned
+	// coordinate systems like UTM or the Bundesmeldenetz and may be nil for all others.
+	CRS(coord fmt.Stringer) (*CRS, error)
+}
+
+// The Helmert parameters transforming the datum into WGS84 in position vector convention. Reports false if the
+// transformation into WGS84 can not be expressed by Helmert parameters, like a grid shift without fallback.
+func (d *Datum) towgs84() (*Helmert, bool) {
+
+	dt := d.ToWGS84
+	if gs, ok := dt.(*GridShift); ok {
+		dt = gs.Fallback
+	}
+
+	switch t := dt.(type) {
+	case nil:
+		if d.ToWGS84 != nil {
+			return nil, false
+		}
+		return NewHelmertTransformer(0, 0, 0, 0, 0, 0, 0, d.Name+"toWGS84"), true
+	case *GeocentricTransformer:
+		if t.From == d.El && t.To == WGS84Ellipsoid {
+			return t.Helmert.PositionVector(), true
+		}
+	case *Molodensky:
+		if t.From == d.El && t.To == WGS84Ellipsoid {
+			return NewHelmertTransformer(t.Dx, t.Dy, t.Dz, 0, 0, 0, 0, t.Datum), true
+		}
+	case inverseTransformer:
+		switch it := t.dt.(type) {
+		case *GeocentricTransformer:
+			if it.From == WGS84Ellipsoid && it.To == d.El {
+				return it.Helmert.Inverse().Helmert(), true
+			}
+		case *Molodensky:
+			if it.From == WGS84Ellipsoid && it.To == d.El {
+				return NewHelmertTransformer(-it.Dx, -it.Dy, -it.Dz, 0, 0, 0, 0, it.Datum), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ## Coordinate reference systems of this package
+
+// Web Mercator (EPSG:3857) of the web mercator, tile and quadkey coordinate systems
+var webMercatorCRS = &CRS{Name: "WGS 84 / Pseudo-Mercator", Datum: WGS84Datum, Projection: &Projection{Method: WebMercator, Scale: 1}}
+
+// The datum of coordinates like UTM, which only state their ellipsoid: WGS84 and ETRS89 for their ellipsoids,
+// otherwise a datum which coincides with WGS84, as assumed by the conversions of such coordinates.
+func ellipsoidDatum(el *Ellipsoid) *Datum {
+	switch el {
+	case nil, WGS84Ellipsoid:
+		return WGS84Datum
+	case GRS80Ellipsoid:
+		return ETRS89Datum
+	}
+	return NewDatum(el.CommonName, el, GreenwichMeridian, nil)
+}
+
+// The coordinate reference system of the UTM zone, eg. "33U", on the ellipsoid el
+func utmCRS(zone string, el *Ellipsoid) (*CRS, error) {
+
+	if len(zone) < 2 {
+		return nil, ErrRange
+	}
+	zonenumber, err := strconv.ParseUint(zone[:len(zone)-1], 10, 0)
+	if err != nil || zonenumber < 1 || zonenumber > 60 {
+		return nil, ErrRange
+	}
+
+	hemisphere, fn := "N", 0.0
+	if zone[len(zone)-1] < 'N' {
+		hemisphere, fn = "S", 10000000
+	}
+
+	d := ellipsoidDatum(el)
+	geog, _, _ := wktNames(d)
+	return &CRS{
+		Name:       geog + " / UTM zone " + strconv.FormatUint(zonenumber, 10) + hemisphere,
+		Datum:      d,
+		Projection: &Projection{Method: TransverseMercator, LongO: float64(zonenumber-1)*6 - 180 + 3, Scale: 0.9996, FE: 500000, FN: fn},
+	}, nil
+}
+
+// The coordinate reference system of the UPS zone, one of "A", "B", "Y" and "Z", on the ellipsoid el
+func upsCRS(zone string, el *Ellipsoid) (*CRS, error) {
+
+	var hemisphere string
+	var latO float64
+
+	switch zone {
+	case "A", "B":
+		hemisphere, latO = "South", -90
+	case "Y", "Z":
+		hemisphere, latO = "North", 90
+	default:
+		return nil, ErrRange
+	}
+
+	d := ellipsoidDatum(el)
+	geog, _, _ := wktNames(d)
+	return &CRS{
+		Name:       geog + " / UPS " + hemisphere + " (E,N)",
+		Datum:      d,
+		Projection: &Projection{Method: PolarStereographic, LatO: latO, Scale: 0.994, FE: 2000000, FN: 2000000},
+	}, nil
+}
+
+// ## Coordinate systems of coordinate reference systems
+
+// A coordinate of a CRS: easting and northing in meters, or longitude and latitude in degrees of a geographic
+// CRS
+type crsPoint struct {
+	GeoPoint
+	crs *CRS
+}
+
+func (pt *crsPoint) String() string {
+	if pt.crs.IsGeographic() {
+		return fmt.Sprintf("%.7f %.7f", pt.X, pt.Y)
+	}
+	return fmt.Sprintf("%.2f %.2f", pt.X, pt.Y)
+}
+
+type crsSystem struct {
+	name, description string
+	crs               *CRS
+}
+
+// Returns a coordinate system of the coordinates of crs, eg. of a national grid parsed by ParseProj4 or
+// ParseWKT, to be registered by RegisterCoordinateSystem. Coordinates are literals of the form
+// "EASTING NORTHING" in meters, or "LONGITUDE LATITUDE" in decimal degrees of a geographic CRS. Latitude and
+// longitude refer to WGS84, and are transformed from their datum, if any, by FromLatLong.
+func NewCRSCoordinateSystem(name, description string, crs *CRS) CRSCoordinateSystem {
+	return &crsSystem{name: name, description: description, crs: crs}
+}
+
+func (cs *crsSystem) Name() string        { return cs.name }
+func (cs *crsSystem) Description() string { return cs.description }
+
+func (cs *crsSystem) Parse(coord string) (fmt.Stringer, error) {
+
+	fields := strings.Fields(coord)
+	if len(fields) != 2 {
+		return nil, CartographyError{Coord: coord, Err: ErrSyntax}
+	}
+
+	var values [2]float64
+	for i, field := range fields {
+		val, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, CartographyError{Coord: coord, Err: ErrSyntax}
+		}
+		values[i] = val
+	}
+	return &crsPoint{GeoPoint: GeoPoint{X: values[0], Y: values[1], El: cs.crs.Datum.El}, crs: cs.crs}, nil
+}
+
+func (cs *crsSystem) Format(coord fmt.Stringer) (string, error) {
+	if pt, ok := coord.(*crsPoint); !ok || pt.crs != cs.crs {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (cs *crsSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	pt, ok := coord.(*crsPoint)
+	if !ok || pt.crs != cs.crs {
+		return nil, ErrCoordType
+	}
+	return cs.crs.ToWGS84(&pt.GeoPoint)
+}
+
+func (cs *crsSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+
+	from := pc.Datum
+	if from == nil {
+		from = WGS84Datum
+	}
+	gc, err := TransformDatum(pc, from, cs.crs.Datum)
+	if err != nil {
+		return nil, err
+	}
+
+	gp, err := cs.crs.Direct(gc)
+	if err != nil {
+		return nil, err
+	}
+	return &crsPoint{GeoPoint: *gp, crs: cs.crs}, nil
+}
+
+func (cs *crsSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return cs.crs, nil
+}
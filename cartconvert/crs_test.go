@@ -0,0 +1,167 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"math"
+	"testing"
+)
+
+// ## CRS.Direct, CRS.Inverse
+func TestCRSDirectInverse(t *testing.T) {
+
+	in := &PolarCoord{Latitude: 43.642567, Longitude: -79.387139, El: WGS84Ellipsoid}
+
+	utm, err := LatLongToUTM(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crs, err := utmCRS(utm.Zone, WGS84Ellipsoid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crs.Name != "WGS 84 / UTM zone 17N" {
+		t.Errorf("utmCRS: expected WGS 84 / UTM zone 17N, got %s", crs)
+	}
+
+	out, err := crs.Direct(in)
+	if err != nil || math.Abs(out.X-utm.Easting) > 0.001 || math.Abs(out.Y-utm.Northing) > 0.001 {
+		t.Errorf("CRS.Direct: expected %s, got %v (%v)", utm, out, err)
+	}
+	if pc, err := crs.Inverse(out); err != nil || !latlongequal(in, pc) || pc.Datum != WGS84Datum {
+		t.Errorf("CRS.Inverse: expected %s, got %v (%v)", in, pc, err)
+	}
+
+	if out, err := webMercatorCRS.FromWGS84(in); err != nil || !geopointcmequal(DirectWebMercator(in), out) {
+		t.Errorf("CRS.FromWGS84: expected %v, got %v (%v)", DirectWebMercator(in), out, err)
+	}
+
+	// a geographic CRS counts longitudes from its prime meridian
+	crs = &CRS{Name: "NTF (Paris)", Datum: NTFParisDatum}
+	pt, err := crs.FromWGS84(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := TransformDatum(in, WGS84Datum, NTFParisDatum); expected.Longitude != pt.X || expected.Latitude != pt.Y {
+		t.Errorf("CRS.FromWGS84: expected %s, got %v", expected, pt)
+	}
+	if out, err := crs.ToWGS84(pt); err != nil || !latlongequal(in, out) || out.Datum != WGS84Datum {
+		t.Errorf("CRS.ToWGS84: expected %s, got %v (%v)", in, out, err)
+	}
+
+	// the transverse mercator projects by the algorithm of the projection
+	test := tmAlgorithmFarTest
+	crs = &CRS{Name: "Krueger", Datum: WGS84Datum, Projection: &Projection{Method: TransverseMercator, Scale: 0.9996, FE: 500000, Algorithm: TMKrueger}}
+	if out, err := crs.Direct(test.in.pc); err != nil || math.Hypot(out.X-test.out.X, out.Y-test.out.Y) > 0.005 {
+		t.Errorf("CRS.Direct: expected %v, got %v (%v)", test.out, out, err)
+	}
+	if pc, err := crs.Inverse(test.out); err != nil || !latlongequal(test.in.pc, pc) {
+		t.Errorf("CRS.Inverse: expected %s, got %v (%v)", test.in.pc, pc, err)
+	}
+
+	// invalid parameters of the projection method
+	crs = &CRS{Name: "UPS", Datum: WGS84Datum, Projection: &Projection{Method: PolarStereographic, LatO: 45, Scale: 0.994}}
+	if _, err := crs.Direct(in); err != ErrUnsupported {
+		t.Errorf("CRS.Direct: expected %s, got %v", ErrUnsupported, err)
+	}
+	for _, p := range []*Projection{
+		{Method: TransverseMercator, LatO: 91, Scale: 1},
+		{Method: TransverseMercator, Scale: 0},
+		{Method: LambertConformalConic1SP, Scale: 1},
+		{Method: LambertConformalConic2SP, Lat1: 30, Lat2: -30},
+	} {
+		crs = &CRS{Name: "Invalid", Datum: WGS84Datum, Projection: p}
+		if _, err := crs.Direct(in); err != ErrRange {
+			t.Errorf("CRS.Direct: expected %s for %v, got %v", ErrRange, p, err)
+		}
+	}
+}
+
+// ## CRSCoordinateSystem
+func TestCRSCoordinateSystem(t *testing.T) {
+	for index, test := range coordinateSystemTests {
+		cs, _ := LookupCoordinateSystem(test.name)
+		crscs, ok := cs.(CRSCoordinateSystem)
+		if !ok {
+			t.Errorf("CRSCoordinateSystem [%d]: %s does not refer to a coordinate reference system", index, test.name)
+			continue
+		}
+
+		coord, err := cs.Parse(test.in)
+		if err != nil {
+			t.Errorf("CRSCoordinateSystem [%d]: %s", index, err)
+			continue
+		}
+		crs, err := crscs.CRS(coord)
+		if err != nil {
+			t.Errorf("CRSCoordinateSystem [%d]: %s", index, err)
+			continue
+		}
+		if _, err := crs.WKT(); err != nil {
+			t.Errorf("CRSCoordinateSystem [%d]: %s", index, err)
+		}
+
+		// the CRS and the coordinate system agree on latitude and longitude
+		gc, _ := cs.ToLatLong(coord)
+		if crs.IsGeographic() || test.name == "utm" || test.name == "ups" || test.name == "webmercator" {
+			if pt, err := crs.FromWGS84(gc); err != nil || math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+				t.Errorf("CRSCoordinateSystem [%d]: %s can not project %s (%v)", index, crs, gc, err)
+			} else if out, err := crs.ToWGS84(pt); err != nil || !latlongequal(gc, out) {
+				t.Errorf("CRSCoordinateSystem [%d]: expected %s, got %v (%v)", index, gc, out, err)
+			}
+		}
+	}
+}
+
+// ## NewCRSCoordinateSystem
+func TestNewCRSCoordinateSystem(t *testing.T) {
+
+	crs, err := ParseProj4("+proj=utm +zone=17 +ellps=WGS84")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := NewCRSCoordinateSystem("utm17", "UTM zone 17N", crs)
+
+	if out, err := cs.CRS(nil); err != nil || out != crs || cs.Name() != "utm17" || cs.Description() != "UTM zone 17N" {
+		t.Errorf("NewCRSCoordinateSystem: expected %s, got %v (%v)", crs, out, err)
+	}
+
+	coord, err := cs.Parse(" 630084.31    4833438.548 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := cs.Format(coord); err != nil || out != "630084.31 4833438.55" {
+		t.Errorf("Format: expected 630084.31 4833438.55, got %s (%v)", out, err)
+	}
+
+	expected := &PolarCoord{Latitude: 43.642567, Longitude: -79.387139}
+	gc, err := cs.ToLatLong(coord)
+	if err != nil || !latlongequal(expected, gc) || gc.Datum != WGS84Datum {
+		t.Errorf("ToLatLong: expected %s, got %v (%v)", expected, gc, err)
+	}
+	if out, err := cs.FromLatLong(gc); err != nil || out.String() != coord.String() {
+		t.Errorf("FromLatLong: expected %s, got %v (%v)", coord, out, err)
+	}
+
+	// latitude and longitude of another datum are transformed
+	gc, _ = TransformDatum(gc, WGS84Datum, ED50Datum)
+	if out, err := cs.FromLatLong(gc); err != nil || out.String() != coord.String() {
+		t.Errorf("FromLatLong: expected %s, got %v (%v)", coord, out, err)
+	}
+
+	for _, in := range []string{"630084.31", "630084.31 4833438.548m"} {
+		_, err := cs.Parse(in)
+		if cerr, ok := err.(CartographyError); !ok || cerr.Err != ErrSyntax {
+			t.Errorf("Parse: expected %s for %q, got %v", ErrSyntax, in, err)
+		}
+	}
+	if _, err := cs.Format(&UTMCoord{}); err != ErrCoordType {
+		t.Errorf("Format: expected %s, got %v", ErrCoordType, err)
+	}
+	if _, err := NewCRSCoordinateSystem("wgs84", "WGS84", WGS84CRS).ToLatLong(coord); err != ErrCoordType {
+		t.Errorf("ToLatLong: expected %s, got %v", ErrCoordType, err)
+	}
+}
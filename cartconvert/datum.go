@@ -0,0 +1,246 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"sort"
+	"strings"
+)
+
+// ## Geodetic datums
+
+// The reference ellipsoid alone does not define where a coordinate lies: MGI, DHDN and S-JTSK all use the
+// Bessel ellipsoid, yet the same latitude and longitude differ by hundreds of meters between them. A Datum
+// adds the prime meridian and the transformation into WGS84, so coordinates of any two datums can be
+// transformed into each other via WGS84.
+
+// A prime meridian, from which longitudes of a datum are counted
+type PrimeMeridian struct {
+	Name string
+	// longitude east of Greenwich in decimal degrees
+	Longitude float64
+}
+
+// Prime meridians of historical datums of Europe
+var (
+	GreenwichMeridian = &PrimeMeridian{Name: "Greenwich", Longitude: 0}
+	// Ferro (Hierro), the westernmost of the Canary Islands, conventionally 17°40' west of Greenwich. Used by
+	// the datums of the Austro-Hungarian surveys like MGI and S-JTSK.
+	FerroMeridian = &PrimeMeridian{Name: "Ferro", Longitude: -(17 + 40.0/60)}
+	// The Paris observatory, 2°20'14.025'' east of Greenwich. Used by NTF.
+	ParisMeridian = &PrimeMeridian{Name: "Paris", Longitude: 2 + 20.0/60 + 14.025/3600}
+)
+
+var primeMeridians = map[string]*PrimeMeridian{
+	"greenwich": GreenwichMeridian,
+	"ferro":     FerroMeridian,
+	"hierro":    FerroMeridian,
+	"paris":     ParisMeridian,
+}
+
+// LookupPrimeMeridian returns the prime meridian named name, one of Greenwich, Ferro (or Hierro) and Paris.
+// The lookup is case insensitive.
+func LookupPrimeMeridian(name string) (pm *PrimeMeridian, ok bool) {
+	pm, ok = primeMeridians[strings.ToLower(name)]
+	return
+}
+
+// Canonical representation of a prime meridian
+func (pm *PrimeMeridian) String() string {
+	return pm.Name
+}
+
+// Convert the longitude long in decimal degrees east of the prime meridian from into the longitude east of
+// the prime meridian to, in the range (-180, 180]. A nil prime meridian is Greenwich.
+func ConvertPrimeMeridian(long float64, from, to *PrimeMeridian) float64 {
+	if from != nil {
+		long += from.Longitude
+	}
+	if to != nil {
+		long -= to.Longitude
+	}
+	return normalizeLongitude(long)
+}
+
+// The prime meridian of the longitude of pc: the one of its datum, or Greenwich if the datum is not known
+func (pc *PolarCoord) PrimeMeridian() *PrimeMeridian {
+	if pc.Datum == nil || pc.Datum.PrimeMeridian == nil {
+		return GreenwichMeridian
+	}
+	return pc.Datum.PrimeMeridian
+}
+
+// A geodetic datum: the reference ellipsoid El, the prime meridian and the transformation of latitude,
+// longitude and ellipsoidal height on El into WGS84. A nil ToWGS84 states that the datum coincides with WGS84
+// within the accuracy of this package, like ETRS89.
+type Datum struct {
+	Name          string
+	El            *Ellipsoid
+	PrimeMeridian *PrimeMeridian
+	ToWGS84       DatumTransformer
+}
+
+// Returns a new datum. The longitudes of the datum refer to the prime meridian pm, which defaults to
+// Greenwich if nil. towgs84 transforms latitude, longitude and height on el into WGS84, eg. a
+// GeocentricTransformer, a Molodensky transformation or a GridShift.
+func NewDatum(name string, el *Ellipsoid, pm *PrimeMeridian, towgs84 DatumTransformer) *Datum {
+	if pm == nil {
+		pm = GreenwichMeridian
+	}
+	return &Datum{Name: name, El: el, PrimeMeridian: pm, ToWGS84: towgs84}
+}
+
+// Returns a new datum referring to Greenwich, which is transformed into WGS84 by the helmert parameters
+// towgs84, as given by the TOWGS84 clause of WKT or the +towgs84 parameter of PROJ.
+func NewHelmertDatum(name string, el *Ellipsoid, towgs84 *Helmert) *Datum {
+	return NewDatum(name, el, GreenwichMeridian, NewGeocentricTransformer(towgs84, el, WGS84Ellipsoid))
+}
+
+// Canonical representation of a datum
+func (d *Datum) String() string {
+	return d.Name
+}
+
+// Longitude of the prime meridian east of Greenwich
+func (d *Datum) primeMeridian() float64 {
+	if d.PrimeMeridian == nil {
+		return 0
+	}
+	return d.PrimeMeridian.Longitude
+}
+
+// A catalogue of common datums of Europe. The transformations into WGS84 are those used by the subpackages
+// and common published parameter sets, mostly accurate to a few meters; National grid shifts are more
+// accurate.
+var (
+	WGS84Datum = NewDatum("WGS84", WGS84Ellipsoid, GreenwichMeridian, nil)
+	// ETRS89 drifts from WGS84 by the movement of the Eurasian plate, which is neglected
+	ETRS89Datum = NewDatum("ETRS89", GRS80Ellipsoid, GreenwichMeridian, nil)
+	// RGF93, the French realization of ETRS89 used by Lambert-93
+	RGF93Datum = NewDatum("RGF93", GRS80Ellipsoid, GreenwichMeridian, nil)
+	// Militärgeographisches Institut, Austria
+	MGIDatum = NewDatum("MGI", Bessel1841MGIEllipsoid, GreenwichMeridian,
+		InverseDatumTransformer(NewGeocentricTransformer(HelmertWGS84ToMGI, WGS84Ellipsoid, Bessel1841MGIEllipsoid)))
+	// MGI with longitudes east of Ferro, as used by the Bundesmeldenetz and the Austrian cadastre
+	MGIFerroDatum = NewDatum("MGI (Ferro)", Bessel1841MGIEllipsoid, FerroMeridian, MGIDatum.ToWGS84)
+	// Deutsches Hauptdreiecksnetz, Germany
+	DHDNDatum = NewHelmertDatum("DHDN", Bessel1841Ellipsoid,
+		NewHelmertTransformer(598.1, 73.7, 418.2, 6.7, 0.202, 0.045, -2.455, "DHDNtoWGS84"))
+	// System Jednotné Trigonometrické Sítě Katastrální, Czech Republic and Slovakia
+	SJTSKDatum = NewHelmertDatum("S-JTSK", Bessel1841Ellipsoid,
+		NewHelmertTransformer(589, 76, 480, 0, 0, 0, 0, "S-JTSKtoWGS84"))
+	// S-JTSK with longitudes east of Ferro, as used by the Krovak projection
+	SJTSKFerroDatum = NewDatum("S-JTSK (Ferro)", Bessel1841Ellipsoid, FerroMeridian, SJTSKDatum.ToWGS84)
+	// Nouvelle Triangulation de la France
+	NTFDatum = NewHelmertDatum("NTF", Clarke1880IGNEllipsoid,
+		NewHelmertTransformer(-168, -60, 320, 0, 0, 0, 0, "NTFtoWGS84"))
+	// NTF with longitudes east of Paris, as used by the former Lambert zones of France
+	NTFParisDatum = NewDatum("NTF (Paris)", Clarke1880IGNEllipsoid, ParisMeridian, NTFDatum.ToWGS84)
+	// CH1903 of the former Swiss LV03 grid, approximated by the geocentric translation of CH1903+, from which it
+	// diverges by up to 1.5m; the official transformation is FINELTRA, see lv03p
+	CH1903Datum = NewHelmertDatum("CH1903", Bessel1841Ellipsoid,
+		NewHelmertTransformer(674.374, 15.056, 405.346, 0, 0, 0, 0, "CH1903toWGS84"))
+	// CH1903+ of the Swiss LV95 grid; EPSG:1676 "CH1903+ to ETRS89 (1)"
+	CH1903PlusDatum = NewHelmertDatum("CH1903+", Bessel1841Ellipsoid,
+		NewHelmertTransformer(674.374, 15.056, 405.346, 0, 0, 0, 0, "CH1903+toWGS84"))
+	// Amersfoort of the Dutch RD grid
+	AmersfoortDatum = NewHelmertDatum("Amersfoort", Bessel1841Ellipsoid,
+		NewHelmertTransformer(593.16, 26.15, 478.54, 0, 0, 0, 0, "AmersfoorttoWGS84"))
+	// Ordnance Survey of Great Britain 1936
+	OSGB36Datum = NewDatum("OSGB36", Airy1830Ellipsoid, GreenwichMeridian,
+		InverseDatumTransformer(NewGeocentricTransformer(HelmertWGS84ToOSGB36, WGS84Ellipsoid, Airy1830Ellipsoid)))
+	// Ireland 1965 of the Irish Grid
+	Ireland65Datum = NewDatum("Ireland65", AiryModifiedEllipsoid, GreenwichMeridian,
+		InverseDatumTransformer(NewGeocentricTransformer(HelmertWGS84ToIreland65, WGS84Ellipsoid, AiryModifiedEllipsoid)))
+	// European Datum 1950
+	ED50Datum = NewDatum("ED50", International1924Ellipsoid, GreenwichMeridian, MolodenskyED50ToWGS84)
+	// Pulkovo 1942, S-42
+	Pulkovo1942Datum = NewHelmertDatum("Pulkovo1942", Krassowsky1940Ellipsoid,
+		NewHelmertTransformer(23.92, -141.27, -80.9, -0.12, 0, 0.35, 0.82, "Pulkovo1942toWGS84"))
+)
+
+var datums = map[string]*Datum{}
+
+func init() {
+	for _, d := range []*Datum{WGS84Datum, ETRS89Datum, RGF93Datum, MGIDatum, MGIFerroDatum, DHDNDatum, SJTSKDatum,
+		SJTSKFerroDatum, NTFDatum, NTFParisDatum, CH1903Datum, CH1903PlusDatum, AmersfoortDatum, OSGB36Datum, Ireland65Datum,
+		ED50Datum, Pulkovo1942Datum} {
+		datums[strings.ToLower(d.Name)] = d
+	}
+}
+
+// LookupDatum returns the datum of the catalogue named name. The lookup is case insensitive.
+func LookupDatum(name string) (d *Datum, ok bool) {
+	d, ok = datums[strings.ToLower(name)]
+	return
+}
+
+// Datums returns the sorted names of all datums of the catalogue.
+func Datums() []string {
+	names := make([]string, 0, len(datums))
+	for _, d := range datums {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reports whether dt1 and dt2 are the very same transformation. Only the transformers of this package are
+// compared, by identity; other implementations might not be comparable and are never the same.
+func sameTransformer(dt1, dt2 DatumTransformer) bool {
+	if dt1 == nil || dt2 == nil {
+		return dt1 == nil && dt2 == nil
+	}
+
+	switch t1 := dt1.(type) {
+	case inverseTransformer:
+		t2, ok := dt2.(inverseTransformer)
+		return ok && sameTransformer(t1.dt, t2.dt)
+	case *GeocentricTransformer:
+		t2, ok := dt2.(*GeocentricTransformer)
+		return ok && t1 == t2
+	case *Molodensky:
+		t2, ok := dt2.(*Molodensky)
+		return ok && t1 == t2
+	case *GridShift:
+		t2, ok := dt2.(*GridShift)
+		return ok && t1 == t2
+	}
+	return false
+}
+
+// Transform the latitude, longitude and ellipsoidal height pc of the datum from into the datum to, via WGS84.
+// The longitude of pc refers to the prime meridian of from, the longitude of the result to the prime
+// meridian of to. The result refers to the ellipsoid and the datum to.
+//
+// Returns ErrRange if a grid based transformation does not cover pc.
+func TransformDatum(pc *PolarCoord, from, to *Datum) (*PolarCoord, error) {
+
+	out := &PolarCoord{Latitude: pc.Latitude, Longitude: pc.Longitude + from.primeMeridian(), Height: pc.Height, El: from.El}
+
+	// datums differing only by the prime meridian, like MGI and MGI (Ferro), need no shift
+	if from != to && (from.El != to.El || !sameTransformer(from.ToWGS84, to.ToWGS84)) {
+		if from.ToWGS84 != nil {
+			if out = from.ToWGS84.TransformPolar(out); out == nil {
+				return nil, ErrRange
+			}
+		}
+
+		if to.ToWGS84 != nil {
+			out = &PolarCoord{Latitude: out.Latitude, Longitude: out.Longitude, Height: out.Height, El: WGS84Ellipsoid}
+			if out = to.ToWGS84.InverseTransformPolar(out); out == nil {
+				return nil, ErrRange
+			}
+		}
+	}
+
+	out.Longitude -= to.primeMeridian()
+	if from.primeMeridian() != to.primeMeridian() {
+		out.Longitude = normalizeLongitude(out.Longitude)
+	}
+	out.El = to.El
+	out.Datum = to
+	return out, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// ## LookupDatum, Datums
+func TestLookupDatum(t *testing.T) {
+	for _, name := range []string{"WGS84", "mgi", "S-JTSK", "ch1903", "ch1903+", "ED50"} {
+		if d, ok := LookupDatum(name); !ok || d.El == nil || d.PrimeMeridian != GreenwichMeridian {
+			t.Errorf("LookupDatum: expected the datum %s, got %v", name, d)
+		}
+	}
+
+	if _, ok := LookupDatum("NAD83"); ok {
+		t.Error("LookupDatum: expected no datum NAD83")
+	}
+
+	names := Datums()
+	if len(names) != len(datums) {
+		t.Errorf("Datums: expected %d datums, got %v", len(datums), names)
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("Datums: expected sorted names, got %v", names)
+		}
+	}
+}
+
+// ## TransformDatum
+func TestTransformDatum(t *testing.T) {
+
+	wgs84 := &PolarCoord{Latitude: 48.2082, Longitude: 16.3738, Height: 230.6, El: WGS84Ellipsoid}
+
+	// the transformation equals the helmert transformation into MGI
+	cart := PolarToCartesian(wgs84)
+	pt := HelmertWGS84ToMGI.Transform(&Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
+	expected := CartesianToPolar(&CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: Bessel1841MGIEllipsoid})
+
+	mgi, err := TransformDatum(wgs84, WGS84Datum, MGIDatum)
+	if err != nil || !latlongmmequal(expected, mgi) || math.Abs(mgi.Height-expected.Height) > 0.001 || mgi.El != Bessel1841MGIEllipsoid || mgi.Datum != MGIDatum {
+		t.Errorf("TransformDatum: expected %s at %fm, got %s (%v)", expected, expected.Height, mgi, err)
+	}
+
+	// MGI and DHDN share the Bessel ellipsoid, yet the datums differ
+	dhdn, err := TransformDatum(mgi, MGIDatum, DHDNDatum)
+	if err != nil || dhdn.El != Bessel1841Ellipsoid || dhdn.Datum != DHDNDatum {
+		t.Fatalf("TransformDatum: expected a DHDN coordinate, got %s (%v)", dhdn, err)
+	}
+	if d, _, _ := GeodesicInverse(mgi, dhdn); d < 10 {
+		t.Errorf("TransformDatum: expected MGI and DHDN to differ, got %fm", d)
+	}
+
+	for _, d := range []*Datum{WGS84Datum, ETRS89Datum, MGIDatum, DHDNDatum, SJTSKDatum, CH1903Datum, CH1903PlusDatum,
+		AmersfoortDatum, OSGB36Datum, Ireland65Datum, ED50Datum, Pulkovo1942Datum} {
+		local, err := TransformDatum(dhdn, DHDNDatum, d)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		out, err := TransformDatum(local, d, WGS84Datum)
+		if err != nil || !latlongequal(wgs84, out) || math.Abs(out.Height-wgs84.Height) > 0.01 || out.Datum != WGS84Datum {
+			t.Errorf("TransformDatum %s: expected %s at %fm, got %s at %fm (%v)", d, wgs84, wgs84.Height, out, out.Height, err)
+		}
+	}
+
+	// datums without transformation keep latitude, longitude and height
+	if out, err := TransformDatum(wgs84, WGS84Datum, ETRS89Datum); err != nil || out.Latitude != wgs84.Latitude || out.Longitude != wgs84.Longitude || out.Height != wgs84.Height || out.El != GRS80Ellipsoid {
+		t.Errorf("TransformDatum: expected %s on %s, got %s (%v)", wgs84, GRS80Ellipsoid.CommonName, out, err)
+	}
+}
+
+func TestTransformDatumPrimeMeridian(t *testing.T) {
+
+	east := NewDatum("East", WGS84Ellipsoid, &PrimeMeridian{Name: "East", Longitude: 170}, nil)
+
+	out, err := TransformDatum(&PolarCoord{Latitude: 10, Longitude: 15}, east, WGS84Datum)
+	if err != nil || out.Latitude != 10 || math.Abs(out.Longitude+175) > 1e-9 {
+		t.Errorf("TransformDatum: expected lat 10 long -175, got %s (%v)", out, err)
+	}
+
+	back, err := TransformDatum(out, WGS84Datum, east)
+	if err != nil || math.Abs(back.Longitude-15) > 1e-9 || back.Datum != east {
+		t.Errorf("TransformDatum: expected lat 10 long 15, got %s (%v)", back, err)
+	}
+}
+
+func TestTransformDatumGridShift(t *testing.T) {
+
+	nt, err := LoadNTv2(bytes.NewReader(writeNTv2(binary.LittleEndian, ntv2TestGrids)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grid := NewDatum("MGI", Bessel1841MGIEllipsoid, nil, NewGridShift(nt, nil))
+
+	inside := &PolarCoord{Latitude: 47.5, Longitude: 13.25, Height: 500, El: Bessel1841MGIEllipsoid}
+	out, err := TransformDatum(inside, grid, WGS84Datum)
+	expected := &PolarCoord{Latitude: 47.5 + 1.15/3600, Longitude: 13.25 + 3.05/3600}
+	if err != nil || !latlongmmequal(expected, out) || out.El != WGS84Ellipsoid {
+		t.Errorf("TransformDatum: expected %s, got %s (%v)", expected, out, err)
+	}
+
+	if back, err := TransformDatum(out, WGS84Datum, grid); err != nil || !latlongmmequal(inside, back) || back.El != Bessel1841MGIEllipsoid {
+		t.Errorf("TransformDatum: expected %s, got %s (%v)", inside, back, err)
+	}
+
+	outside := &PolarCoord{Latitude: 45, Longitude: 13.25, El: Bessel1841MGIEllipsoid}
+	if _, err := TransformDatum(outside, grid, WGS84Datum); err != ErrRange {
+		t.Errorf("TransformDatum: expected %s outside the grid, got %v", ErrRange, err)
+	}
+	if _, err := TransformDatum(outside, WGS84Datum, grid); err != ErrRange {
+		t.Errorf("TransformDatum: expected %s outside the grid, got %v", ErrRange, err)
+	}
+}
+
+// ## ConvertPrimeMeridian
+type convertPrimeMeridianTest struct {
+	in       float64
+	from, to *PrimeMeridian
+	out      float64
+}
+
+var convertPrimeMeridianTests = []convertPrimeMeridianTest{
+	{34, FerroMeridian, GreenwichMeridian, 16 + 20.0/60},
+	{16 + 20.0/60, GreenwichMeridian, FerroMeridian, 34},
+	{0, ParisMeridian, nil, 2.337229},
+	{0, ParisMeridian, FerroMeridian, 20.003896},
+	{-170, GreenwichMeridian, ParisMeridian, -172.337229},
+	{170, FerroMeridian, ParisMeridian, 149.996104},
+	{-170, FerroMeridian, nil, 172.333333},
+}
+
+func TestConvertPrimeMeridian(t *testing.T) {
+	for cnt, test := range convertPrimeMeridianTests {
+		if out := ConvertPrimeMeridian(test.in, test.from, test.to); math.Abs(out-test.out) > 1e-6 {
+			t.Errorf("ConvertPrimeMeridian [%d]: expected %f, got %f", cnt, test.out, out)
+		}
+	}
+
+	for _, name := range []string{"Ferro", "HIERRO", "paris", "Greenwich"} {
+		if _, ok := LookupPrimeMeridian(name); !ok {
+			t.Errorf("LookupPrimeMeridian: expected the prime meridian %s", name)
+		}
+	}
+
+	if pm := (&PolarCoord{}).PrimeMeridian(); pm != GreenwichMeridian {
+		t.Errorf("PolarCoord.PrimeMeridian: expected %s, got %s", GreenwichMeridian, pm)
+	}
+	if pm := (&PolarCoord{Datum: SJTSKFerroDatum}).PrimeMeridian(); pm != FerroMeridian {
+		t.Errorf("PolarCoord.PrimeMeridian: expected %s, got %s", FerroMeridian, pm)
+	}
+}
+
+func TestTransformDatumFerro(t *testing.T) {
+
+	ferro := &PolarCoord{Latitude: 48.2, Longitude: 34, Height: 200, El: Bessel1841MGIEllipsoid}
+
+	// datums differing by the prime meridian only change the longitude
+	mgi, err := TransformDatum(ferro, MGIFerroDatum, MGIDatum)
+	if err != nil || mgi.Latitude != ferro.Latitude || math.Abs(mgi.Longitude-(16+20.0/60)) > 1e-12 || mgi.Height != ferro.Height || mgi.Datum != MGIDatum {
+		t.Errorf("TransformDatum: expected lat 48.2 long 16.333333 in %s, got %s in %s (%v)", MGIDatum, mgi, mgi.Datum, err)
+	}
+
+	wgs84, _ := TransformDatum(mgi, MGIDatum, WGS84Datum)
+	out, err := TransformDatum(ferro, MGIFerroDatum, WGS84Datum)
+	if err != nil || !latlongmmequal(wgs84, out) || math.Abs(out.Height-wgs84.Height) > 1e-6 {
+		t.Errorf("TransformDatum: expected %s, got %s (%v)", wgs84, out, err)
+	}
+
+	back, err := TransformDatum(out, WGS84Datum, MGIFerroDatum)
+	if err != nil || !latlongmmequal(ferro, back) || math.Abs(back.Height-ferro.Height) > 0.001 {
+		t.Errorf("TransformDatum: expected %s, got %s (%v)", ferro, back, err)
+	}
+
+	// NTF (Paris) and S-JTSK (Ferro) round trip
+	for _, d := range []*Datum{NTFParisDatum, SJTSKFerroDatum} {
+		local, err := TransformDatum(wgs84, WGS84Datum, d)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		out, err := TransformDatum(local, d, WGS84Datum)
+		if err != nil || !latlongmmequal(wgs84, out) {
+			t.Errorf("TransformDatum %s: expected %s, got %s (%v)", d, wgs84, out, err)
+		}
+	}
+}
+
+// A transformer whose values are not comparable: it shifts the latitude by the sum of its offsets
+type offsetTransformer []float64
+
+func (ot offsetTransformer) shift() (sum float64) {
+	for _, o := range ot {
+		sum += o
+	}
+	return
+}
+
+func (ot offsetTransformer) TransformPolar(pc *PolarCoord) *PolarCoord {
+	return &PolarCoord{Latitude: pc.Latitude + ot.shift(), Longitude: pc.Longitude, Height: pc.Height, El: WGS84Ellipsoid}
+}
+
+func (ot offsetTransformer) InverseTransformPolar(pc *PolarCoord) *PolarCoord {
+	return &PolarCoord{Latitude: pc.Latitude - ot.shift(), Longitude: pc.Longitude, Height: pc.Height, El: pc.El}
+}
+
+func TestTransformDatumTransformer(t *testing.T) {
+
+	from := NewDatum("From", Bessel1841Ellipsoid, nil, offsetTransformer{0.5})
+	to := NewDatum("To", Bessel1841Ellipsoid, nil, offsetTransformer{0.25, 0.5})
+	in := &PolarCoord{Latitude: 47, Longitude: 13, El: Bessel1841Ellipsoid}
+
+	// transformers of other types are never the same, even of the same datum
+	for _, test := range []struct {
+		from, to *Datum
+		expected float64
+	}{
+		{from, to, 46.75},
+		{from, from, 47},
+		{to, NewDatum("To (Ferro)", Bessel1841Ellipsoid, FerroMeridian, to.ToWGS84), 47},
+	} {
+		out, err := TransformDatum(in, test.from, test.to)
+		if err != nil || math.Abs(out.Latitude-test.expected) > 1e-12 || out.Datum != test.to {
+			t.Errorf("TransformDatum %s to %s: expected lat %f, got %v (%v)", test.from, test.to, test.expected, out, err)
+		}
+	}
+
+	// transformers of this package are the same by identity
+	if !sameTransformer(MGIDatum.ToWGS84, MGIFerroDatum.ToWGS84) || sameTransformer(MGIDatum.ToWGS84, InverseDatumTransformer(MGIDatum.ToWGS84)) ||
+		sameTransformer(CH1903Datum.ToWGS84, CH1903PlusDatum.ToWGS84) || sameTransformer(from.ToWGS84, from.ToWGS84) {
+		t.Error("sameTransformer: expected the transformers of MGI and MGI (Ferro) only to be the same")
+	}
+}
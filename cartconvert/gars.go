@@ -0,0 +1,194 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ## Global Area Reference System (GARS)
+
+// GARS divides the world into cells of 30' by 30', designated by a three digit longitudinal band
+// 001 - 720 counted eastwards from 180°W and a two letter latitudinal band AA - QZ counted northwards
+// from 90°S. Each cell is divided into four 15' quadrants numbered 1 - 4 starting at the north west,
+// and each quadrant into nine 5' areas numbered 1 - 9 like a telephone keypad. Described in
+// http://earth-info.nga.mil/GandG/coordsys/grids/gars.html
+
+// Letters of the latitudinal bands, omitting I and O
+const garsLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// Length of a GARS designation of the registered GARS coordinate system when converting latitude and
+// longitude. A length of 7 denotes a 5' area.
+const DefaultGARSLength = 7
+
+// Return the GARS designation of length length of a latitude & longitude bearing point:
+// 5 for the 30' cell, 6 for the 15' quadrant and 7 for the 5' area.
+//
+// Returns ErrRange if length is not one of 5, 6 or 7 or the latitude is outside ±90°
+func LatLongToGARS(pc *PolarCoord, length int) (string, error) {
+
+	if length < 5 || length > 7 || math.Abs(pc.Latitude) > 90 {
+		return "", ErrRange
+	}
+
+	// index of the 5' area counting from the antimeridian and the south pole; the north pole belongs to the last cell
+	long := int(math.Floor((geoHashLongitude(pc.Longitude) + 180) * 12))
+	lat := int(math.Floor((pc.Latitude + 90) * 12))
+	if lat == 180*12 {
+		lat--
+	}
+
+	longband, latband := long/6, lat/6
+	gars := fmt.Sprintf("%03d%c%c", longband+1, garsLetters[latband/len(garsLetters)], garsLetters[latband%len(garsLetters)])
+
+	if length >= 6 {
+		// quadrants are numbered from the north west
+		quadrant := 1 + (long%6)/3
+		if (lat%6)/3 == 0 {
+			quadrant += 2
+		}
+		gars += strconv.Itoa(quadrant)
+	}
+
+	if length == 7 {
+		// keypad areas are numbered from the north west
+		gars += strconv.Itoa(1 + long%3 + 3*(2-lat%3))
+	}
+
+	return gars, nil
+}
+
+// Return the area of a GARS designation as a bounding box. Letters are case insensitive.
+// If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+//
+// returns ErrSyntax if gars is not a GARS designation of a cell, quadrant or 5' area
+func GARSToBoundingBox(gars string, el *Ellipsoid) (*BoundingBox, error) {
+
+	gars = strings.ToUpper(strings.TrimSpace(gars))
+	if len(gars) < 5 || len(gars) > 7 {
+		return nil, CartographyError{Coord: gars, Err: ErrSyntax}
+	}
+
+	for i := 0; i < 3; i++ {
+		if gars[i] < '0' || gars[i] > '9' {
+			return nil, CartographyError{Coord: gars, Index: i, Err: ErrSyntax}
+		}
+	}
+	longband, _ := strconv.Atoi(gars[:3])
+	if longband < 1 || longband > 720 {
+		return nil, CartographyError{Coord: gars, Err: ErrSyntax}
+	}
+
+	first, second := strings.IndexByte(garsLetters, gars[3]), strings.IndexByte(garsLetters, gars[4])
+	latband := first*len(garsLetters) + second
+	if first < 0 || second < 0 || latband >= 360 {
+		return nil, CartographyError{Coord: gars, Index: 3, Err: ErrSyntax}
+	}
+
+	west := float64(longband-1)/2 - 180
+	south := float64(latband)/2 - 90
+	size := 0.5
+
+	if len(gars) >= 6 {
+		quadrant := int(gars[5]) - '0'
+		if quadrant < 1 || quadrant > 4 {
+			return nil, CartographyError{Coord: gars, Index: 5, Err: ErrSyntax}
+		}
+		size /= 2
+		west += float64((quadrant-1)%2) * size
+		south += float64(1-(quadrant-1)/2) * size
+	}
+
+	if len(gars) == 7 {
+		keypad := int(gars[6]) - '0'
+		if keypad < 1 || keypad > 9 {
+			return nil, CartographyError{Coord: gars, Index: 6, Err: ErrSyntax}
+		}
+		size /= 3
+		west += float64((keypad-1)%3) * size
+		south += float64(2-(keypad-1)/3) * size
+	}
+
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+
+	return &BoundingBox{South: south, West: west, North: south + size, East: west + size, El: el}, nil
+}
+
+// Return latitude & longitude of the centre of the area of a GARS designation.
+// If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+//
+// returns ErrSyntax if gars is not a GARS designation
+func GARSToLatLong(gars string, el *Ellipsoid) (*PolarCoord, error) {
+
+	bb, err := GARSToBoundingBox(gars, el)
+	if err != nil {
+		return nil, err
+	}
+	return &PolarCoord{Latitude: (bb.South + bb.North) / 2, Longitude: (bb.West + bb.East) / 2, El: bb.El}, nil
+}
+
+// A GARS literal
+type garsDesignation string
+
+func (gars garsDesignation) String() string { return string(gars) }
+
+// GARS as a CoordinateSystem
+type garsSystem struct {
+	name   string
+	length int
+}
+
+// Returns a GARS coordinate system named name, to be registered by RegisterCoordinateSystem.
+// FromLatLong returns designations of length length, see LatLongToGARS.
+func NewGARSCoordinateSystem(name string, length int) CoordinateSystem {
+	return garsSystem{name: name, length: length}
+}
+
+func (gs garsSystem) Name() string     { return gs.name }
+func (garsSystem) Description() string { return "Global Area Reference System" }
+
+func (garsSystem) Parse(coord string) (fmt.Stringer, error) {
+	gars := strings.ToUpper(strings.TrimSpace(coord))
+	if _, err := GARSToBoundingBox(gars, nil); err != nil {
+		return nil, err
+	}
+	return garsDesignation(gars), nil
+}
+
+func (garsSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(garsDesignation); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (garsSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	gars, ok := coord.(garsDesignation)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return GARSToLatLong(string(gars), nil)
+}
+
+func (gs garsSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	gars, err := LatLongToGARS(pc, gs.length)
+	if err != nil {
+		return nil, err
+	}
+	return garsDesignation(gars), nil
+}
+
+func (garsSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return WGS84CRS, nil
+}
+
+func init() {
+	RegisterCoordinateSystem(NewGARSCoordinateSystem("gars", DefaultGARSLength))
+}
@@ -0,0 +1,161 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// ## LatLongToGARS
+type latLongToGARSTest struct {
+	in     *PolarCoord
+	length int
+	out    string
+}
+
+var latLongToGARSTests = []latLongToGARSTest{
+	{&PolarCoord{Latitude: -86.95, Longitude: -177.3}, 5, "006AG"},
+	{&PolarCoord{Latitude: -86.95, Longitude: -177.3}, 6, "006AG3"},
+	{&PolarCoord{Latitude: -86.95, Longitude: -177.3}, 7, "006AG39"},
+	{&PolarCoord{Latitude: 0.1, Longitude: 0.1}, 7, "361HN35"},
+	{&PolarCoord{Latitude: 0.45, Longitude: 0.45}, 7, "361HN23"},
+	{&PolarCoord{Latitude: -0.1, Longitude: -0.1}, 7, "360HM25"},
+	{&PolarCoord{Latitude: 38.89, Longitude: -77.03}, 7, "206LT26"},
+	{&PolarCoord{Latitude: 90, Longitude: 180}, 7, "001QZ11"},
+	{&PolarCoord{Latitude: -90, Longitude: -180}, 7, "001AA37"},
+}
+
+func TestLatLongToGARS(t *testing.T) {
+	for cnt, test := range latLongToGARSTests {
+		out, err := LatLongToGARS(test.in, test.length)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.out != out {
+			t.Errorf("LatLongToGARS [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+
+	for _, length := range []int{0, 4, 8} {
+		if _, err := LatLongToGARS(&PolarCoord{}, length); err != ErrRange {
+			t.Errorf("LatLongToGARS: expected %s for length %d, got %v", ErrRange, length, err)
+		}
+	}
+}
+
+// ## GARSToBoundingBox
+type garsToBoundingBoxTest struct {
+	in  string
+	out *BoundingBox
+}
+
+var garsToBoundingBoxTests = []garsToBoundingBoxTest{
+	{"006AG", &BoundingBox{South: -87, West: -177.5, North: -86.5, East: -177}},
+	{"006AG3", &BoundingBox{South: -87, West: -177.5, North: -86.75, East: -177.25}},
+	{"006ag39", &BoundingBox{South: -87, West: -177.333333, North: -86.916667, East: -177.25}},
+	{"361HN1", &BoundingBox{South: 0.25, West: 0, North: 0.5, East: 0.25}},
+	{"361HN25", &BoundingBox{South: 0.333333, West: 0.333333, North: 0.416667, East: 0.416667}},
+	{"720QZ", &BoundingBox{South: 89.5, West: 179.5, North: 90, East: 180}},
+}
+
+func TestGARSToBoundingBox(t *testing.T) {
+	for cnt, test := range garsToBoundingBoxTests {
+		out, err := GARSToBoundingBox(test.in, nil)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !boundingboxequal(test.out, out) {
+			t.Errorf("GARSToBoundingBox [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+}
+
+type garsErrorTest struct {
+	in    string
+	index int
+}
+
+var garsErrorTests = []garsErrorTest{
+	{"006A", 0},
+	{"006AG391", 0},
+	{"0X6AG", 1},
+	{"000AG", 0},
+	{"721AG", 0},
+	{"006IG", 3},
+	{"006AO", 3},
+	{"006RA", 3},
+	{"006AG5", 5},
+	{"006AG30", 6},
+}
+
+func TestGARSErrors(t *testing.T) {
+	for cnt, test := range garsErrorTests {
+		_, err := GARSToBoundingBox(test.in, nil)
+
+		cerr, ok := err.(CartographyError)
+		if !ok || cerr.Err != ErrSyntax || cerr.Index != test.index {
+			t.Errorf("GARSToBoundingBox [%d]: expected %s at index %d, got %v", cnt, ErrSyntax, test.index, err)
+		}
+	}
+}
+
+// ## Round trip of every precision
+func TestGARSRoundTrip(t *testing.T) {
+	for _, in := range []*PolarCoord{
+		{Latitude: 48.208333, Longitude: 16.372778},
+		{Latitude: -33.856784, Longitude: 151.215297},
+		{Latitude: 64.146582, Longitude: -21.942635},
+	} {
+		for length := 5; length <= 7; length++ {
+			gars, err := LatLongToGARS(in, length)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			bb, err := GARSToBoundingBox(gars, nil)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			if !bb.Contains(in) {
+				t.Errorf("GARS round trip: %s of %s does not contain %s", bb, gars, in)
+			}
+
+			if out, _ := LatLongToGARS(bb.Center(), length); out != gars {
+				t.Errorf("GARS round trip: expected %s for the centre of %s, got %s", gars, bb, out)
+			}
+		}
+	}
+}
+
+// ## NewGARSCoordinateSystem
+func TestGARSCoordinateSystem(t *testing.T) {
+	test := latLongToGARSTests[0]
+
+	cs := NewGARSCoordinateSystem("gars5", test.length)
+	if out, err := cs.FromLatLong(test.in); err != nil || cs.Name() != "gars5" || out.String() != test.out {
+		t.Errorf("NewGARSCoordinateSystem: Expected gars5 %s, got %s %v (%v)", test.out, cs.Name(), out, err)
+	}
+
+	if _, err := NewGARSCoordinateSystem("gars", 8).FromLatLong(test.in); err != ErrRange {
+		t.Errorf("NewGARSCoordinateSystem: Expected %s for length 8, got %v", ErrRange, err)
+	}
+
+	// the registered coordinate system converts at the default length
+	if cs, ok := LookupCoordinateSystem("gars"); !ok {
+		t.Error("LookupCoordinateSystem: gars not registered")
+	} else if out, err := cs.FromLatLong(test.in); err != nil || len(out.String()) != DefaultGARSLength {
+		t.Errorf("gars: Expected a designation of length %d, got %v (%v)", DefaultGARSLength, out, err)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+)
+
+// ## Geodesics on the ellipsoid
+
+// Normalize an angle in decimal degrees to the range [0, 360)
+func normalizeAzimuth(az float64) float64 {
+	az = math.Mod(az, 360)
+	if az < 0 {
+		az += 360
+	}
+	if az >= 360 {
+		az = 0
+	}
+	return az
+}
+
+// Normalize a longitude in decimal degrees to the range (-180, 180]
+func normalizeLongitude(long float64) float64 {
+	long = math.Mod(long, 360)
+	switch {
+	case long <= -180:
+		long += 360
+	case long > 180:
+		long -= 360
+	}
+	return long
+}
+
+// Coefficients A and B of Vincenty's series of the geodesic distance for cosSqAlpha, the squared
+// cosine of the azimuth of the geodesic at the equator
+func vincentyAB(el *Ellipsoid, cosSqAlpha float64) (A, B float64) {
+	uSq := cosSqAlpha * (el.a*el.a - el.b*el.b) / (el.b * el.b)
+	A = 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B = uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	return
+}
+
+// Difference of the arc length on the auxiliary sphere and the geodesic distance, scaled by b*A
+func vincentyDeltaSigma(B, sinSigma, cosSigma, cos2SigmaM float64) float64 {
+	return B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+}
+
+// Difference of the longitude on the auxiliary sphere and on the ellipsoid
+func vincentyDeltaLambda(f, sinAlpha, cosSqAlpha, sigma, sinSigma, cosSigma, cos2SigmaM float64) float64 {
+	C := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+	return (1 - C) * f * sinAlpha * (sigma + C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+}
+
+// Sine and cosine of the reduced latitude of lat, given in decimal degrees
+func reducedLatitude(lat, f float64) (sinU, cosU float64) {
+	latrad := degtorad(lat)
+	U := math.Atan2((1-f)*math.Sin(latrad), math.Cos(latrad))
+	return math.Sin(U), math.Cos(U)
+}
+
+// Solve the inverse geodesic problem by Vincenty's iteration over the longitude on the auxiliary sphere.
+// Returns false if the iteration does not converge, which happens for nearly antipodal points.
+func vincentyInverse(el *Ellipsoid, sinU1, cosU1, sinU2, cosU2, L float64) (s, alpha1, alpha2 float64, ok bool) {
+
+	f := 1 - el.b/el.a
+
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, sinAlpha, cosSqAlpha, cos2SigmaM float64
+
+	lambda := L
+	for i := 0; i < 200; i++ {
+		sinLambda, cosLambda = math.Sincos(lambda)
+
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			// coincident points; exactly antipodal points are left to the caller
+			return 0, 0, 0, cosU1*cosU2*cosLambda+sinU1*sinU2 > 0
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+
+		sinAlpha = cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+
+		cos2SigmaM = 0 // equatorial line
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+
+		lambdaP := lambda
+		lambda = L + vincentyDeltaLambda(f, sinAlpha, cosSqAlpha, sigma, sinSigma, cosSigma, cos2SigmaM)
+
+		if math.Abs(lambda) > math.Pi {
+			return 0, 0, 0, false
+		}
+
+		if math.Abs(lambda-lambdaP) < 1e-12 {
+			A, B := vincentyAB(el, cosSqAlpha)
+			s = el.b * A * (sigma - vincentyDeltaSigma(B, sinSigma, cosSigma, cos2SigmaM))
+			alpha1 = math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+			alpha2 = math.Atan2(cosU1*sinLambda, -sinU1*cosU2+cosU1*sinU2*cosLambda)
+			return s, alpha1, alpha2, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// Follow the geodesic leaving the reduced latitude U1 <= 0 with azimuth alpha1 in [0, pi] up to its ascending
+// crossing of the reduced latitude U2, |U2| <= |U1|. Returns the difference in longitude, the distance and the
+// azimuth at the crossing.
+func geodesicToLatitude(el *Ellipsoid, sinU1, cosU1, sinU2, cosU2, alpha1 float64) (lambda12, s, alpha2 float64) {
+
+	f := 1 - el.b/el.a
+
+	sinAlpha1, cosAlpha1 := math.Sincos(alpha1)
+
+	sinAlpha0 := sinAlpha1 * cosU1
+	cosSqAlpha0 := 1 - sinAlpha0*sinAlpha0
+
+	cosAlpha2cosU2 := math.Sqrt(math.Max(0, cosAlpha1*cosAlpha1*cosU1*cosU1+(cosU2*cosU2-cosU1*cosU1)))
+
+	// sinU1 <= 0, keep the arcs of the first point in [-pi, 0] also on the equator
+	sigma1 := math.Atan2(sinU1, cosAlpha1*cosU1)
+	if sigma1 > 0 {
+		sigma1 -= 2 * math.Pi
+	}
+	omega1 := math.Atan2(sinAlpha0*sinU1, cosAlpha1*cosU1)
+	if omega1 > 0 {
+		omega1 -= 2 * math.Pi
+	}
+	sigma2 := math.Atan2(sinU2, cosAlpha2cosU2)
+	omega2 := math.Atan2(sinAlpha0*sinU2, cosAlpha2cosU2)
+
+	sigma := sigma2 - sigma1
+	sinSigma, cosSigma := math.Sincos(sigma)
+	cos2SigmaM := math.Cos(sigma1 + sigma2)
+
+	lambda12 = omega2 - omega1 - vincentyDeltaLambda(f, sinAlpha0, cosSqAlpha0, sigma, sinSigma, cosSigma, cos2SigmaM)
+
+	A, B := vincentyAB(el, cosSqAlpha0)
+	s = el.b * A * (sigma - vincentyDeltaSigma(B, sinSigma, cosSigma, cos2SigmaM))
+
+	alpha2 = math.Atan2(sinAlpha0, cosAlpha2cosU2)
+
+	return
+}
+
+// Computes the length of the geodesic, the shortest path on the ellipsoid, between the points from and to,
+// as well as the azimuth of the geodesic at from and the azimuth from to back to from. The distance is
+// in meters, the azimuths are in decimal degrees clockwise from north in the range [0, 360).
+// If the polar coordinates do not contain a reference ellipsoid, the DefaultEllipsoid is assumed.
+// The reference ellipsoid of from is used for both points.
+//
+// The geodesic is computed by Vincenty's inverse formula. For nearly antipodal points, where Vincenty's
+// iteration fails to converge, the azimuth at from is determined by bisection, following
+// "C. F. F. Karney: Algorithms for geodesics, J. Geodesy 87(1), 43-55 (Jan. 2013)"
+//
+// Taken from "T. Vincenty: Direct and inverse solutions of geodesics on the ellipsoid with application
+// of nested equations, Survey Review 23(176), 88-93 (Apr. 1975)"
+func GeodesicInverse(from, to *PolarCoord) (distance, azimuth, backazimuth float64) {
+
+	el := from.El
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+	f := 1 - el.b/el.a
+
+	lat1, lat2 := from.Latitude, to.Latitude
+	long12 := normalizeLongitude(to.Longitude - from.Longitude)
+
+	// Transform the problem to the canonical configuration lat1 <= 0, |lat2| <= |lat1| and long12 >= 0
+	lonsign := 1.0
+	if long12 < 0 {
+		lonsign = -1
+	}
+	long12 *= lonsign
+
+	swapp := 1.0
+	if math.Abs(lat1) < math.Abs(lat2) {
+		swapp = -1
+		lonsign *= -1
+		lat1, lat2 = lat2, lat1
+	}
+
+	latsign := 1.0
+	if lat1 > 0 {
+		latsign = -1
+	}
+	lat1 *= latsign
+	lat2 *= latsign
+
+	sinU1, cosU1 := reducedLatitude(lat1, f)
+	sinU2, cosU2 := reducedLatitude(lat2, f)
+	L := degtorad(long12)
+
+	s, alpha1, alpha2, ok := vincentyInverse(el, sinU1, cosU1, sinU2, cosU2, L)
+
+	if !ok {
+		// The difference in longitude grows monotonically with the azimuth at the first point
+		lo, hi := 0.0, math.Pi
+		for i := 0; i < 100 && hi-lo > 1e-15; i++ {
+			mid := (lo + hi) / 2
+			if lambda12, _, _ := geodesicToLatitude(el, sinU1, cosU1, sinU2, cosU2, mid); lambda12 < L {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		alpha1 = (lo + hi) / 2
+		_, s, alpha2 = geodesicToLatitude(el, sinU1, cosU1, sinU2, cosU2, alpha1)
+	}
+
+	// Transform the azimuths back to the original configuration
+	sinAlpha1, cosAlpha1 := math.Sincos(alpha1)
+	sinAlpha2, cosAlpha2 := math.Sincos(alpha2)
+	if swapp < 0 {
+		sinAlpha1, sinAlpha2 = sinAlpha2, sinAlpha1
+		cosAlpha1, cosAlpha2 = cosAlpha2, cosAlpha1
+	}
+	sinAlpha1 *= swapp * lonsign
+	cosAlpha1 *= swapp * latsign
+	sinAlpha2 *= swapp * lonsign
+	cosAlpha2 *= swapp * latsign
+
+	distance = s
+	azimuth = normalizeAzimuth(radtodeg(math.Atan2(sinAlpha1, cosAlpha1)))
+	backazimuth = normalizeAzimuth(radtodeg(math.Atan2(sinAlpha2, cosAlpha2)) + 180)
+
+	return
+}
+
+// Computes the point reached when following the geodesic leaving from with the azimuth azimuth for distance
+// meters, and the azimuth from the destination back to from. Azimuths are in decimal degrees clockwise from
+// north, the back azimuth is in the range [0, 360). If the polar coordinates do not contain a reference
+// ellipsoid, the DefaultEllipsoid is assumed and copied to the destination.
+//
+// Taken from "T. Vincenty: Direct and inverse solutions of geodesics on the ellipsoid with application
+// of nested equations, Survey Review 23(176), 88-93 (Apr. 1975)"
+func GeodesicDirect(from *PolarCoord, azimuth, distance float64) (to *PolarCoord, backazimuth float64) {
+
+	el := from.El
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+	f := 1 - el.b/el.a
+
+	sinAlpha1, cosAlpha1 := math.Sincos(degtorad(azimuth))
+	sinU1, cosU1 := reducedLatitude(from.Latitude, f)
+
+	sigma1 := math.Atan2(sinU1, cosU1*cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cosSqAlpha := 1 - sinAlpha*sinAlpha
+
+	A, B := vincentyAB(el, cosSqAlpha)
+
+	var sinSigma, cosSigma, cos2SigmaM float64
+
+	sigma := distance / (el.b * A)
+	for i := 0; i < 200; i++ {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sincos(sigma)
+
+		sigmaP := sigma
+		sigma = distance/(el.b*A) + vincentyDeltaSigma(B, sinSigma, cosSigma, cos2SigmaM)
+
+		if math.Abs(sigma-sigmaP) < 1e-12 {
+			break
+		}
+	}
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+	sinSigma, cosSigma = math.Sincos(sigma)
+
+	tmp := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat2 := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-f)*math.Hypot(sinAlpha, tmp))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	L := lambda - vincentyDeltaLambda(f, sinAlpha, cosSqAlpha, sigma, sinSigma, cosSigma, cos2SigmaM)
+
+	to = &PolarCoord{
+		Latitude:  radtodeg(lat2),
+		Longitude: normalizeLongitude(from.Longitude + radtodeg(L)),
+		Height:    from.Height,
+		El:        el,
+	}
+	backazimuth = normalizeAzimuth(radtodeg(math.Atan2(sinAlpha, -tmp)) + 180)
+
+	return
+}
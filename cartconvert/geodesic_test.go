@@ -0,0 +1,102 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ## GeodesicInverse
+type geodesicInverseTest struct {
+	from, to                       *PolarCoord
+	distance, azimuth, backazimuth float64
+}
+
+var geodesicInverseTests = []geodesicInverseTest{
+	{
+		// Flinders Peak to Buninyong, Vincenty (1975) and Geoscience Australia
+		&PolarCoord{Latitude: -(37 + 57.0/60 + 3.7203/3600), Longitude: 144 + 25.0/60 + 29.5244/3600, El: GRS80Ellipsoid},
+		&PolarCoord{Latitude: -(37 + 39.0/60 + 10.1561/3600), Longitude: 143 + 55.0/60 + 35.3839/3600, El: GRS80Ellipsoid},
+		54972.271, 306 + 52.0/60 + 5.37/3600, 127 + 10.0/60 + 25.07/3600,
+	},
+	{
+		&PolarCoord{Latitude: -(37 + 39.0/60 + 10.1561/3600), Longitude: 143 + 55.0/60 + 35.3839/3600, El: GRS80Ellipsoid},
+		&PolarCoord{Latitude: -(37 + 57.0/60 + 3.7203/3600), Longitude: 144 + 25.0/60 + 29.5244/3600, El: GRS80Ellipsoid},
+		54972.271, 127 + 10.0/60 + 25.07/3600, 306 + 52.0/60 + 5.37/3600,
+	},
+	{
+		// Half meridian of WGS84
+		&PolarCoord{Latitude: 90, Longitude: 0, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: -90, Longitude: 0, El: WGS84Ellipsoid},
+		20003931.459, 180, 0,
+	},
+	{
+		// Antipodal points on the equator: the geodesic runs across the poles
+		&PolarCoord{Latitude: 0, Longitude: 0, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: 0, Longitude: 180, El: WGS84Ellipsoid},
+		20003931.459, 180, 180,
+	},
+	{
+		// Nearly antipodal points, Karney: Algorithms for geodesics (2013)
+		&PolarCoord{Latitude: -30, Longitude: 0, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: 29.9, Longitude: 179.8, El: WGS84Ellipsoid},
+		19989832.828, 161.8905247, 198.0907372,
+	},
+	{
+		&PolarCoord{Latitude: 30, Longitude: 0, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: -29.9, Longitude: -179.8, El: WGS84Ellipsoid},
+		19989832.828, 341.8905247, 18.0907372,
+	},
+	{
+		&PolarCoord{Latitude: 0, Longitude: 0, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: 0.5, Longitude: 179.5, El: WGS84Ellipsoid},
+		19936288.579, 25.6718729, 334.3270855,
+	},
+}
+
+func geodesicequal(d1, az1, baz1, d2, az2, baz2 float64) bool {
+	g1 := fmt.Sprintf("%.3f %.5f %.5f", d1, az1, baz1)
+	g2 := fmt.Sprintf("%.3f %.5f %.5f", d2, az2, baz2)
+
+	return g1 == g2
+}
+
+func TestGeodesicInverse(t *testing.T) {
+	pc := &PolarCoord{Latitude: 48.2, Longitude: 16.37}
+	if distance, _, _ := GeodesicInverse(pc, pc); distance != 0 {
+		t.Errorf("GeodesicInverse: Expected 0 for coincident points, got %f", distance)
+	}
+
+	for cnt, test := range geodesicInverseTests {
+		distance, azimuth, backazimuth := GeodesicInverse(test.from, test.to)
+		if !geodesicequal(test.distance, test.azimuth, test.backazimuth, distance, azimuth, backazimuth) {
+			t.Errorf("GeodesicInverse [%d]: Expected %.3f %.6f %.6f, got %.3f %.6f %.6f", cnt,
+				test.distance, test.azimuth, test.backazimuth, distance, azimuth, backazimuth)
+		}
+	}
+}
+
+// ## GeodesicDirect
+func TestGeodesicDirect(t *testing.T) {
+	for cnt, test := range geodesicInverseTests[:2] {
+		to, backazimuth := GeodesicDirect(test.from, test.azimuth, test.distance)
+		if !latlongequal(test.to, to) || !geodesicequal(0, 0, test.backazimuth, 0, 0, backazimuth) {
+			t.Errorf("GeodesicDirect [%d]: Expected %s %f, got %s %f", cnt, test.to, test.backazimuth, to, backazimuth)
+		}
+	}
+
+	// Round trip across half the globe
+	from := &PolarCoord{Latitude: -30, Longitude: 0, El: WGS84Ellipsoid}
+	for _, azimuth := range []float64{45, 161.8905247, 200} {
+		to, backazimuth := GeodesicDirect(from, azimuth, 19989832.828)
+		distance, az, baz := GeodesicInverse(from, to)
+		if !geodesicequal(19989832.828, azimuth, backazimuth, distance, az, baz) {
+			t.Errorf("GeodesicDirect %f: Expected %.3f %.6f %.6f, got %.3f %.6f %.6f", azimuth,
+				19989832.828, azimuth, backazimuth, distance, az, baz)
+		}
+	}
+}
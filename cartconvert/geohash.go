@@ -0,0 +1,230 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ## Geohash cell functions for proximity search
+
+// Compass direction of an adjacent cell
+type Direction int
+
+const (
+	North Direction = iota
+	NorthEast
+	East
+	SouthEast
+	South
+	SouthWest
+	West
+	NorthWest
+)
+
+func (dir Direction) String() string {
+	switch dir {
+	case North:
+		return "North"
+	case NorthEast:
+		return "NorthEast"
+	case East:
+		return "East"
+	case SouthEast:
+		return "SouthEast"
+	case South:
+		return "South"
+	case SouthWest:
+		return "SouthWest"
+	case West:
+		return "West"
+	case NorthWest:
+		return "NorthWest"
+	}
+	return "#unknown"
+}
+
+// Number of cells in north and east direction to step towards dir
+func (dir Direction) offset() (dlat, dlong int) {
+	switch dir {
+	case North:
+		return 1, 0
+	case NorthEast:
+		return 1, 1
+	case East:
+		return 0, 1
+	case SouthEast:
+		return -1, 1
+	case South:
+		return -1, 0
+	case SouthWest:
+		return -1, -1
+	case West:
+		return 0, -1
+	case NorthWest:
+		return 1, -1
+	}
+	return 0, 0
+}
+
+// The maximum number of geohashes GeoHashCovering returns
+const maxGeoHashCovering = 1 << 16
+
+// The maximum precision of GeoHashCovering: up to 50 bits of latitude and longitude each, the cell indices are
+// exact in a float64 and an int. Finer cells are below the resolution of a float64 degree anyway.
+const maxGeoHashCoveringPrecision = 20
+
+// Size of a geohash cell of precision characters in decimal degrees
+func geoHashCellSize(precision int) (dlat, dlong float64) {
+	latbits := 5 * precision / 2
+	longbits := 5*precision - latbits
+	return 180 / math.Exp2(float64(latbits)), 360 / math.Exp2(float64(longbits))
+}
+
+// Wrap a longitude into the range [-180, 180) used by geohashes
+func geoHashLongitude(long float64) float64 {
+	long = math.Mod(long+180, 360)
+	if long < 0 {
+		long += 360
+	}
+	return long - 180
+}
+
+// Returns the geohash of the same precision adjacent to geohash in direction dir. Neighbours
+// across the antimeridian wrap around.
+//
+// Returns ErrRange if geohash is not a geohash or if there is no neighbour beyond the poles.
+func GeoHashNeighbour(geohash string, dir Direction) (string, error) {
+
+	if len(geohash) == 0 {
+		return "", ErrRange
+	}
+
+	bb, err := GeoHashToBoundingBox(geohash, nil)
+	if err != nil {
+		return "", err
+	}
+
+	dlat, dlong := dir.offset()
+	center := bb.Center()
+
+	lat := center.Latitude + float64(dlat)*(bb.North-bb.South)
+	if lat < -90 || lat > 90 {
+		return "", ErrRange
+	}
+	long := geoHashLongitude(center.Longitude + float64(dlong)*(bb.East-bb.West))
+
+	return LatLongToGeoHashBits(&PolarCoord{Latitude: lat, Longitude: long}, byte(len(geohash))), nil
+}
+
+// Returns the eight geohashes surrounding geohash, indexed by Direction. At the poles the
+// neighbours beyond the pole are left empty.
+//
+// Returns ErrRange if geohash is not a geohash.
+func GeoHashNeighbours(geohash string) (neighbours [8]string, err error) {
+
+	if _, err = GeoHashToBoundingBox(geohash, nil); err != nil || len(geohash) == 0 {
+		return neighbours, ErrRange
+	}
+
+	for dir := North; dir <= NorthWest; dir++ {
+		// only the neighbours beyond the poles do not exist
+		if neighbour, err := GeoHashNeighbour(geohash, dir); err == nil {
+			neighbours[dir] = neighbour
+		}
+	}
+	return neighbours, nil
+}
+
+// Returns the geohash of the enclosing cell, which is one character shorter.
+//
+// Returns ErrRange if geohash is not a geohash or has no parent.
+func GeoHashParent(geohash string) (string, error) {
+
+	if len(geohash) < 2 {
+		return "", ErrRange
+	}
+	if _, err := GeoHashToBoundingBox(geohash, nil); err != nil {
+		return "", err
+	}
+	return geohash[:len(geohash)-1], nil
+}
+
+// Returns the 32 geohashes of the cells within geohash, which are one character longer.
+//
+// Returns ErrRange if geohash is not a geohash.
+func GeoHashChildren(geohash string) ([]string, error) {
+
+	if _, err := GeoHashToBoundingBox(geohash, nil); err != nil {
+		return nil, err
+	}
+
+	children := make([]string, len(Base32GeohashCode))
+	for i, c := range Base32GeohashCode {
+		children[i] = geohash + string(c)
+	}
+	return children, nil
+}
+
+// Returns the sorted geohashes of precision characters whose cells together cover the bounding box bb.
+// Bounding boxes crossing the antimeridian have West larger than East.
+//
+// Returns ErrRange if the bounding box is invalid or more than 65536 geohashes would be needed to cover the
+// bounding box, and a CartographyError with ErrRange if the precision is 0 or larger than 20.
+func GeoHashCovering(bb *BoundingBox, precision byte) ([]string, error) {
+
+	if precision == 0 || precision > maxGeoHashCoveringPrecision {
+		return nil, CartographyError{Coord: strconv.Itoa(int(precision)), Val: float64(precision), Err: ErrRange}
+	}
+
+	if bb.South > bb.North || bb.South < -90 || bb.North > 90 ||
+		bb.West < -180 || bb.West > 180 || bb.East < -180 || bb.East > 180 {
+		return nil, ErrRange
+	}
+
+	dlat, dlong := geoHashCellSize(int(precision))
+
+	latcells := int(180 / dlat)
+	longcells := int(360 / dlong)
+
+	cellindex := func(val, min, size float64, cells int) int {
+		index := int(math.Floor((val - min) / size))
+		if index >= cells {
+			index = cells - 1
+		}
+		return index
+	}
+
+	south := cellindex(bb.South, -90, dlat, latcells)
+	north := cellindex(bb.North, -90, dlat, latcells)
+	west := cellindex(bb.West, -180, dlong, longcells)
+	east := cellindex(bb.East, -180, dlong, longcells)
+
+	columns := east - west + 1
+	if bb.West > bb.East {
+		columns += longcells
+	}
+	if columns > longcells {
+		columns = longcells
+	}
+
+	if (north-south+1)*columns > maxGeoHashCovering {
+		return nil, ErrRange
+	}
+
+	geohashes := make([]string, 0, (north-south+1)*columns)
+	for row := south; row <= north; row++ {
+		lat := -90 + (float64(row)+0.5)*dlat
+		for col := 0; col < columns; col++ {
+			long := -180 + (float64((west+col)%longcells)+0.5)*dlong
+			geohashes = append(geohashes, LatLongToGeoHashBits(&PolarCoord{Latitude: lat, Longitude: long}, precision))
+		}
+	}
+
+	sort.Strings(geohashes)
+	return geohashes, nil
+}
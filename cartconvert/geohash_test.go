@@ -0,0 +1,178 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// ## GeoHashToBoundingBox
+type geoHashToBoundingBoxTest struct {
+	in  string
+	out *BoundingBox
+}
+
+var geoHashToBoundingBoxTests = []geoHashToBoundingBoxTest{
+	{"ezs42", &BoundingBox{South: 42.583008, West: -5.625, North: 42.626953, East: -5.581055}},
+	{"u", &BoundingBox{South: 45, West: 0, North: 90, East: 45}},
+	{"", &BoundingBox{South: -90, West: -180, North: 90, East: 180}},
+}
+
+func boundingboxequal(bb1, bb2 *BoundingBox) bool {
+	bb1s := fmt.Sprintf("%.6f %.6f %.6f %.6f", bb1.South, bb1.West, bb1.North, bb1.East)
+	bb2s := fmt.Sprintf("%.6f %.6f %.6f %.6f", bb2.South, bb2.West, bb2.North, bb2.East)
+
+	return bb1s == bb2s
+}
+
+func TestGeoHashToBoundingBox(t *testing.T) {
+	for cnt, test := range geoHashToBoundingBoxTests {
+		out, err := GeoHashToBoundingBox(test.in, nil)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !boundingboxequal(test.out, out) {
+			t.Errorf("GeoHashToBoundingBox [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+
+		if !out.Contains(out.Center()) {
+			t.Errorf("GeoHashToBoundingBox [%d]: %s does not contain its centre", cnt, out)
+		}
+	}
+
+	if _, err := GeoHashToBoundingBox("ezs4a", nil); err != ErrRange {
+		t.Errorf("GeoHashToBoundingBox: expected %s, got %v", ErrRange, err)
+	}
+}
+
+// ## BoundingBox
+func TestBoundingBoxAntimeridian(t *testing.T) {
+	bb := &BoundingBox{South: -10, West: 170, North: 10, East: -160}
+
+	if center := bb.Center(); !latlongequal(&PolarCoord{Latitude: 0, Longitude: -175}, center) {
+		t.Errorf("BoundingBox.Center: expected -175, got %s", center)
+	}
+
+	for _, long := range []float64{175, 180, -180, -165} {
+		if !bb.Contains(&PolarCoord{Longitude: long}) {
+			t.Errorf("BoundingBox.Contains: expected %s to contain longitude %f", bb, long)
+		}
+	}
+
+	if bb.Contains(&PolarCoord{Longitude: 0}) {
+		t.Errorf("BoundingBox.Contains: expected %s not to contain longitude 0", bb)
+	}
+}
+
+// ## GeoHashNeighbours
+type geoHashNeighboursTest struct {
+	in  string
+	out [8]string
+}
+
+var geoHashNeighboursTests = []geoHashNeighboursTest{
+	{"ezs42", [8]string{"ezs48", "ezs49", "ezs43", "ezs41", "ezs40", "ezefp", "ezefr", "ezefx"}},
+	{"u4pruydqqvj", [8]string{"u4pruydqqvm", "u4pruydqqvq", "u4pruydqqvn", "u4pruydqquy", "u4pruydqquv", "u4pruydqquu", "u4pruydqqvh", "u4pruydqqvk"}},
+	// east of the antimeridian
+	{"xbpb", [8]string{"xbpc", "8001", "8000", "2pbp", "rzzz", "rzzx", "xbp8", "xbp9"}},
+	// at the north pole
+	{"upbp", [8]string{"", "", "upbr", "upbq", "upbn", "gzzy", "gzzz", ""}},
+}
+
+func TestGeoHashNeighbours(t *testing.T) {
+	for cnt, test := range geoHashNeighboursTests {
+		out, err := GeoHashNeighbours(test.in)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if out != test.out {
+			t.Errorf("GeoHashNeighbours [%d]: expected %v, got %v", cnt, test.out, out)
+		}
+	}
+
+	if _, err := GeoHashNeighbour("upbp", North); err != ErrRange {
+		t.Errorf("GeoHashNeighbour: expected %s beyond the pole, got %v", ErrRange, err)
+	}
+
+	if _, err := GeoHashNeighbours(""); err != ErrRange {
+		t.Errorf("GeoHashNeighbours: expected %s, got %v", ErrRange, err)
+	}
+}
+
+// ## GeoHashParent, GeoHashChildren
+func TestGeoHashParentChildren(t *testing.T) {
+	children, err := GeoHashChildren("ezs4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(children) != 32 || children[2] != "ezs42" {
+		t.Errorf("GeoHashChildren: unexpected children %v", children)
+	}
+
+	for _, child := range children {
+		if parent, err := GeoHashParent(child); err != nil || parent != "ezs4" {
+			t.Errorf("GeoHashParent: expected ezs4 for %s, got %s (%v)", child, parent, err)
+		}
+	}
+
+	if _, err := GeoHashParent("e"); err != ErrRange {
+		t.Errorf("GeoHashParent: expected %s, got %v", ErrRange, err)
+	}
+}
+
+// ## GeoHashCovering
+type geoHashCoveringTest struct {
+	bb        *BoundingBox
+	precision byte
+	out       []string
+}
+
+var geoHashCoveringTests = []geoHashCoveringTest{
+	{&BoundingBox{South: 57.64, West: 10.40, North: 57.65, East: 10.41}, 5, []string{"u4pru"}},
+	{&BoundingBox{South: 42.6, West: -5.6, North: 42.6, East: -5.6}, 5, []string{"ezs42"}},
+	{&BoundingBox{South: 42.6, West: -5.7, North: 42.65, East: -5.6}, 5, []string{"ezefq", "ezefr", "ezefw", "ezefx", "ezs42", "ezs48"}},
+	// crossing the antimeridian
+	{&BoundingBox{South: -1, West: 179, North: 1, East: -179}, 2, []string{"2p", "80", "rz", "xb"}},
+}
+
+func TestGeoHashCovering(t *testing.T) {
+	for cnt, test := range geoHashCoveringTests {
+		out, err := GeoHashCovering(test.bb, test.precision)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !reflect.DeepEqual(test.out, out) {
+			t.Errorf("GeoHashCovering [%d]: expected %v, got %v", cnt, test.out, out)
+		}
+	}
+
+	// the cell counts of fine precisions overflow
+	for _, precision := range []byte{0, 21, 25, 30} {
+		_, err := GeoHashCovering(&BoundingBox{South: 42.6, West: -5.6, North: 42.6, East: -5.6}, precision)
+		if ce, ok := err.(CartographyError); !ok || ce.Err != ErrRange || ce.Val != float64(precision) {
+			t.Errorf("GeoHashCovering [%d]: expected %s, got %v", precision, ErrRange, err)
+		}
+	}
+	if out, err := GeoHashCovering(&BoundingBox{South: 42.6, West: -5.6, North: 42.6, East: -5.6}, maxGeoHashCoveringPrecision); err != nil || len(out) != 1 || out[0][:5] != "ezs42" {
+		t.Errorf("GeoHashCovering: expected a single geohash in ezs42, got %v (%v)", out, err)
+	}
+
+	if _, err := GeoHashCovering(&BoundingBox{South: -90, West: -180, North: 90, East: 180}, 6); err != ErrRange {
+		t.Errorf("GeoHashCovering: expected %s, got %v", ErrRange, err)
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// ## Geoid models
+
+// The height of a PolarCoord is the ellipsoidal height above the reference ellipsoid. Orthometric heights, as
+// given by maps and elevation models, refer to the geoid; The difference is the geoid undulation N:
+//
+//	h = H + N
+//
+// with h the ellipsoidal and H the orthometric height.
+
+// A model of the geoid, which returns the geoid undulation in meters at a coordinate
+type Geoid interface {
+	Undulation(pc *PolarCoord) (float64, error)
+}
+
+// Returns the orthometric height of pc, whose Height is ellipsoidal, by the geoid model g
+func OrthometricHeight(g Geoid, pc *PolarCoord) (float64, error) {
+	n, err := g.Undulation(pc)
+	if err != nil {
+		return 0, err
+	}
+	return pc.Height - n, nil
+}
+
+// Returns a copy of pc with the ellipsoidal height of the orthometric height by the geoid model g
+func EllipsoidalHeight(g Geoid, pc *PolarCoord, orthometric float64) (*PolarCoord, error) {
+	n, err := g.Undulation(pc)
+	if err != nil {
+		return nil, err
+	}
+	out := *pc
+	out.Height = orthometric + n
+	return &out, nil
+}
+
+// Interpolation of geoid undulations between the nodes of a grid
+type GeoidInterpolation byte
+
+const (
+	// bilinear interpolation of the four surrounding nodes
+	GeoidBilinear GeoidInterpolation = iota
+	// bicubic convolution of the sixteen surrounding nodes, continuous in the first derivative
+	GeoidBicubic
+)
+
+func (gi GeoidInterpolation) String() string {
+	switch gi {
+	case GeoidBilinear:
+		return "Bilinear"
+	case GeoidBicubic:
+		return "Bicubic"
+	}
+	return "GeoidInterpolation(" + fmt.Sprint(byte(gi)) + ")"
+}
+
+// A regular grid of geoid undulations in meters, like EGM96, EGM2008 or national geoid models. South, West and
+// the increments are in decimal degrees; Grids spanning 360 degrees of longitude wrap around.
+type GeoidGrid struct {
+	South, West     float64
+	LatInc, LongInc float64
+	Rows, Cols      int
+	Interpolation   GeoidInterpolation
+	// undulations from south to north and west to east; NaN for nodes without data
+	undulation []float32
+}
+
+// The value of nodes without data in GTX files
+const gtxNoData = -88.8888
+
+// Reads a geoid grid in the GTX format of the NOAA VDatum project, as used by PROJ for EGM96, EGM2008 and many
+// national geoid models. The big endian header gives the latitude and longitude of the south-west node, the
+// increments in degrees and the number of rows and columns, followed by the undulations as 32bit floats from
+// south to north and west to east.
+//
+// Returns a CartographyError with ErrSyntax as the error if the header is malformed.
+func LoadGTX(r io.Reader) (*GeoidGrid, error) {
+
+	var header struct {
+		South, West, LatInc, LongInc float64
+		Rows, Cols                   int32
+	}
+
+	br := bufio.NewReader(r)
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	grid := &GeoidGrid{South: header.South, West: header.West, LatInc: header.LatInc, LongInc: header.LongInc, Rows: int(header.Rows), Cols: int(header.Cols)}
+	if err := grid.check(); err != nil {
+		return nil, err
+	}
+
+	grid.undulation = make([]float32, grid.Rows*grid.Cols)
+	if err := binary.Read(br, binary.BigEndian, grid.undulation); err != nil {
+		return nil, err
+	}
+
+	for i, n := range grid.undulation {
+		if math.Abs(float64(n)-gtxNoData) < 1e-3 {
+			grid.undulation[i] = float32(math.NaN())
+		}
+	}
+	return grid, nil
+}
+
+// Reads a geoid grid in the GTX format from the file name. See LoadGTX.
+func LoadGTXFile(name string) (*GeoidGrid, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadGTX(f)
+}
+
+// Reads a geoid grid in the text format of the NGA, like the EGM96 grid WW15MGH.GRD. The first six numbers
+// give the southern, northern, western and eastern bound and the increments of latitude and longitude in
+// degrees, followed by the undulations from north to south and west to east, separated by white space.
+//
+// Returns a CartographyError with ErrSyntax as the error for malformed numbers, the index of the number as Index.
+func LoadGRD(r io.Reader) (*GeoidGrid, error) {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	index := 0
+	next := func() (float64, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, CartographyError{Coord: "", Index: index, Err: ErrSyntax}
+		}
+		val, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return 0, CartographyError{Coord: scanner.Text(), Index: index, Err: ErrSyntax}
+		}
+		index++
+		return val, nil
+	}
+
+	var header [6]float64
+	for i := range header {
+		val, err := next()
+		if err != nil {
+			return nil, err
+		}
+		header[i] = val
+	}
+
+	south, north, west, east, latinc, longinc := header[0], header[1], header[2], header[3], header[4], header[5]
+	if latinc <= 0 || longinc <= 0 {
+		return nil, CartographyError{Coord: "", Index: 4, Err: ErrSyntax}
+	}
+
+	grid := &GeoidGrid{
+		South:   south,
+		West:    west,
+		LatInc:  latinc,
+		LongInc: longinc,
+		Rows:    int(math.Floor((north-south)/latinc+0.5)) + 1,
+		Cols:    int(math.Floor((east-west)/longinc+0.5)) + 1}
+	if err := grid.check(); err != nil {
+		return nil, err
+	}
+
+	grid.undulation = make([]float32, grid.Rows*grid.Cols)
+	for row := grid.Rows - 1; row >= 0; row-- {
+		for col := 0; col < grid.Cols; col++ {
+			val, err := next()
+			if err != nil {
+				return nil, err
+			}
+			grid.undulation[row*grid.Cols+col] = float32(val)
+		}
+	}
+	return grid, nil
+}
+
+// Reads a geoid grid in the text format of the NGA from the file name. See LoadGRD.
+func LoadGRDFile(name string) (*GeoidGrid, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadGRD(f)
+}
+
+func (g *GeoidGrid) check() error {
+	if g.Rows < 2 || g.Cols < 2 || g.LatInc <= 0 || g.LongInc <= 0 || g.Rows > 1<<16 || g.Cols > 1<<16 {
+		return CartographyError{Coord: fmt.Sprintf("%dx%d", g.Rows, g.Cols), Err: ErrSyntax}
+	}
+	return nil
+}
+
+// Canonical representation of a geoid grid
+func (g *GeoidGrid) String() string {
+	return fmt.Sprintf("GeoidGrid(%dx%d from %f %f by %f %f, %s)", g.Rows, g.Cols, g.South, g.West, g.LatInc, g.LongInc, g.Interpolation)
+}
+
+// Number of columns covering 360 degrees of longitude, or zero if the grid does not wrap around
+func (g *GeoidGrid) wrap() int {
+	n := int(math.Floor(360/g.LongInc + 0.5))
+	if math.Abs(float64(n)*g.LongInc-360) < 1e-9 && g.Cols >= n {
+		return n
+	}
+	return 0
+}
+
+// The undulation at the node row, col. Rows beyond the grid are clamped to its border, columns wrap around
+// for global grids and are clamped otherwise.
+func (g *GeoidGrid) node(row, col, wrap int) float64 {
+	if row < 0 {
+		row = 0
+	} else if row >= g.Rows {
+		row = g.Rows - 1
+	}
+
+	if wrap > 0 {
+		col = ((col % wrap) + wrap) % wrap
+	} else if col < 0 {
+		col = 0
+	} else if col >= g.Cols {
+		col = g.Cols - 1
+	}
+	return float64(g.undulation[row*g.Cols+col])
+}
+
+// Cubic convolution kernel weights for the fraction t of the interval between the second and the third of
+// four nodes, after Keys (1981) with a = -0.5
+func cubicWeights(t float64) [4]float64 {
+	return [4]float64{
+		((-0.5*t+1)*t - 0.5) * t,
+		(1.5*t-2.5)*t*t + 1,
+		((-1.5*t+2)*t + 0.5) * t,
+		(0.5*t - 0.5) * t * t,
+	}
+}
+
+// Returns the geoid undulation in meters at pc, interpolated as set by Interpolation. Returns ErrRange if pc
+// lies outside the grid or next to nodes without data.
+func (g *GeoidGrid) Undulation(pc *PolarCoord) (float64, error) {
+
+	y := (pc.Latitude - g.South) / g.LatInc
+	if y < 0 || y > float64(g.Rows-1) {
+		return 0, ErrRange
+	}
+
+	wrap := g.wrap()
+	long := pc.Longitude - g.West
+	if wrap > 0 {
+		long = math.Mod(math.Mod(long, 360)+360, 360)
+	} else if long < 0 {
+		long += 360
+	} else if long > float64(g.Cols-1)*g.LongInc {
+		long -= 360
+	}
+	x := long / g.LongInc
+	if x < 0 || (wrap == 0 && x > float64(g.Cols-1)) {
+		return 0, ErrRange
+	}
+
+	row, col := int(math.Floor(y)), int(math.Floor(x))
+	if row >= g.Rows-1 {
+		row = g.Rows - 2
+	}
+	if col >= g.Cols-1 && wrap == 0 {
+		col = g.Cols - 2
+	}
+	fy, fx := y-float64(row), x-float64(col)
+
+	var n float64
+
+	switch g.Interpolation {
+	case GeoidBicubic:
+		wy, wx := cubicWeights(fy), cubicWeights(fx)
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				n += wy[i] * wx[j] * g.node(row-1+i, col-1+j, wrap)
+			}
+		}
+	default:
+		n = (1-fy)*((1-fx)*g.node(row, col, wrap)+fx*g.node(row, col+1, wrap)) +
+			fy*((1-fx)*g.node(row+1, col, wrap)+fx*g.node(row+1, col+1, wrap))
+	}
+
+	if math.IsNaN(n) {
+		return 0, ErrRange
+	}
+	return n, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// ## LoadGRD
+
+// A grid from 46°N to 49°N and 9°E to 18°E with undulations linear in latitude and longitude,
+// which are interpolated exactly
+func grdTestGrid() string {
+	var b strings.Builder
+	b.WriteString("46 49 9 18 1 1\n")
+	for lat := 49; lat >= 46; lat-- {
+		for long := 9; long <= 18; long++ {
+			fmt.Fprintf(&b, " %.3f", 40+2*float64(lat)+0.5*float64(long))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestLoadGRD(t *testing.T) {
+
+	grid, err := LoadGRD(strings.NewReader(grdTestGrid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grid.Rows != 4 || grid.Cols != 10 || grid.South != 46 || grid.West != 9 {
+		t.Errorf("LoadGRD: unexpected grid %s", grid)
+	}
+
+	for _, in := range []string{
+		"46 49 9 18 1",
+		"46 49 9 18 0 1",
+		"46 49 9 18 1 1 100.0",
+		strings.Replace(grdTestGrid(), "142.500", "x", 1),
+	} {
+		if _, err := LoadGRD(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadGRD: Expected an error for %q", in)
+		}
+	}
+}
+
+// ## LoadGTX
+
+// Write the GTX file of a grid of rows by cols nodes
+func writeGTX(south, west, latinc, longinc float64, rows, cols int, undulation func(row, col int) float64) []byte {
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.BigEndian, []float64{south, west, latinc, longinc})
+	binary.Write(&buf, binary.BigEndian, []int32{int32(rows), int32(cols)})
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			binary.Write(&buf, binary.BigEndian, float32(undulation(row, col)))
+		}
+	}
+	return buf.Bytes()
+}
+
+// A global grid of 45 degrees with the column index as undulation, no data at the node 45°N 90°E
+func gtxTestGrid() []byte {
+	return writeGTX(-90, 0, 45, 45, 5, 8, func(row, col int) float64 {
+		if row == 3 && col == 2 {
+			return gtxNoData
+		}
+		return float64(col)
+	})
+}
+
+func TestLoadGTX(t *testing.T) {
+
+	grid, err := LoadGTX(bytes.NewReader(gtxTestGrid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grid.Rows != 5 || grid.Cols != 8 || grid.wrap() != 8 {
+		t.Errorf("LoadGTX: unexpected grid %s", grid)
+	}
+
+	valid := gtxTestGrid()
+	for cnt, in := range [][]byte{
+		valid[:len(valid)-2],
+		writeGTX(-90, 0, 45, 45, 1, 8, func(row, col int) float64 { return 0 }),
+		writeGTX(-90, 0, 0, 45, 5, 8, func(row, col int) float64 { return 0 }),
+	} {
+		if _, err := LoadGTX(bytes.NewReader(in)); err == nil {
+			t.Errorf("LoadGTX [%d]: Expected an error", cnt)
+		}
+	}
+}
+
+// ## GeoidGrid.Undulation
+type undulationTest struct {
+	in  *PolarCoord
+	out float64
+	err error
+}
+
+var grdUndulationTests = []undulationTest{
+	{&PolarCoord{Latitude: 47.5, Longitude: 13.25}, 141.625, nil},
+	{&PolarCoord{Latitude: 46, Longitude: 9}, 136.5, nil},
+	{&PolarCoord{Latitude: 49, Longitude: 18}, 147, nil},
+	// the longitude 373.25 equals 13.25
+	{&PolarCoord{Latitude: 47.5, Longitude: 373.25}, 141.625, nil},
+	{&PolarCoord{Latitude: 45.9, Longitude: 13}, 0, ErrRange},
+	{&PolarCoord{Latitude: 47, Longitude: 18.5}, 0, ErrRange},
+}
+
+var gtxUndulationTests = []undulationTest{
+	{&PolarCoord{Latitude: 0, Longitude: 22.5}, 0.5, nil},
+	// wraps around between 315°E and 360°E
+	{&PolarCoord{Latitude: 0, Longitude: -22.5}, 3.5, nil},
+	{&PolarCoord{Latitude: 0, Longitude: 337.5}, 3.5, nil},
+	{&PolarCoord{Latitude: -90, Longitude: 180}, 4, nil},
+	{&PolarCoord{Latitude: 90, Longitude: 270}, 6, nil},
+	// next to the node without data
+	{&PolarCoord{Latitude: 50, Longitude: 100}, 0, ErrRange},
+}
+
+func TestUndulation(t *testing.T) {
+
+	grd, err := LoadGRD(strings.NewReader(grdTestGrid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gtx, err := LoadGTX(bytes.NewReader(gtxTestGrid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interpolation := range []GeoidInterpolation{GeoidBilinear, GeoidBicubic} {
+		grd.Interpolation = interpolation
+		for cnt, test := range grdUndulationTests {
+			out, err := grd.Undulation(test.in)
+			if err != test.err || math.Abs(out-test.out) > 1e-4 {
+				t.Errorf("GeoidGrid.Undulation %s [%d]: Expected %f %v, got %f %v", interpolation, cnt, test.out, test.err, out, err)
+			}
+		}
+	}
+
+	for cnt, test := range gtxUndulationTests {
+		out, err := gtx.Undulation(test.in)
+		if err != test.err || math.Abs(out-test.out) > 1e-4 {
+			t.Errorf("GeoidGrid.Undulation [%d]: Expected %f %v, got %f %v", cnt, test.out, test.err, out, err)
+		}
+	}
+
+	// the bicubic convolution is smooth across nodes, where the bilinear interpolation bends
+	gtx.Interpolation = GeoidBicubic
+	if out, err := gtx.Undulation(&PolarCoord{Latitude: -45, Longitude: 326.25}); err != nil || math.Abs(out-5.625) > 1e-4 {
+		t.Errorf("GeoidGrid.Undulation %s: Expected 5.625, got %f %v", GeoidBicubic, out, err)
+	}
+}
+
+// ## OrthometricHeight, EllipsoidalHeight
+func TestOrthometricHeight(t *testing.T) {
+
+	grid, err := LoadGRD(strings.NewReader(grdTestGrid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &PolarCoord{Latitude: 47.5, Longitude: 13.25, Height: 500, El: WGS84Ellipsoid, Datum: WGS84Datum}
+
+	h, err := OrthometricHeight(grid, pc)
+	if err != nil || math.Abs(h-358.375) > 1e-4 {
+		t.Errorf("OrthometricHeight: Expected 358.375, got %f %v", h, err)
+	}
+
+	out, err := EllipsoidalHeight(grid, pc, h)
+	if err != nil || math.Abs(out.Height-500) > 1e-4 || out.El != WGS84Ellipsoid || out.Datum != WGS84Datum || out.Latitude != pc.Latitude || out == pc {
+		t.Errorf("EllipsoidalHeight: Expected %s at 500m, got %s at %fm %v", pc, out, out.Height, err)
+	}
+
+	if _, err := OrthometricHeight(grid, &PolarCoord{Latitude: 50, Longitude: 13}); err != ErrRange {
+		t.Errorf("OrthometricHeight: Expected %s outside the grid, got %v", ErrRange, err)
+	}
+}
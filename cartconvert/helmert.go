@@ -0,0 +1,249 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ## Helmert transformation
+
+// Sign convention of the rotation parameters of a Helmert transformation. Published parameter sets
+// state their convention, eg. EPSG method 1033 (position vector) or 1032 (coordinate frame).
+type RotationConvention byte
+
+const (
+	// Rotations of the position vector; used by the IERS, PROJ's +towgs84 and WKT's TOWGS84
+	PositionVector RotationConvention = iota
+	// Rotations of the coordinate frame; the sign of the rotations is reversed to the position vector convention
+	CoordinateFrame
+)
+
+func (rc RotationConvention) String() string {
+	switch rc {
+	case PositionVector:
+		return "PositionVector"
+	case CoordinateFrame:
+		return "CoordinateFrame"
+	}
+	return "RotationConvention(" + fmt.Sprint(byte(rc)) + ")"
+}
+
+// The seven parameters of a Helmert transformation between two 3D datums
+type Helmert struct {
+	// translation of the coordinate origin in meters
+	Dx, Dy, Dz float64
+	// scale correction in parts per million
+	DM float64
+	// rotation about the x, y and z axes in arc seconds
+	Rx, Ry, Rz float64
+	// sign convention of Rx, Ry and Rz
+	Convention RotationConvention
+	Datum      string
+}
+
+// A linear transformation of a 3D datum X' = T + M * X, like a Helmert transformation or the
+// result of the inverse or the composition of Helmert transformations
+type Affine3D struct {
+	M     [3][3]float64
+	T     Point3D
+	Datum string
+}
+
+// Create a new instance of helmert parameters with rotations in position vector convention.
+//
+//	dx, dy, dz: delta of coordinate origin in meters
+//	drx, dry, drz: rotation of the position vector in arc seconds
+//	dM:  scale correction to be made to the position vector in the source coordinate reference
+//		system, in parts of per million
+func NewHelmertTransformer(dx, dy, dz, dM, drx, dry, drz float64, datum string) *Helmert {
+	return NewHelmert(dx, dy, dz, dM, drx, dry, drz, PositionVector, datum)
+}
+
+// Create a new instance of helmert parameters with rotations rx, ry, rz in arc seconds
+// according to the rotation convention, dM in parts per million and dx, dy, dz in meters.
+func NewHelmert(dx, dy, dz, dM, rx, ry, rz float64, convention RotationConvention, datum string) *Helmert {
+	return &Helmert{Dx: dx, Dy: dy, Dz: dz, DM: dM, Rx: rx, Ry: ry, Rz: rz, Convention: convention, Datum: datum}
+}
+
+// Returns the helmert parameters with rotations in position vector convention
+func (hp *Helmert) PositionVector() *Helmert {
+	pv := *hp
+	if hp.Convention == CoordinateFrame {
+		pv.Rx, pv.Ry, pv.Rz = -hp.Rx, -hp.Ry, -hp.Rz
+		pv.Convention = PositionVector
+	}
+	return &pv
+}
+
+// Returns the helmert parameters with rotations in coordinate frame convention
+func (hp *Helmert) CoordinateFrame() *Helmert {
+	cf := *hp
+	if hp.Convention == PositionVector {
+		cf.Rx, cf.Ry, cf.Rz = -hp.Rx, -hp.Ry, -hp.Rz
+		cf.Convention = CoordinateFrame
+	}
+	return &cf
+}
+
+// Returns the linear transformation of the helmert parameters using the small angle approximation
+// of the rotation matrix, as defined in "OGP Publication 373-7-2 – Surveying and Positioning
+// Guidance Note number 7, part 2 – November 2010", Helmert 7-parameter transformations, pp. 126 - 129
+func (hp *Helmert) Affine() *Affine3D {
+
+	pv := hp.PositionVector()
+
+	s := 1 + pv.DM/1e6
+	rx := degtorad(pv.Rx / 3600)
+	ry := degtorad(pv.Ry / 3600)
+	rz := degtorad(pv.Rz / 3600)
+
+	// Each row rotates the other two coordinates of the point: the y row by rz about X and by rx about Z.
+	// Releases up to 2012 used rx in place of rz in the y row, which displaced MGI and Ireland 1965
+	// coordinates by a few metres.
+	return &Affine3D{
+		M: [3][3]float64{
+			{s, -s * rz, s * ry},
+			{s * rz, s, -s * rx},
+			{-s * ry, s * rx, s},
+		},
+		T:     Point3D{X: pv.Dx, Y: pv.Dy, Z: pv.Dz},
+		Datum: hp.Datum,
+	}
+}
+
+// Method to perform the Helmert transformation on a generic 3D datum and return a new datum.
+// Instances of helmert transformations might be created by calls to NewHelmert or NewHelmertTransformer
+func (hp *Helmert) Transform(ip *Point3D) *Point3D {
+	return hp.Affine().Transform(ip)
+}
+
+// Method to perform the exact inverse of the helmert transformation on a generic 3D datum and return a new datum.
+func (hp *Helmert) InverseTransform(pt *Point3D) *Point3D {
+	return hp.Affine().InverseTransform(pt)
+}
+
+// Returns the exact inverse of the helmert transformation
+func (hp *Helmert) Inverse() *Affine3D {
+	return hp.Affine().Inverse()
+}
+
+// Returns the transformation which first applies hp and then next
+func (hp *Helmert) Compose(next *Helmert) *Affine3D {
+	return hp.Affine().Compose(next.Affine())
+}
+
+// Returns a canoncial representation of the helmert parameters
+func (tp *Helmert) String() string {
+	return fmt.Sprintf("Helmert[%s](dx,dy,dz,dM,drx, dry,drz): (%f, %f, %f, %f, %f, %f, %f) %s", tp.Datum, tp.Dx, tp.Dy, tp.Dz, tp.DM, tp.Rx, tp.Ry, tp.Rz, tp.Convention)
+}
+
+// Get the well known text (WKT) for the helmert transformation as defined in
+// http://www.geoapi.org/2.0/javadoc/org/opengis/referencing/doc-files/WKT.html:
+// TOWGS84[dx,dy,dz,rx,ry,rz,ds] with the rotations in position vector convention.
+func (tp *Helmert) WellKnownString() string {
+	pv := tp.PositionVector()
+	params := make([]string, 0, 7)
+	for _, val := range []float64{pv.Dx, pv.Dy, pv.Dz, pv.Rx, pv.Ry, pv.Rz, pv.DM} {
+		params = append(params, wktFloat(val))
+	}
+	return "TOWGS84[" + strings.Join(params, ",") + "]"
+}
+
+// Transform a generic 3D datum and return a new datum.
+func (at *Affine3D) Transform(ip *Point3D) *Point3D {
+
+	var tp Point3D
+
+	m := &at.M
+
+	tp.X = at.T.X + m[0][0]*ip.X + m[0][1]*ip.Y + m[0][2]*ip.Z
+	tp.Y = at.T.Y + m[1][0]*ip.X + m[1][1]*ip.Y + m[1][2]*ip.Z
+	tp.Z = at.T.Z + m[2][0]*ip.X + m[2][1]*ip.Y + m[2][2]*ip.Z
+
+	return &tp
+}
+
+// Perform the inverse transformation on a generic 3D datum and return a new datum.
+func (at *Affine3D) InverseTransform(pt *Point3D) *Point3D {
+	return at.Inverse().Transform(pt)
+}
+
+// Returns the exact inverse X = M^-1 * (X' - T) of the transformation. The matrix is assumed to be regular,
+// which holds for every Helmert transformation.
+func (at *Affine3D) Inverse() *Affine3D {
+
+	m := &at.M
+
+	// adjugate of M
+	var inv [3][3]float64
+	inv[0][0] = m[1][1]*m[2][2] - m[1][2]*m[2][1]
+	inv[0][1] = m[0][2]*m[2][1] - m[0][1]*m[2][2]
+	inv[0][2] = m[0][1]*m[1][2] - m[0][2]*m[1][1]
+	inv[1][0] = m[1][2]*m[2][0] - m[1][0]*m[2][2]
+	inv[1][1] = m[0][0]*m[2][2] - m[0][2]*m[2][0]
+	inv[1][2] = m[0][2]*m[1][0] - m[0][0]*m[1][2]
+	inv[2][0] = m[1][0]*m[2][1] - m[1][1]*m[2][0]
+	inv[2][1] = m[0][1]*m[2][0] - m[0][0]*m[2][1]
+	inv[2][2] = m[0][0]*m[1][1] - m[0][1]*m[1][0]
+
+	det := m[0][0]*inv[0][0] + m[0][1]*inv[1][0] + m[0][2]*inv[2][0]
+	for i := range inv {
+		for j := range inv[i] {
+			inv[i][j] /= det
+		}
+	}
+
+	ia := &Affine3D{M: inv, Datum: at.Datum}
+	t := ia.Transform(&at.T)
+	ia.T = Point3D{X: -t.X, Y: -t.Y, Z: -t.Z}
+
+	return ia
+}
+
+// Returns the transformation which first applies at and then next
+func (at *Affine3D) Compose(next *Affine3D) *Affine3D {
+
+	var ct Affine3D
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				ct.M[i][j] += next.M[i][k] * at.M[k][j]
+			}
+		}
+	}
+	ct.T = *next.Transform(&at.T)
+	ct.Datum = at.Datum + "+" + next.Datum
+
+	return &ct
+}
+
+// Returns the helmert parameters in position vector convention approximating the transformation, eg. to
+// publish the inverse or a composition of helmert transformations. The approximation is exact to the
+// order of the rotation angles times the scale correction.
+func (at *Affine3D) Helmert() *Helmert {
+
+	m := &at.M
+	s := (m[0][0] + m[1][1] + m[2][2]) / 3
+
+	return &Helmert{
+		Dx:         at.T.X,
+		Dy:         at.T.Y,
+		Dz:         at.T.Z,
+		DM:         (s - 1) * 1e6,
+		Rx:         radtodeg((m[2][1]-m[1][2])/(2*s)) * 3600,
+		Ry:         radtodeg((m[0][2]-m[2][0])/(2*s)) * 3600,
+		Rz:         radtodeg((m[1][0]-m[0][1])/(2*s)) * 3600,
+		Convention: PositionVector,
+		Datum:      at.Datum,
+	}
+}
+
+// Returns a canoncial representation of the transformation
+func (at *Affine3D) String() string {
+	return fmt.Sprintf("Affine3D[%s](M,T): (%v, %v)", at.Datum, at.M, at.T)
+}
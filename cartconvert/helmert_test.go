@@ -0,0 +1,138 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func point3dcmequal(p1, p2 *Point3D) bool {
+	pt1 := fmt.Sprintf("%.2f %.2f %.2f", p1.X, p1.Y, p1.Z)
+	pt2 := fmt.Sprintf("%.2f %.2f %.2f", p2.X, p2.Y, p2.Z)
+
+	return pt1 == pt2
+}
+
+// ## Rotation conventions
+// OGP Publication 373-7-2, WGS 72 to WGS 84 in both rotation conventions
+var helmertConventionTests = []*Helmert{
+	NewHelmert(0, 0, 4.5, 0.219, 0, 0, 0.554, PositionVector, "WGS72toWGS84"),
+	NewHelmert(0, 0, 4.5, 0.219, 0, 0, -0.554, CoordinateFrame, "WGS72toWGS84"),
+}
+
+func TestHelmertConvention(t *testing.T) {
+	in := &Point3D{X: 3657660.66, Y: 255768.55, Z: 5201382.11}
+	expected := &Point3D{X: 3657660.77, Y: 255778.43, Z: 5201387.75}
+
+	for cnt, hp := range helmertConventionTests {
+		if out := hp.Transform(in); !point3dcmequal(expected, out) {
+			t.Errorf("Helmert.Transform [%d]: expected %v, got %v", cnt, expected, out)
+		}
+	}
+
+	pv := helmertConventionTests[1].PositionVector()
+	if *pv != *helmertConventionTests[0] {
+		t.Errorf("Helmert.PositionVector: expected %s, got %s", helmertConventionTests[0], pv)
+	}
+
+	cf := helmertConventionTests[0].CoordinateFrame()
+	if *cf != *helmertConventionTests[1] {
+		t.Errorf("Helmert.CoordinateFrame: expected %s, got %s", helmertConventionTests[1], cf)
+	}
+}
+
+// ## Affine
+// A rotation about one axis moves the point only along the two other axes
+func TestHelmertAffine(t *testing.T) {
+	// one arc second displaces a point one million metres from the axis by 4.848137m
+	const d = 4.848137
+	x, y, z := &Point3D{X: 1e6}, &Point3D{Y: 1e6}, &Point3D{Z: 1e6}
+
+	tests := []struct {
+		hp       *Helmert
+		in       *Point3D
+		expected *Point3D
+	}{
+		{NewHelmertTransformer(0, 0, 0, 0, 0, 0, 1, ""), x, &Point3D{X: 1e6, Y: d}},
+		{NewHelmertTransformer(0, 0, 0, 0, 0, 0, 1, ""), y, &Point3D{X: -d, Y: 1e6}},
+		{NewHelmertTransformer(0, 0, 0, 0, 1, 0, 0, ""), x, x},
+		{NewHelmertTransformer(0, 0, 0, 0, 1, 0, 0, ""), z, &Point3D{Y: -d, Z: 1e6}},
+		{NewHelmertTransformer(0, 0, 0, 0, 0, 1, 0, ""), x, &Point3D{X: 1e6, Z: -d}},
+		{NewHelmertTransformer(0, 0, 0, 0, 0, 1, 0, ""), z, &Point3D{X: d, Z: 1e6}},
+	}
+
+	for cnt, test := range tests {
+		if out := test.hp.Transform(test.in); !point3dequal(test.expected, out) {
+			t.Errorf("Helmert.Affine [%d]: expected %v, got %v", cnt, test.expected, out)
+		}
+	}
+}
+
+// ## Inverse, Compose
+func TestHelmertInverseCompose(t *testing.T) {
+	in := &Point3D{X: 4027893.924, Y: 307041.993, Z: 4919474.91}
+
+	for _, hp := range []*Helmert{HelmertWGS84ToMGI, HelmertWGS84ToOSGB36, HelmertWGS84ToIreland65, HelmertLV03ToWGS84Granit87} {
+		if out := hp.Inverse().Transform(hp.Transform(in)); !point3dequal(in, out) {
+			t.Errorf("Helmert.Inverse [%s]: expected %v, got %v", hp.Datum, in, out)
+		}
+
+		if out := hp.Compose(hp.Inverse().Helmert()).Transform(in); !point3dcmequal(in, out) {
+			t.Errorf("Helmert.Compose [%s]: expected %v, got %v", hp.Datum, in, out)
+		}
+	}
+
+	// from OSGB36 via WGS84 to MGI in a single step
+	osgb36tomgi := HelmertWGS84ToOSGB36.Inverse().Compose(HelmertWGS84ToMGI.Affine())
+	expected := HelmertWGS84ToMGI.Transform(HelmertWGS84ToOSGB36.InverseTransform(in))
+	if out := osgb36tomgi.Transform(in); !point3dequal(expected, out) {
+		t.Errorf("Affine3D.Compose: expected %v, got %v", expected, out)
+	}
+	if out := osgb36tomgi.InverseTransform(expected); !point3dequal(in, out) {
+		t.Errorf("Affine3D.InverseTransform: expected %v, got %v", in, out)
+	}
+}
+
+// ## Published parameters
+// The transformations of the catalogue datums into WGS84 as published by EPSG, position vector
+func TestHelmertEPSG(t *testing.T) {
+	tests := []struct {
+		datum    *Datum
+		expected *Helmert
+	}{
+		{MGIDatum, NewHelmertTransformer(577.326, 90.129, 463.919, 2.4232, 5.137, 1.474, 5.297, "EPSG:1618")},
+		{MGIFerroDatum, NewHelmertTransformer(577.326, 90.129, 463.919, 2.4232, 5.137, 1.474, 5.297, "EPSG:1618")},
+		{OSGB36Datum, NewHelmertTransformer(446.448, -125.157, 542.06, -20.489, 0.15, 0.247, 0.842, "EPSG:1314")},
+		{Ireland65Datum, NewHelmertTransformer(482.53, -130.596, 564.557, 8.15, -1.042, -0.214, -0.631, "EPSG:1641")},
+	}
+
+	for _, test := range tests {
+		if hp, ok := test.datum.towgs84(); !ok || !helmertEqual(test.expected, hp) {
+			t.Errorf("Helmert [%s]: expected %s, got %v", test.datum.Name, test.expected, hp)
+		}
+	}
+
+	// Granit87 agrees with the geocentric translation of CH1903 within a metre in Bern
+	bern := PolarToCartesian(&PolarCoord{Latitude: 46.95, Longitude: 7.44, El: Bessel1841Ellipsoid})
+	in := &Point3D{X: bern.X, Y: bern.Y, Z: bern.Z}
+	out := HelmertLV03ToWGS84Granit87.Transform(in)
+	if d := math.Sqrt(math.Pow(out.X-in.X-674.374, 2) + math.Pow(out.Y-in.Y-15.056, 2) + math.Pow(out.Z-in.Z-405.346, 2)); d > 1 {
+		t.Errorf("Helmert [%s]: expected a shift of (674.374, 15.056, 405.346) in Bern, got (%f, %f, %f)",
+			HelmertLV03ToWGS84Granit87.Datum, out.X-in.X, out.Y-in.Y, out.Z-in.Z)
+	}
+}
+
+// ## WellKnownString
+func TestHelmertWellKnownString(t *testing.T) {
+	expected := "TOWGS84[0,0,4.5,0,0,0.554,0.219]"
+	for cnt, hp := range helmertConventionTests {
+		if out := hp.WellKnownString(); out != expected {
+			t.Errorf("Helmert.WellKnownString [%d]: expected %s, got %s", cnt, expected, out)
+		}
+	}
+}
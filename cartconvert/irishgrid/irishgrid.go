@@ -0,0 +1,460 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// This package provides functions to deal with conversion and transformations of coordinates
+// of the Irish Grid on the Ireland 1965 datum and of the Irish Transverse Mercator (ITM).
+//
+// Irish Grid references use a single letter to specify the 100x100 km zone, followed by easting
+// and northing within the zone. The grid is a transverse mercator projection of the Airy Modified
+// ellipsoid. The conversion between WGS84 and the Ireland 1965 datum uses a simple helmert
+// transformation, which results in an accuracy of about +/- 5m.
+//
+// ITM is a transverse mercator projection of ETRS89 on the GRS80 ellipsoid, which is
+// compatible with WGS84 to better than one meter. No datum shift is applied.
+//
+// For further info see http://en.wikipedia.org/wiki/Irish_grid_reference_system
+// and http://en.wikipedia.org/wiki/Irish_Transverse_Mercator
+package irishgrid
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A Irish Grid coordinate is specified by zone, easting and northing. RelHeight is the ellipsoidal height
+// above the modified Airy ellipsoid of the Ireland 1965 datum.
+type IrishGridCoord struct {
+	Easting, Northing uint
+	RelHeight         float64
+	Zone              string
+	El                *cartconvert.Ellipsoid
+	Datum             *cartconvert.Datum
+	gridLen           byte
+}
+
+// Controls formatting of an Irish Grid coordinate.
+//
+//	IrishGridAuto - for easting and northing the most compact representation will be found. Eg.: O1200065000 will be stored as O1265
+//	IrishGridLeave - the adverse of IrishGridAuto: Do not try to do any compacting on easting and northing
+//	IrishGrid_1 ... IrishGrid_5 - set northing resp. easting to exactly 1 .. 5 digits. If the inputs are shorter than n,
+//	   they will be filled with '0'. O1256 with precision 5 will result in O1200056000. If the input is longer than n,
+//	   it will be truncated. O123567 with precision 2 will result in O1256. When shortening bearings, no rounding takes place.
+//
+// The precision of an Irish Grid coordinate can either be set explicitly; From meter - resolution (IrishGrid_5)
+// to the bare 100x100 km Zone IrishGrid_Min.
+type IrishGridprec byte
+
+const (
+	IrishGrid_Min IrishGridprec = iota
+	IrishGrid_1
+	IrishGrid_2
+	IrishGrid_3
+	IrishGrid_4
+	IrishGrid_5
+	IrishGrid_Max  = IrishGrid_5
+	IrishGridLeave = 128
+	IrishGridAuto  = IrishGridLeave + 1
+)
+
+// Letters of the 100x100 km zones, starting in the north west. 'I' is not used.
+const zoneLetters = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// Canonical representation of an Irish Grid datum, eg. "O 15904 34671"
+func (coord *IrishGridCoord) String() string {
+	if coord.gridLen > 0 {
+		return fmt.Sprintf("%s %0*d %0*d", coord.Zone, int(coord.gridLen), coord.Easting, int(coord.gridLen), coord.Northing)
+	}
+	return coord.Zone
+}
+
+// Parses a string representation of an Irish Grid coordinate datum into a Irish Grid coordinate struct. The literal
+// can be specified as follows:
+//
+//	Z EA NO
+//	Z EANO
+//	ZEANO
+//
+// with Z the single letter zone specifier, EA easting and NO northing to the accuracy of up to a meter.
+//
+// For a description of prec see IrishGridprec.
+//
+// The reference ellipsoid of an Irish Grid coordinate will always be set to the AiryModifiedEllipsoid.
+//
+// returns cartconvert.ErrSyntax if format is not understood
+// returns cartconvert.ErrRange if values are outside the defined parameters for an Irish Grid bearing
+func AIrishGridToStruct(irishgridcoord string, prec IrishGridprec) (*IrishGridCoord, error) {
+
+	compact := strings.ToUpper(strings.TrimSpace(irishgridcoord))
+	var zone, enn string
+
+	for _, item := range compact {
+		switch {
+		case item == ' ':
+			continue
+		case item >= '0' && item <= '9':
+			if len(zone) == 0 {
+				return nil, cartconvert.ErrSyntax
+			}
+			enn += string(item)
+		default:
+			if len(enn) > 0 {
+				return nil, cartconvert.ErrSyntax
+			}
+			zone += string(item)
+		}
+	}
+
+	if len(zone) != 1 || strings.Index(zoneLetters, zone) < 0 {
+		return nil, cartconvert.ErrSyntax
+	}
+
+	var east, north int
+	var err error
+
+	ennlen := byte(len(enn))
+	if ennlen > 0 {
+		if ennlen%2 > 0 {
+			return nil, cartconvert.ErrRange
+		}
+
+		ennlen /= 2
+		if ennlen > byte(IrishGrid_Max) {
+			return nil, cartconvert.ErrRange
+		}
+
+		east, err = strconv.Atoi(enn[:ennlen])
+		if err != nil {
+			return nil, err
+		}
+		north, err = strconv.Atoi(enn[ennlen:])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewIrishGridCoord(zone, uint(east), uint(north), 0, ennlen, prec), nil
+}
+
+// Returns easting and northing based on the Irish Grid zone specifier relative to the false origin.
+// For a zone with easting and northing the location at the middle of the square is returned,
+// for a plain zone specifier its south western corner.
+func IrishGridZoneToRefCoords(coord *IrishGridCoord) (easting, northing uint) {
+
+	l := uint(strings.Index(zoneLetters, coord.Zone))
+	fact := uint(math.Pow(10, float64(byte(IrishGrid_Max)-coord.gridLen)))
+
+	// zone V is at the false origin
+	easting = l%5*100000 + coord.Easting*fact
+	northing = (4-l/5)*100000 + coord.Northing*fact
+
+	if fact < 100000 {
+		easting += 5 * (fact / 10)
+		northing += 5 * (fact / 10)
+	}
+	return
+}
+
+// Convert an Irish Grid coordinate value to a WGS84 based latitude and longitude coordinate.
+//
+// Important: A Irish Grid datum like O11 will be internally expanded to O1500015000 to point to the middle of the zone.
+// For the point at O1000010000 it is necessary to fully qualify northing and easting.
+func IrishGridToWGS84LatLong(coord *IrishGridCoord) *cartconvert.PolarCoord {
+	return IrishGridToWGS84LatLongAlgorithm(coord, cartconvert.DefaultTMAlgorithm)
+}
+
+// Like IrishGridToWGS84LatLong, but projects by the transverse mercator algorithm alg.
+func IrishGridToWGS84LatLongAlgorithm(coord *IrishGridCoord, alg cartconvert.TMAlgorithm) *cartconvert.PolarCoord {
+
+	easting, northing := IrishGridZoneToRefCoords(coord)
+
+	gc := alg.InverseTransverseMercator(
+		&cartconvert.GeoPoint{Y: float64(northing), X: float64(easting), H: coord.RelHeight, El: cartconvert.AiryModifiedEllipsoid},
+		53.5,
+		-8,
+		1.000035,
+		200000,
+		250000)
+
+	// the helmert transformation of the datum covers every coordinate
+	pc, _ := cartconvert.TransformDatum(gc, cartconvert.Ireland65Datum, cartconvert.WGS84Datum)
+	return pc
+}
+
+// Perform formating on an Irish Grid datum. For formatting see IrishGridprec.
+func SanitizeIrishGridCoordToPrec(easting, northing *uint, inputprec byte, desiredprec IrishGridprec) byte {
+
+	if *easting+*northing == 0 || inputprec == 0 {
+		return byte(IrishGrid_Min)
+	}
+
+	if inputprec < byte(IrishGrid_Max) {
+		fact := uint(math.Pow(10, float64(byte(IrishGrid_Max)-inputprec)))
+		*easting *= fact
+		*northing *= fact
+	}
+
+	switch desiredprec {
+	case IrishGridLeave:
+		desiredprec = IrishGridprec(inputprec)
+	case IrishGridAuto:
+		northprec, eastprec := IrishGrid_Max, IrishGrid_Max
+		easttmp, northtmp := *easting, *northing
+
+		for easttmp%10 == 0 && eastprec > 0 {
+			easttmp /= 10
+			eastprec--
+		}
+
+		for northtmp%10 == 0 && northprec > 0 {
+			northtmp /= 10
+			northprec--
+		}
+		desiredprec = eastprec
+		if northprec > desiredprec {
+			desiredprec = northprec
+		}
+	}
+
+	if desiredprec < IrishGrid_Max {
+		fact := uint(math.Pow(10, float64(IrishGrid_Max-desiredprec)))
+		*easting /= fact
+		*northing /= fact
+	}
+	return byte(desiredprec)
+}
+
+// Build Irish Grid coordinate from easting and northing relative to the false origin. The parameter prec controls
+// how the resulting Irish Grid coordinates are formated. See IrishGridprec.
+//
+// The function will return cartconvert.ErrRange if easting or northing are not within the Irish Grid.
+func GridRefNumToLet(easting, northing uint, height float64, prec IrishGridprec) (*IrishGridCoord, error) {
+
+	easting100k := easting / 100000
+	northing100k := northing / 100000
+
+	if easting100k > 4 || northing100k > 4 {
+		return nil, cartconvert.ErrRange
+	}
+
+	zone := string(zoneLetters[(4-northing100k)*5+easting100k])
+
+	return NewIrishGridCoord(zone, easting%100000, northing%100000, height, 5, prec), nil
+}
+
+// Transform a latitude / longitude coordinate datum into a Irish Grid coordinate.
+//
+// Important: The reference ellipsoid of the originating coordinate system will be assumed
+// to be the WGS84Ellipsoid, regardless of the actually set reference ellipsoid.
+func WGS84LatLongToIrishGrid(gc *cartconvert.PolarCoord) (*IrishGridCoord, error) {
+	return WGS84LatLongToIrishGridAlgorithm(gc, cartconvert.DefaultTMAlgorithm)
+}
+
+// Like WGS84LatLongToIrishGrid, but projects by the transverse mercator algorithm alg.
+func WGS84LatLongToIrishGridAlgorithm(gcin *cartconvert.PolarCoord, alg cartconvert.TMAlgorithm) (*IrishGridCoord, error) {
+	// This sets the Ellipsoid to WGS84 on a copy, regardless of the actual value set
+	gc := *gcin
+	gc.El = cartconvert.WGS84Ellipsoid
+
+	polar, err := cartconvert.TransformDatum(&gc, cartconvert.WGS84Datum, cartconvert.Ireland65Datum)
+	if err != nil {
+		return nil, err
+	}
+
+	gp := alg.DirectTransverseMercator(
+		polar,
+		53.5,
+		-8,
+		1.000035,
+		200000,
+		250000)
+
+	if gp.X < 0 || gp.Y < 0 {
+		return nil, cartconvert.ErrRange
+	}
+	return GridRefNumToLet(uint(gp.X+0.5), uint(gp.Y+0.5), gp.H, IrishGrid_Max)
+}
+
+// Create a new Irish Grid coordinate from literals. The parameter prec plays an important role in how the literals
+// are interpreted:
+//
+//	IrishGridAuto - for easting and northing the most compact representation will be found. Eg.: O1200065000 will be stored as O1265
+//	IrishGridLeave - the adverse of IrishGridAuto: Do not try to do any compacting on easting and northing
+//	IrishGrid_1 ... IrishGrid_5 - set northing resp. easting to exactly 1 .. 5 digits. If the inputs are shorter than n,
+//	   they will be filled with '0'. O1256 with precision 5 will result in O1200056000. If the input is longer than n,
+//	   it will be truncated. O123567 with precision 2 will result in O1256. When shortening bearings, no rounding takes place.
+func NewIrishGridCoord(Zone string, easting, northing uint, relheight float64, inputprec byte, desiredprec IrishGridprec) *IrishGridCoord {
+	effbytes := SanitizeIrishGridCoordToPrec(&easting, &northing, inputprec, desiredprec)
+	return &IrishGridCoord{Easting: easting, Northing: northing, RelHeight: relheight, Zone: Zone, gridLen: effbytes, El: cartconvert.AiryModifiedEllipsoid, Datum: cartconvert.Ireland65Datum}
+}
+
+// ## Irish Transverse Mercator
+
+// An ITM coordinate is specified by easting and northing in meters and the ellipsoidal height. The datum
+// is ETRS89, taken as WGS84.
+type ITMCoord struct {
+	Easting, Northing, RelHeight float64
+	El                           *cartconvert.Ellipsoid
+	Datum                        *cartconvert.Datum
+}
+
+// Canonical representation of an ITM coordinate, eg. "715830 734697"
+func (coord *ITMCoord) String() string {
+	return fmt.Sprintf("%.0f %.0f", coord.Easting, coord.Northing)
+}
+
+// Parses a string representation of an ITM coordinate of the form
+//
+//	EASTING NORTHING
+//
+// in meters. The reference ellipsoid will always be set to the GRS80Ellipsoid.
+//
+// returns a cartconvert.CartographyError of cartconvert.ErrSyntax if format is not understood
+func AITMToStruct(itmcoord string) (*ITMCoord, error) {
+
+	fields := strings.Fields(itmcoord)
+	if len(fields) != 2 {
+		return nil, cartconvert.CartographyError{Coord: itmcoord, Err: cartconvert.ErrSyntax}
+	}
+
+	easting, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, cartconvert.CartographyError{Coord: itmcoord, Err: cartconvert.ErrSyntax}
+	}
+	northing, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, cartconvert.CartographyError{Coord: itmcoord, Err: cartconvert.ErrSyntax}
+	}
+	return &ITMCoord{Easting: easting, Northing: northing, El: cartconvert.GRS80Ellipsoid, Datum: cartconvert.ETRS89Datum}, nil
+}
+
+// Convert an ITM coordinate value to a WGS84 based latitude and longitude coordinate.
+func ITMToWGS84LatLong(coord *ITMCoord) *cartconvert.PolarCoord {
+	return ITMToWGS84LatLongAlgorithm(coord, cartconvert.DefaultTMAlgorithm)
+}
+
+// Like ITMToWGS84LatLong, but projects by the transverse mercator algorithm alg.
+func ITMToWGS84LatLongAlgorithm(coord *ITMCoord, alg cartconvert.TMAlgorithm) *cartconvert.PolarCoord {
+
+	gc := alg.InverseTransverseMercator(
+		&cartconvert.GeoPoint{Y: coord.Northing, X: coord.Easting, H: coord.RelHeight, El: cartconvert.GRS80Ellipsoid},
+		53.5,
+		-8,
+		0.99982,
+		600000,
+		750000)
+
+	gc.El = cartconvert.WGS84Ellipsoid
+	gc.Datum = cartconvert.WGS84Datum
+	return gc
+}
+
+// Transform a latitude / longitude coordinate datum into an ITM coordinate.
+//
+// Important: The reference ellipsoid of the originating coordinate system will be assumed
+// to be the WGS84Ellipsoid, regardless of the actually set reference ellipsoid.
+func WGS84LatLongToITM(gc *cartconvert.PolarCoord) *ITMCoord {
+	return WGS84LatLongToITMAlgorithm(gc, cartconvert.DefaultTMAlgorithm)
+}
+
+// Like WGS84LatLongToITM, but projects by the transverse mercator algorithm alg.
+func WGS84LatLongToITMAlgorithm(gc *cartconvert.PolarCoord, alg cartconvert.TMAlgorithm) *ITMCoord {
+
+	gp := alg.DirectTransverseMercator(
+		&cartconvert.PolarCoord{Latitude: gc.Latitude, Longitude: gc.Longitude, Height: gc.Height, El: cartconvert.GRS80Ellipsoid},
+		53.5,
+		-8,
+		0.99982,
+		600000,
+		750000)
+
+	return &ITMCoord{Easting: gp.X, Northing: gp.Y, RelHeight: gp.H, El: cartconvert.GRS80Ellipsoid, Datum: cartconvert.ETRS89Datum}
+}
+
+// The coordinate reference system of the Irish Grid on the datum Ireland 1965 (TM65)
+var IrishGridCRS = &cartconvert.CRS{
+	Name:       "TM65 / Irish Grid",
+	Datum:      cartconvert.Ireland65Datum,
+	Projection: &cartconvert.Projection{Method: cartconvert.TransverseMercator, LatO: 53.5, LongO: -8, Scale: 1.000035, FE: 200000, FN: 250000},
+}
+
+// The coordinate reference system of the Irish Transverse Mercator on the datum ETRS89, whose Irish
+// realization is IRENET95
+var ITMCRS = &cartconvert.CRS{
+	Name:       "IRENET95 / Irish Transverse Mercator",
+	Datum:      cartconvert.ETRS89Datum,
+	Projection: &cartconvert.Projection{Method: cartconvert.TransverseMercator, LatO: 53.5, LongO: -8, Scale: 0.99982, FE: 600000, FN: 750000},
+}
+
+// The Irish Grid as a cartconvert.CoordinateSystem
+type irishGridSystem struct{}
+
+func (irishGridSystem) Name() string        { return "irishgrid" }
+func (irishGridSystem) Description() string { return "IE:Irish Grid" }
+
+func (irishGridSystem) Parse(coord string) (fmt.Stringer, error) {
+	return AIrishGridToStruct(coord, IrishGridLeave)
+}
+
+func (irishGridSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*IrishGridCoord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (irishGridSystem) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	irishgridcoord, ok := coord.(*IrishGridCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return IrishGridToWGS84LatLong(irishgridcoord), nil
+}
+
+func (irishGridSystem) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return WGS84LatLongToIrishGrid(pc)
+}
+
+func (irishGridSystem) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	return IrishGridCRS, nil
+}
+
+// The Irish Transverse Mercator as a cartconvert.CoordinateSystem
+type itmSystem struct{}
+
+func (itmSystem) Name() string        { return "itm" }
+func (itmSystem) Description() string { return "IE:Irish Transverse Mercator" }
+
+func (itmSystem) Parse(coord string) (fmt.Stringer, error) {
+	return AITMToStruct(coord)
+}
+
+func (itmSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*ITMCoord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (itmSystem) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	itmcoord, ok := coord.(*ITMCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return ITMToWGS84LatLong(itmcoord), nil
+}
+
+func (itmSystem) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return WGS84LatLongToITM(pc), nil
+}
+
+func (itmSystem) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	return ITMCRS, nil
+}
+
+func init() {
+	cartconvert.RegisterCoordinateSystem(irishGridSystem{})
+	cartconvert.RegisterCoordinateSystem(itmSystem{})
+}
@@ -0,0 +1,225 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert/irishgrid package
+package irishgrid
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"testing"
+)
+
+// ## AIrishGridToStruct
+type irishGridStringToStructParam struct {
+	irishgridcoord string
+	prec           IrishGridprec
+}
+
+type irishGridStringToStructTest struct {
+	in  irishGridStringToStructParam
+	out *IrishGridCoord
+}
+
+var irishGridStringToStructTestssuc = []irishGridStringToStructTest{
+	{
+		irishGridStringToStructParam{"O 15 34", IrishGridLeave},
+		&IrishGridCoord{Zone: "O", Easting: 15, Northing: 34, gridLen: 2},
+	},
+	{
+		irishGridStringToStructParam{"o1534", IrishGridAuto},
+		&IrishGridCoord{Zone: "O", Easting: 15, Northing: 34, gridLen: 2},
+	},
+	{
+		irishGridStringToStructParam{"O 15904 34671", IrishGridLeave},
+		&IrishGridCoord{Zone: "O", Easting: 15904, Northing: 34671, gridLen: 5},
+	},
+	{
+		irishGridStringToStructParam{"O 15904 34671", IrishGrid_3},
+		&IrishGridCoord{Zone: "O", Easting: 159, Northing: 346, gridLen: 3},
+	},
+	{
+		irishGridStringToStructParam{"N 12000 65000", IrishGridAuto},
+		&IrishGridCoord{Zone: "N", Easting: 12, Northing: 65, gridLen: 2},
+	},
+	{
+		irishGridStringToStructParam{"N1265", IrishGrid_5},
+		&IrishGridCoord{Zone: "N", Easting: 12000, Northing: 65000, gridLen: 5},
+	},
+	{
+		irishGridStringToStructParam{"V", IrishGridAuto},
+		&IrishGridCoord{Zone: "V", gridLen: 0},
+	},
+}
+
+func irishgridequal(ig1, ig2 *IrishGridCoord) bool {
+	return fmt.Sprintf("%s", ig1) == fmt.Sprintf("%s", ig2)
+}
+
+func TestIrishGridStringToStruct(t *testing.T) {
+	for cnt, test := range irishGridStringToStructTestssuc {
+		out, err := AIrishGridToStruct(test.in.irishgridcoord, test.in.prec)
+
+		if err != nil {
+			t.Errorf("AIrishGridToStruct [%d]: Error: %s", cnt, err)
+		} else if !irishgridequal(test.out, out) {
+			t.Errorf("AIrishGridToStruct [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+	}
+}
+
+var irishGridStringToStructTestsfail = []struct {
+	in  string
+	err error
+}{
+	{"", cartconvert.ErrSyntax},
+	{"I 15 34", cartconvert.ErrSyntax},
+	{"OO 15 34", cartconvert.ErrSyntax},
+	{"15 34", cartconvert.ErrSyntax},
+	{"O 15 3", cartconvert.ErrRange},
+	{"O 159041 346712", cartconvert.ErrRange},
+}
+
+func TestIrishGridStringToStructFail(t *testing.T) {
+	for cnt, test := range irishGridStringToStructTestsfail {
+		if _, err := AIrishGridToStruct(test.in, IrishGridLeave); err != test.err {
+			t.Errorf("AIrishGridToStruct [%d]: Expected %s, got %v", cnt, test.err, err)
+		}
+	}
+}
+
+// ## IrishGridToWGS84LatLong, WGS84LatLongToIrishGrid
+
+// The Spire of Dublin as published by Ordnance Survey Ireland: Irish Grid O 15904 34671, ITM 715830 734697
+var spireITM = &ITMCoord{Easting: 715830, Northing: 734697, El: cartconvert.GRS80Ellipsoid}
+var spireLatLong = &cartconvert.PolarCoord{Latitude: 53.34979391, Longitude: -6.26024777}
+
+// the helmert transformation between the Ireland 1965 datum and WGS84 will not attain a higher accuracy
+// than a few meters. Therefore we accept a deviation of max +/- 5m as correct.
+func latlongfuzzyequal(pc1, pc2 *cartconvert.PolarCoord) bool {
+	itm1, itm2 := WGS84LatLongToITM(pc1), WGS84LatLongToITM(pc2)
+	return math.Hypot(itm1.Easting-itm2.Easting, itm1.Northing-itm2.Northing) < 5.0
+}
+
+func TestIrishGridToWGS84LatLong(t *testing.T) {
+	tests := []struct {
+		in  *IrishGridCoord
+		out *cartconvert.PolarCoord
+	}{
+		{NewIrishGridCoord("O", 15904, 34671, 0, 5, IrishGridLeave), spireLatLong},
+		// the middle of the 10m square
+		{NewIrishGridCoord("O", 1590, 3467, 0, 4, IrishGridLeave), spireLatLong},
+	}
+
+	for cnt, test := range tests {
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			out := IrishGridToWGS84LatLongAlgorithm(test.in, alg)
+
+			if !latlongfuzzyequal(test.out, out) {
+				t.Errorf("IrishGridToWGS84LatLongAlgorithm:%d [%s, %s]: Expected %s, got %s", cnt, test.in, alg, test.out, out)
+			}
+		}
+	}
+}
+
+func TestWGS84LatLongToIrishGrid(t *testing.T) {
+	in := &cartconvert.PolarCoord{Latitude: spireLatLong.Latitude, Longitude: spireLatLong.Longitude, El: cartconvert.GRS80Ellipsoid}
+	out, err := WGS84LatLongToIrishGrid(in)
+	if err != nil {
+		t.Fatalf("WGS84LatLongToIrishGrid: Error: %s", err)
+	}
+
+	// the input is left unmodified
+	if in.El != cartconvert.GRS80Ellipsoid {
+		t.Errorf("WGS84LatLongToIrishGrid: Expected the input on %v, got %v", cartconvert.GRS80Ellipsoid, in.El)
+	}
+
+	if out.Zone != "O" || math.Hypot(float64(out.Easting)-15904, float64(out.Northing)-34671) > 15.0 {
+		t.Errorf("WGS84LatLongToIrishGrid: Expected O 15904 34671, got %s", out)
+	}
+
+	if krueger, err := WGS84LatLongToIrishGridAlgorithm(&cartconvert.PolarCoord{Latitude: spireLatLong.Latitude, Longitude: spireLatLong.Longitude}, cartconvert.TMKrueger); err != nil || krueger.String() != out.String() {
+		t.Errorf("WGS84LatLongToIrishGridAlgorithm: Expected %s, got %v (%v)", out, krueger, err)
+	}
+
+	if _, err := WGS84LatLongToIrishGrid(&cartconvert.PolarCoord{Latitude: 48, Longitude: -12}); err != cartconvert.ErrRange {
+		t.Errorf("WGS84LatLongToIrishGrid: Expected %s, got %v", cartconvert.ErrRange, err)
+	}
+}
+
+// ## ITM
+type itmTest struct {
+	itm     *ITMCoord
+	latlong *cartconvert.PolarCoord
+}
+
+var itmTests = []itmTest{
+	{spireITM, spireLatLong},
+	{&ITMCoord{Easting: 600000, Northing: 750000}, &cartconvert.PolarCoord{Latitude: 53.5, Longitude: -8}},
+	{&ITMCoord{Easting: 663981.5016, Northing: 917391.7870}, &cartconvert.PolarCoord{Latitude: 55, Longitude: -7}},
+	{&ITMCoord{Easting: 455488.7363, Northing: 574067.5565}, &cartconvert.PolarCoord{Latitude: 51.9, Longitude: -10.1}},
+}
+
+func TestITMToWGS84LatLong(t *testing.T) {
+	for cnt, test := range itmTests {
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			out := ITMToWGS84LatLongAlgorithm(test.itm, alg)
+
+			if fmt.Sprintf("%.7f %.7f", test.latlong.Latitude, test.latlong.Longitude) != fmt.Sprintf("%.7f %.7f", out.Latitude, out.Longitude) {
+				t.Errorf("ITMToWGS84LatLongAlgorithm [%d, %s]: Expected %s, got %s", cnt, alg, test.latlong, out)
+			}
+		}
+	}
+}
+
+func TestWGS84LatLongToITM(t *testing.T) {
+	for cnt, test := range itmTests {
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			out := WGS84LatLongToITMAlgorithm(test.latlong, alg)
+
+			if math.Hypot(out.Easting-test.itm.Easting, out.Northing-test.itm.Northing) > 0.01 {
+				t.Errorf("WGS84LatLongToITMAlgorithm [%d, %s]: Expected %.3f %.3f, got %.3f %.3f", cnt, alg, test.itm.Easting, test.itm.Northing, out.Easting, out.Northing)
+			}
+		}
+	}
+
+	if out, err := AITMToStruct(" 715830  734697.4 "); err != nil || out.String() != "715830 734697" {
+		t.Errorf("AITMToStruct: Expected 715830 734697, got %s (%v)", out, err)
+	}
+
+	for _, in := range []string{"715830", "715830 734697 0", "715830x 734697", "715830 y"} {
+		_, err := AITMToStruct(in)
+		if cerr, ok := err.(cartconvert.CartographyError); !ok || cerr.Err != cartconvert.ErrSyntax {
+			t.Errorf("AITMToStruct: Expected %s for %q, got %v", cartconvert.ErrSyntax, in, err)
+		}
+	}
+}
+
+// ## 3D round trip
+func TestIrishGridHeight(t *testing.T) {
+
+	in := &cartconvert.PolarCoord{Latitude: 53.349765, Longitude: -6.260273, Height: 84.2, El: cartconvert.WGS84Ellipsoid}
+
+	coord, err := WGS84LatLongToIrishGrid(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the height above the modified Airy ellipsoid differs by the datum shift
+	if math.Abs(coord.RelHeight-in.Height) < 1 {
+		t.Errorf("WGS84LatLongToIrishGrid: Expected the height to be shifted, got %f", coord.RelHeight)
+	}
+
+	// the grid reference is rounded to the meter
+	out := IrishGridToWGS84LatLong(coord)
+	if !latlongfuzzyequal(in, out) || math.Abs(out.Height-in.Height) > 0.01 {
+		t.Errorf("IrishGridToWGS84LatLong: Expected %s at %fm, got %s at %fm", in, in.Height, out, out.Height)
+	}
+
+	itm := WGS84LatLongToITM(in)
+	if out := ITMToWGS84LatLong(itm); itm.RelHeight != in.Height || math.Abs(out.Height-in.Height) > 1e-6 {
+		t.Errorf("ITMToWGS84LatLong: Expected %fm, got %fm", in.Height, out.Height)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+)
+
+// ## Transverse Mercator Projection, Krüger n-series
+
+// Algorithm used to compute the transverse mercator projection
+type TMAlgorithm int
+
+const (
+	TMRedfearn TMAlgorithm = iota // Series as given by OGP Publication 373-7-2, see DirectTransverseMercator
+	TMKrueger                     // Krüger n-series of sixth order as given by Karney, see DirectTransverseMercatorKrueger
+)
+
+// Algorithm of the transverse mercator projection used by the UTM conversion functions and by the national
+// grids. Functions ending in Algorithm and the Algorithm of a Projection select another algorithm.
+const DefaultTMAlgorithm = TMRedfearn
+
+func (alg TMAlgorithm) String() string {
+	switch alg {
+	case TMRedfearn:
+		return "TMRedfearn"
+	case TMKrueger:
+		return "TMKrueger"
+	}
+	return "#unknown"
+}
+
+// Direct transverse mercator projection using the algorithm alg. For the parameters see DirectTransverseMercator.
+func (alg TMAlgorithm) DirectTransverseMercator(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
+	if alg == TMKrueger {
+		return DirectTransverseMercatorKrueger(gc, latO, longO, scale, fe, fn)
+	}
+	return DirectTransverseMercator(gc, latO, longO, scale, fe, fn)
+}
+
+// Inverse transverse mercator projection using the algorithm alg. For the parameters see InverseTransverseMercator.
+func (alg TMAlgorithm) InverseTransverseMercator(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
+	if alg == TMKrueger {
+		return InverseTransverseMercatorKrueger(pt, latO, longO, scale, fe, fn)
+	}
+	return InverseTransverseMercator(pt, latO, longO, scale, fe, fn)
+}
+
+// Rectifying radius and coefficients of the Krüger series for the ellipsoid el: alpha for the
+// direct, beta for the inverse projection.
+func kruegerSeries(el *Ellipsoid) (A float64, alpha, beta [6]float64) {
+
+	f := 1 - el.b/el.a
+	n := f / (2 - f)
+	n2 := n * n
+	n3 := n2 * n
+	n4 := n3 * n
+	n5 := n4 * n
+	n6 := n5 * n
+
+	A = el.a / (1 + n) * (1 + n2/4 + n4/64 + n6/256)
+
+	alpha = [6]float64{
+		n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180 - 127*n5/288 + 7891*n6/37800,
+		13*n2/48 - 3*n3/5 + 557*n4/1440 + 281*n5/630 - 1983433*n6/1935360,
+		61*n3/240 - 103*n4/140 + 15061*n5/26880 + 167603*n6/181440,
+		49561*n4/161280 - 179*n5/168 + 6601661*n6/7257600,
+		34729*n5/80640 - 3418889*n6/1995840,
+		212378941 * n6 / 319334400,
+	}
+
+	beta = [6]float64{
+		n/2 - 2*n2/3 + 37*n3/96 - n4/360 - 81*n5/512 + 96199*n6/604800,
+		n2/48 + n3/15 - 437*n4/1440 + 46*n5/105 - 1118711*n6/3870720,
+		17*n3/480 - 37*n4/840 - 209*n5/4480 + 5569*n6/90720,
+		4397*n4/161280 - 11*n5/504 - 830251*n6/7257600,
+		4583*n5/161280 - 108847*n6/3991680,
+		20648693 * n6 / 638668800,
+	}
+
+	return
+}
+
+// Tangent of the conformal latitude for the tangent tau of the geodetic latitude
+func conformalTan(tau, e float64) float64 {
+	sigma := math.Sinh(e * math.Atanh(e*tau/math.Hypot(1, tau)))
+	return tau*math.Hypot(1, sigma) - sigma*math.Hypot(1, tau)
+}
+
+// Direct transverse mercator projection: Projection of an ellipsoid onto the surface of
+// of a cylinder. Also known as Gauss-Krüger projection. For the input parameters see DirectTransverseMercator.
+//
+// This algorithm uses the Krüger n-series to sixth order, which is accurate to a few nanometers
+// within 3900km of the central meridian.
+//
+// Taken from "C. F. F. Karney: Transverse Mercator with an accuracy of a few nanometers,
+// J. Geodesy 85(8), 475-485 (Aug. 2011)"
+func DirectTransverseMercatorKrueger(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
+
+	var pt GeoPoint
+
+	el := gc.El
+
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+	A, alpha, _ := kruegerSeries(el)
+
+	latrad := degtorad(gc.Latitude)
+	dlong := degtorad(gc.Longitude - longO)
+
+	taup := conformalTan(math.Tan(latrad), e)
+	xip := math.Atan2(taup, math.Cos(dlong))
+	etap := math.Asinh(math.Sin(dlong) / math.Hypot(taup, math.Cos(dlong)))
+
+	xi, eta := xip, etap
+	for j := range alpha {
+		k := float64(2 * (j + 1))
+		xi += alpha[j] * math.Sin(k*xip) * math.Cosh(k*etap)
+		eta += alpha[j] * math.Cos(k*xip) * math.Sinh(k*etap)
+	}
+
+	// meridian distance of the latitude of origin
+	var SO float64
+	if latO != 0.0 {
+		xiO := math.Atan(conformalTan(math.Tan(degtorad(latO)), e))
+		SO = xiO
+		for j := range alpha {
+			SO += alpha[j] * math.Sin(float64(2*(j+1))*xiO)
+		}
+		SO *= A
+	}
+
+	pt.X = fe + scale*A*eta
+	pt.Y = fn + scale*(A*xi-SO)
+
+	pt.El = el
+	pt.H = gc.Height
+
+	return &pt
+}
+
+// Inverse transverse mercator projection: Projection of an cylinder onto the surface of
+// of an ellipsoid. Also known as reverse Gauss-Krüger projection. For the input parameters see
+// InverseTransverseMercator.
+//
+// This algorithm uses the Krüger n-series to sixth order, which is accurate to a few nanometers
+// within 3900km of the central meridian.
+//
+// Taken from "C. F. F. Karney: Transverse Mercator with an accuracy of a few nanometers,
+// J. Geodesy 85(8), 475-485 (Aug. 2011)"
+func InverseTransverseMercatorKrueger(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
+
+	var gc PolarCoord
+
+	el := pt.El
+
+	esq := (el.a*el.a - el.b*el.b) / (el.a * el.a)
+	e := math.Sqrt(esq)
+	A, alpha, beta := kruegerSeries(el)
+
+	var SO float64
+	if latO != 0.0 {
+		xiO := math.Atan(conformalTan(math.Tan(degtorad(latO)), e))
+		SO = xiO
+		for j := range alpha {
+			SO += alpha[j] * math.Sin(float64(2*(j+1))*xiO)
+		}
+		SO *= A
+	}
+
+	eta := (pt.X - fe) / (scale * A)
+	xi := ((pt.Y - fn) + scale*SO) / (scale * A)
+
+	xip, etap := xi, eta
+	for j := range beta {
+		k := float64(2 * (j + 1))
+		xip -= beta[j] * math.Sin(k*xi) * math.Cosh(k*eta)
+		etap -= beta[j] * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	taup := math.Sin(xip) / math.Hypot(math.Sinh(etap), math.Cos(xip))
+
+	// Newton's method to solve for the tangent of the geodetic latitude
+	tau := taup
+	for i := 0; i < 10; i++ {
+		taupi := conformalTan(tau, e)
+		dtau := (taup - taupi) / math.Hypot(1, taupi) *
+			(1 + (1-esq)*tau*tau) / ((1 - esq) * math.Hypot(1, tau))
+		tau += dtau
+		if math.Abs(dtau) < 1e-14*math.Max(1, math.Abs(tau)) {
+			break
+		}
+	}
+
+	gc.Latitude = radtodeg(math.Atan(tau))
+	gc.Longitude = longO + radtodeg(math.Atan2(math.Sinh(etap), math.Cos(xip)))
+
+	gc.El = el
+	gc.Height = pt.H
+
+	return &gc
+}
@@ -0,0 +1,128 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// WGS84 with the defining flattening of 1/298.257223563
+var kruegerWGS84Ellipsoid = NewEllipsoid(6378137, 6378137*(1-1/298.257223563), "WGS84")
+
+// Reference values of the exact transverse mercator projection (Lee, 1976) with UTM parameters.
+// The first point is the UTM example of GeographicLib's GeoConvert, 38N 444140.54 3684706.36
+var kruegerTests = []directtransversemercatorTest{
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 33.3, Longitude: 44.4, El: kruegerWGS84Ellipsoid}, 0, 45, 0.9996, 500000, 0},
+		&GeoPoint{X: 444140.5449, Y: 3684706.3555},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 40, Longitude: 10, El: kruegerWGS84Ellipsoid}, 0, 0, 0.9996, 500000, 0},
+		&GeoPoint{X: 1354342.8422, Y: 4475948.5494},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 60, Longitude: 20, El: kruegerWGS84Ellipsoid}, 0, 0, 0.9996, 500000, 0},
+		&GeoPoint{X: 1603890.1050, Y: 6820843.1707},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 30, Longitude: 25, El: kruegerWGS84Ellipsoid}, 0, 0, 0.9996, 500000, 0},
+		&GeoPoint{X: 2949238.7107, Y: 3597203.1708},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: -45, Longitude: -15, El: kruegerWGS84Ellipsoid}, 0, 0, 0.9996, 500000, 0},
+		&GeoPoint{X: -682109.3293, Y: -5093638.2073},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 75, Longitude: 30, El: kruegerWGS84Ellipsoid}, 0, 0, 0.9996, 500000, 0},
+		&GeoPoint{X: 1332317.9002, Y: 8539677.1000},
+	},
+}
+
+func latlongmmequal(pcp1, pcp2 *PolarCoord) bool {
+	pp1s := fmt.Sprintf("%.8f %.8f", pcp1.Latitude, pcp1.Longitude)
+	pp2s := fmt.Sprintf("%.8f %.8f", pcp2.Latitude, pcp2.Longitude)
+
+	return pp1s == pp2s
+}
+
+func TestDirectTransverseMercatorKrueger(t *testing.T) {
+	for cnt, test := range append(kruegerTests, directtransversemercatorTests...) {
+		out := DirectTransverseMercatorKrueger(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !geopointequal(test.out, out) {
+			t.Errorf("DirectTransverseMercatorKrueger [%d]: Expected %v, got %v", cnt, test.out, out)
+		}
+	}
+}
+
+func TestInverseTransverseMercatorKrueger(t *testing.T) {
+	for cnt, test := range kruegerTests {
+		pt := &GeoPoint{X: test.out.X, Y: test.out.Y, El: test.in.pc.El}
+		out := InverseTransverseMercatorKrueger(pt, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !latlongmmequal(test.in.pc, out) {
+			t.Errorf("InverseTransverseMercatorKrueger [%d]: Expected %s, got %s", cnt, test.in.pc, out)
+		}
+	}
+
+	for _, test := range inversetransversemercatorTests {
+		out := InverseTransverseMercatorKrueger(test.in.pt, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !latlongequal(test.out, out) {
+			t.Errorf("InverseTransverseMercatorKrueger: Expected %s, got %s", test.out, out)
+		}
+	}
+}
+
+// 70° from the central meridian, where the series of OGP 373-7-2 misses the exact transverse mercator by half a
+// metre. The reference value evaluates the meridian arc at the complex latitude, whose isometric latitude is the
+// isometric latitude plus i times the longitude, after Lee (1976), by numerical integration.
+var tmAlgorithmFarTest = directtransversemercatorTest{
+	directtransversemercatorParam{&PolarCoord{Latitude: 5, Longitude: 70, El: kruegerWGS84Ellipsoid}, 0, 0, 0.9996, 500000, 0},
+	&GeoPoint{X: 11431197.5646, Y: 1624349.7060},
+}
+
+func TestTMAlgorithm(t *testing.T) {
+	test := tmAlgorithmFarTest
+	for _, alg := range []struct {
+		alg            TMAlgorithm
+		minerr, maxerr float64
+	}{
+		{TMRedfearn, 0.4, 0.5},
+		{TMKrueger, 0, 0.005},
+	} {
+		pt := alg.alg.DirectTransverseMercator(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if d := math.Hypot(pt.X-test.out.X, pt.Y-test.out.Y); d < alg.minerr || d > alg.maxerr {
+			t.Errorf("%s.DirectTransverseMercator: Expected an error of %.3fm to %.3fm, got %.4fm", alg.alg, alg.minerr, alg.maxerr, d)
+		}
+	}
+
+	test = kruegerTests[3]
+	for _, alg := range []TMAlgorithm{TMRedfearn, TMKrueger} {
+		pt := alg.DirectTransverseMercator(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !geopointequal(test.out, pt) {
+			t.Errorf("%s.DirectTransverseMercator: Expected %v, got %v", alg, test.out, pt)
+		}
+		pc := alg.InverseTransverseMercator(pt, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !latlongmmequal(test.in.pc, pc) {
+			t.Errorf("%s.InverseTransverseMercator: Expected %s, got %s", alg, test.in.pc, pc)
+		}
+	}
+}
+
+// The UTM example of GeographicLib's GeoConvert
+func TestUTMAlgorithm(t *testing.T) {
+	test := kruegerTests[0]
+	expected := &UTMCoord{Easting: test.out.X, Northing: test.out.Y, Zone: "38S"}
+
+	utm, err := LatLongToUTMAlgorithm(test.in.pc, TMKrueger)
+	if err != nil || utm.Zone != expected.Zone || !geopointequal(test.out, &GeoPoint{X: utm.Easting, Y: utm.Northing}) {
+		t.Fatalf("LatLongToUTMAlgorithm: Expected %s, got %v (%v)", expected, utm, err)
+	}
+
+	if pc, err := UTMToLatLongAlgorithm(utm, TMKrueger); err != nil || !latlongmmequal(test.in.pc, pc) {
+		t.Errorf("UTMToLatLongAlgorithm: Expected %s, got %v (%v)", test.in.pc, pc, err)
+	}
+}
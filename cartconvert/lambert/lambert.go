@@ -0,0 +1,235 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// This package provides a series of functions to deal with
+// conversion and transformations of national grids based on the Lambert conformal conic projection,
+//
+// and here specifically of the Austria Lambert grid (EPSG:31287), which is the successor of the
+// Bundesmeldenetz and uses the same datum MGI on the Bessel reference ellipsoid but a single
+// projection covering all of Austria, and of the French Lambert-93 grid (EPSG:2154) on the
+// datum RGF93, which is compatible with WGS84 to better than one meter.
+// For more information see
+//
+// [DE]: http://de.wikipedia.org/wiki/Lambert-Kegelprojektion
+// [EN]: http://www.asprs.org/resources/grids/03-2004-austria.pdf
+package lambert
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"strconv"
+	"strings"
+)
+
+// The national Lambert grid of a coordinate. The grid plays the same role as the zone specifier of UTM.
+type LambertGrid byte
+
+const (
+	LambertGridDet LambertGrid = iota
+	AustriaLambert
+	Lambert93
+)
+
+func (lg LambertGrid) String() (rep string) {
+	switch lg {
+	case AustriaLambert:
+		rep = "AT"
+	case Lambert93:
+		rep = "FR"
+	case LambertGridDet:
+		rep = "autodetect"
+	default:
+		rep = "#unknown"
+	}
+	return
+}
+
+// A Lambert coordinate is specified by easting, northing, the ellipsoidal height and the national grid.
+// The datum is MGI for Austria Lambert and RGF93 for Lambert-93.
+type LambertCoord struct {
+	Easting, Northing, RelHeight float64
+	Grid                         LambertGrid
+	El                           *cartconvert.Ellipsoid
+	Datum                        *cartconvert.Datum
+}
+
+// Canonical representation of a Lambert coordinate, eg. "AT 400000 400000"
+func (lc *LambertCoord) String() string {
+	return fmt.Sprintf("%s %.0f %.0f", lc.Grid, lc.Easting, lc.Northing)
+}
+
+// Parameters of a Lambert conformal conic grid with two standard parallels
+type lambertGridParameters struct {
+	latF, longF, lat1, lat2, ef, nf float64
+	datum                           *cartconvert.Datum
+}
+
+func (lg LambertGrid) parameters() (*lambertGridParameters, error) {
+	switch lg {
+	case AustriaLambert:
+		return &lambertGridParameters{latF: 47.5, longF: 13 + 20.0/60, lat1: 49, lat2: 46, ef: 400000, nf: 400000, datum: cartconvert.MGIDatum}, nil
+	case Lambert93:
+		return &lambertGridParameters{latF: 46.5, longF: 3, lat1: 49, lat2: 44, ef: 700000, nf: 6600000, datum: cartconvert.RGF93Datum}, nil
+	}
+	return nil, cartconvert.ErrRange
+}
+
+// The coordinate reference system of the grid, as published by EPSG. Returns cartconvert.ErrRange if the grid
+// is not set.
+func (lg LambertGrid) CRS() (*cartconvert.CRS, error) {
+	params, err := lg.parameters()
+	if err != nil {
+		return nil, err
+	}
+	name := " / Austria Lambert"
+	if lg == Lambert93 {
+		name = " / Lambert-93"
+	}
+	return &cartconvert.CRS{
+		Name:  params.datum.Name + name,
+		Datum: params.datum,
+		Projection: &cartconvert.Projection{Method: cartconvert.LambertConformalConic2SP, LatO: params.latF, LongO: params.longF,
+			Lat1: params.lat1, Lat2: params.lat2, Scale: 1, FE: params.ef, FN: params.nf},
+	}, nil
+}
+
+// Parses a string representation of a Lambert coordinate of the format
+//
+//	"GRID EASTING NORTHING"
+//
+// into a struct holding a Lambert coordinate value. GRID is one of AT (Austria Lambert) or FR (Lambert-93).
+// The reference ellipsoid is the one of the grid.
+//
+// returns a cartconvert.CartographyError of cartconvert.ErrSyntax if format is not understood
+func ALambertToStruct(lambertcoord string) (*LambertCoord, error) {
+
+	fields := strings.Fields(strings.ToUpper(lambertcoord))
+	if len(fields) != 3 {
+		return nil, cartconvert.CartographyError{Coord: lambertcoord, Err: cartconvert.ErrSyntax}
+	}
+
+	var grid LambertGrid
+	switch fields[0] {
+	case "AT":
+		grid = AustriaLambert
+	case "FR":
+		grid = Lambert93
+	default:
+		return nil, cartconvert.CartographyError{Coord: lambertcoord, Err: cartconvert.ErrSyntax}
+	}
+
+	easting, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, cartconvert.CartographyError{Coord: lambertcoord, Err: cartconvert.ErrSyntax}
+	}
+
+	northing, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, cartconvert.CartographyError{Coord: lambertcoord, Err: cartconvert.ErrSyntax}
+	}
+
+	params, _ := grid.parameters()
+	return &LambertCoord{Easting: easting, Northing: northing, Grid: grid, El: params.datum.El, Datum: params.datum}, nil
+}
+
+// Transform a Lambert coordinate value to a WGS84 based latitude and longitude coordinate. Function returns
+// cartconvert.ErrRange, if the grid of the Lambert coordinate is not set
+func LambertToWGS84LatLong(coord *LambertCoord) (*cartconvert.PolarCoord, error) {
+
+	params, err := coord.Grid.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	gc := cartconvert.InverseLambertConformalConic2SP(
+		&cartconvert.GeoPoint{X: coord.Easting, Y: coord.Northing, H: coord.RelHeight, El: params.datum.El},
+		params.latF,
+		params.longF,
+		params.lat1,
+		params.lat2,
+		params.ef,
+		params.nf)
+
+	return cartconvert.TransformDatum(gc, params.datum, cartconvert.WGS84Datum)
+}
+
+// Transform a latitude / longitude coordinate datum into a Lambert coordinate of the national grid grid.
+// If grid is LambertGridDet, the grid is determined from the location: Austria Lambert within Austria,
+// Lambert-93 within France. Function returns cartconvert.ErrRange, if no grid covers the location.
+//
+// Important: The reference ellipsoid of the originating coordinate system will be assumed
+// to be the WGS84Ellipsoid, regardless of the actually set reference ellipsoid.
+func WGS84LatLongToLambert(gc *cartconvert.PolarCoord, grid LambertGrid) (*LambertCoord, error) {
+
+	if grid == LambertGridDet {
+		switch {
+		case 46.3 <= gc.Latitude && gc.Latitude <= 49.1 && 9.5 <= gc.Longitude && gc.Longitude <= 17.2:
+			grid = AustriaLambert
+		case 41.3 <= gc.Latitude && gc.Latitude <= 51.1 && -5.2 <= gc.Longitude && gc.Longitude <= 9.6:
+			grid = Lambert93
+		}
+	}
+
+	params, err := grid.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	polar, err := cartconvert.TransformDatum(gc, cartconvert.WGS84Datum, params.datum)
+	if err != nil {
+		return nil, err
+	}
+
+	gp := cartconvert.DirectLambertConformalConic2SP(
+		polar,
+		params.latF,
+		params.longF,
+		params.lat1,
+		params.lat2,
+		params.ef,
+		params.nf)
+
+	return &LambertCoord{Easting: gp.X, Northing: gp.Y, RelHeight: gp.H, Grid: grid, El: gp.El, Datum: params.datum}, nil
+}
+
+// Lambert grids as a cartconvert.CoordinateSystem
+type lambertSystem struct{}
+
+func (lambertSystem) Name() string        { return "lambert" }
+func (lambertSystem) Description() string { return "AT:Austria Lambert / FR:Lambert-93" }
+
+func (lambertSystem) Parse(coord string) (fmt.Stringer, error) {
+	return ALambertToStruct(coord)
+}
+
+func (lambertSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*LambertCoord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (lambertSystem) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	lambertcoord, ok := coord.(*LambertCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return LambertToWGS84LatLong(lambertcoord)
+}
+
+func (lambertSystem) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return WGS84LatLongToLambert(pc, LambertGridDet)
+}
+
+func (lambertSystem) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	lambertcoord, ok := coord.(*LambertCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return lambertcoord.Grid.CRS()
+}
+
+func init() {
+	cartconvert.RegisterCoordinateSystem(lambertSystem{})
+}
@@ -0,0 +1,160 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert/lambert package
+package lambert
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"testing"
+)
+
+// ## ALambertToStruct
+type aLambertToStructTest struct {
+	in  string
+	out *LambertCoord
+}
+
+var aLambertToStructTests = []aLambertToStructTest{
+	{" at 400000  400000 ", &LambertCoord{Easting: 400000, Northing: 400000, Grid: AustriaLambert}},
+	{"FR 652469.5 6862035.2", &LambertCoord{Easting: 652469.5, Northing: 6862035.2, Grid: Lambert93}},
+}
+
+func lambertequal(lc1, lc2 *LambertCoord) bool {
+	return lc1.String() == lc2.String()
+}
+
+func TestALambertToStruct(t *testing.T) {
+	for cnt, test := range aLambertToStructTests {
+		out, err := ALambertToStruct(test.in)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !lambertequal(test.out, out) {
+			t.Errorf("ALambertToStruct [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+
+	for _, in := range []string{"BE 150000 170000", "AT 400000", "AT x 400000", "AT 400000 x"} {
+		_, err := ALambertToStruct(in)
+		if cerr, ok := err.(cartconvert.CartographyError); !ok || cerr.Err != cartconvert.ErrSyntax {
+			t.Errorf("ALambertToStruct: expected %s for %q, got %v", cartconvert.ErrSyntax, in, err)
+		}
+	}
+}
+
+func latlongequal(pcp1, pcp2 *cartconvert.PolarCoord) bool {
+	pp1s := fmt.Sprintf("%.6f %.6f", pcp1.Latitude, pcp1.Longitude)
+	pp2s := fmt.Sprintf("%.6f %.6f", pcp2.Latitude, pcp2.Longitude)
+
+	return pp1s == pp2s
+}
+
+// ## WGS84LatLongToLambert
+func TestWGS84LatLongToLambertOrigin(t *testing.T) {
+	// The false origin of Austria Lambert on MGI, transformed to WGS84
+	mgi := cartconvert.PolarToCartesian(&cartconvert.PolarCoord{Latitude: 47.5, Longitude: 13 + 20.0/60, El: cartconvert.Bessel1841MGIEllipsoid})
+	pt := cartconvert.HelmertWGS84ToMGI.InverseTransform(&cartconvert.Point3D{X: mgi.X, Y: mgi.Y, Z: mgi.Z})
+	origin := cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.WGS84Ellipsoid})
+
+	for _, test := range []struct {
+		in  *cartconvert.PolarCoord
+		out *LambertCoord
+	}{
+		{origin, &LambertCoord{Easting: 400000, Northing: 400000, Grid: AustriaLambert}},
+		{&cartconvert.PolarCoord{Latitude: 46.5, Longitude: 3}, &LambertCoord{Easting: 700000, Northing: 6600000, Grid: Lambert93}},
+	} {
+		out, err := WGS84LatLongToLambert(test.in, LambertGridDet)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !lambertequal(test.out, out) {
+			t.Errorf("WGS84LatLongToLambert: expected %s, got %s", test.out, out)
+		}
+	}
+
+	if _, err := WGS84LatLongToLambert(&cartconvert.PolarCoord{Latitude: 40.4, Longitude: -3.7}, LambertGridDet); err != cartconvert.ErrRange {
+		t.Errorf("WGS84LatLongToLambert: expected %s, got %v", cartconvert.ErrRange, err)
+	}
+}
+
+// ## LambertToWGS84LatLong
+var lambertRoundTripTests = []*cartconvert.PolarCoord{
+	{Latitude: 48.507001, Longitude: 15.698748}, // M34 703168 374510 of the Bundesmeldenetz
+	{Latitude: 47.26, Longitude: 9.6},
+	{Latitude: 48.8530, Longitude: 2.3498},
+	{Latitude: 43.2965, Longitude: 5.3698},
+}
+
+func TestLambertToWGS84LatLong(t *testing.T) {
+	for cnt, in := range lambertRoundTripTests {
+		lambertcoord, err := WGS84LatLongToLambert(in, LambertGridDet)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		out, err := LambertToWGS84LatLong(lambertcoord)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !latlongequal(in, out) {
+			t.Errorf("LambertToWGS84LatLong [%d]: expected %s, got %s", cnt, in, out)
+		}
+	}
+
+	if _, err := LambertToWGS84LatLong(&LambertCoord{Easting: 400000, Northing: 400000}); err != cartconvert.ErrRange {
+		t.Errorf("LambertToWGS84LatLong: expected %s, got %v", cartconvert.ErrRange, err)
+	}
+}
+
+// ## Coordinate system registry
+func TestLambertCoordinateSystem(t *testing.T) {
+	cs, ok := cartconvert.LookupCoordinateSystem("lambert")
+	if !ok {
+		t.Fatal("LambertCoordinateSystem: lambert is not registered")
+	}
+
+	coord, err := cs.Parse("FR 700000 6600000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := cs.ToLatLong(coord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := (&cartconvert.PolarCoord{Latitude: 46.5, Longitude: 3}); !latlongequal(expected, out) {
+		t.Errorf("LambertCoordinateSystem: expected %s, got %s", expected, out)
+	}
+}
+
+// ## 3D round trip
+func TestLambertHeight(t *testing.T) {
+
+	for _, in := range []*cartconvert.PolarCoord{
+		{Latitude: 48.2082, Longitude: 16.3738, Height: 230.6, El: cartconvert.WGS84Ellipsoid},
+		{Latitude: 46.5, Longitude: 3, Height: 312.1, El: cartconvert.WGS84Ellipsoid},
+	} {
+		coord, err := WGS84LatLongToLambert(in, LambertGridDet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := LambertToWGS84LatLong(coord)
+		if err != nil || !latlongequal(in, out) || math.Abs(out.Height-in.Height) > 0.001 {
+			t.Errorf("LambertToWGS84LatLong %s: Expected %s at %fm, got %s at %fm (%v)", coord, in, in.Height, out, out.Height, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+)
+
+// ## Lambert Conformal Conic Projection
+
+// Isometric helper functions of the Lambert conformal conic projection for the latitude lat in radians
+func lccm(lat, e float64) float64 {
+	esin := e * math.Sin(lat)
+	return math.Cos(lat) / math.Sqrt(1-esin*esin)
+}
+
+func lcct(lat, e float64) float64 {
+	esin := e * math.Sin(lat)
+	return math.Tan(math.Pi/4-lat/2) / math.Pow((1-esin)/(1+esin), e/2)
+}
+
+// Project gc onto the cone of cone constant n and radius factor aF. The origin latO, longO is mapped to fe, fn.
+func directLambertConformalConic(gc *PolarCoord, n, aF, latO, longO, fe, fn float64) *GeoPoint {
+
+	var pt GeoPoint
+
+	el := gc.El
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+
+	r := aF * math.Pow(lcct(degtorad(gc.Latitude), e), n)
+	rO := aF * math.Pow(lcct(degtorad(latO), e), n)
+	theta := n * degtorad(gc.Longitude-longO)
+
+	pt.X = fe + r*math.Sin(theta)
+	pt.Y = fn + rO - r*math.Cos(theta)
+
+	pt.El = el
+	pt.H = gc.Height
+
+	return &pt
+}
+
+// Inverse of directLambertConformalConic
+func inverseLambertConformalConic(pt *GeoPoint, n, aF, latO, longO, fe, fn float64) *PolarCoord {
+
+	var gc PolarCoord
+
+	el := pt.El
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+
+	rO := aF * math.Pow(lcct(degtorad(latO), e), n)
+
+	dx := pt.X - fe
+	dy := rO - (pt.Y - fn)
+
+	sign := 1.0
+	if n < 0 {
+		sign = -1
+	}
+
+	r := sign * math.Hypot(dx, dy)
+	theta := math.Atan2(sign*dx, sign*dy)
+	t := math.Pow(r/aF, 1/n)
+
+	lat := math.Pi/2 - 2*math.Atan(t)
+	for i := 0; i < 20; i++ {
+		esin := e * math.Sin(lat)
+		latn := math.Pi/2 - 2*math.Atan(t*math.Pow((1-esin)/(1+esin), e/2))
+		if math.Abs(latn-lat) < 1e-12 {
+			lat = latn
+			break
+		}
+		lat = latn
+	}
+
+	gc.Latitude = radtodeg(lat)
+	gc.Longitude = longO + radtodeg(theta/n)
+
+	gc.El = el
+	gc.Height = pt.H
+
+	return &gc
+}
+
+// Cone constant and radius factor of the Lambert conformal conic projection with one standard parallel
+func lcc1SPParameters(el *Ellipsoid, latO, scale float64) (n, aF float64) {
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+	latOrad := degtorad(latO)
+
+	n = math.Sin(latOrad)
+	aF = el.a * scale * lccm(latOrad, e) / (n * math.Pow(lcct(latOrad, e), n))
+	return
+}
+
+// Cone constant and radius factor of the Lambert conformal conic projection with two standard parallels
+func lcc2SPParameters(el *Ellipsoid, lat1, lat2 float64) (n, aF float64) {
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+	lat1rad := degtorad(lat1)
+	lat2rad := degtorad(lat2)
+
+	m1, m2 := lccm(lat1rad, e), lccm(lat2rad, e)
+	t1, t2 := lcct(lat1rad, e), lcct(lat2rad, e)
+
+	if lat1 == lat2 {
+		n = math.Sin(lat1rad)
+	} else {
+		n = (math.Log(m1) - math.Log(m2)) / (math.Log(t1) - math.Log(t2))
+	}
+	aF = el.a * m1 / (n * math.Pow(t1, n))
+	return
+}
+
+// Direct Lambert conformal conic projection with one standard parallel: Projection of an ellipsoid
+// onto the surface of a cone touching the ellipsoid at the latitude of origin. Input parameters:
+//
+//	gc *PolarCoord: Latitude and Longitude or point to be projected; in decimal degrees
+//	latO, longO: Latitude and longitude of natural origin in decimal degrees
+//	fe, fn: False easting and northing respectively in meters
+//	scale: Projection scaling at the natural origin; Dimensionless, typically 1 or little bellow
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Lambert Conic Conformal (1SP), pp. 18 - 20
+func DirectLambertConformalConic1SP(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
+	n, aF := lcc1SPParameters(gc.El, latO, scale)
+	return directLambertConformalConic(gc, n, aF, latO, longO, fe, fn)
+}
+
+// Inverse Lambert conformal conic projection with one standard parallel: Projection of a cone touching
+// the ellipsoid at the latitude of origin back onto the ellipsoid. Input parameters:
+//
+//	pt *GeoPoint: Easting(X) and Northing(Y) of map point to be projected; in meters
+//	latO, longO: Latitude and longitude of natural origin in decimal degrees
+//	fe, fn: False easting and northing respectively in meters
+//	scale: Projection scaling at the natural origin; Dimensionless, typically 1 or little bellow
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Lambert Conic Conformal (1SP), pp. 18 - 20
+func InverseLambertConformalConic1SP(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
+	n, aF := lcc1SPParameters(pt.El, latO, scale)
+	return inverseLambertConformalConic(pt, n, aF, latO, longO, fe, fn)
+}
+
+// Direct Lambert conformal conic projection with two standard parallels: Projection of an ellipsoid
+// onto the surface of a cone intersecting the ellipsoid at the standard parallels. Input parameters:
+//
+//	gc *PolarCoord: Latitude and Longitude or point to be projected; in decimal degrees
+//	latF, longF: Latitude and longitude of false origin in decimal degrees
+//	lat1, lat2: Latitude of the first and second standard parallel in decimal degrees
+//	ef, nf: Easting and northing at the false origin respectively in meters
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Lambert Conic Conformal (2SP), pp. 17 - 18
+func DirectLambertConformalConic2SP(gc *PolarCoord, latF, longF, lat1, lat2, ef, nf float64) *GeoPoint {
+	n, aF := lcc2SPParameters(gc.El, lat1, lat2)
+	return directLambertConformalConic(gc, n, aF, latF, longF, ef, nf)
+}
+
+// Inverse Lambert conformal conic projection with two standard parallels: Projection of a cone intersecting
+// the ellipsoid at the standard parallels back onto the ellipsoid. Input parameters:
+//
+//	pt *GeoPoint: Easting(X) and Northing(Y) of map point to be projected; in meters
+//	latF, longF: Latitude and longitude of false origin in decimal degrees
+//	lat1, lat2: Latitude of the first and second standard parallel in decimal degrees
+//	ef, nf: Easting and northing at the false origin respectively in meters
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Lambert Conic Conformal (2SP), pp. 17 - 18
+func InverseLambertConformalConic2SP(pt *GeoPoint, latF, longF, lat1, lat2, ef, nf float64) *PolarCoord {
+	n, aF := lcc2SPParameters(pt.El, lat1, lat2)
+	return inverseLambertConformalConic(pt, n, aF, latF, longF, ef, nf)
+}
@@ -0,0 +1,90 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// US survey foot in meters
+const usSurveyFoot = 0.30480060960122
+
+// ## Lambert Conformal Conic (1SP)
+// OGP Publication 373-7-2, JAD69 / Jamaica National Grid
+var lcc1SPTests = []directtransversemercatorTest{
+	{
+		directtransversemercatorParam{
+			&PolarCoord{Latitude: 17 + 55.0/60 + 55.8/3600, Longitude: -(76 + 56.0/60 + 37.26/3600), El: Clarke1866Ellipsoid},
+			18,
+			-77,
+			1,
+			250000,
+			150000,
+		},
+		&GeoPoint{X: 255966.5818, Y: 142493.5110},
+	},
+}
+
+func TestLambertConformalConic1SP(t *testing.T) {
+	for cnt, test := range lcc1SPTests {
+		out := DirectLambertConformalConic1SP(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !geopointequal(test.out, out) {
+			t.Errorf("DirectLambertConformalConic1SP [%d]: Expected %v, got %v", cnt, test.out, out)
+		}
+
+		pt := &GeoPoint{X: test.out.X, Y: test.out.Y, El: test.in.pc.El}
+		pc := InverseLambertConformalConic1SP(pt, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !latlongmmequal(test.in.pc, pc) {
+			t.Errorf("InverseLambertConformalConic1SP [%d]: Expected %s, got %s", cnt, test.in.pc, pc)
+		}
+	}
+}
+
+// ## Lambert Conformal Conic (2SP)
+type lcc2SPParam struct {
+	pc                              *PolarCoord
+	latF, longF, lat1, lat2, ef, nf float64
+}
+
+type lcc2SPTest struct {
+	in  lcc2SPParam
+	out *GeoPoint
+}
+
+var lcc2SPTests = []lcc2SPTest{
+	{
+		// OGP Publication 373-7-2, NAD27 / Texas South Central; 2963503.91, 254759.80 US survey feet
+		lcc2SPParam{
+			&PolarCoord{Latitude: 28.5, Longitude: -96, El: Clarke1866Ellipsoid},
+			27 + 50.0/60,
+			-99,
+			28 + 23.0/60,
+			30 + 17.0/60,
+			2000000 * usSurveyFoot,
+			0,
+		},
+		&GeoPoint{X: 903277.80, Y: 77650.94},
+	},
+	{
+		// Origin of Lambert-93
+		lcc2SPParam{&PolarCoord{Latitude: 46.5, Longitude: 3, El: GRS80Ellipsoid}, 46.5, 3, 49, 44, 700000, 6600000},
+		&GeoPoint{X: 700000, Y: 6600000},
+	},
+}
+
+func TestLambertConformalConic2SP(t *testing.T) {
+	for cnt, test := range lcc2SPTests {
+		out := DirectLambertConformalConic2SP(test.in.pc, test.in.latF, test.in.longF, test.in.lat1, test.in.lat2, test.in.ef, test.in.nf)
+		if !geopointcmequal(test.out, out) {
+			t.Errorf("DirectLambertConformalConic2SP [%d]: Expected %v, got %v", cnt, test.out, out)
+		}
+
+		pc := InverseLambertConformalConic2SP(out, test.in.latF, test.in.longF, test.in.lat1, test.in.lat2, test.in.ef, test.in.nf)
+		if !latlongmmequal(test.in.pc, pc) {
+			t.Errorf("InverseLambertConformalConic2SP [%d]: Expected %s, got %s", cnt, test.in.pc, pc)
+		}
+	}
+}
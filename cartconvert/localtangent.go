@@ -0,0 +1,177 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+)
+
+// ## Local tangent planes
+
+// A local tangent plane touches the reference ellipsoid at a reference point. Its axes point east, north and
+// up along the ellipsoidal normal (ENU), or north, east and down (NED) as common in aviation. Coordinates are
+// in meters relative to the reference point, which is converted by PolarToCartesian into the geocentric
+// cartesian frame of the CartPoints. Longitudes refer to the prime meridian of their datum.
+
+// A point in East-North-Up coordinates of a local tangent plane, in meters
+type ENUPoint struct {
+	East, North, Up float64
+}
+
+// A point in North-East-Down coordinates of a local tangent plane, in meters
+type NEDPoint struct {
+	North, East, Down float64
+}
+
+// Canonical representation of an East-North-Up point
+func (enu *ENUPoint) String() string {
+	return fmt.Sprintf("E: %f N: %f U: %f", enu.East, enu.North, enu.Up)
+}
+
+// Canonical representation of a North-East-Down point
+func (ned *NEDPoint) String() string {
+	return fmt.Sprintf("N: %f E: %f D: %f", ned.North, ned.East, ned.Down)
+}
+
+// The same point in North-East-Down coordinates
+func (enu *ENUPoint) NED() *NEDPoint {
+	return &NEDPoint{North: enu.North, East: enu.East, Down: -enu.Up}
+}
+
+// The same point in East-North-Up coordinates
+func (ned *NEDPoint) ENU() *ENUPoint {
+	return &ENUPoint{East: ned.East, North: ned.North, Up: -ned.Down}
+}
+
+// The reference point on the DefaultEllipsoid if it has no reference ellipsoid
+func withEllipsoid(pc *PolarCoord) *PolarCoord {
+	if pc.El != nil {
+		return pc
+	}
+	out := *pc
+	out.El = DefaultEllipsoid
+	return &out
+}
+
+// The geocentric origin and the sine and cosine of latitude and longitude east of Greenwich of the
+// reference point
+func tangentPlane(ref *PolarCoord) (origin *CartPoint, sinlat, coslat, sinlong, coslong float64) {
+	origin = PolarToCartesian(withEllipsoid(ref))
+
+	sinlat, coslat = math.Sincos(degtorad(ref.Latitude))
+	sinlong, coslong = math.Sincos(degtorad(ref.Longitude + ref.PrimeMeridian().Longitude))
+	return
+}
+
+// Convert the geocentric cartesian point pt into East-North-Up coordinates of the tangent plane at the
+// reference point ref. pt must refer to the same datum as ref.
+func CartesianToENU(pt *CartPoint, ref *PolarCoord) *ENUPoint {
+
+	origin, sinlat, coslat, sinlong, coslong := tangentPlane(ref)
+	dx, dy, dz := pt.X-origin.X, pt.Y-origin.Y, pt.Z-origin.Z
+
+	return &ENUPoint{
+		East:  -sinlong*dx + coslong*dy,
+		North: -sinlat*coslong*dx - sinlat*sinlong*dy + coslat*dz,
+		Up:    coslat*coslong*dx + coslat*sinlong*dy + sinlat*dz,
+	}
+}
+
+// Convert East-North-Up coordinates of the tangent plane at the reference point ref into a geocentric
+// cartesian point. The reference ellipsoid of ref is copied to the result.
+func ENUToCartesian(enu *ENUPoint, ref *PolarCoord) *CartPoint {
+
+	origin, sinlat, coslat, sinlong, coslong := tangentPlane(ref)
+
+	return &CartPoint{
+		X:  origin.X - sinlong*enu.East - sinlat*coslong*enu.North + coslat*coslong*enu.Up,
+		Y:  origin.Y + coslong*enu.East - sinlat*sinlong*enu.North + coslat*sinlong*enu.Up,
+		Z:  origin.Z + coslat*enu.North + sinlat*enu.Up,
+		El: origin.El,
+	}
+}
+
+// Convert the geocentric cartesian point pt into North-East-Down coordinates of the tangent plane at the
+// reference point ref. pt must refer to the same datum as ref.
+func CartesianToNED(pt *CartPoint, ref *PolarCoord) *NEDPoint {
+	return CartesianToENU(pt, ref).NED()
+}
+
+// Convert North-East-Down coordinates of the tangent plane at the reference point ref into a geocentric
+// cartesian point. The reference ellipsoid of ref is copied to the result.
+func NEDToCartesian(ned *NEDPoint, ref *PolarCoord) *CartPoint {
+	return ENUToCartesian(ned.ENU(), ref)
+}
+
+// Convert the latitude, longitude and ellipsoidal height pc into East-North-Up coordinates of the tangent
+// plane at the reference point ref. Both coordinates must refer to the same datum.
+func PolarToENU(pc, ref *PolarCoord) *ENUPoint {
+	return CartesianToENU(PolarToCartesian(withEllipsoid(pc)), ref)
+}
+
+// Convert East-North-Up coordinates of the tangent plane at the reference point ref into latitude, longitude
+// and ellipsoidal height on the reference ellipsoid and datum of ref.
+func ENUToPolar(enu *ENUPoint, ref *PolarCoord) *PolarCoord {
+	pc := CartesianToPolar(ENUToCartesian(enu, ref))
+	pc.Longitude = ConvertPrimeMeridian(pc.Longitude, nil, ref.PrimeMeridian())
+	pc.Datum = ref.Datum
+	return pc
+}
+
+// ## Look angles
+
+// The direction and distance from an observer to a target. Azimuth is measured clockwise from north in the
+// range [0, 360), Elevation above the local horizon in the range [-90, 90], both in decimal degrees; Range is
+// the slant range in meters.
+type LookAngle struct {
+	Azimuth, Elevation, Range float64
+}
+
+// Canonical representation of look angles
+func (la *LookAngle) String() string {
+	return fmt.Sprintf("az: %f°, el: %f°, range: %fm", la.Azimuth, la.Elevation, la.Range)
+}
+
+// Returns the look angles of the East-North-Up point enu as seen from the origin of its tangent plane.
+// The azimuth of a point within a micrometer straight above or below the origin is zero.
+func (enu *ENUPoint) LookAngle() *LookAngle {
+	horizontal := math.Hypot(enu.East, enu.North)
+
+	la := &LookAngle{Range: math.Sqrt(horizontal*horizontal + enu.Up*enu.Up)}
+	if la.Range == 0 {
+		return la
+	}
+	if horizontal > 1e-6 {
+		la.Azimuth = normalizeAzimuth(radtodeg(math.Atan2(enu.East, enu.North)))
+	}
+	la.Elevation = radtodeg(math.Atan2(enu.Up, horizontal))
+	return la
+}
+
+// Returns the East-North-Up point in the direction and distance of the look angles
+func (la *LookAngle) ENU() *ENUPoint {
+	sinaz, cosaz := math.Sincos(degtorad(la.Azimuth))
+	sinel, cosel := math.Sincos(degtorad(la.Elevation))
+
+	return &ENUPoint{
+		East:  la.Range * cosel * sinaz,
+		North: la.Range * cosel * cosaz,
+		Up:    la.Range * sinel,
+	}
+}
+
+// Returns azimuth, elevation and slant range from the observer to the target, both given as latitude,
+// longitude and ellipsoidal height of the same datum. The elevation refers to the ellipsoidal horizon of the
+// observer; Atmospheric refraction is not taken into account.
+func LookAngles(observer, target *PolarCoord) *LookAngle {
+	return PolarToENU(target, observer).LookAngle()
+}
+
+// Returns the latitude, longitude and ellipsoidal height of the target seen from the observer at the look
+// angles la, on the reference ellipsoid of the observer
+func LookAngleToPolar(observer *PolarCoord, la *LookAngle) *PolarCoord {
+	return ENUToPolar(la.ENU(), observer)
+}
@@ -0,0 +1,156 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func enuequal(enu1, enu2 *ENUPoint) bool {
+	return fmt.Sprintf("%.4f %.4f %.4f", enu1.East, enu1.North, enu1.Up) == fmt.Sprintf("%.4f %.4f %.4f", enu2.East, enu2.North, enu2.Up)
+}
+
+// ## CartesianToENU, CartesianToNED
+type cartesianToENUTest struct {
+	in  *CartPoint
+	ref *PolarCoord
+	out *ENUPoint
+}
+
+var cartesianToENUTests = []cartesianToENUTest{
+	// on the equator at the prime meridian, the axes are parallel to Y, Z and X
+	{&CartPoint{X: 6378137 + 100, Y: 0, Z: 0, El: WGS84Ellipsoid}, &PolarCoord{El: WGS84Ellipsoid}, &ENUPoint{Up: 100}},
+	{&CartPoint{X: 6378137, Y: 50, Z: 20, El: WGS84Ellipsoid}, &PolarCoord{El: WGS84Ellipsoid}, &ENUPoint{East: 50, North: 20}},
+	// at the north pole, east points along Y and north along -X
+	{&CartPoint{X: -30, Y: 40, Z: 6356752.314245 + 10, El: WGS84Ellipsoid}, &PolarCoord{Latitude: 90, El: WGS84Ellipsoid}, &ENUPoint{East: 40, North: 30, Up: 10}},
+	// on the equator at 90°E, east points along -X
+	{&CartPoint{X: -25, Y: 6378137 - 5, Z: 0, El: WGS84Ellipsoid}, &PolarCoord{Longitude: 90, El: WGS84Ellipsoid}, &ENUPoint{East: 25, Up: -5}},
+}
+
+func TestCartesianToENU(t *testing.T) {
+	for cnt, test := range cartesianToENUTests {
+		out := CartesianToENU(test.in, test.ref)
+		if !enuequal(test.out, out) {
+			t.Errorf("CartesianToENU [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+
+		ned := CartesianToNED(test.in, test.ref)
+		if ned.North != out.North || ned.East != out.East || ned.Down != -out.Up {
+			t.Errorf("CartesianToNED [%d]: Expected %s, got %s", cnt, out.NED(), ned)
+		}
+	}
+}
+
+// ## ENUToCartesian, NEDToCartesian
+func TestENUToCartesian(t *testing.T) {
+	ref := &PolarCoord{Latitude: 48.2082, Longitude: 16.3738, Height: 171, El: WGS84Ellipsoid}
+
+	for cnt, in := range []*ENUPoint{
+		{East: 0, North: 0, Up: 0},
+		{East: 1234.5, North: -987.6, Up: 55.5},
+		{East: -250000, North: 120000, Up: -4000},
+	} {
+		pt := ENUToCartesian(in, ref)
+		if pt.El != ref.El {
+			t.Errorf("ENUToCartesian [%d]: Expected the ellipsoid of the reference point", cnt)
+		}
+		if out := CartesianToENU(pt, ref); !enuequal(in, out) {
+			t.Errorf("ENUToCartesian [%d]: Expected %s, got %s", cnt, in, out)
+		}
+		if out := CartesianToNED(NEDToCartesian(in.NED(), ref), ref).ENU(); !enuequal(in, out) {
+			t.Errorf("NEDToCartesian [%d]: Expected %s, got %s", cnt, in, out)
+		}
+	}
+
+	// the origin of the tangent plane is the reference point
+	if out := ENUToPolar(&ENUPoint{}, ref); !latlongequal(ref, out) || math.Abs(out.Height-ref.Height) > 1e-6 {
+		t.Errorf("ENUToPolar: Expected %s at %fm, got %s at %fm", ref, ref.Height, out, out.Height)
+	}
+}
+
+// ## LookAngles, LookAngleToPolar
+type lookAnglesTest struct {
+	observer, target *PolarCoord
+	out              *LookAngle
+}
+
+var lookAnglesTests = []lookAnglesTest{
+	// straight above the observer
+	{&PolarCoord{Latitude: 47.07, Longitude: 15.44, Height: 350, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: 47.07, Longitude: 15.44, Height: 470, El: WGS84Ellipsoid},
+		&LookAngle{Azimuth: 0, Elevation: 90, Range: 120}},
+	// straight below the observer
+	{&PolarCoord{Latitude: -33.9, Longitude: 151.2, Height: 100, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: -33.9, Longitude: 151.2, Height: 40, El: WGS84Ellipsoid},
+		&LookAngle{Azimuth: 0, Elevation: -90, Range: 60}},
+	// the observer itself
+	{&PolarCoord{Latitude: 10, Longitude: 20, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: 10, Longitude: 20, El: WGS84Ellipsoid},
+		&LookAngle{}},
+}
+
+func TestLookAngles(t *testing.T) {
+	for cnt, test := range lookAnglesTests {
+		out := LookAngles(test.observer, test.target)
+		if fmt.Sprintf("%.6f %.6f %.4f", out.Azimuth, out.Elevation, out.Range) != fmt.Sprintf("%.6f %.6f %.4f", test.out.Azimuth, test.out.Elevation, test.out.Range) {
+			t.Errorf("LookAngles [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+	}
+
+	observer := &PolarCoord{Latitude: 48.2082, Longitude: 16.3738, Height: 171, El: WGS84Ellipsoid}
+
+	// targets along the meridian and the parallel of the observer lie below its horizon
+	north := LookAngles(observer, &PolarCoord{Latitude: 48.3, Longitude: 16.3738, Height: 171, El: WGS84Ellipsoid})
+	if math.Abs(north.Azimuth) > 1e-9 || north.Elevation >= 0 {
+		t.Errorf("LookAngles: Expected a target to the north below the horizon, got %s", north)
+	}
+	south := LookAngles(observer, &PolarCoord{Latitude: 48.1, Longitude: 16.3738, Height: 171, El: WGS84Ellipsoid})
+	if math.Abs(south.Azimuth-180) > 1e-9 || south.Elevation >= 0 {
+		t.Errorf("LookAngles: Expected a target to the south below the horizon, got %s", south)
+	}
+	west := LookAngles(observer, &PolarCoord{Latitude: 48.2082, Longitude: 16.2, Height: 171, El: WGS84Ellipsoid})
+	if west.Azimuth < 269 || west.Azimuth > 271 {
+		t.Errorf("LookAngles: Expected a target to the west, got %s", west)
+	}
+
+	// for short distances on the ellipsoid, the slant range approaches the geodesic distance
+	target := &PolarCoord{Latitude: 48.2182, Longitude: 16.3938, Height: 171, El: WGS84Ellipsoid}
+	distance, azimuth, _ := GeodesicInverse(observer, target)
+	la := LookAngles(&PolarCoord{Latitude: observer.Latitude, Longitude: observer.Longitude, El: WGS84Ellipsoid},
+		&PolarCoord{Latitude: target.Latitude, Longitude: target.Longitude, El: WGS84Ellipsoid})
+	if math.Abs(la.Range-distance) > 0.01 || math.Abs(la.Azimuth-azimuth) > 0.01 {
+		t.Errorf("LookAngles: Expected about %f° at %fm, got %s", azimuth, distance, la)
+	}
+
+	for cnt, test := range []*PolarCoord{
+		target,
+		{Latitude: 47.5, Longitude: 13, Height: 35786000, El: WGS84Ellipsoid},
+		{Latitude: 48.2, Longitude: 16.37, Height: 120.5, El: WGS84Ellipsoid},
+	} {
+		out := LookAngleToPolar(observer, LookAngles(observer, test))
+		if !latlongmmequal(test, out) || math.Abs(out.Height-test.Height) > 0.001 {
+			t.Errorf("LookAngleToPolar [%d]: Expected %s at %fm, got %s at %fm", cnt, test, test.Height, out, out.Height)
+		}
+	}
+
+	// longitudes east of Ferro refer to the same points
+	ferro := NewDatum("WGS84 (Ferro)", WGS84Ellipsoid, FerroMeridian, nil)
+	toferro := func(pc *PolarCoord) *PolarCoord {
+		return &PolarCoord{Latitude: pc.Latitude, Longitude: ConvertPrimeMeridian(pc.Longitude, nil, FerroMeridian), Height: pc.Height, El: pc.El, Datum: ferro}
+	}
+	expected := LookAngles(observer, target)
+	for _, in := range [][2]*PolarCoord{{toferro(observer), toferro(target)}, {toferro(observer), target}, {observer, toferro(target)}} {
+		if out := LookAngles(in[0], in[1]); math.Abs(out.Range-expected.Range) > 0.001 || math.Abs(out.Azimuth-expected.Azimuth) > 1e-6 || math.Abs(out.Elevation-expected.Elevation) > 1e-6 {
+			t.Errorf("LookAngles: Expected %s from %s to %s, got %s", expected, in[0], in[1], out)
+		}
+	}
+
+	if out := LookAngleToPolar(toferro(observer), expected); !latlongmmequal(toferro(target), out) || out.Datum != ferro {
+		t.Errorf("LookAngleToPolar: Expected %s on %s, got %s on %v", toferro(target), ferro, out, out.Datum)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package lv03p
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ## FINELTRA
+
+// FINELTRA transforms between LV03 and LV95 by an affine transformation within each triangle of a
+// triangulation, whose vertices are known in both reference frames. The triangulation of swisstopo
+// (CHENyx06) covers Switzerland and Liechtenstein and defines the official transformation. This package does
+// not read the formats in which swisstopo distributes CHENyx06, but a plain text format of its own, see
+// LoadFineltra.
+
+// A vertex of the triangulation with its coordinates in LV03 and LV95
+type fineltraPoint struct {
+	lv03y, lv03x, lv95e, lv95n float64
+}
+
+// The coordinates of a vertex in one reference frame
+type fineltraFrame func(p *fineltraPoint) (x, y float64)
+
+func fineltraLV03(p *fineltraPoint) (x, y float64) { return p.lv03y, p.lv03x }
+func fineltraLV95(p *fineltraPoint) (x, y float64) { return p.lv95e, p.lv95n }
+
+// A triangle of the triangulation
+type fineltraTriangle struct {
+	p [3]*fineltraPoint
+}
+
+// A grid of square buckets over the triangulation in one reference frame. Each bucket lists the triangles
+// whose bounding box overlaps it, so a lookup tests a few triangles instead of all of them.
+type fineltraIndex struct {
+	minx, miny, size float64
+	cols, rows       int
+	buckets          [][]int
+}
+
+// Returns the index of the triangles in the reference frame frame, of about one bucket per triangle
+func newFineltraIndex(triangles []fineltraTriangle, frame fineltraFrame) *fineltraIndex {
+
+	fi := &fineltraIndex{}
+	if len(triangles) == 0 {
+		return fi
+	}
+
+	minx, miny, maxx, maxy := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, tr := range triangles {
+		for _, p := range tr.p {
+			x, y := frame(p)
+			minx, miny, maxx, maxy = math.Min(minx, x), math.Min(miny, y), math.Max(maxx, x), math.Max(maxy, y)
+		}
+	}
+
+	fi.minx, fi.miny = minx, miny
+	fi.size = math.Max(maxx-minx, maxy-miny) / math.Ceil(math.Sqrt(float64(len(triangles))))
+	if fi.size == 0 {
+		fi.size = 1
+	}
+	fi.cols, fi.rows = int((maxx-minx)/fi.size)+1, int((maxy-miny)/fi.size)+1
+	fi.buckets = make([][]int, fi.cols*fi.rows)
+
+	for i, tr := range triangles {
+		x0, y0 := frame(tr.p[0])
+		x1, y1 := frame(tr.p[1])
+		x2, y2 := frame(tr.p[2])
+		col0, row0 := fi.cell(math.Min(x0, math.Min(x1, x2)), math.Min(y0, math.Min(y1, y2)))
+		col1, row1 := fi.cell(math.Max(x0, math.Max(x1, x2)), math.Max(y0, math.Max(y1, y2)))
+		for row := row0; row <= row1; row++ {
+			for col := col0; col <= col1; col++ {
+				fi.buckets[row*fi.cols+col] = append(fi.buckets[row*fi.cols+col], i)
+			}
+		}
+	}
+	return fi
+}
+
+// The column and row of the bucket of x, y, which might lie outside the grid
+func (fi *fineltraIndex) cell(x, y float64) (col, row int) {
+	return int(math.Floor((x - fi.minx) / fi.size)), int(math.Floor((y - fi.miny) / fi.size))
+}
+
+// The triangles which might contain x, y
+func (fi *fineltraIndex) candidates(x, y float64) []int {
+	col, row := fi.cell(x, y)
+	if col < 0 || col >= fi.cols || row < 0 || row >= fi.rows {
+		return nil
+	}
+	return fi.buckets[row*fi.cols+col]
+}
+
+// A FINELTRA triangulation between LV03 and LV95. Instances are created by LoadFineltra.
+type Fineltra struct {
+	triangles  []fineltraTriangle
+	lv03, lv95 *fineltraIndex
+}
+
+// If set, SwissCoordToGRS80LatLong and GRS80LatLongToSwissCoord transform LV03 coordinates by this
+// triangulation into LV95 and vice-versa. If nil, or if a coordinate lies outside the triangulation,
+// the LV03 datum is approximated by the geocentric translation of CH1903+, which diverges by up to 1.5m.
+// SwissCoordToGRS80LatLongShift and GRS80LatLongToSwissCoordShift report which transformation has been used.
+var DefaultFineltra *Fineltra
+
+// Reads a FINELTRA triangulation from r. The triangulation is a plain text file with one entry per line;
+// blank lines and lines starting with '#' are ignored. Vertices are given by name and their LV03 y, x and
+// LV95 E, N coordinates, triangles by the names of their vertices:
+//
+//	point <name> <LV03 y> <LV03 x> <LV95 E> <LV95 N>
+//	triangle <name> <name> <name>
+//
+// To use CHENyx06, export its vertices with both coordinate pairs and its triangles by the names of their
+// vertices from the dataset of swisstopo, eg. by a GIS, and write them as point and triangle lines.
+// testdata/fineltra.txt is a synthetic example of the format, not an excerpt of CHENyx06.
+//
+// Returns a cartconvert.CartographyError with cartconvert.ErrSyntax as the error on malformed lines, the
+// line number as Index.
+func LoadFineltra(r io.Reader) (*Fineltra, error) {
+
+	points := make(map[string]*fineltraPoint)
+	ft := &Fineltra{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		synerr := cartconvert.CartographyError{Coord: text, Index: line, Err: cartconvert.ErrSyntax}
+
+		switch {
+		case strings.ToLower(fields[0]) == "point" && len(fields) == 6:
+			var values [4]float64
+			for i := range values {
+				val, err := strconv.ParseFloat(fields[i+2], 64)
+				if err != nil {
+					return nil, synerr
+				}
+				values[i] = val
+			}
+			points[fields[1]] = &fineltraPoint{lv03y: values[0], lv03x: values[1], lv95e: values[2], lv95n: values[3]}
+
+		case strings.ToLower(fields[0]) == "triangle" && len(fields) == 4:
+			var tr fineltraTriangle
+			for i := range tr.p {
+				p, ok := points[fields[i+1]]
+				if !ok {
+					return nil, synerr
+				}
+				tr.p[i] = p
+			}
+			ft.triangles = append(ft.triangles, tr)
+
+		default:
+			return nil, synerr
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	ft.lv03 = newFineltraIndex(ft.triangles, fineltraLV03)
+	ft.lv95 = newFineltraIndex(ft.triangles, fineltraLV95)
+	return ft, nil
+}
+
+// Reads a FINELTRA triangulation from the file name. See LoadFineltra for the file format.
+func LoadFineltraFile(name string) (*Fineltra, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadFineltra(f)
+}
+
+// Number of triangles of the triangulation
+func (ft *Fineltra) Len() int {
+	return len(ft.triangles)
+}
+
+// Canonical representation of a FINELTRA triangulation
+func (ft *Fineltra) String() string {
+	return fmt.Sprintf("FINELTRA(%d triangles)", len(ft.triangles))
+}
+
+// Barycentric coordinates of x, y in the triangle of vertices (x0, y0), (x1, y1), (x2, y2).
+// Returns false if the point lies outside the triangle.
+func barycentric(x, y, x0, y0, x1, y1, x2, y2 float64) (l0, l1, l2 float64, inside bool) {
+	det := (y1-y2)*(x0-x2) + (x2-x1)*(y0-y2)
+	if det == 0 {
+		return
+	}
+	l0 = ((y1-y2)*(x-x2) + (x2-x1)*(y-y2)) / det
+	l1 = ((y2-y0)*(x-x2) + (x0-x2)*(y-y2)) / det
+	l2 = 1 - l0 - l1
+
+	const eps = -1e-12
+	inside = l0 >= eps && l1 >= eps && l2 >= eps
+	return
+}
+
+// Transform x, y of the reference frame from into the reference frame to by the triangle of the index of
+// from, which contains x, y. Returns false if no triangle contains x, y.
+func (ft *Fineltra) transform(index *fineltraIndex, x, y float64, from, to fineltraFrame) (float64, float64, bool) {
+
+	for _, i := range index.candidates(x, y) {
+		p0, p1, p2 := ft.triangles[i].p[0], ft.triangles[i].p[1], ft.triangles[i].p[2]
+		x0, y0 := from(p0)
+		x1, y1 := from(p1)
+		x2, y2 := from(p2)
+		if l0, l1, l2, ok := barycentric(x, y, x0, y0, x1, y1, x2, y2); ok {
+			u0, v0 := to(p0)
+			u1, v1 := to(p1)
+			u2, v2 := to(p2)
+			return l0*u0 + l1*u1 + l2*u2, l0*v0 + l1*v1 + l2*v2, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Transform a LV03 coordinate into LV95. Returns cartconvert.ErrCoordType if the coordinate is not of
+// type LV03, cartconvert.ErrRange if it lies outside the triangulation.
+func (ft *Fineltra) LV03ToLV95(coord *SwissCoord) (*SwissCoord, error) {
+
+	if coord.CoordType != LV03 {
+		return nil, cartconvert.ErrCoordType
+	}
+
+	e, n, ok := ft.transform(ft.lv03, coord.Easting, coord.Northing, fineltraLV03, fineltraLV95)
+	if !ok {
+		return nil, cartconvert.ErrRange
+	}
+	return &SwissCoord{Easting: e, Northing: n, RelHeight: coord.RelHeight, CoordType: LV95, El: coord.El, Datum: cartconvert.CH1903PlusDatum}, nil
+}
+
+// Transform a LV95 coordinate into LV03. Returns cartconvert.ErrCoordType if the coordinate is not of
+// type LV95, cartconvert.ErrRange if it lies outside the triangulation.
+func (ft *Fineltra) LV95ToLV03(coord *SwissCoord) (*SwissCoord, error) {
+
+	if coord.CoordType != LV95 {
+		return nil, cartconvert.ErrCoordType
+	}
+
+	y, x, ok := ft.transform(ft.lv95, coord.Easting, coord.Northing, fineltraLV95, fineltraLV03)
+	if !ok {
+		return nil, cartconvert.ErrRange
+	}
+	return &SwissCoord{Easting: y, Northing: x, RelHeight: coord.RelHeight, CoordType: LV03, El: coord.El, Datum: cartconvert.CH1903Datum}, nil
+}
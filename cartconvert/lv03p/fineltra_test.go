@@ -0,0 +1,209 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert/lv03p package
+package lv03p
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"strings"
+	"testing"
+)
+
+// ## LoadFineltra
+func TestLoadFineltra(t *testing.T) {
+
+	ft, err := LoadFineltraFile("testdata/fineltra.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ft.Len() != 2 {
+		t.Errorf("LoadFineltraFile: Expected 2 triangles, got %d", ft.Len())
+	}
+
+	for _, in := range []string{
+		"point A 600000 200000 2600000",
+		"point A 600000 200000 2600000 1200000x",
+		"point A 600000 200000 2600000 1200000\ntriangle A B C",
+		"line A B",
+	} {
+		if _, err := LoadFineltra(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadFineltra: Expected an error for %q", in)
+		}
+	}
+}
+
+// ## LV03ToLV95, LV95ToLV03
+type fineltraTest struct {
+	lv03, lv95 *SwissCoord
+}
+
+var fineltraTests = []fineltraTest{
+	{NewSwissCoord(LV03, 700000, 200000, 0), NewSwissCoord(LV95, 2700000.52, 1200000.31, 0)},
+	{NewSwissCoord(LV03, 650000, 150000, 0), NewSwissCoord(LV95, 2650000.55, 1149999.7, 0)},
+	{NewSwissCoord(LV03, 690000, 120000, 0), NewSwissCoord(LV95, 2690000.932, 1119999.551, 0)},
+	{NewSwissCoord(LV03, 610000, 110000, 0), NewSwissCoord(LV95, 2609999.95, 1109999.86, 0)},
+}
+
+func swisscoordmmequal(c1, c2 *SwissCoord) bool {
+	return c1.CoordType == c2.CoordType && fmt.Sprintf("%.3f %.3f", c1.Easting, c1.Northing) == fmt.Sprintf("%.3f %.3f", c2.Easting, c2.Northing)
+}
+
+func TestFineltra(t *testing.T) {
+
+	ft, err := LoadFineltraFile("testdata/fineltra.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cnt, test := range fineltraTests {
+		out, err := ft.LV03ToLV95(test.lv03)
+		if err != nil {
+			t.Error(err)
+		} else if !swisscoordmmequal(test.lv95, out) {
+			t.Errorf("LV03ToLV95 [%d]: Expected %s, got %s", cnt, test.lv95, out)
+		}
+
+		out, err = ft.LV95ToLV03(test.lv95)
+		if err != nil {
+			t.Error(err)
+		} else if !swisscoordmmequal(test.lv03, out) {
+			t.Errorf("LV95ToLV03 [%d]: Expected %s, got %s", cnt, test.lv03, out)
+		}
+	}
+
+	if _, err := ft.LV03ToLV95(NewSwissCoord(LV03, 750000, 150000, 0)); err != cartconvert.ErrRange {
+		t.Errorf("LV03ToLV95: Expected %s outside the triangulation, got %v", cartconvert.ErrRange, err)
+	}
+
+	if _, err := ft.LV03ToLV95(NewSwissCoord(LV95, 2650000, 1150000, 0)); err != cartconvert.ErrCoordType {
+		t.Errorf("LV03ToLV95: Expected %s for a LV95 coordinate, got %v", cartconvert.ErrCoordType, err)
+	}
+}
+
+// ## Spatial index
+// A triangulation of 40 x 30 squares of 5km, each split into two triangles, of an affine transformation
+func TestFineltraIndex(t *testing.T) {
+
+	lv95 := func(y, x float64) (float64, float64) { return 2000000 + y*(1+2e-6) + 1e-6*x, 1000000 + x*(1-1e-6) }
+
+	var def strings.Builder
+	for i := 0; i <= 40; i++ {
+		for j := 0; j <= 30; j++ {
+			y, x := 480000+5000*float64(i), 70000+5000*float64(j)
+			e, n := lv95(y, x)
+			fmt.Fprintf(&def, "point %d_%d %.0f %.0f %.6f %.6f\n", i, j, y, x, e, n)
+		}
+	}
+	for i := 0; i < 40; i++ {
+		for j := 0; j < 30; j++ {
+			fmt.Fprintf(&def, "triangle %d_%d %d_%d %d_%d\n", i, j, i+1, j, i+1, j+1)
+			fmt.Fprintf(&def, "triangle %d_%d %d_%d %d_%d\n", i, j, i+1, j+1, i, j+1)
+		}
+	}
+
+	ft, err := LoadFineltra(strings.NewReader(def.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a lookup tests a few triangles only
+	for _, fi := range []*fineltraIndex{ft.lv03, ft.lv95} {
+		for _, bucket := range fi.buckets {
+			if len(bucket) > 8 {
+				t.Fatalf("fineltraIndex: Expected at most 8 triangles per bucket, got %d", len(bucket))
+			}
+		}
+	}
+
+	// inside the squares, on their edges and on the vertices
+	for _, in := range [][2]float64{{480000, 70000}, {680000, 220000}, {612345.6, 123456.7}, {502500, 150000}, {575000, 97500}, {679999.9, 70000.1}} {
+		e, n := lv95(in[0], in[1])
+		expected := NewSwissCoord(LV95, e, n, 0)
+		out, err := ft.LV03ToLV95(NewSwissCoord(LV03, in[0], in[1], 0))
+		if err != nil || math.Hypot(expected.Easting-out.Easting, expected.Northing-out.Northing) > 1e-5 {
+			t.Errorf("LV03ToLV95: Expected %s, got %v (%v)", expected, out, err)
+			continue
+		}
+		if back, err := ft.LV95ToLV03(out); err != nil || math.Hypot(in[0]-back.Easting, in[1]-back.Northing) > 1e-5 {
+			t.Errorf("LV95ToLV03: Expected %.1f %.1f, got %v (%v)", in[0], in[1], back, err)
+		}
+	}
+
+	for _, in := range [][2]float64{{479999, 100000}, {680001, 100000}, {600000, 69999}, {600000, 220001}} {
+		if _, err := ft.LV03ToLV95(NewSwissCoord(LV03, in[0], in[1], 0)); err != cartconvert.ErrRange {
+			t.Errorf("LV03ToLV95: Expected %s outside the triangulation at %.0f %.0f, got %v", cartconvert.ErrRange, in[0], in[1], err)
+		}
+	}
+}
+
+// ## DefaultFineltra
+func TestDefaultFineltra(t *testing.T) {
+
+	ft, err := LoadFineltraFile("testdata/fineltra.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	DefaultFineltra = ft
+	defer func() { DefaultFineltra = nil }()
+
+	// the vertices on the border of the triangulation do not round trip
+	for cnt, test := range fineltraTests[1:] {
+		gc03, err := SwissCoordToGRS80LatLong(test.lv03)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		gc95, err := SwissCoordToGRS80LatLong(test.lv95)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !latlongcmequal(gc95, gc03) {
+			t.Errorf("SwissCoordToGRS80LatLong [%d]: Expected %s, got %s", cnt, gc95, gc03)
+		}
+
+		out, err := GRS80LatLongToSwissCoord(gc03, LV03)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !swisscoordmmequal(test.lv03, out) {
+			t.Errorf("GRS80LatLongToSwissCoord [%d]: Expected %s, got %s", cnt, test.lv03, out)
+		}
+	}
+
+	// the transformation used within and outside the triangulation, LV95 is exact
+	inside, outside := NewSwissCoord(LV03, 650000, 150000, 0), NewSwissCoord(LV03, 750000, 250000, 0)
+	for _, test := range []struct {
+		coord  *SwissCoord
+		method cartconvert.ShiftMethod
+	}{
+		{inside, cartconvert.ShiftGrid},
+		{outside, cartconvert.ShiftFallback},
+		{NewSwissCoord(LV95, 2750000, 1250000, 0), cartconvert.ShiftGrid},
+	} {
+		gc, method, err := SwissCoordToGRS80LatLongShift(test.coord)
+		if err != nil || method != test.method {
+			t.Errorf("SwissCoordToGRS80LatLongShift %s: Expected %s, got %s (%v)", test.coord, test.method, method, err)
+			continue
+		}
+
+		out, method, err := GRS80LatLongToSwissCoordShift(gc, test.coord.CoordType)
+		if err != nil || method != test.method || !swisscoordmmequal(test.coord, out) || out.Datum != test.coord.Datum {
+			t.Errorf("GRS80LatLongToSwissCoordShift %s: Expected %s on %s by %s, got %v by %s (%v)", gc, test.coord, test.coord.Datum, test.method, out, method, err)
+		}
+	}
+
+	DefaultFineltra = nil
+	if _, method, err := SwissCoordToGRS80LatLongShift(inside); err != nil || method != cartconvert.ShiftFallback {
+		t.Errorf("SwissCoordToGRS80LatLongShift: Expected %s without a triangulation, got %s (%v)", cartconvert.ShiftFallback, method, err)
+	}
+}
@@ -5,9 +5,11 @@
 // This package provides a series of functions to deal with
 // conversion and transformations of coordinates in the Swiss coordinate system.
 //
-// The Swiss coordinate system recently switched from lv03 to lv95, however the difference is
-// generall below one meter and ignored by this package. For accuracy within 1cm the
-//  FINELTRA-Transformation has to be applied.
+// The Swiss coordinate system recently switched from lv03 to lv95. LV95 coordinates are projected
+// from the CH1903+ datum, which is defined by a geocentric translation from CHTRS95 (compatible
+// with GRS80/WGS84 to better than one meter) and thus converted exactly. LV03 coordinates are projected
+// from the CH1903 datum, which diverges from CH1903+ by up to 1.5m. For accuracy within 1cm the
+// FINELTRA-Transformation between LV03 and LV95 has to be applied by setting DefaultFineltra.
 //
 // References:
 //
@@ -21,7 +23,7 @@ import (
 	"strings"
 )
 
-// Coordinate type of Switzerland. Only affects string representation but not accuracy (The two systems diverge by about 1m)
+// Coordinate type of Switzerland: LV03 on the CH1903 datum or LV95 on the CH1903+ datum
 type SwissCoordType byte
 
 const (
@@ -29,15 +31,43 @@ const (
 	LV95
 )
 
-// A coordinate in Switzerland is specified by easting (right-value, x), and northing (height-value, y)
+// A coordinate in Switzerland is specified by easting (right-value, x), and northing (height-value, y).
+// RelHeight is the ellipsoidal height above the Bessel ellipsoid of the CH1903 / CH1903+ datum.
 type SwissCoord struct {
 	Easting, Northing, RelHeight float64
 	CoordType                    SwissCoordType
 	El                           *cartconvert.Ellipsoid
+	Datum                        *cartconvert.Datum
 }
 
 var coordliterals = [][]string{{"y:", " x:"}, {"E:", " N:"}}
 
+// Origin of the Swiss oblique mercator projection, the old observatory of Bern
+const (
+	swissLatO  = 46 + 57.0/60 + 8.66/3600
+	swissLongO = 7 + 26.0/60 + 22.5/3600
+)
+
+// False easting and northing of a Swiss coordinate type. Returns cartconvert.ErrRange if
+// the coordinate type is not one of LV03 or LV95
+func swissFalseOrigin(coordType SwissCoordType) (fe, fn float64, err error) {
+	switch coordType {
+	case LV03:
+		return 600000, 200000, nil
+	case LV95:
+		return 2600000, 1200000, nil
+	}
+	return 0, 0, cartconvert.ErrRange
+}
+
+// The datum of a Swiss coordinate type: CH1903 for LV03, otherwise CH1903+
+func swissDatum(coordType SwissCoordType) *cartconvert.Datum {
+	if coordType == LV03 {
+		return cartconvert.CH1903Datum
+	}
+	return cartconvert.CH1903PlusDatum
+}
+
 // Canonical representation of a SwissCoord-value
 func (bc *SwissCoord) String() (fs string) {
 
@@ -123,7 +153,7 @@ L1:
 
 			height, err = strconv.ParseFloat(heights, 64)
 			if err == nil {
-				return &SwissCoord{Easting: right, Northing: height, CoordType: coordType, El: cartconvert.Bessel1841Ellipsoid}, nil
+				return &SwissCoord{Easting: right, Northing: height, CoordType: coordType, El: cartconvert.Bessel1841Ellipsoid, Datum: swissDatum(coordType)}, nil
 			}
 		}
 	}
@@ -131,83 +161,185 @@ L1:
 	return nil, err
 }
 
-// Transform a Swiss coordinate value to a GRS80 based latitude and longitude coordinate. Function returns
+// Transform a Swiss coordinate value to a GRS80 based latitude and longitude coordinate. LV03 coordinates
+// are transformed into LV95 by DefaultFineltra, if set. Function returns
 // cartconvert.ErrRange, if the swiss coordinate type is not one of LV03 or LV95
 func SwissCoordToGRS80LatLong(coord *SwissCoord) (*cartconvert.PolarCoord, error) {
+	pc, _, err := SwissCoordToGRS80LatLongShift(coord)
+	return pc, err
+}
 
-	var fn, fe float64
+// Like SwissCoordToGRS80LatLong, but additionally reports whether a LV03 coordinate has been transformed by
+// DefaultFineltra or by the fallback geocentric translation of CH1903, which diverges by up to
+// 1.5m. LV95 coordinates are transformed exactly and reported as cartconvert.ShiftGrid.
+func SwissCoordToGRS80LatLongShift(coord *SwissCoord) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, error) {
+
+	method := cartconvert.ShiftGrid
+	if coord.CoordType == LV03 {
+		method = cartconvert.ShiftFallback
+		if DefaultFineltra != nil {
+			if lv95, err := DefaultFineltra.LV03ToLV95(coord); err == nil {
+				coord, method = lv95, cartconvert.ShiftGrid
+			}
+		}
+	}
 
-	switch coord.CoordType {
-	case LV03:
-		fe = 600000
-		fn = 200000
-	case LV95:
-		fe = -2600000
-		fn = -1200000
-	default:
-		return nil, cartconvert.ErrRange
+	fe, fn, err := swissFalseOrigin(coord.CoordType)
+	if err != nil {
+		return nil, method, err
 	}
 
-	gc := cartconvert.InverseTransverseMercator(
-		&cartconvert.GeoPoint{Y: coord.Northing, X: coord.Easting, El: coord.El},
-		46.952406, // lat0
-		7.439583,  // long0
+	gc := cartconvert.InverseSwissObliqueMercator(
+		&cartconvert.GeoPoint{Y: coord.Northing, X: coord.Easting, H: coord.RelHeight, El: coord.El},
+		swissLatO,
+		swissLongO,
 		1,
 		fe, // fe
 		fn) // fn
 
-	cart := cartconvert.PolarToCartesian(gc)
 	// According to literature, the Granit87 parameters shall not be used in favour of
-	// higher accuracy of the following shift values
-
-	// pt := cartconvert.HelmertLV03ToWGS84Granit87.Transform(&cartconvert.Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
-	pt := &cartconvert.Point3D{X: cart.X + 674.374, Y: cart.Y + 15.056, Z: cart.Z + 405.346}
-
-	return cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.GRS80Ellipsoid}), nil
+	// higher accuracy of the geocentric translation, which defines CH1903+
+	pc, err := cartconvert.TransformDatum(gc, swissDatum(coord.CoordType), cartconvert.ETRS89Datum)
+	return pc, method, err
 }
 
-// Transform a latitude / longitude coordinate datum into a Swiss coordinate. Function returns
+// Transform a latitude / longitude coordinate datum into a Swiss coordinate. LV03 coordinates are
+// transformed from LV95 by DefaultFineltra, if set. Function returns
 // cartconvert.ErrRange, if the coordinate type is not set.
 //
 // Important: The reference ellipsoid of the originating coordinate system will be assumed
-// to be the GRS80Ellipsoid and will be set thereupon, regardless of the actually set reference ellipsoid.
+// to be the GRS80Ellipsoid, regardless of the actually set reference ellipsoid.
 func GRS80LatLongToSwissCoord(gc *cartconvert.PolarCoord, coordType SwissCoordType) (*SwissCoord, error) {
+	coord, _, err := GRS80LatLongToSwissCoordShift(gc, coordType)
+	return coord, err
+}
+
+// Like GRS80LatLongToSwissCoord, but additionally reports whether a LV03 coordinate has been transformed by
+// DefaultFineltra or by the fallback geocentric translation of CH1903, which diverges by up to
+// 1.5m. LV95 coordinates are transformed exactly and reported as cartconvert.ShiftGrid.
+func GRS80LatLongToSwissCoordShift(gcin *cartconvert.PolarCoord, coordType SwissCoordType) (*SwissCoord, cartconvert.ShiftMethod, error) {
 
-	var fn, fe float64
+	fe, fn, err := swissFalseOrigin(coordType)
+	if err != nil {
+		return nil, cartconvert.ShiftFallback, err
+	}
 
-	// This sets the Ellipsoid to GRS80, regardless of the actual value set
+	// This sets the Ellipsoid to GRS80 on a copy, regardless of the actual value set
+	gc := *gcin
 	gc.El = cartconvert.GRS80Ellipsoid
 
-	cart := cartconvert.PolarToCartesian(gc)
 	// According to literature, the Granit87 parameters shall not be used in favour of
-	// higher accuracy of the following shift values
+	// higher accuracy of the geocentric translation, which defines CH1903+
+	if coordType == LV03 && DefaultFineltra != nil {
+		polar, err := cartconvert.TransformDatum(&gc, cartconvert.ETRS89Datum, cartconvert.CH1903PlusDatum)
+		if err != nil {
+			return nil, cartconvert.ShiftFallback, err
+		}
+		gp := cartconvert.DirectSwissObliqueMercator(polar, swissLatO, swissLongO, 1, 2600000, 1200000)
+		if lv03, err := DefaultFineltra.LV95ToLV03(&SwissCoord{CoordType: LV95, Northing: gp.Y, Easting: gp.X, RelHeight: gp.H, El: gp.El, Datum: cartconvert.CH1903PlusDatum}); err == nil {
+			return lv03, cartconvert.ShiftGrid, nil
+		}
+	}
 
-	// pt := cartconvert.HelmertWGS84ToMGI.Transform(&cartconvert.Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
-	pt := &cartconvert.Point3D{X: cart.X - 674.374, Y: cart.Y - 15.056, Z: cart.Z - 405.346}
-	polar := cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.Bessel1841Ellipsoid})
+	method := cartconvert.ShiftGrid
+	if coordType == LV03 {
+		method = cartconvert.ShiftFallback
+	}
 
-	switch coordType {
-	case LV03:
-		fe = 600000
-		fn = 200000
-	case LV95:
-		fe = -2600000
-		fn = -1200000
-	default:
-		return nil, cartconvert.ErrRange
+	polar, err := cartconvert.TransformDatum(&gc, cartconvert.ETRS89Datum, swissDatum(coordType))
+	if err != nil {
+		return nil, method, err
 	}
 
-	gp := cartconvert.DirectTransverseMercator(
+	gp := cartconvert.DirectSwissObliqueMercator(
 		polar,
-		46.952406, // lat0
-		7.439583,  // long0
+		swissLatO,
+		swissLongO,
 		1,
 		fe, // fe
 		fn) // fn
 
-	return &SwissCoord{CoordType: coordType, Northing: gp.Y, Easting: gp.X, El: gp.El}, nil
+	return &SwissCoord{CoordType: coordType, Northing: gp.Y, Easting: gp.X, RelHeight: gp.H, El: gp.El, Datum: swissDatum(coordType)}, method, nil
+}
+
+// The coordinate reference system of the coordinate type on its datum. Returns cartconvert.ErrRange
+// if the coordinate type is not one of LV03 or LV95.
+func (ct SwissCoordType) CRS() (*cartconvert.CRS, error) {
+	fe, fn, err := swissFalseOrigin(ct)
+	if err != nil {
+		return nil, err
+	}
+	name := "CH1903 / LV03"
+	if ct == LV95 {
+		name = "CH1903+ / LV95"
+	}
+	return &cartconvert.CRS{
+		Name:       name,
+		Datum:      swissDatum(ct),
+		Projection: &cartconvert.Projection{Method: cartconvert.SwissObliqueMercator, LatO: swissLatO, LongO: swissLongO, Scale: 1, FE: fe, FN: fn},
+	}, nil
 }
 
 func NewSwissCoord(CoordType SwissCoordType, Easting, Northing, RelHeight float64) *SwissCoord {
-	return &SwissCoord{Easting: Easting, Northing: Northing, RelHeight: RelHeight, CoordType: CoordType, El: cartconvert.Bessel1841Ellipsoid}
+	return &SwissCoord{Easting: Easting, Northing: Northing, RelHeight: RelHeight, CoordType: CoordType, El: cartconvert.Bessel1841Ellipsoid, Datum: swissDatum(CoordType)}
+}
+
+// The Swiss coordinate system as a cartconvert.CoordinateSystem. Both LV03 and LV95 coordinates
+// are parsed, conversions from latitude and longitude result in coordType.
+type swissSystem struct {
+	coordType SwissCoordType
+}
+
+func (ss swissSystem) Name() string {
+	if ss.coordType == LV95 {
+		return "lv95"
+	}
+	return "lv03"
+}
+
+func (ss swissSystem) Description() string {
+	if ss.coordType == LV95 {
+		return "CH:LV95"
+	}
+	return "CH:LV03"
+}
+
+func (swissSystem) Parse(coord string) (fmt.Stringer, error) {
+	return ASwissCoordToStruct(coord)
+}
+
+func (swissSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*SwissCoord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (swissSystem) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	swisscoord, ok := coord.(*SwissCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return SwissCoordToGRS80LatLong(swisscoord)
+}
+
+func (ss swissSystem) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return GRS80LatLongToSwissCoord(pc, ss.coordType)
+}
+
+// The coordinate reference system of the coordinate type of coord, or of the coordinate system if nil
+func (ss swissSystem) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	if coord == nil {
+		return ss.coordType.CRS()
+	}
+	swisscoord, ok := coord.(*SwissCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return swisscoord.CoordType.CRS()
+}
+
+func init() {
+	cartconvert.RegisterCoordinateSystem(swissSystem{coordType: LV03})
+	cartconvert.RegisterCoordinateSystem(swissSystem{coordType: LV95})
 }
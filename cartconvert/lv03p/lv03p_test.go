@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"github.com/the42/cartconvert/cartconvert"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -96,6 +97,10 @@ var swissCoordToGRS80LatLongTests = []swissCoordToGRS80LatLongTest{
 		&SwissCoord{Easting: 750536, Northing: 265013, CoordType: LV03, El: cartconvert.Bessel1841Ellipsoid},
 		&cartconvert.PolarCoord{Latitude: 47.518605, Longitude: 9.437422},
 	},
+	{
+		&SwissCoord{Easting: 700000, Northing: 100000, CoordType: LV03, El: cartconvert.Bessel1841Ellipsoid},
+		&cartconvert.PolarCoord{Latitude: 46 + 2.0/60 + 38.87/3600, Longitude: 8 + 43.0/60 + 49.79/3600},
+	},
 }
 
 func latlongequal(pcp1, pcp2 *cartconvert.PolarCoord) bool {
@@ -134,17 +139,161 @@ var gRS80LatLongToSwissCoordTests = []gRS80LatLongToSwissCoordTest{
 			coordType: LV03},
 		NewSwissCoord(LV03, 750536, 265013, 0),
 	},
+	{
+		// swisstopo: Näherungslösungen für die direkte Transformation CH1903 <=> WGS84
+		gRS80LatLongToSwissCoordParam{
+			gc:        &cartconvert.PolarCoord{Latitude: 46 + 2.0/60 + 38.87/3600, Longitude: 8 + 43.0/60 + 49.79/3600, El: cartconvert.GRS80Ellipsoid},
+			coordType: LV03},
+		NewSwissCoord(LV03, 700000, 100000, 0),
+	},
 }
 
 func swisscoordfuzzyequal(c1, c2 *SwissCoord) bool {
-	return math.Sqrt(math.Pow(c1.Easting-c2.Easting, 2)+math.Pow(c1.Northing-c2.Northing, 2)) < 2.0
+	return math.Sqrt(math.Pow(c1.Easting-c2.Easting, 2)+math.Pow(c1.Northing-c2.Northing, 2)) < 0.5
 }
 
 func TestGRS80LatLongToSwissCoord(t *testing.T) {
 	for cnt, test := range gRS80LatLongToSwissCoordTests {
 		out, _ := GRS80LatLongToSwissCoord(test.in.gc, test.in.coordType)
-		if swisscoordfuzzyequal(test.out, out) {
+		if !swisscoordfuzzyequal(test.out, out) {
 			t.Errorf("GRS80LatLongToSwissCoord [%d]: Expected %s, got %s", cnt, test.out, out)
 		}
 	}
+
+	// the ellipsoid of the input is left alone
+	in := &cartconvert.PolarCoord{Latitude: 47.518605, Longitude: 9.437422, El: cartconvert.WGS84Ellipsoid}
+	if _, err := GRS80LatLongToSwissCoord(in, LV03); err != nil || in.El != cartconvert.WGS84Ellipsoid {
+		t.Errorf("GRS80LatLongToSwissCoord: Expected the input on %v, got %v (%v)", cartconvert.WGS84Ellipsoid, in.El, err)
+	}
+}
+
+// ## LV95
+type lv95Test struct {
+	lv95 *SwissCoord
+	gc   *cartconvert.PolarCoord
+}
+
+// CH1903+ is defined by a geocentric translation of CHTRS95, hence conversions are exact
+var lv95Tests = []lv95Test{
+	{NewSwissCoord(LV95, 2600000, 1200000, 0), &cartconvert.PolarCoord{Latitude: 46.951082773, Longitude: 7.438632421}},
+	{NewSwissCoord(LV95, 2700000, 1100000, 0), &cartconvert.PolarCoord{Latitude: 46.044130244, Longitude: 8.730496987}},
+	{NewSwissCoord(LV95, 2683000, 1248000, 0), &cartconvert.PolarCoord{Latitude: 47.377607217, Longitude: 8.537690296}},
+}
+
+// equal within about one centimeter
+func latlongcmequal(pcp1, pcp2 *cartconvert.PolarCoord) bool {
+	pp1s := fmt.Sprintf("%.7f %.7f", pcp1.Latitude, pcp1.Longitude)
+	pp2s := fmt.Sprintf("%.7f %.7f", pcp2.Latitude, pcp2.Longitude)
+
+	return pp1s == pp2s
+}
+
+func TestLV95(t *testing.T) {
+	for cnt, test := range lv95Tests {
+		gc, err := SwissCoordToGRS80LatLong(test.lv95)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !latlongcmequal(test.gc, gc) {
+			t.Errorf("SwissCoordToGRS80LatLong [%d]: Expected %s, got %s", cnt, test.gc, gc)
+		}
+
+		out, err := GRS80LatLongToSwissCoord(&cartconvert.PolarCoord{Latitude: test.gc.Latitude, Longitude: test.gc.Longitude}, LV95)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if math.Hypot(test.lv95.Easting-out.Easting, test.lv95.Northing-out.Northing) > 0.01 {
+			t.Errorf("GRS80LatLongToSwissCoord [%d]: Expected %s, got %s", cnt, test.lv95, out)
+		}
+	}
+
+	if _, err := SwissCoordToGRS80LatLong(NewSwissCoord(SwissCoordType(2), 600000, 200000, 0)); err != cartconvert.ErrRange {
+		t.Errorf("SwissCoordToGRS80LatLong: Expected %s, got %v", cartconvert.ErrRange, err)
+	}
+}
+
+// ## 3D round trip
+func TestSwissCoordHeight(t *testing.T) {
+
+	for _, coordType := range []SwissCoordType{LV03, LV95} {
+		in := &cartconvert.PolarCoord{Latitude: 46.951082, Longitude: 7.438637, Height: 600.4, El: cartconvert.GRS80Ellipsoid}
+
+		coord, err := GRS80LatLongToSwissCoord(in, coordType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the height above the Bessel ellipsoid differs by the datum shift
+		if math.Abs(coord.RelHeight-in.Height) < 1 {
+			t.Errorf("GRS80LatLongToSwissCoord %s: Expected the height to be shifted, got %f", coord, coord.RelHeight)
+		}
+
+		out, err := SwissCoordToGRS80LatLong(coord)
+		if err != nil || !latlongcmequal(in, out) || math.Abs(out.Height-in.Height) > 0.001 {
+			t.Errorf("SwissCoordToGRS80LatLong %s: Expected %s at %fm, got %s at %fm (%v)", coord, in, in.Height, out, out.Height, err)
+		}
+	}
+
+	// swisstopo: Näherungslösungen für die direkte Transformation CH1903 <=> WGS84, whose example point
+	// 700000 100000 at 600m above the Bessel ellipsoid lies 650.60m above the WGS84 ellipsoid
+	example := swissCoordToGRS80LatLongTests[1].out
+	gc, err := SwissCoordToGRS80LatLong(NewSwissCoord(LV03, 700000, 100000, 600))
+	if err != nil || fmt.Sprintf("%.2f", gc.Height) != "650.60" {
+		t.Errorf("SwissCoordToGRS80LatLong: Expected 650.60m, got %v (%v)", gc, err)
+	}
+
+	coord, err := GRS80LatLongToSwissCoord(&cartconvert.PolarCoord{Latitude: example.Latitude, Longitude: example.Longitude, Height: 650.60}, LV03)
+	if err != nil || fmt.Sprintf("%.2f", coord.RelHeight) != "600.00" {
+		t.Errorf("GRS80LatLongToSwissCoord: Expected 600.00m, got %v (%v)", coord, err)
+	}
+}
+
+// ## Coordinate reference system
+func TestSwissCoordCRS(t *testing.T) {
+	crs, err := LV95.CRS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cnt, test := range lv95Tests {
+		out, err := crs.ToWGS84(&cartconvert.GeoPoint{X: test.lv95.Easting, Y: test.lv95.Northing})
+		if err != nil || !latlongcmequal(test.gc, out) {
+			t.Errorf("CRS.ToWGS84 [%d]: Expected %s, got %v (%v)", cnt, test.gc, out, err)
+		}
+	}
+
+	for _, coordType := range []SwissCoordType{LV03, LV95} {
+		crs, _ := coordType.CRS()
+		wkt, err := crs.WKT2()
+		if err != nil || !strings.Contains(wkt, `PROJCRS["`+crs.Name+`"`) || !strings.Contains(wkt, `METHOD["Hotine Oblique Mercator (variant B)",ID["EPSG",9815]]`) {
+			t.Errorf("CRS.WKT2: Expected %s, got %s (%v)", crs, wkt, err)
+		}
+	}
+
+	// LV03 is based on CH1903 (EPSG:4149), LV95 on CH1903+ (EPSG:4150)
+	for _, test := range []struct {
+		coordType SwissCoordType
+		prefix    string
+	}{
+		{LV03, `PROJCS["CH1903 / LV03",GEOGCS["CH1903",DATUM["CH1903",`},
+		{LV95, `PROJCS["CH1903+ / LV95",GEOGCS["CH1903+",DATUM["CH1903+",`},
+	} {
+		crs, _ := test.coordType.CRS()
+		wkt, err := crs.WKT()
+		if err != nil || !strings.HasPrefix(wkt, test.prefix) {
+			t.Errorf("CRS.WKT: Expected %s, got %s (%v)", test.prefix, wkt, err)
+			continue
+		}
+		if parsed, err := cartconvert.ParseWKT(wkt); err != nil || parsed.Datum != crs.Datum {
+			t.Errorf("ParseWKT: Expected the datum %s, got %v (%v)", crs.Datum, parsed, err)
+		}
+	}
+
+	if _, err := SwissCoordType(2).CRS(); err != cartconvert.ErrRange {
+		t.Errorf("CRS: Expected %s, got %v", cartconvert.ErrRange, err)
+	}
 }
@@ -0,0 +1,203 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ## Maidenhead Locator System
+
+// The Maidenhead locator divides the world into fields of 20° longitude by 10° latitude,
+// each field into squares of 2° by 1°, each square into subsquares of 5' by 2.5' and
+// each subsquare into extended squares of 30'' by 15''. Fields and subsquares are denoted by
+// letters, squares and extended squares by digits, longitude first. Described in
+// http://en.wikipedia.org/wiki/Maidenhead_Locator_System
+
+// The number of divisions of each pair of a locator and whether it is denoted by letters
+var maidenheadPairs = [...]struct {
+	divisions int
+	letter    bool
+}{
+	{18, true},
+	{10, false},
+	{24, true},
+	{10, false},
+	{24, true},
+}
+
+// Length of a Maidenhead locator of the registered Maidenhead coordinate system when converting latitude
+// and longitude. A length of 6 denotes a subsquare.
+const DefaultMaidenheadLength = 6
+
+// Return the Maidenhead locator of length length of a latitude & longitude bearing point. Fields are
+// written as upper case, subsquares as lower case letters.
+//
+// Returns ErrRange if length is not one of 2, 4, 6, 8 or 10 or the latitude is outside ±90°
+func LatLongToMaidenhead(pc *PolarCoord, length int) (string, error) {
+
+	if length < 2 || length > 2*len(maidenheadPairs) || length%2 == 1 || math.Abs(pc.Latitude) > 90 {
+		return "", ErrRange
+	}
+
+	// number of cells of the requested precision along a parallel
+	cells := 1
+	for i := 0; i < length/2; i++ {
+		cells *= maidenheadPairs[i].divisions
+	}
+
+	// index of the cell counting from the antimeridian and the south pole; the north pole belongs to the last cell
+	long := int(math.Floor((geoHashLongitude(pc.Longitude) + 180) * float64(cells) / 360))
+	lat := int(math.Floor((pc.Latitude + 90) * float64(cells) / 180))
+	if lat == cells {
+		lat--
+	}
+
+	locator := make([]byte, length)
+	for i := length/2 - 1; i >= 0; i-- {
+		pair := maidenheadPairs[i]
+
+		longdigit, latdigit := long%pair.divisions, lat%pair.divisions
+		long /= pair.divisions
+		lat /= pair.divisions
+
+		base := byte('0')
+		if pair.letter {
+			base = 'A'
+			if i > 0 {
+				base = 'a'
+			}
+		}
+		locator[2*i] = base + byte(longdigit)
+		locator[2*i+1] = base + byte(latdigit)
+	}
+	return string(locator), nil
+}
+
+// Return the area of a Maidenhead locator as a bounding box. Letters are case insensitive.
+// If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+//
+// returns ErrSyntax if locator is not a Maidenhead locator of length 2, 4, 6, 8 or 10
+func MaidenheadToBoundingBox(locator string, el *Ellipsoid) (*BoundingBox, error) {
+
+	locator = strings.TrimSpace(locator)
+	if len(locator) < 2 || len(locator) > 2*len(maidenheadPairs) || len(locator)%2 == 1 {
+		return nil, CartographyError{Coord: locator, Err: ErrSyntax}
+	}
+
+	var south, west float64
+	longsize, latsize := 360.0, 180.0
+
+	for i := 0; i < len(locator); i++ {
+		pair := maidenheadPairs[i/2]
+
+		c := locator[i]
+		digit := int(c) - '0'
+		if pair.letter {
+			if c >= 'a' && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			digit = int(c) - 'A'
+		}
+		if digit < 0 || digit >= pair.divisions {
+			return nil, CartographyError{Coord: locator, Index: i, Err: ErrSyntax}
+		}
+
+		if i%2 == 0 {
+			longsize /= float64(pair.divisions)
+			west += float64(digit) * longsize
+		} else {
+			latsize /= float64(pair.divisions)
+			south += float64(digit) * latsize
+		}
+	}
+
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+
+	return &BoundingBox{South: south - 90, West: west - 180, North: south + latsize - 90, East: west + longsize - 180, El: el}, nil
+}
+
+// Return latitude & longitude of the centre of the area of a Maidenhead locator.
+// If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+//
+// returns ErrSyntax if locator is not a Maidenhead locator
+func MaidenheadToLatLong(locator string, el *Ellipsoid) (*PolarCoord, error) {
+
+	bb, err := MaidenheadToBoundingBox(locator, el)
+	if err != nil {
+		return nil, err
+	}
+	return &PolarCoord{Latitude: (bb.South + bb.North) / 2, Longitude: (bb.West + bb.East) / 2, El: bb.El}, nil
+}
+
+// A Maidenhead locator literal
+type maidenheadLocator string
+
+func (loc maidenheadLocator) String() string { return string(loc) }
+
+// Maidenhead locators as a CoordinateSystem
+type maidenheadSystem struct {
+	name   string
+	length int
+}
+
+// Returns a Maidenhead coordinate system named name, to be registered by RegisterCoordinateSystem.
+// FromLatLong returns locators of length length, see LatLongToMaidenhead.
+func NewMaidenheadCoordinateSystem(name string, length int) CoordinateSystem {
+	return maidenheadSystem{name: name, length: length}
+}
+
+func (ms maidenheadSystem) Name() string     { return ms.name }
+func (maidenheadSystem) Description() string { return "Maidenhead locator" }
+
+func (maidenheadSystem) Parse(coord string) (fmt.Stringer, error) {
+
+	bb, err := MaidenheadToBoundingBox(coord, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// canonical representation of upper case fields and lower case subsquares
+	loc, err := LatLongToMaidenhead(bb.Center(), len(strings.TrimSpace(coord)))
+	if err != nil {
+		return nil, err
+	}
+	return maidenheadLocator(loc), nil
+}
+
+func (maidenheadSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(maidenheadLocator); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (maidenheadSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	loc, ok := coord.(maidenheadLocator)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return MaidenheadToLatLong(string(loc), nil)
+}
+
+func (ms maidenheadSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	loc, err := LatLongToMaidenhead(pc, ms.length)
+	if err != nil {
+		return nil, err
+	}
+	return maidenheadLocator(loc), nil
+}
+
+func (maidenheadSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return WGS84CRS, nil
+}
+
+func init() {
+	RegisterCoordinateSystem(NewMaidenheadCoordinateSystem("maidenhead", DefaultMaidenheadLength))
+}
@@ -0,0 +1,166 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// ## LatLongToMaidenhead
+type latLongToMaidenheadTest struct {
+	in     *PolarCoord
+	length int
+	out    string
+}
+
+var latLongToMaidenheadTests = []latLongToMaidenheadTest{
+	{&PolarCoord{Latitude: 48.14666, Longitude: 11.60833}, 2, "JN"},
+	{&PolarCoord{Latitude: 48.14666, Longitude: 11.60833}, 4, "JN58"},
+	{&PolarCoord{Latitude: 48.14666, Longitude: 11.60833}, 6, "JN58td"},
+	{&PolarCoord{Latitude: 48.14666, Longitude: 11.60833}, 8, "JN58td25"},
+	{&PolarCoord{Latitude: 48.14666, Longitude: 11.60833}, 10, "JN58td25xe"},
+	{&PolarCoord{Latitude: 38.89, Longitude: -77.03}, 8, "FM18lv63"},
+	{&PolarCoord{Latitude: -34.91, Longitude: -56.21166}, 6, "GF15vc"},
+	{&PolarCoord{Latitude: 48, Longitude: 16}, 4, "JN88"},
+	{&PolarCoord{Latitude: 90, Longitude: 180}, 4, "AR09"},
+	{&PolarCoord{Latitude: -90, Longitude: -180}, 2, "AA"},
+}
+
+func TestLatLongToMaidenhead(t *testing.T) {
+	for cnt, test := range latLongToMaidenheadTests {
+		out, err := LatLongToMaidenhead(test.in, test.length)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.out != out {
+			t.Errorf("LatLongToMaidenhead [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+
+	for _, length := range []int{0, 3, 12} {
+		if _, err := LatLongToMaidenhead(&PolarCoord{}, length); err != ErrRange {
+			t.Errorf("LatLongToMaidenhead: expected %s for length %d, got %v", ErrRange, length, err)
+		}
+	}
+
+	if _, err := LatLongToMaidenhead(&PolarCoord{Latitude: 90.5}, 6); err != ErrRange {
+		t.Errorf("LatLongToMaidenhead: expected %s for latitude 90.5, got %v", ErrRange, err)
+	}
+}
+
+// ## MaidenheadToBoundingBox
+type maidenheadToBoundingBoxTest struct {
+	in  string
+	out *BoundingBox
+}
+
+var maidenheadToBoundingBoxTests = []maidenheadToBoundingBoxTest{
+	{"JN", &BoundingBox{South: 40, West: 0, North: 50, East: 20}},
+	{"JN88", &BoundingBox{South: 48, West: 16, North: 49, East: 18}},
+	{"JN88dd", &BoundingBox{South: 48.125, West: 16.25, North: 48.166667, East: 16.333333}},
+	{" jn88DD ", &BoundingBox{South: 48.125, West: 16.25, North: 48.166667, East: 16.333333}},
+	{"JN88dd00", &BoundingBox{South: 48.125, West: 16.25, North: 48.129167, East: 16.258333}},
+	{"JN88dd00aa", &BoundingBox{South: 48.125, West: 16.25, North: 48.125174, East: 16.250347}},
+	{"RR99xx99xx", &BoundingBox{South: 89.999826, West: 179.999653, North: 90, East: 180}},
+}
+
+func TestMaidenheadToBoundingBox(t *testing.T) {
+	for cnt, test := range maidenheadToBoundingBoxTests {
+		out, err := MaidenheadToBoundingBox(test.in, nil)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !boundingboxequal(test.out, out) {
+			t.Errorf("MaidenheadToBoundingBox [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+}
+
+type maidenheadErrorTest struct {
+	in    string
+	index int
+}
+
+var maidenheadErrorTests = []maidenheadErrorTest{
+	{"", 0},
+	{"J", 0},
+	{"JN8", 0},
+	{"JN88dd00aa00", 0},
+	{"SN88", 0},
+	{"JN8a", 3},
+	{"JN88yd", 4},
+	{"JN88ddx0", 6},
+	{"JN88dd00ay", 9},
+}
+
+func TestMaidenheadErrors(t *testing.T) {
+	for cnt, test := range maidenheadErrorTests {
+		_, err := MaidenheadToBoundingBox(test.in, nil)
+
+		cerr, ok := err.(CartographyError)
+		if !ok || cerr.Err != ErrSyntax || cerr.Index != test.index {
+			t.Errorf("MaidenheadToBoundingBox [%d]: expected %s at index %d, got %v", cnt, ErrSyntax, test.index, err)
+		}
+	}
+}
+
+// ## Round trip of every precision
+func TestMaidenheadRoundTrip(t *testing.T) {
+	for _, in := range []*PolarCoord{
+		{Latitude: 48.208333, Longitude: 16.372778},
+		{Latitude: -33.856784, Longitude: 151.215297},
+		{Latitude: 64.146582, Longitude: -21.942635},
+	} {
+		for length := 2; length <= 10; length += 2 {
+			loc, err := LatLongToMaidenhead(in, length)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			bb, err := MaidenheadToBoundingBox(loc, nil)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			if !bb.Contains(in) {
+				t.Errorf("Maidenhead round trip: %s of %s does not contain %s", bb, loc, in)
+			}
+
+			if out, _ := LatLongToMaidenhead(bb.Center(), length); out != loc {
+				t.Errorf("Maidenhead round trip: expected %s for the centre of %s, got %s", loc, bb, out)
+			}
+		}
+	}
+}
+
+// ## NewMaidenheadCoordinateSystem
+func TestMaidenheadCoordinateSystem(t *testing.T) {
+	test := latLongToMaidenheadTests[3]
+
+	cs := NewMaidenheadCoordinateSystem("maidenhead8", test.length)
+	if out, err := cs.FromLatLong(test.in); err != nil || cs.Name() != "maidenhead8" || out.String() != test.out {
+		t.Errorf("NewMaidenheadCoordinateSystem: Expected maidenhead8 %s, got %s %v (%v)", test.out, cs.Name(), out, err)
+	}
+
+	if _, err := NewMaidenheadCoordinateSystem("maidenhead", 5).FromLatLong(test.in); err != ErrRange {
+		t.Errorf("NewMaidenheadCoordinateSystem: Expected %s for length 5, got %v", ErrRange, err)
+	}
+
+	// the registered coordinate system converts at the default length
+	if cs, ok := LookupCoordinateSystem("maidenhead"); !ok {
+		t.Error("LookupCoordinateSystem: maidenhead not registered")
+	} else if out, err := cs.FromLatLong(test.in); err != nil || len(out.String()) != DefaultMaidenheadLength {
+		t.Errorf("maidenhead: Expected a locator of length %d, got %v (%v)", DefaultMaidenheadLength, out, err)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ## MGRS / USNG coordinate functions for parsing and conversion
+
+// Precision of an MGRS coordinate: the number of digits of easting and northing
+// within the 100km square, from the bare square (MGRS100km) to meter resolution (MGRS1m).
+type MGRSprec byte
+
+const (
+	MGRS100km MGRSprec = iota
+	MGRS10km
+	MGRS1km
+	MGRS100m
+	MGRS10m
+	MGRS1m
+)
+
+// An MGRS coordinate, also known as USNG, is specified by the UTM grid zone designator, eg. "33U",
+// the two letter identification of the 100km square, eg. "XP", and easting and northing within the
+// square. Easting and northing are given to Precision digits, thus for MGRS10m "33UXP04501234" is
+// Easting 0450 and Northing 1234.
+//
+// The reference ellipsoid determines the lettering scheme of the 100km squares: Bessel and Clarke
+// ellipsoids use the old lettering scheme (MGRS-AL), all others the new one (MGRS-AA).
+type MGRSCoord struct {
+	Zone, Square      string
+	Easting, Northing uint
+	Precision         MGRSprec
+	El                *Ellipsoid
+}
+
+// Column letters of the 100km squares, repeating every third zone
+var mgrsColumnLetters = [3]string{"STUVWXYZ", "ABCDEFGH", "JKLMNPQR"}
+
+// Row letters of the 100km squares, repeating every 2000km
+const mgrsRowLetters = "ABCDEFGHJKLMNPQRSTUV"
+
+// UTM latitude bands, starting at 80°S, 8° each
+const utmLatitudeBands = "CDEFGHJKLMNPQRSTUVWX"
+
+// Canonical, compact representation of an MGRS coordinate, eg. "33UXP0450012345"
+func (coord *MGRSCoord) String() string {
+	if coord.Precision == MGRS100km {
+		return coord.Zone + coord.Square
+	}
+	return fmt.Sprintf("%s%s%0*d%0*d", coord.Zone, coord.Square, int(coord.Precision), coord.Easting, int(coord.Precision), coord.Northing)
+}
+
+// Representation of an MGRS coordinate as used by the USNG, separated by blanks, eg. "33U XP 04500 12345"
+func (coord *MGRSCoord) USNGString() string {
+	if coord.Precision == MGRS100km {
+		return coord.Zone + " " + coord.Square
+	}
+	return fmt.Sprintf("%s %s %0*d %0*d", coord.Zone, coord.Square, int(coord.Precision), coord.Easting, int(coord.Precision), coord.Northing)
+}
+
+// Bessel and Clarke ellipsoids use the old lettering scheme of the 100km squares
+func mgrsOldLettering(el *Ellipsoid) bool {
+	switch el.a {
+	case 6377397.155, // Bessel 1841
+		6378206.4,   // Clarke 1866
+		6378249.145: // Clarke 1880
+		return true
+	}
+	return false
+}
+
+// Offset of the row letters of the 100km squares of zone zonenumber
+func mgrsRowOffset(zonenumber uint, el *Ellipsoid) int {
+	offset := 0
+	if mgrsOldLettering(el) {
+		offset = 10
+	}
+	if zonenumber%2 == 0 {
+		offset += 5
+	}
+	return offset
+}
+
+// This function parses a string MGRS coordinate literal of the format
+//
+//	"ZZB SQ EEEEENNNNN"
+//
+// ZZ is the UTM zone number, B the latitude band and SQ the 100km square. Blanks
+// are optional. Easting and northing follow with the same number of digits, from zero (100km square)
+// up to five digits (1m). If the reference ellipsoid is nil, the DefaultEllipsoid is assumed.
+//
+// returns ErrSyntax if format is not understood
+// returns ErrRange if values are outside the defined parameters for an MGRS coordinate
+func AMGRSToStruct(mgrscoord string, el *Ellipsoid) (*MGRSCoord, error) {
+
+	compact := strings.ToUpper(removeblank(strings.TrimSpace(mgrscoord)))
+
+	i := 0
+	for i < len(compact) && i < 2 && compact[i] >= '0' && compact[i] <= '9' {
+		i++
+	}
+
+	if i == 0 || len(compact) < i+3 {
+		return nil, CartographyError{Coord: compact, Index: i, Err: ErrSyntax}
+	}
+
+	zonenumber, err := strconv.ParseUint(compact[:i], 10, 0)
+	if err != nil {
+		return nil, CartographyError{Coord: compact, Err: ErrSyntax}
+	}
+	if zonenumber < 1 || zonenumber > 60 || strings.IndexByte(utmLatitudeBands, compact[i]) < 0 {
+		return nil, CartographyError{Coord: compact, Index: i, Err: ErrRange}
+	}
+
+	zone := compact[:i+1]
+	square := compact[i+1 : i+3]
+	digits := compact[i+3:]
+
+	if len(digits)%2 != 0 || len(digits) > 2*int(MGRS1m) {
+		return nil, CartographyError{Coord: compact, Index: i + 3, Err: ErrSyntax}
+	}
+
+	prec := MGRSprec(len(digits) / 2)
+	var east, north uint64
+
+	if prec > MGRS100km {
+		if east, err = strconv.ParseUint(digits[:prec], 10, 0); err != nil {
+			return nil, CartographyError{Coord: compact, Index: i + 3, Err: ErrSyntax}
+		}
+		if north, err = strconv.ParseUint(digits[prec:], 10, 0); err != nil {
+			return nil, CartographyError{Coord: compact, Index: i + 3 + int(prec), Err: ErrSyntax}
+		}
+	}
+
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+
+	coord := &MGRSCoord{Zone: zone, Square: square, Easting: uint(east), Northing: uint(north), Precision: prec, El: el}
+
+	// check the square letters
+	if _, _, err = mgrsSquareOffset(coord); err != nil {
+		return nil, err
+	}
+
+	return coord, nil
+}
+
+// Returns easting and northing of the south west corner of the 100km square, the
+// northing modulo 2000km.
+func mgrsSquareOffset(coord *MGRSCoord) (easting, northing float64, err error) {
+
+	if len(coord.Zone) < 2 || len(coord.Square) != 2 {
+		return 0, 0, ErrSyntax
+	}
+
+	zonenumber, err := strconv.ParseUint(coord.Zone[:len(coord.Zone)-1], 10, 0)
+	if err != nil {
+		return 0, 0, CartographyError{Coord: coord.Zone, Err: ErrSyntax}
+	}
+
+	col := strings.IndexByte(mgrsColumnLetters[zonenumber%3], coord.Square[0])
+	row := strings.IndexByte(mgrsRowLetters, coord.Square[1])
+	if col < 0 || row < 0 {
+		return 0, 0, CartographyError{Coord: coord.Square, Err: ErrRange}
+	}
+
+	row = (row - mgrsRowOffset(uint(zonenumber), coord.El) + len(mgrsRowLetters)) % len(mgrsRowLetters)
+	return float64(col+1) * 100000, float64(row) * 100000, nil
+}
+
+// Convert an MGRS coordinate to latitude and longitude. If the MGRS coordinate is not given to
+// meter precision, the resulting latitude and longitude point to the middle of the designated square.
+// If the MGRS coordinates do not contain a reference ellipsoid, the DefaultEllipsoid is assumed.
+//
+// Returns ErrRange if the 100km square does not exist in the grid zone.
+func MGRSToLatLong(coord *MGRSCoord) (*PolarCoord, error) {
+
+	el := coord.El
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+
+	c := *coord
+	c.El = el
+
+	easting, northing, err := mgrsSquareOffset(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	zonenumber, _ := strconv.ParseUint(c.Zone[:len(c.Zone)-1], 10, 0)
+	band := strings.IndexByte(utmLatitudeBands, c.Zone[len(c.Zone)-1])
+	if band < 0 {
+		return nil, CartographyError{Coord: c.Zone, Err: ErrRange}
+	}
+
+	unit := math.Pow(10, float64(MGRS1m-c.Precision))
+	easting += float64(c.Easting) * unit
+	northing += float64(c.Northing) * unit
+
+	// point to the middle of the square
+	if c.Precision < MGRS1m {
+		easting += unit / 2
+		northing += unit / 2
+	}
+
+	// The row letters repeat every 2000km. Find the least northing of the lower boundary
+	// of the latitude band and add multiples of 2000km until the northing lies within the band.
+	// The parallels bend towards the pole, so the least northing lies on the central meridian north of
+	// the equator and on the edge of the zone, 3° from the central meridian, south of it
+	latband := float64(band*8 - 80)
+	bandnorthing := math.Min(
+		DefaultTMAlgorithm.DirectTransverseMercator(&PolarCoord{Latitude: latband, El: el}, 0, 0, 0.9996, 500000, 0).Y,
+		DefaultTMAlgorithm.DirectTransverseMercator(&PolarCoord{Latitude: latband, Longitude: 3, El: el}, 0, 0, 0.9996, 500000, 0).Y)
+	if latband < 0 {
+		bandnorthing += 10000000
+	}
+	bandnorthing = math.Floor(bandnorthing/100000) * 100000
+
+	for northing < bandnorthing {
+		northing += 2000000
+	}
+
+	return UTMToLatLong(&UTMCoord{Zone: strconv.FormatUint(zonenumber, 10) + c.Zone[len(c.Zone)-1:], Easting: easting, Northing: northing, El: el})
+}
+
+// Convert from latitude and longitude to an MGRS coordinate of precision prec. Easting and northing
+// are truncated, not rounded, to the requested precision. If the polar coordinates do not contain a
+// reference ellipsoid, the DefaultEllipsoid is assumed.
+//
+// Returns ErrRange if the latitude is outside the UTM limits of 84°N and 80°S.
+func LatLongToMGRS(pc *PolarCoord, prec MGRSprec) (*MGRSCoord, error) {
+
+	if prec > MGRS1m {
+		return nil, ErrRange
+	}
+
+	utm, err := LatLongToUTM(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	zonenumber, err := strconv.ParseUint(utm.Zone[:len(utm.Zone)-1], 10, 0)
+	if err != nil {
+		return nil, CartographyError{Coord: utm.Zone, Err: ErrSyntax}
+	}
+
+	col := int(utm.Easting / 100000)
+	if col < 1 || col > len(mgrsColumnLetters[0]) {
+		return nil, ErrRange
+	}
+
+	row := (int(utm.Northing/100000) + mgrsRowOffset(uint(zonenumber), utm.El)) % len(mgrsRowLetters)
+	square := string(mgrsColumnLetters[zonenumber%3][col-1]) + string(mgrsRowLetters[row])
+
+	unit := uint(math.Pow(10, float64(MGRS1m-prec)))
+	easting := uint(math.Floor(utm.Easting)) % 100000 / unit
+	northing := uint(math.Floor(utm.Northing)) % 100000 / unit
+
+	return &MGRSCoord{Zone: utm.Zone, Square: square, Easting: easting, Northing: northing, Precision: prec, El: utm.El}, nil
+}
+
+// MGRS coordinates as a CoordinateSystem
+type mgrsSystem struct{}
+
+func (mgrsSystem) Name() string        { return "mgrs" }
+func (mgrsSystem) Description() string { return "MGRS / USNG" }
+
+func (mgrsSystem) Parse(coord string) (fmt.Stringer, error) {
+	return AMGRSToStruct(coord, nil)
+}
+
+func (mgrsSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*MGRSCoord); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (mgrsSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	mgrs, ok := coord.(*MGRSCoord)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return MGRSToLatLong(mgrs)
+}
+
+func (mgrsSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	return LatLongToMGRS(pc, MGRS1m)
+}
+
+func (mgrsSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	mgrs, ok := coord.(*MGRSCoord)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return utmCRS(mgrs.Zone, mgrs.El)
+}
+
+func init() {
+	RegisterCoordinateSystem(mgrsSystem{})
+}
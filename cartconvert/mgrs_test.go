@@ -0,0 +1,194 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the MGRS functions of the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// ## LatLongToMGRS
+type latLongToMGRSParam struct {
+	pc   *PolarCoord
+	prec MGRSprec
+}
+
+type latLongToMGRSTest struct {
+	in  latLongToMGRSParam
+	out string
+}
+
+var latLongToMGRSTests = []latLongToMGRSTest{
+	// http://www.movable-type.co.uk/scripts/latlong-utm-mgrs.html
+	{latLongToMGRSParam{&PolarCoord{Latitude: 48.8582, Longitude: 2.2945}, MGRS1m}, "31UDQ4825111932"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 48.8582, Longitude: 2.2945}, MGRS10m}, "31UDQ48251193"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 48.8582, Longitude: 2.2945}, MGRS100m}, "31UDQ482119"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 48.8582, Longitude: 2.2945}, MGRS1km}, "31UDQ4811"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 48.8582, Longitude: 2.2945}, MGRS10km}, "31UDQ41"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 48.8582, Longitude: 2.2945}, MGRS100km}, "31UDQ"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: -33.922667, Longitude: 18.416689}, MGRS1m}, "34HBH6119043412"},
+	// Norway and Svalbard zone exceptions
+	{latLongToMGRSParam{&PolarCoord{Latitude: 60.39, Longitude: 5.32}, MGRS1km}, "32VKN9700"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 78.2, Longitude: 15.6}, MGRS1km}, "33XWG1380"},
+	// Bessel ellipsoid uses the old lettering scheme
+	{latLongToMGRSParam{&PolarCoord{Latitude: 50.5, Longitude: 0.5, El: Bessel1841Ellipsoid}, MGRS100km}, "31UCF"},
+	{latLongToMGRSParam{&PolarCoord{Latitude: 50.5, Longitude: 0.5, El: WGS84Ellipsoid}, MGRS100km}, "31UCR"},
+}
+
+func TestLatLongToMGRS(t *testing.T) {
+	for index, test := range latLongToMGRSTests {
+		out, err := LatLongToMGRS(test.in.pc, test.in.prec)
+
+		if err != nil {
+			t.Errorf("LatLongToMGRS [%d]: %s", index, err)
+			continue
+		}
+
+		if out.String() != test.out {
+			t.Errorf("LatLongToMGRS [%d]: expected %s, got %s", index, test.out, out)
+		}
+	}
+}
+
+func TestLatLongToMGRSRange(t *testing.T) {
+	for _, pc := range []*PolarCoord{{Latitude: 84.5, Longitude: 0}, {Latitude: -80.5, Longitude: 0}} {
+		if _, err := LatLongToMGRS(pc, MGRS1m); err != ErrRange {
+			t.Errorf("LatLongToMGRS: expected %s for %s, got %v", ErrRange, pc, err)
+		}
+	}
+}
+
+// ## AMGRSToStruct
+type aMGRSToStructTest struct {
+	in   string
+	out  string
+	usng string
+}
+
+var aMGRSToStructTests = []aMGRSToStructTest{
+	{"31U DQ 48251 11932", "31UDQ4825111932", "31U DQ 48251 11932"},
+	{" 33uxp0450012345 ", "33UXP0450012345", "33U XP 04500 12345"},
+	{"4QFJ12345678", "4QFJ12345678", "4Q FJ 1234 5678"},
+	{"31UDQ", "31UDQ", "31U DQ"},
+}
+
+func TestAMGRSToStruct(t *testing.T) {
+	for index, test := range aMGRSToStructTests {
+		out, err := AMGRSToStruct(test.in, nil)
+
+		if err != nil {
+			t.Errorf("AMGRSToStruct [%d]: %s", index, err)
+			continue
+		}
+
+		if out.String() != test.out || out.USNGString() != test.usng {
+			t.Errorf("AMGRSToStruct [%d]: expected %s (%s), got %s (%s)", index, test.out, test.usng, out, out.USNGString())
+		}
+	}
+}
+
+func TestAMGRSToStructError(t *testing.T) {
+	for _, in := range []string{"", "31U", "31UDQ123", "61UDQ1234", "31IDQ1234", "31USQ1234", "31UDQ123456789012"} {
+		if out, err := AMGRSToStruct(in, nil); err == nil {
+			t.Errorf("AMGRSToStruct: expected error for %s, got %s", in, out)
+		}
+	}
+
+	// digits, which are no numbers
+	for cnt, test := range []struct {
+		in    string
+		index int
+	}{
+		{"31UDQ1X34", 5},
+		{"31UDQ12+4", 7},
+		{"31UDQ-123", 5},
+	} {
+		_, err := AMGRSToStruct(test.in, nil)
+
+		cerr, ok := err.(CartographyError)
+		if !ok || cerr.Err != ErrSyntax || cerr.Index != test.index {
+			t.Errorf("AMGRSToStruct [%d]: expected %s at index %d, got %v", cnt, ErrSyntax, test.index, err)
+		}
+	}
+
+	// a zone of a coordinate which has not been parsed
+	_, err := MGRSToLatLong(&MGRSCoord{Zone: "XU", Square: "DQ"})
+	if cerr, ok := err.(CartographyError); !ok || cerr.Err != ErrSyntax || cerr.Coord != "XU" {
+		t.Errorf("MGRSToLatLong: expected %s for zone XU, got %v", ErrSyntax, err)
+	}
+}
+
+// ## MGRSToLatLong
+type mGRSToLatLongTest struct {
+	in  string
+	out *PolarCoord
+}
+
+var mGRSToLatLongTests = []mGRSToLatLongTest{
+	{"31UDQ4825111932", &PolarCoord{Latitude: 48.8582, Longitude: 2.2945}},
+	{"34HBH6119043412", &PolarCoord{Latitude: -33.92267, Longitude: 18.41669}},
+	{"32VKN9723000510", &PolarCoord{Latitude: 60.39, Longitude: 5.32}},
+	{"33XWG1369680760", &PolarCoord{Latitude: 78.2, Longitude: 15.6}},
+	// middle of the 1km square
+	{"31UDQ4811", &PolarCoord{Latitude: 48.85433, Longitude: 2.29794}},
+}
+
+func mgrslatlongequal(pcp1, pcp2 *PolarCoord) bool {
+	pp1s := fmt.Sprintf("%.4f %.4f", pcp1.Latitude, pcp1.Longitude)
+	pp2s := fmt.Sprintf("%.4f %.4f", pcp2.Latitude, pcp2.Longitude)
+
+	return pp1s == pp2s
+}
+
+// The lower and upper edges of every latitude band, on the central meridian and on the edges of the zone
+func TestMGRSBandEdges(t *testing.T) {
+	for band := 0; band < len(utmLatitudeBands); band++ {
+		for _, lat := range []float64{float64(band*8-80) + 1e-7, float64(band*8-72) - 1e-7} {
+			for _, long := range []float64{-180, -177, -174 + 1e-7, 0, 3, 6 - 1e-7} {
+				in := &PolarCoord{Latitude: lat, Longitude: long}
+
+				coord, err := LatLongToMGRS(in, MGRS1m)
+				if err != nil {
+					t.Errorf("LatLongToMGRS [%s]: %s", in, err)
+					continue
+				}
+
+				out, err := MGRSToLatLong(coord)
+				if err != nil || math.Abs(out.Latitude-lat) > 0.0001 {
+					t.Errorf("MGRSToLatLong [%s]: expected %s, got %v (%v)", coord, in, out, err)
+				}
+			}
+		}
+	}
+
+	// the lower edge of band E at the edge of zone 1
+	if out, err := AMGRSToStruct("1ECJ5330499532", nil); err != nil {
+		t.Error(err)
+	} else if pc, err := MGRSToLatLong(out); err != nil || math.Abs(pc.Latitude+64) > 0.0001 {
+		t.Errorf("MGRSToLatLong [%s]: expected a latitude of -64, got %v (%v)", out, pc, err)
+	}
+}
+
+func TestMGRSToLatLong(t *testing.T) {
+	for index, test := range mGRSToLatLongTests {
+		coord, err := AMGRSToStruct(test.in, nil)
+		if err != nil {
+			t.Errorf("MGRSToLatLong [%d]: %s", index, err)
+			continue
+		}
+
+		out, err := MGRSToLatLong(coord)
+		if err != nil {
+			t.Errorf("MGRSToLatLong [%d]: %s", index, err)
+			continue
+		}
+
+		if !mgrslatlongequal(test.out, out) {
+			t.Errorf("MGRSToLatLong [%d]: expected %s, got %s", index, test.out, out)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+)
+
+// ## Datum transformations of latitude, longitude and ellipsoidal height
+
+// Transformation of latitude, longitude and ellipsoidal height from one geodetic datum into another.
+// Implemented by Molodensky, GeocentricTransformer and GridShift.
+type DatumTransformer interface {
+	// Transform pc of the source datum into the target datum
+	TransformPolar(pc *PolarCoord) *PolarCoord
+	// Transform pc of the target datum back into the source datum
+	InverseTransformPolar(pc *PolarCoord) *PolarCoord
+}
+
+// The reverse of a DatumTransformer, which transforms from its target into its source datum
+type inverseTransformer struct {
+	dt DatumTransformer
+}
+
+// Returns the DatumTransformer performing the inverse transformation of dt, eg. to use published
+// parameters in the opposite direction
+func InverseDatumTransformer(dt DatumTransformer) DatumTransformer {
+	if it, ok := dt.(inverseTransformer); ok {
+		return it.dt
+	}
+	return inverseTransformer{dt: dt}
+}
+
+func (it inverseTransformer) TransformPolar(pc *PolarCoord) *PolarCoord {
+	return it.dt.InverseTransformPolar(pc)
+}
+
+func (it inverseTransformer) InverseTransformPolar(pc *PolarCoord) *PolarCoord {
+	return it.dt.TransformPolar(pc)
+}
+
+// A Helmert transformation between the geocentric cartesian coordinates of two datums, whose latitude and
+// longitude refer to the ellipsoids From and To. Transforms via PolarToCartesian, Helmert.Transform and
+// CartesianToPolar.
+type GeocentricTransformer struct {
+	Helmert  *Helmert
+	From, To *Ellipsoid
+}
+
+// Returns the DatumTransformer of the helmert transformation hp from ellipsoid from to ellipsoid to
+func NewGeocentricTransformer(hp *Helmert, from, to *Ellipsoid) *GeocentricTransformer {
+	return &GeocentricTransformer{Helmert: hp, From: from, To: to}
+}
+
+// Transform pc on the ellipsoid From into a point on the ellipsoid To.
+func (gt *GeocentricTransformer) TransformPolar(pc *PolarCoord) *PolarCoord {
+	cart := PolarToCartesian(&PolarCoord{Latitude: pc.Latitude, Longitude: pc.Longitude, Height: pc.Height, El: gt.From})
+	pt := gt.Helmert.Transform(&Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
+	return CartesianToPolar(&CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: gt.To})
+}
+
+// Transform pc on the ellipsoid To back into a point on the ellipsoid From.
+func (gt *GeocentricTransformer) InverseTransformPolar(pc *PolarCoord) *PolarCoord {
+	cart := PolarToCartesian(&PolarCoord{Latitude: pc.Latitude, Longitude: pc.Longitude, Height: pc.Height, El: gt.To})
+	pt := gt.Helmert.InverseTransform(&Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
+	return CartesianToPolar(&CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: gt.From})
+}
+
+// ## Molodensky transformation
+
+// The Molodensky transformation shifts latitude, longitude and ellipsoidal height directly by the
+// translation of the geocentric origin and the difference of the ellipsoids, without the round trip
+// through cartesian coordinates. The abridged variant neglects the height and terms of higher order;
+// Both are accurate to about the accuracy of the published parameters.
+type Molodensky struct {
+	// translation of the coordinate origin in meters
+	Dx, Dy, Dz float64
+	// source and target ellipsoid
+	From, To *Ellipsoid
+	Abridged bool
+	Datum    string
+}
+
+// A set of Molodensky transformations with the geocentric translations published by EPSG
+var (
+	// ED50 to WGS 84 (1), mean for Western Europe
+	MolodenskyED50ToWGS84 = NewMolodensky(-87, -98, -121, International1924Ellipsoid, WGS84Ellipsoid, "ED50toWGS84")
+	// NAD27 to WGS 84 (4), mean for the conterminous United States
+	MolodenskyNAD27ToWGS84 = NewMolodensky(-8, 160, 176, Clarke1866Ellipsoid, WGS84Ellipsoid, "NAD27toWGS84")
+)
+
+// Create a new instance of the standard Molodensky transformation from the ellipsoid from to the
+// ellipsoid to; dx, dy, dz is the translation of the coordinate origin in meters.
+func NewMolodensky(dx, dy, dz float64, from, to *Ellipsoid, datum string) *Molodensky {
+	return &Molodensky{Dx: dx, Dy: dy, Dz: dz, From: from, To: to, Datum: datum}
+}
+
+// Create a new instance of the abridged Molodensky transformation from the ellipsoid from to the
+// ellipsoid to; dx, dy, dz is the translation of the coordinate origin in meters.
+func NewAbridgedMolodensky(dx, dy, dz float64, from, to *Ellipsoid, datum string) *Molodensky {
+	return &Molodensky{Dx: dx, Dy: dy, Dz: dz, From: from, To: to, Abridged: true, Datum: datum}
+}
+
+// Shift of latitude and longitude in radians and of the height in meters of pc by the translation
+// dx, dy, dz and the difference of the ellipsoids from and to
+func molodenskyShift(pc *PolarCoord, dx, dy, dz float64, from, to *Ellipsoid, abridged bool) (dlat, dlong, dh float64) {
+
+	a, b := from.a, from.b
+	f := (a - b) / a
+	esq := (a*a - b*b) / (a * a)
+
+	da := to.a - a
+	df := (to.a-to.b)/to.a - f
+
+	lat := degtorad(pc.Latitude)
+	long := degtorad(pc.Longitude)
+	sinlat, coslat := math.Sin(lat), math.Cos(lat)
+	sinlong, coslong := math.Sin(long), math.Cos(long)
+
+	rho := a * (1 - esq) / math.Pow(1-esq*sinlat*sinlat, 1.5)
+	nu := a / math.Sqrt(1-esq*sinlat*sinlat)
+
+	if abridged {
+		dlat = (-dx*sinlat*coslong - dy*sinlat*sinlong + dz*coslat + (a*df+f*da)*math.Sin(2*lat)) / rho
+		dlong = (-dx*sinlong + dy*coslong) / (nu * coslat)
+		dh = dx*coslat*coslong + dy*coslat*sinlong + dz*sinlat + (a*df+f*da)*sinlat*sinlat - da
+		return
+	}
+
+	h := pc.Height
+	dlat = (-dx*sinlat*coslong - dy*sinlat*sinlong + dz*coslat + da*nu*esq*sinlat*coslat/a +
+		df*(rho*a/b+nu*b/a)*sinlat*coslat) / (rho + h)
+	dlong = (-dx*sinlong + dy*coslong) / ((nu + h) * coslat)
+	dh = dx*coslat*coslong + dy*coslat*sinlong + dz*sinlat - da*a/nu + df*b/a*nu*sinlat*sinlat
+	return
+}
+
+// Transform pc on the ellipsoid From into a point on the ellipsoid To.
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Molodensky and Abridged Molodensky transformations
+func (mt *Molodensky) TransformPolar(pc *PolarCoord) *PolarCoord {
+
+	dlat, dlong, dh := molodenskyShift(pc, mt.Dx, mt.Dy, mt.Dz, mt.From, mt.To, mt.Abridged)
+
+	return &PolarCoord{
+		Latitude:  pc.Latitude + radtodeg(dlat),
+		Longitude: pc.Longitude + radtodeg(dlong),
+		Height:    pc.Height + dh,
+		El:        mt.To}
+}
+
+// Transform pc on the ellipsoid To back into a point on the ellipsoid From. The shift is evaluated at the
+// source position, which is found iteratively, so the result is the exact inverse of TransformPolar.
+func (mt *Molodensky) InverseTransformPolar(pc *PolarCoord) *PolarCoord {
+
+	src := &PolarCoord{Latitude: pc.Latitude, Longitude: pc.Longitude, Height: pc.Height, El: mt.From}
+
+	for i := 0; i < 10; i++ {
+		dlat, dlong, dh := molodenskyShift(src, mt.Dx, mt.Dy, mt.Dz, mt.From, mt.To, mt.Abridged)
+
+		next := &PolarCoord{
+			Latitude:  pc.Latitude - radtodeg(dlat),
+			Longitude: pc.Longitude - radtodeg(dlong),
+			Height:    pc.Height - dh,
+			El:        mt.From}
+
+		converged := math.Abs(next.Latitude-src.Latitude) < 1e-11 && math.Abs(next.Longitude-src.Longitude) < 1e-11
+		src = next
+		if converged {
+			break
+		}
+	}
+	return src
+}
+
+// Returns a canoncial representation of the Molodensky parameters
+func (mt *Molodensky) String() string {
+	method := "Molodensky"
+	if mt.Abridged {
+		method = "AbridgedMolodensky"
+	}
+	return fmt.Sprintf("%s[%s](dx,dy,dz): (%f, %f, %f) %s->%s", method, mt.Datum, mt.Dx, mt.Dy, mt.Dz, mt.From.CommonName, mt.To.CommonName)
+}
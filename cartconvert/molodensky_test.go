@@ -0,0 +1,90 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ## Molodensky
+type molodenskyTest struct {
+	mt  *Molodensky
+	in  *PolarCoord
+	out *PolarCoord
+}
+
+// OGP Publication 373-7-2, WGS 84 to ED50
+var molodenskyTests = []molodenskyTest{
+	{
+		NewMolodensky(84.87, 96.49, 116.95, WGS84Ellipsoid, International1924Ellipsoid, "WGS84toED50"),
+		&PolarCoord{Latitude: 53 + 48.0/60 + 33.82/3600, Longitude: 2 + 7.0/60 + 46.38/3600, Height: 73},
+		&PolarCoord{Latitude: 53 + 48.0/60 + 36.565/3600, Longitude: 2 + 7.0/60 + 51.477/3600, Height: 28.02},
+	},
+	{
+		NewAbridgedMolodensky(84.87, 96.49, 116.95, WGS84Ellipsoid, International1924Ellipsoid, "WGS84toED50"),
+		&PolarCoord{Latitude: 53 + 48.0/60 + 33.82/3600, Longitude: 2 + 7.0/60 + 46.38/3600, Height: 73},
+		&PolarCoord{Latitude: 53 + 48.0/60 + 36.563/3600, Longitude: 2 + 7.0/60 + 51.477/3600, Height: 28.09},
+	},
+}
+
+// equal within a few centimeters
+func polar3dequal(pc1, pc2 *PolarCoord) bool {
+	pp1s := fmt.Sprintf("%.6f %.6f %.1f", pc1.Latitude, pc1.Longitude, pc1.Height)
+	pp2s := fmt.Sprintf("%.6f %.6f %.1f", pc2.Latitude, pc2.Longitude, pc2.Height)
+
+	return pp1s == pp2s
+}
+
+func TestMolodensky(t *testing.T) {
+	for cnt, test := range molodenskyTests {
+		out := test.mt.TransformPolar(test.in)
+		if !polar3dequal(test.out, out) || out.El != International1924Ellipsoid {
+			t.Errorf("Molodensky.TransformPolar [%d]: expected %s %f, got %s %f", cnt, test.out, test.out.Height, out, out.Height)
+		}
+
+		in := test.mt.InverseTransformPolar(out)
+		if !latlongmmequal(test.in, in) || fmt.Sprintf("%.3f", test.in.Height) != fmt.Sprintf("%.3f", in.Height) || in.El != WGS84Ellipsoid {
+			t.Errorf("Molodensky.InverseTransformPolar [%d]: expected %s %f, got %s %f", cnt, test.in, test.in.Height, in, in.Height)
+		}
+	}
+}
+
+// ## DatumTransformer
+func TestDatumTransformer(t *testing.T) {
+
+	// the Molodensky transformation approximates the geocentric translation
+	helmert := NewGeocentricTransformer(NewHelmertTransformer(-87, -98, -121, 0, 0, 0, 0, "ED50toWGS84"), International1924Ellipsoid, WGS84Ellipsoid)
+	in := &PolarCoord{Latitude: 48.2082, Longitude: 16.3738, Height: 200}
+
+	for _, dt := range []DatumTransformer{MolodenskyED50ToWGS84, helmert} {
+		out := dt.TransformPolar(in)
+		expected := helmert.TransformPolar(in)
+		if !polar3dequal(expected, out) {
+			t.Errorf("DatumTransformer %s: expected %s %f, got %s %f", dt, expected, expected.Height, out, out.Height)
+		}
+
+		back := dt.InverseTransformPolar(out)
+		if !latlongmmequal(in, back) {
+			t.Errorf("DatumTransformer %s: expected %s, got %s", dt, in, back)
+		}
+	}
+}
+
+// ## InverseDatumTransformer
+func TestInverseDatumTransformer(t *testing.T) {
+
+	in := &PolarCoord{Latitude: 48.2082, Longitude: 16.3738, Height: 200, El: WGS84Ellipsoid}
+	inverse := InverseDatumTransformer(MolodenskyED50ToWGS84)
+
+	if out, expected := inverse.TransformPolar(in), MolodenskyED50ToWGS84.InverseTransformPolar(in); !polar3dequal(expected, out) || out.El != International1924Ellipsoid {
+		t.Errorf("InverseDatumTransformer: expected %s %f, got %s %f", expected, expected.Height, out, out.Height)
+	}
+
+	if InverseDatumTransformer(inverse) != DatumTransformer(MolodenskyED50ToWGS84) {
+		t.Error("InverseDatumTransformer: expected the inverse of the inverse to be the transformation")
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ## NTv2 grid shift
+
+// NTv2 (National Transformation version 2) grid shift files contain the shifts of latitude and longitude
+// between two datums at the nodes of regular grids. Grids may be refined by sub grids of higher density.
+// Described in "NTv2 Developer's Guide, Geodetic Survey Division, Natural Resources Canada"
+
+// A grid of an NTv2 file. Bounds and increments are in seconds of arc, longitudes positive west as in the file.
+type NTv2Grid struct {
+	Name, Parent             string
+	South, North, East, West float64
+	LatInc, LongInc          float64
+	rows, cols               int
+	latshift, longshift      []float32
+	children                 []*NTv2Grid
+}
+
+// The grids of an NTv2 grid shift file between the datums SystemFrom and SystemTo on the ellipsoids From and To
+type NTv2 struct {
+	SystemFrom, SystemTo string
+	From, To             *Ellipsoid
+	// the top level grids; every grid contains its sub grids
+	Grids []*NTv2Grid
+}
+
+// Reads the 16 byte records of an NTv2 file
+type ntv2Reader struct {
+	r      io.Reader
+	order  binary.ByteOrder
+	record [16]byte
+	offset int
+}
+
+func (nr *ntv2Reader) next(key string) error {
+	if _, err := io.ReadFull(nr.r, nr.record[:]); err != nil {
+		return err
+	}
+	nr.offset += len(nr.record)
+
+	if strings.TrimSpace(string(nr.record[:8])) != key {
+		return CartographyError{Coord: string(nr.record[:8]), Index: nr.offset - len(nr.record), Err: ErrSyntax}
+	}
+	return nil
+}
+
+func (nr *ntv2Reader) int(key string) (int, error) {
+	if err := nr.next(key); err != nil {
+		return 0, err
+	}
+	return int(int32(nr.order.Uint32(nr.record[8:12]))), nil
+}
+
+func (nr *ntv2Reader) float(key string) (float64, error) {
+	if err := nr.next(key); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(nr.order.Uint64(nr.record[8:])), nil
+}
+
+func (nr *ntv2Reader) string(key string) (string, error) {
+	if err := nr.next(key); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(nr.record[8:])), nil
+}
+
+// Reads an NTv2 grid shift file from r. Little and big endian files are supported, shifts in seconds,
+// minutes or degrees.
+//
+// Returns a CartographyError with ErrSyntax as the error if the file is malformed, the byte offset of the
+// offending record as Index.
+func LoadNTv2(r io.Reader) (*NTv2, error) {
+
+	nr := &ntv2Reader{r: bufio.NewReader(r), order: binary.LittleEndian}
+
+	if err := nr.next("NUM_OREC"); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(nr.record[8:12]) != 11 {
+		nr.order = binary.BigEndian
+		if binary.BigEndian.Uint32(nr.record[8:12]) != 11 {
+			return nil, CartographyError{Coord: "NUM_OREC", Err: ErrSyntax}
+		}
+	}
+
+	var nt NTv2
+	var err error
+	var numfile int
+	var gstype string
+	var majorf, minorf, majort, minort float64
+
+	if _, err = nr.int("NUM_SREC"); err != nil {
+		return nil, err
+	}
+	if numfile, err = nr.int("NUM_FILE"); err != nil {
+		return nil, err
+	}
+	if gstype, err = nr.string("GS_TYPE"); err != nil {
+		return nil, err
+	}
+	if _, err = nr.string("VERSION"); err != nil {
+		return nil, err
+	}
+	if nt.SystemFrom, err = nr.string("SYSTEM_F"); err != nil {
+		return nil, err
+	}
+	if nt.SystemTo, err = nr.string("SYSTEM_T"); err != nil {
+		return nil, err
+	}
+	if majorf, err = nr.float("MAJOR_F"); err != nil {
+		return nil, err
+	}
+	if minorf, err = nr.float("MINOR_F"); err != nil {
+		return nil, err
+	}
+	if majort, err = nr.float("MAJOR_T"); err != nil {
+		return nil, err
+	}
+	if minort, err = nr.float("MINOR_T"); err != nil {
+		return nil, err
+	}
+
+	nt.From = NewEllipsoid(majorf, minorf, nt.SystemFrom)
+	nt.To = NewEllipsoid(majort, minort, nt.SystemTo)
+
+	var unit float64
+	switch strings.ToUpper(gstype) {
+	case "SECONDS":
+		unit = 1
+	case "MINUTES":
+		unit = 60
+	case "DEGREES":
+		unit = 3600
+	default:
+		return nil, CartographyError{Coord: gstype, Index: 48, Err: ErrSyntax}
+	}
+
+	grids := make(map[string]*NTv2Grid)
+
+	for i := 0; i < numfile; i++ {
+		grid, err := nr.grid(unit)
+		if err != nil {
+			return nil, err
+		}
+
+		if parent, ok := grids[grid.Parent]; ok {
+			parent.children = append(parent.children, grid)
+		} else if strings.ToUpper(grid.Parent) == "NONE" {
+			nt.Grids = append(nt.Grids, grid)
+		} else {
+			return nil, CartographyError{Coord: grid.Parent, Index: nr.offset, Err: ErrSyntax}
+		}
+		grids[grid.Name] = grid
+	}
+
+	return &nt, nil
+}
+
+// Reads a sub grid of an NTv2 file, converting bounds and shifts of unit seconds into seconds
+func (nr *ntv2Reader) grid(unit float64) (*NTv2Grid, error) {
+
+	var g NTv2Grid
+	var err error
+	var count int
+
+	if g.Name, err = nr.string("SUB_NAME"); err != nil {
+		return nil, err
+	}
+	if g.Parent, err = nr.string("PARENT"); err != nil {
+		return nil, err
+	}
+	if _, err = nr.string("CREATED"); err != nil {
+		return nil, err
+	}
+	if _, err = nr.string("UPDATED"); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		key string
+		val *float64
+	}{{"S_LAT", &g.South}, {"N_LAT", &g.North}, {"E_LONG", &g.East}, {"W_LONG", &g.West}, {"LAT_INC", &g.LatInc}, {"LONG_INC", &g.LongInc}} {
+		if *field.val, err = nr.float(field.key); err != nil {
+			return nil, err
+		}
+		*field.val *= unit
+	}
+
+	if count, err = nr.int("GS_COUNT"); err != nil {
+		return nil, err
+	}
+
+	if g.LatInc <= 0 || g.LongInc <= 0 {
+		return nil, CartographyError{Coord: g.Name, Index: nr.offset, Err: ErrSyntax}
+	}
+	g.rows = int(math.Floor((g.North-g.South)/g.LatInc+0.5)) + 1
+	g.cols = int(math.Floor((g.West-g.East)/g.LongInc+0.5)) + 1
+	if g.rows < 2 || g.cols < 2 || g.rows*g.cols != count {
+		return nil, CartographyError{Coord: g.Name, Index: nr.offset, Err: ErrSyntax}
+	}
+
+	g.latshift = make([]float32, count)
+	g.longshift = make([]float32, count)
+
+	var node [16]byte
+	for i := 0; i < count; i++ {
+		if _, err := io.ReadFull(nr.r, node[:]); err != nil {
+			return nil, err
+		}
+		nr.offset += len(node)
+		g.latshift[i] = math.Float32frombits(nr.order.Uint32(node[0:4])) * float32(unit)
+		g.longshift[i] = math.Float32frombits(nr.order.Uint32(node[4:8])) * float32(unit)
+	}
+
+	return &g, nil
+}
+
+// Reads an NTv2 grid shift file from the file name. See LoadNTv2.
+func LoadNTv2File(name string) (*NTv2, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadNTv2(f)
+}
+
+// Returns true if the grid contains the latitude lat and the longitude longw, positive west, both in seconds
+func (g *NTv2Grid) contains(lat, longw float64) bool {
+	return lat >= g.South && lat <= g.North && longw >= g.East && longw <= g.West
+}
+
+// Bilinear interpolation of the shifts in seconds at the latitude lat and the longitude longw, positive west
+func (g *NTv2Grid) interpolate(lat, longw float64) (dlat, dlongw float64) {
+
+	x := (longw - g.East) / g.LongInc
+	y := (lat - g.South) / g.LatInc
+
+	col := int(math.Floor(x))
+	if col >= g.cols-1 {
+		col = g.cols - 2
+	}
+	row := int(math.Floor(y))
+	if row >= g.rows-1 {
+		row = g.rows - 2
+	}
+	fx, fy := x-float64(col), y-float64(row)
+
+	// nodes are ordered from south to north and east to west
+	i00 := row*g.cols + col
+	i01 := i00 + 1
+	i10 := i00 + g.cols
+	i11 := i10 + 1
+
+	bilinear := func(v []float32) float64 {
+		return float64(v[i00])*(1-fx)*(1-fy) + float64(v[i01])*fx*(1-fy) + float64(v[i10])*(1-fx)*fy + float64(v[i11])*fx*fy
+	}
+	return bilinear(g.latshift), bilinear(g.longshift)
+}
+
+// The densest grid containing the latitude lat and the longitude longw, positive west, both in seconds
+func densestNTv2Grid(grids []*NTv2Grid, lat, longw float64) *NTv2Grid {
+	for _, g := range grids {
+		if g.contains(lat, longw) {
+			if child := densestNTv2Grid(g.children, lat, longw); child != nil {
+				return child
+			}
+			return g
+		}
+	}
+	return nil
+}
+
+// Returns the shift of latitude and longitude in decimal degrees (longitude positive east) at pc, interpolated
+// in the densest grid containing pc. Returns false if pc lies outside all grids.
+func (nt *NTv2) Shift(pc *PolarCoord) (dlat, dlong float64, ok bool) {
+
+	lat, longw := pc.Latitude*3600, -pc.Longitude*3600
+
+	g := densestNTv2Grid(nt.Grids, lat, longw)
+	if g == nil {
+		return 0, 0, false
+	}
+
+	dlat, dlongw := g.interpolate(lat, longw)
+	return dlat / 3600, -dlongw / 3600, true
+}
+
+// Canonical representation of an NTv2 grid shift file
+func (nt *NTv2) String() string {
+	return fmt.Sprintf("NTv2[%s->%s](%d grids)", nt.SystemFrom, nt.SystemTo, len(nt.Grids))
+}
+
+// ## Grid based datum shift
+
+// How a coordinate has been shifted by a GridShift
+type ShiftMethod byte
+
+const (
+	// interpolated in the grid
+	ShiftGrid ShiftMethod = iota
+	// transformed by the fallback transformation, as the coordinate lies outside the grid
+	ShiftFallback
+)
+
+func (sm ShiftMethod) String() string {
+	switch sm {
+	case ShiftGrid:
+		return "Grid"
+	case ShiftFallback:
+		return "Fallback"
+	}
+	return "ShiftMethod(" + fmt.Sprint(byte(sm)) + ")"
+}
+
+// A datum shift by an NTv2 grid, which falls back to another transformation for coordinates outside the grid,
+// typically a GeocentricTransformer of Helmert parameters of the same datums.
+type GridShift struct {
+	Grid     *NTv2
+	Fallback DatumTransformer
+}
+
+// Returns the grid based datum shift of grid with the fallback transformation fallback, which might be nil
+func NewGridShift(grid *NTv2, fallback DatumTransformer) *GridShift {
+	return &GridShift{Grid: grid, Fallback: fallback}
+}
+
+// Shift pc of the source datum into the target datum. The method reports, whether pc has been interpolated
+// in the grid or transformed by the fallback transformation. Returns ErrRange if pc lies outside the
+// grid and there is no fallback transformation.
+func (gs *GridShift) Shift(pc *PolarCoord) (*PolarCoord, ShiftMethod, error) {
+
+	if dlat, dlong, ok := gs.Grid.Shift(pc); ok {
+		return &PolarCoord{Latitude: pc.Latitude + dlat, Longitude: pc.Longitude + dlong, Height: pc.Height, El: gs.Grid.To}, ShiftGrid, nil
+	}
+
+	if gs.Fallback == nil {
+		return nil, ShiftFallback, ErrRange
+	}
+	return gs.Fallback.TransformPolar(pc), ShiftFallback, nil
+}
+
+// Shift pc of the target datum back into the source datum. The source position is found iteratively, so
+// the result is the exact inverse of Shift. Reports the method and returns errors like Shift.
+func (gs *GridShift) InverseShift(pc *PolarCoord) (*PolarCoord, ShiftMethod, error) {
+
+	src := &PolarCoord{Latitude: pc.Latitude, Longitude: pc.Longitude, Height: pc.Height, El: gs.Grid.From}
+
+	for i := 0; i < 10; i++ {
+		dlat, dlong, ok := gs.Grid.Shift(src)
+		if !ok {
+			if gs.Fallback == nil {
+				return nil, ShiftFallback, ErrRange
+			}
+			return gs.Fallback.InverseTransformPolar(pc), ShiftFallback, nil
+		}
+
+		next := &PolarCoord{Latitude: pc.Latitude - dlat, Longitude: pc.Longitude - dlong, Height: pc.Height, El: gs.Grid.From}
+		converged := math.Abs(next.Latitude-src.Latitude) < 1e-11 && math.Abs(next.Longitude-src.Longitude) < 1e-11
+		src = next
+		if converged {
+			break
+		}
+	}
+	return src, ShiftGrid, nil
+}
+
+// Implements DatumTransformer. Returns nil if pc lies outside the grid and there is no fallback transformation.
+func (gs *GridShift) TransformPolar(pc *PolarCoord) *PolarCoord {
+	out, _, _ := gs.Shift(pc)
+	return out
+}
+
+// Implements DatumTransformer. Returns nil if pc lies outside the grid and there is no fallback transformation.
+func (gs *GridShift) InverseTransformPolar(pc *PolarCoord) *PolarCoord {
+	out, _, _ := gs.InverseShift(pc)
+	return out
+}
@@ -0,0 +1,218 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// ## LoadNTv2
+
+// A sub grid of a synthetic NTv2 file in degrees, longitudes positive east; the shifts in seconds
+// are given by the function shift of the latitude and the longitude, positive west
+type ntv2TestGrid struct {
+	name, parent             string
+	south, north, west, east float64
+	inc                      float64
+	shift                    func(lat, longw float64) (dlat, dlongw float64)
+}
+
+// A parent grid covering Austria with shifts linear in latitude and longitude, which are interpolated
+// exactly, and a denser sub grid around Vienna with a constant shift
+var ntv2TestGrids = []ntv2TestGrid{
+	{"AUSTRIA", "NONE", 46, 49, 9, 18, 1, func(lat, longw float64) (float64, float64) {
+		return 1 + 0.1*(lat-46*3600)/3600, -4 + 0.2*(longw+18*3600)/3600
+	}},
+	{"VIENNA", "AUSTRIA", 48, 48.5, 16, 16.5, 0.25, func(lat, longw float64) (float64, float64) {
+		return 5, -5
+	}},
+}
+
+// Write the NTv2 file of the grids in seconds in the byte order order
+func writeNTv2(order binary.ByteOrder, grids []ntv2TestGrid) []byte {
+
+	var buf bytes.Buffer
+
+	record := func(key string, value interface{}) {
+		var rec [16]byte
+		copy(rec[:8], fmt.Sprintf("%-8s", key))
+		switch v := value.(type) {
+		case int:
+			order.PutUint32(rec[8:12], uint32(v))
+		case float64:
+			order.PutUint64(rec[8:], math.Float64bits(v))
+		case string:
+			copy(rec[8:], fmt.Sprintf("%-8s", v))
+		}
+		buf.Write(rec[:])
+	}
+
+	record("NUM_OREC", 11)
+	record("NUM_SREC", 11)
+	record("NUM_FILE", len(grids))
+	record("GS_TYPE", "SECONDS")
+	record("VERSION", "NTv2.0")
+	record("SYSTEM_F", "MGI")
+	record("SYSTEM_T", "ETRS89")
+	record("MAJOR_F", 6377397.155)
+	record("MINOR_F", 6356078.962818)
+	record("MAJOR_T", 6378137.0)
+	record("MINOR_T", 6356752.314140)
+
+	for _, g := range grids {
+		rows := int((g.north-g.south)/g.inc) + 1
+		cols := int((g.east-g.west)/g.inc) + 1
+
+		record("SUB_NAME", g.name)
+		record("PARENT", g.parent)
+		record("CREATED", "20121231")
+		record("UPDATED", "20121231")
+		record("S_LAT", g.south*3600)
+		record("N_LAT", g.north*3600)
+		record("E_LONG", -g.east*3600)
+		record("W_LONG", -g.west*3600)
+		record("LAT_INC", g.inc*3600)
+		record("LONG_INC", g.inc*3600)
+		record("GS_COUNT", rows*cols)
+
+		for row := 0; row < rows; row++ {
+			for col := 0; col < cols; col++ {
+				dlat, dlongw := g.shift((g.south+float64(row)*g.inc)*3600, (-g.east+float64(col)*g.inc)*3600)
+
+				var node [16]byte
+				order.PutUint32(node[0:4], math.Float32bits(float32(dlat)))
+				order.PutUint32(node[4:8], math.Float32bits(float32(dlongw)))
+				buf.Write(node[:])
+			}
+		}
+	}
+	record("END", 0)
+
+	return buf.Bytes()
+}
+
+func TestLoadNTv2(t *testing.T) {
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		nt, err := LoadNTv2(bytes.NewReader(writeNTv2(order, ntv2TestGrids)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if nt.SystemFrom != "MGI" || nt.SystemTo != "ETRS89" || len(nt.Grids) != 1 || len(nt.Grids[0].children) != 1 {
+			t.Errorf("LoadNTv2 %s: unexpected grid %s", order, nt)
+		}
+		if nt.From.a != 6377397.155 || nt.To.a != 6378137.0 {
+			t.Errorf("LoadNTv2 %s: unexpected ellipsoids %f, %f", order, nt.From.a, nt.To.a)
+		}
+	}
+
+	valid := writeNTv2(binary.LittleEndian, ntv2TestGrids)
+
+	for cnt, in := range [][]byte{
+		// truncated shifts
+		valid[:len(valid)-100],
+		// unknown parent
+		writeNTv2(binary.LittleEndian, ntv2TestGrids[1:]),
+		// wrong key
+		append(append([]byte("NUM_XREC"), valid[8:16]...), valid[16:]...),
+	} {
+		if _, err := LoadNTv2(bytes.NewReader(in)); err == nil {
+			t.Errorf("LoadNTv2 [%d]: Expected an error", cnt)
+		}
+	}
+}
+
+// ## NTv2.Shift
+type ntv2ShiftTest struct {
+	in          *PolarCoord
+	dlat, dlong float64
+	ok          bool
+}
+
+var ntv2ShiftTests = []ntv2ShiftTest{
+	// parent grid, dlat = 1 + 0.1 * 1.5, dlongw = -4 + 0.2 * 4.75
+	{&PolarCoord{Latitude: 47.5, Longitude: 13.25}, 1.15, 3.05, true},
+	// nodes of the parent grid
+	{&PolarCoord{Latitude: 46, Longitude: 18}, 1, 4, true},
+	{&PolarCoord{Latitude: 49, Longitude: 9}, 1.3, 2.2, true},
+	// sub grid
+	{&PolarCoord{Latitude: 48.2082, Longitude: 16.3738}, 5, 5, true},
+	{&PolarCoord{Latitude: 48.5, Longitude: 16}, 5, 5, true},
+	// outside
+	{&PolarCoord{Latitude: 45.9, Longitude: 13}, 0, 0, false},
+	{&PolarCoord{Latitude: 47, Longitude: 18.1}, 0, 0, false},
+}
+
+func TestNTv2Shift(t *testing.T) {
+
+	nt, err := LoadNTv2(bytes.NewReader(writeNTv2(binary.LittleEndian, ntv2TestGrids)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cnt, test := range ntv2ShiftTests {
+		dlat, dlong, ok := nt.Shift(test.in)
+		if ok != test.ok || math.Abs(dlat*3600-test.dlat) > 1e-5 || math.Abs(dlong*3600-test.dlong) > 1e-5 {
+			t.Errorf("NTv2.Shift [%d]: expected %f\", %f\" %t, got %f\", %f\" %t", cnt, test.dlat, test.dlong, test.ok, dlat*3600, dlong*3600, ok)
+		}
+	}
+}
+
+// ## GridShift
+func TestGridShift(t *testing.T) {
+
+	nt, err := LoadNTv2(bytes.NewReader(writeNTv2(binary.LittleEndian, ntv2TestGrids)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fallback := NewGeocentricTransformer(NewHelmertTransformer(577.326, 90.129, 463.919, 2.4232, 5.137, 1.474, 5.297, "MGItoWGS84"), Bessel1841MGIEllipsoid, WGS84Ellipsoid)
+
+	inside := &PolarCoord{Latitude: 47.5, Longitude: 13.25, Height: 500, El: Bessel1841MGIEllipsoid}
+	outside := &PolarCoord{Latitude: 45, Longitude: 13.25, Height: 500, El: Bessel1841MGIEllipsoid}
+
+	gs := NewGridShift(nt, fallback)
+
+	out, method, err := gs.Shift(inside)
+	expected := &PolarCoord{Latitude: 47.5 + 1.15/3600, Longitude: 13.25 + 3.05/3600}
+	if err != nil || method != ShiftGrid || !latlongmmequal(expected, out) || out.Height != 500 || out.El != nt.To {
+		t.Errorf("GridShift.Shift: expected %s by %s, got %s by %s (%v)", expected, ShiftGrid, out, method, err)
+	}
+
+	back, method, err := gs.InverseShift(out)
+	if err != nil || method != ShiftGrid || !latlongmmequal(inside, back) || back.El != nt.From {
+		t.Errorf("GridShift.InverseShift: expected %s by %s, got %s by %s (%v)", inside, ShiftGrid, back, method, err)
+	}
+
+	out, method, err = gs.Shift(outside)
+	expected = fallback.TransformPolar(outside)
+	if err != nil || method != ShiftFallback || !latlongmmequal(expected, out) {
+		t.Errorf("GridShift.Shift: expected %s by %s, got %s by %s (%v)", expected, ShiftFallback, out, method, err)
+	}
+
+	back, method, err = gs.InverseShift(out)
+	if err != nil || method != ShiftFallback || !latlongmmequal(outside, back) {
+		t.Errorf("GridShift.InverseShift: expected %s by %s, got %s by %s (%v)", outside, ShiftFallback, back, method, err)
+	}
+
+	// no fallback
+	gs = NewGridShift(nt, nil)
+
+	if _, _, err := gs.Shift(outside); err != ErrRange {
+		t.Errorf("GridShift.Shift: expected %s outside the grid, got %v", ErrRange, err)
+	}
+	if _, _, err := gs.InverseShift(outside); err != ErrRange {
+		t.Errorf("GridShift.InverseShift: expected %s outside the grid, got %v", ErrRange, err)
+	}
+	if out := gs.TransformPolar(outside); out != nil {
+		t.Errorf("GridShift.TransformPolar: expected nil outside the grid, got %s", out)
+	}
+}
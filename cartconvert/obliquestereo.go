@@ -0,0 +1,128 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+)
+
+// ## Oblique Stereographic Projection
+
+// Parameters of the conformal sphere of the oblique stereographic double projection for the
+// latitude of origin latO in radians: the radius of the conformal sphere r, the exponents n and c
+// and the conformal latitude of origin chiO
+func obliqueStereographicParameters(el *Ellipsoid, latO float64) (r, n, c, chiO float64) {
+	e2 := (el.a*el.a - el.b*el.b) / (el.a * el.a)
+	e := math.Sqrt(e2)
+
+	sinlatO := math.Sin(latO)
+	coslatO := math.Cos(latO)
+	rho := el.a * (1 - e2) / math.Pow(1-e2*sinlatO*sinlatO, 1.5)
+	nu := el.a / math.Sqrt(1-e2*sinlatO*sinlatO)
+
+	r = math.Sqrt(rho * nu)
+	n = math.Sqrt(1 + e2*coslatO*coslatO*coslatO*coslatO/(1-e2))
+
+	s1 := (1 + sinlatO) / (1 - sinlatO)
+	s2 := (1 - e*sinlatO) / (1 + e*sinlatO)
+	w1 := math.Pow(s1*math.Pow(s2, e), n)
+	sinchiO := (w1 - 1) / (w1 + 1)
+	c = (n + sinlatO) * (1 - sinchiO) / ((n - sinlatO) * (1 + sinchiO))
+	w2 := c * w1
+	chiO = math.Asin((w2 - 1) / (w2 + 1))
+	return
+}
+
+// Direct oblique stereographic projection: Conformal projection of the ellipsoid onto a sphere which is
+// projected onto a plane touching the sphere at the origin. Input parameters:
+//
+//	gc *PolarCoord: Latitude and Longitude or point to be projected; in decimal degrees
+//	latO, longO: Latitude and longitude of natural origin in decimal degrees
+//	scale: Projection scaling at the natural origin; Dimensionless, typically 1 or little bellow
+//	fe, fn: False easting and northing respectively in meters
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Oblique and Equatorial Stereographic, pp. 64 - 66
+func DirectObliqueStereographic(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
+
+	var pt GeoPoint
+
+	el := gc.El
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+	r, n, c, chiO := obliqueStereographicParameters(el, degtorad(latO))
+
+	lat := degtorad(gc.Latitude)
+	sinlat := math.Sin(lat)
+
+	dlong := n * degtorad(gc.Longitude-longO)
+
+	sa := (1 + sinlat) / (1 - sinlat)
+	sb := (1 - e*sinlat) / (1 + e*sinlat)
+	w := c * math.Pow(sa*math.Pow(sb, e), n)
+	chi := math.Asin((w - 1) / (w + 1))
+
+	b := 1 + math.Sin(chi)*math.Sin(chiO) + math.Cos(chi)*math.Cos(chiO)*math.Cos(dlong)
+
+	pt.X = fe + 2*r*scale*math.Cos(chi)*math.Sin(dlong)/b
+	pt.Y = fn + 2*r*scale*(math.Sin(chi)*math.Cos(chiO)-math.Cos(chi)*math.Sin(chiO)*math.Cos(dlong))/b
+
+	pt.El = el
+	pt.H = gc.Height
+
+	return &pt
+}
+
+// Inverse oblique stereographic projection: Projection of a plane touching the conformal sphere at the
+// origin back onto the ellipsoid. Input parameters:
+//
+//	pt *GeoPoint: Easting(X) and Northing(Y) of map point to be projected; in meters
+//	latO, longO: Latitude and longitude of natural origin in decimal degrees
+//	scale: Projection scaling at the natural origin; Dimensionless, typically 1 or little bellow
+//	fe, fn: False easting and northing respectively in meters
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Oblique and Equatorial Stereographic, pp. 64 - 66
+func InverseObliqueStereographic(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
+
+	var gc PolarCoord
+
+	el := pt.El
+	e2 := (el.a*el.a - el.b*el.b) / (el.a * el.a)
+	e := math.Sqrt(e2)
+	r, n, c, chiO := obliqueStereographicParameters(el, degtorad(latO))
+
+	de := pt.X - fe
+	dn := pt.Y - fn
+
+	g := 2 * r * scale * math.Tan(math.Pi/4-chiO/2)
+	h := 4*r*scale*math.Tan(chiO) + g
+	i := math.Atan(de / (h + dn))
+	j := math.Atan(de/(g-dn)) - i
+
+	chi := chiO + 2*math.Atan((dn-de*math.Tan(j/2))/(2*r*scale))
+	dlong := j + 2*i
+
+	// isometric latitude of the conformal sphere, iterated onto the ellipsoid
+	psi := 0.5 * math.Log((1+math.Sin(chi))/(c*(1-math.Sin(chi)))) / n
+	lat := 2*math.Atan(math.Exp(psi)) - math.Pi/2
+	for k := 0; k < 20; k++ {
+		esin := e * math.Sin(lat)
+		psii := math.Log(math.Tan(lat/2+math.Pi/4) * math.Pow((1-esin)/(1+esin), e/2))
+		latn := lat - (psii-psi)*math.Cos(lat)*(1-esin*esin)/(1-e2)
+		if math.Abs(latn-lat) < 1e-12 {
+			lat = latn
+			break
+		}
+		lat = latn
+	}
+
+	gc.Latitude = radtodeg(lat)
+	gc.Longitude = longO + radtodeg(dlong/n)
+
+	gc.El = el
+	gc.Height = pt.H
+
+	return &gc
+}
@@ -0,0 +1,53 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// ## Oblique Stereographic
+var obliqueStereographicTests = []directtransversemercatorTest{
+	{
+		// OGP Publication 373-7-2, Amersfoort / RD New
+		directtransversemercatorParam{
+			&PolarCoord{Latitude: 53, Longitude: 6, El: Bessel1841Ellipsoid},
+			52 + 9.0/60 + 22.178/3600,
+			5 + 23.0/60 + 15.5/3600,
+			0.9999079,
+			155000,
+			463000,
+		},
+		&GeoPoint{X: 196105.283, Y: 557057.739},
+	},
+	{
+		// natural origin
+		directtransversemercatorParam{
+			&PolarCoord{Latitude: 52 + 9.0/60 + 22.178/3600, Longitude: 5 + 23.0/60 + 15.5/3600, El: Bessel1841Ellipsoid},
+			52 + 9.0/60 + 22.178/3600,
+			5 + 23.0/60 + 15.5/3600,
+			0.9999079,
+			155000,
+			463000,
+		},
+		&GeoPoint{X: 155000, Y: 463000},
+	},
+}
+
+func TestObliqueStereographic(t *testing.T) {
+	for cnt, test := range obliqueStereographicTests {
+		out := DirectObliqueStereographic(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !geopointcmequal(test.out, out) {
+			t.Errorf("DirectObliqueStereographic [%d]: Expected %v, got %v", cnt, test.out, out)
+		}
+
+		pt := &GeoPoint{X: test.out.X, Y: test.out.Y, El: test.in.pc.El}
+		pc := InverseObliqueStereographic(pt, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !latlongmmequal(test.in.pc, pc) {
+			t.Errorf("InverseObliqueStereographic [%d]: Expected %s, got %s", cnt, test.in.pc, pc)
+		}
+	}
+}
@@ -8,8 +8,9 @@
 // The conversion between WGS84 and OSGB36 uses a simple helmert transformation,
 // which in the case of OSGB36 inconsistencies, may result in an accuracy not exceeding +/- 5m, and
 // for cornercases worse than +/- 15m.
-// If a higher accuracy is required, a set of helmert parameters must be used or the
-// procedure described at http://www.ordnancesurvey.co.uk/gps/docs/Geomatics_world.pdf.
+// If a higher accuracy is required, the official OSTN15 transformation can be loaded from the data file
+// published by the Ordnance Survey by LoadOSTN15File and set as DefaultOSTN15. This also converts heights
+// between ODN and ETRS89 ellipsoidal heights by the geoid model OSGM15.
 //
 // For further info see http://gps.ordnancesurvey.co.uk/etrs89geo_natgrid.asp
 package osgb36
@@ -22,12 +23,14 @@ import (
 	"strings"
 )
 
-// A OSGB36 coordinate is specified by zone, easting and northing.
+// A OSGB36 coordinate is specified by zone, easting and northing. RelHeight is the ellipsoidal height above
+// the Airy ellipsoid, or the height of the local height datum if transformed by DefaultOSTN15.
 type OSGB36Coord struct {
 	Easting, Northing uint
 	RelHeight         float64
 	Zone              string
 	El                *cartconvert.Ellipsoid
+	Datum             *cartconvert.Datum
 	gridLen           byte
 }
 
@@ -165,22 +168,52 @@ func OSGB36ZoneToRefCoords(coord *OSGB36Coord) (easting, northing uint) {
 // For the point at NN1000010000 it is necessary to fully qualify northing and easting.
 //
 // Plain grid zone specifiers will NOT be shifted towards the middle of the square.
+//
+// If DefaultOSTN15 is set and the coordinate lies within its grid and OSGM15, the coordinate is transformed by
+// OSTN15 and RelHeight is taken as the height of the local height datum and converted to the ellipsoidal height.
+// Otherwise RelHeight is taken as the height above the Airy ellipsoid.
 func OSGB36ToWGS84LatLong(coord *OSGB36Coord) *cartconvert.PolarCoord {
+	pc, _, _ := osgb36ToWGS84LatLong(coord, cartconvert.DefaultTMAlgorithm)
+	return pc
+}
+
+// Like OSGB36ToWGS84LatLong, but projects by the transverse mercator algorithm alg. OSTN15 is always
+// applied with the projection it is defined by.
+func OSGB36ToWGS84LatLongAlgorithm(coord *OSGB36Coord, alg cartconvert.TMAlgorithm) *cartconvert.PolarCoord {
+	pc, _, _ := osgb36ToWGS84LatLong(coord, alg)
+	return pc
+}
+
+// Like OSGB36ToWGS84LatLong, but additionally reports whether the coordinate has been transformed by
+// DefaultOSTN15 or by the fallback helmert transformation, and the height datum of RelHeight, which is
+// HeightDatumNone for the height above the Airy ellipsoid of the fallback.
+func OSGB36ToWGS84LatLongShift(coord *OSGB36Coord) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, HeightDatum) {
+	return osgb36ToWGS84LatLong(coord, cartconvert.DefaultTMAlgorithm)
+}
+
+func osgb36ToWGS84LatLong(coord *OSGB36Coord, alg cartconvert.TMAlgorithm) (*cartconvert.PolarCoord, cartconvert.ShiftMethod, HeightDatum) {
+
+	if DefaultOSTN15 != nil {
+		if gc, datum, err := DefaultOSTN15.OSGB36ToETRS89LatLong(coord); err == nil {
+			gc.El = cartconvert.WGS84Ellipsoid
+			gc.Datum = cartconvert.WGS84Datum
+			return gc, cartconvert.ShiftGrid, datum
+		}
+	}
 
 	easting, northing := OSGB36ZoneToRefCoords(coord)
 
-	gc := cartconvert.InverseTransverseMercator(
-		&cartconvert.GeoPoint{Y: float64(northing), X: float64(easting), El: coord.El},
+	gc := alg.InverseTransverseMercator(
+		&cartconvert.GeoPoint{Y: float64(northing), X: float64(easting), H: coord.RelHeight, El: coord.El},
 		49,
 		-2,
 		0.9996012717,
 		400000,
 		-100000)
 
-	cart := cartconvert.PolarToCartesian(gc)
-	pt := cartconvert.HelmertWGS84ToOSGB36.InverseTransform(&cartconvert.Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
-
-	return cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.WGS84Ellipsoid})
+	// the helmert transformation of the datum covers every coordinate
+	pc, _ := cartconvert.TransformDatum(gc, cartconvert.OSGB36Datum, cartconvert.WGS84Datum)
+	return pc, cartconvert.ShiftFallback, HeightDatumNone
 }
 
 // Perform formating on an OSGB36 datum. For formatting see OSGB36prec.
@@ -257,22 +290,53 @@ func GridRefNumToLet(easting, northing uint, height float64, prec OSGB36prec) (*
 	easting %= 100000
 	northing %= 100000
 
-	return NewOSGB36Coord(zone, easting, northing, 0, 5, OSGB36Leave), nil
+	return NewOSGB36Coord(zone, easting, northing, height, 5, OSGB36Leave), nil
 }
 
 // Transform a latitude / longitude coordinate datum into a OSGB36 coordinate.
 //
 // Important: The reference ellipsoid of the originating coordinate system will be assumed
-// to be the WGS84Ellipsoid and will be set thereupon, regardless of the actually set reference ellipsoid.
+// to be the WGS84Ellipsoid, regardless of the actually set reference ellipsoid.
+//
+// If DefaultOSTN15 is set and the coordinate lies within its grid and OSGM15, the coordinate is transformed by
+// OSTN15 and the ellipsoidal height converted into the RelHeight of the local height datum. Otherwise RelHeight
+// is the height above the Airy ellipsoid.
 func WGS84LatLongToOSGB36(gc *cartconvert.PolarCoord) (*OSGB36Coord, error) {
-	// This sets the Ellipsoid to WGS84, regardless of the actual value set
+	coord, _, _, err := wgs84LatLongToOSGB36(gc, cartconvert.DefaultTMAlgorithm)
+	return coord, err
+}
+
+// Like WGS84LatLongToOSGB36, but projects by the transverse mercator algorithm alg. OSTN15 is always
+// applied with the projection it is defined by.
+func WGS84LatLongToOSGB36Algorithm(gc *cartconvert.PolarCoord, alg cartconvert.TMAlgorithm) (*OSGB36Coord, error) {
+	coord, _, _, err := wgs84LatLongToOSGB36(gc, alg)
+	return coord, err
+}
+
+// Like WGS84LatLongToOSGB36, but additionally reports whether the coordinate has been transformed by
+// DefaultOSTN15 or by the fallback helmert transformation, and the height datum of RelHeight, which is
+// HeightDatumNone for the height above the Airy ellipsoid of the fallback.
+func WGS84LatLongToOSGB36Shift(gc *cartconvert.PolarCoord) (*OSGB36Coord, cartconvert.ShiftMethod, HeightDatum, error) {
+	return wgs84LatLongToOSGB36(gc, cartconvert.DefaultTMAlgorithm)
+}
+
+func wgs84LatLongToOSGB36(gcin *cartconvert.PolarCoord, alg cartconvert.TMAlgorithm) (*OSGB36Coord, cartconvert.ShiftMethod, HeightDatum, error) {
+	// This sets the Ellipsoid to WGS84 on a copy, regardless of the actual value set
+	gc := *gcin
 	gc.El = cartconvert.WGS84Ellipsoid
 
-	cart := cartconvert.PolarToCartesian(gc)
-	pt := cartconvert.HelmertWGS84ToOSGB36.Transform(&cartconvert.Point3D{X: cart.X, Y: cart.Y, Z: cart.Z})
-	polar := cartconvert.CartesianToPolar(&cartconvert.CartPoint{X: pt.X, Y: pt.Y, Z: pt.Z, El: cartconvert.Airy1830Ellipsoid})
+	if DefaultOSTN15 != nil {
+		if coord, datum, err := DefaultOSTN15.ETRS89LatLongToOSGB36(&gc); err == nil {
+			return coord, cartconvert.ShiftGrid, datum, nil
+		}
+	}
+
+	polar, err := cartconvert.TransformDatum(&gc, cartconvert.WGS84Datum, cartconvert.OSGB36Datum)
+	if err != nil {
+		return nil, cartconvert.ShiftFallback, HeightDatumNone, err
+	}
 
-	gp := cartconvert.DirectTransverseMercator(
+	gp := alg.DirectTransverseMercator(
 		polar,
 		49,
 		-2,
@@ -280,7 +344,8 @@ func WGS84LatLongToOSGB36(gc *cartconvert.PolarCoord) (*OSGB36Coord, error) {
 		400000,
 		-100000)
 
-	return GridRefNumToLet(uint(gp.X+0.5), uint(gp.Y+0.5), 0, OSGB36_Max)
+	coord, err := GridRefNumToLet(uint(gp.X+0.5), uint(gp.Y+0.5), gp.H, OSGB36_Max)
+	return coord, cartconvert.ShiftFallback, HeightDatumNone, err
 }
 
 func max(x, y int) int {
@@ -300,5 +365,49 @@ func max(x, y int) int {
 //
 func NewOSGB36Coord(Zone string, easting, northing uint, relheight float64, inputprec byte, desiredprec OSGB36prec) *OSGB36Coord {
 	effbytes := SanitizeOSGB36CoordToPrec(&easting, &northing, inputprec, desiredprec)
-	return &OSGB36Coord{Easting: easting, Northing: northing, RelHeight: relheight, Zone: Zone, gridLen: effbytes, El: cartconvert.Airy1830Ellipsoid}
+	return &OSGB36Coord{Easting: easting, Northing: northing, RelHeight: relheight, Zone: Zone, gridLen: effbytes, El: cartconvert.Airy1830Ellipsoid, Datum: cartconvert.OSGB36Datum}
+}
+
+// The coordinate reference system of the British National Grid on the datum OSGB36
+var NationalGridCRS = &cartconvert.CRS{
+	Name:       "OSGB36 / British National Grid",
+	Datum:      cartconvert.OSGB36Datum,
+	Projection: &cartconvert.Projection{Method: cartconvert.TransverseMercator, LatO: 49, LongO: -2, Scale: 0.9996012717, FE: 400000, FN: -100000},
+}
+
+// The UK National Grid as a cartconvert.CoordinateSystem
+type osgb36System struct{}
+
+func (osgb36System) Name() string        { return "osgb" }
+func (osgb36System) Description() string { return "UK:OSGB36" }
+
+func (osgb36System) Parse(coord string) (fmt.Stringer, error) {
+	return AOSGB36ToStruct(coord, OSGB36Leave)
+}
+
+func (osgb36System) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*OSGB36Coord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (osgb36System) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	osgb36coord, ok := coord.(*OSGB36Coord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return OSGB36ToWGS84LatLong(osgb36coord), nil
+}
+
+func (osgb36System) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return WGS84LatLongToOSGB36(pc)
+}
+
+func (osgb36System) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	return NationalGridCRS, nil
+}
+
+func init() {
+	cartconvert.RegisterCoordinateSystem(osgb36System{})
 }
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"github.com/the42/cartconvert/cartconvert"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -143,10 +144,12 @@ func latlongequal(pcp1, pcp2 *cartconvert.PolarCoord) bool {
 func TestOSGB36ToWGS84LatLong(t *testing.T) {
 	for cnt, test := range oSGB36ToWGS84LatLongTests {
 
-		out := OSGB36ToWGS84LatLong(test.in)
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			out := OSGB36ToWGS84LatLongAlgorithm(test.in, alg)
 
-		if !latlongequal(test.out, out) {
-			t.Errorf("OSGB36ToWGS84LatLong:%d [%s]: Expected %s, got %s", cnt, test.in, test.out, out)
+			if !latlongequal(test.out, out) {
+				t.Errorf("OSGB36ToWGS84LatLongAlgorithm:%d [%s, %s]: Expected %s, got %s", cnt, test.in, alg, test.out, out)
+			}
 		}
 	}
 }
@@ -180,13 +183,57 @@ func osgb36fuzzyequal(osgb1, osgb2 *OSGB36Coord) bool {
 
 func TestWGS84LatLongToOSGB36(t *testing.T) {
 	for cnt, test := range wGS84LatLongToOSGB36Tests {
-		out, err := WGS84LatLongToOSGB36(test.in)
-		if err != nil {
-			t.Errorf("WGS84LatLongToOSGB36 [%d]: Error: %s", cnt, err)
-		} else {
-			if !osgb36fuzzyequal(test.out, out) {
-				t.Errorf("WGS84LatLongToOSGB36:%d [%s]: Expected %s, got %s", cnt, test.in, test.out, out)
+		for _, alg := range []cartconvert.TMAlgorithm{cartconvert.TMRedfearn, cartconvert.TMKrueger} {
+			out, err := WGS84LatLongToOSGB36Algorithm(test.in, alg)
+			if err != nil {
+				t.Errorf("WGS84LatLongToOSGB36Algorithm [%d, %s]: Error: %s", cnt, alg, err)
+			} else {
+				if !osgb36fuzzyequal(test.out, out) {
+					t.Errorf("WGS84LatLongToOSGB36Algorithm:%d [%s, %s]: Expected %s, got %s", cnt, test.in, alg, test.out, out)
+				}
 			}
 		}
 	}
 }
+
+// ## 3D round trip
+func TestOSGB36Height(t *testing.T) {
+
+	in := &cartconvert.PolarCoord{Latitude: 53.799638, Longitude: -1.5491515, Height: 112.7, El: cartconvert.WGS84Ellipsoid}
+
+	coord, err := WGS84LatLongToOSGB36(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the height above the Airy ellipsoid differs by the datum shift
+	if math.Abs(coord.RelHeight-in.Height) < 1 {
+		t.Errorf("WGS84LatLongToOSGB36: Expected the height to be shifted, got %f", coord.RelHeight)
+	}
+
+	// the grid reference is rounded to the meter
+	out := OSGB36ToWGS84LatLong(coord)
+	if !latlongequal(in, out) || math.Abs(out.Height-in.Height) > 0.01 {
+		t.Errorf("OSGB36ToWGS84LatLong: Expected %s at %fm, got %s at %fm", in, in.Height, out, out.Height)
+	}
+}
+
+// ## Coordinate reference system
+func TestNationalGridCRS(t *testing.T) {
+
+	// SE 29793 33798
+	out, err := NationalGridCRS.ToWGS84(&cartconvert.GeoPoint{X: 429793, Y: 433798})
+	if expected := oSGB36ToWGS84LatLongTests[0].out; err != nil || !latlongequal(expected, out) {
+		t.Errorf("CRS.ToWGS84: Expected %s, got %v (%v)", expected, out, err)
+	}
+
+	wkt, err := NationalGridCRS.WKT()
+	if err != nil || !strings.HasPrefix(wkt, `PROJCS["OSGB36 / British National Grid",GEOGCS["OSGB36",DATUM["OSGB_1936",`) {
+		t.Errorf("CRS.WKT: Expected OSGB36 / British National Grid, got %s (%v)", wkt, err)
+	}
+
+	crs, err := cartconvert.ParseWKT(wkt)
+	if err != nil || crs.Datum != cartconvert.OSGB36Datum || *crs.Projection != *NationalGridCRS.Projection {
+		t.Errorf("ParseWKT: Expected %s, got %v (%v)", NationalGridCRS, crs, err)
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package osgb36
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ## OSTN15 / OSGM15
+
+// OSTN15 is the official rubber-sheet transformation between ETRS89 and the OSGB36 National Grid, OSGM15 the
+// geoid model relating ETRS89 ellipsoidal heights to ODN and the other local height datums of Great Britain.
+// Both are published by the Ordnance Survey as one data file, giving the shifts of easting, northing and height
+// at the nodes of a 1km grid of ETRS89 eastings and northings. Between the nodes the shifts are interpolated
+// bilinearly.
+
+const (
+	ostnSpacing = 1000
+	// number of nodes from east to west and from south to north
+	ostnColumns = 701
+	ostnRows    = 1251
+)
+
+// The OSGM15 height datum flag of a grid node, to which heights of the node refer. Flag 1 is ODN (Newlyn),
+// the others are the local height datums of islands as listed by the Ordnance Survey.
+type HeightDatum byte
+
+const (
+	// the point lies outside OSGM15; no height transformation is defined
+	HeightDatumNone HeightDatum = 0
+	HeightDatumODN  HeightDatum = 1
+)
+
+func (hd HeightDatum) String() string {
+	switch hd {
+	case HeightDatumNone:
+		return "None"
+	case HeightDatumODN:
+		return "ODN"
+	}
+	return "HeightDatum(" + fmt.Sprint(byte(hd)) + ")"
+}
+
+// Shifts of a node of the grid in meters
+type ostnNode struct {
+	se, sn, sg float32
+	datum      HeightDatum
+	ok         bool
+}
+
+// The OSTN15 transformation and OSGM15 geoid model. Instances are created by LoadOSTN15.
+type OSTN15 struct {
+	nodes []ostnNode
+	count int
+}
+
+// If set, OSGB36ToWGS84LatLong and WGS84LatLongToOSGB36 transform by OSTN15 instead of the helmert
+// parameters cartconvert.HelmertWGS84ToOSGB36, taking WGS84 as ETRS89, and convert the RelHeight of OSGB36
+// coordinates to and from heights of the local height datum by OSGM15. Coordinates outside the grid or outside
+// OSGM15 fall back to the helmert transformation, their RelHeight is the height above the Airy ellipsoid.
+// OSGB36ToWGS84LatLongShift and WGS84LatLongToOSGB36Shift report which transformation has been used.
+var DefaultOSTN15 *OSTN15
+
+// Reads the OSTN15 / OSGM15 data file from r. The file is a comma separated list of one grid node per line:
+//
+//	Point_ID,ETRS89_Easting,ETRS89_Northing,ETRS89_OSGB36_EShift,ETRS89_OSGB36_NShift,ETRS89_ODN_HeightShift,Height_Datum_Flag
+//
+// The header line, blank lines and lines starting with '#' are ignored. Nodes may be missing, transformations
+// in their vicinity return cartconvert.ErrRange.
+//
+// Returns a cartconvert.CartographyError with cartconvert.ErrSyntax as the error on malformed lines, the
+// line number as Index.
+func LoadOSTN15(r io.Reader) (*OSTN15, error) {
+
+	ostn := &OSTN15{nodes: make([]ostnNode, ostnColumns*ostnRows)}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(strings.ToLower(text), "point_id") {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+		synerr := cartconvert.CartographyError{Coord: text, Index: line, Err: cartconvert.ErrSyntax}
+
+		if len(fields) != 7 {
+			return nil, synerr
+		}
+
+		var values [5]float64
+		for i := range values {
+			val, err := strconv.ParseFloat(strings.TrimSpace(fields[i+1]), 64)
+			if err != nil {
+				return nil, synerr
+			}
+			values[i] = val
+		}
+
+		flag, err := strconv.ParseUint(strings.TrimSpace(fields[6]), 10, 8)
+		if err != nil {
+			return nil, synerr
+		}
+
+		col, row := int(values[0])/ostnSpacing, int(values[1])/ostnSpacing
+		if values[0] != float64(col*ostnSpacing) || values[1] != float64(row*ostnSpacing) ||
+			col < 0 || col >= ostnColumns || row < 0 || row >= ostnRows {
+			return nil, synerr
+		}
+
+		node := &ostn.nodes[row*ostnColumns+col]
+		if !node.ok {
+			ostn.count++
+		}
+		*node = ostnNode{se: float32(values[2]), sn: float32(values[3]), sg: float32(values[4]), datum: HeightDatum(flag), ok: true}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ostn, nil
+}
+
+// Reads the OSTN15 / OSGM15 data file from the file name. See LoadOSTN15 for the file format.
+func LoadOSTN15File(name string) (*OSTN15, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadOSTN15(f)
+}
+
+// Number of grid nodes read
+func (ostn *OSTN15) Len() int {
+	return ostn.count
+}
+
+// Canonical representation of the OSTN15 grid
+func (ostn *OSTN15) String() string {
+	return fmt.Sprintf("OSTN15(%d nodes)", ostn.count)
+}
+
+// Returns the shifts of easting se, northing sn and the geoid height sg in meters at the ETRS89 easting
+// and northing, interpolated bilinearly between the surrounding grid nodes. The height datum is the one of the
+// nearest node. Returns cartconvert.ErrRange if the point lies outside the grid.
+func (ostn *OSTN15) Shift(easting, northing float64) (se, sn, sg float64, datum HeightDatum, err error) {
+
+	if easting < 0 || northing < 0 {
+		return 0, 0, 0, HeightDatumNone, cartconvert.ErrRange
+	}
+
+	col, row := int(easting/ostnSpacing), int(northing/ostnSpacing)
+	if col >= ostnColumns-1 || row >= ostnRows-1 {
+		return 0, 0, 0, HeightDatumNone, cartconvert.ErrRange
+	}
+
+	// nodes counterclockwise from the south-west
+	i0 := row*ostnColumns + col
+	nodes := [4]*ostnNode{&ostn.nodes[i0], &ostn.nodes[i0+1], &ostn.nodes[i0+ostnColumns+1], &ostn.nodes[i0+ostnColumns]}
+	for _, node := range nodes {
+		if !node.ok {
+			return 0, 0, 0, HeightDatumNone, cartconvert.ErrRange
+		}
+	}
+
+	t := (easting - float64(col*ostnSpacing)) / ostnSpacing
+	u := (northing - float64(row*ostnSpacing)) / ostnSpacing
+	weights := [4]float64{(1 - t) * (1 - u), t * (1 - u), t * u, (1 - t) * u}
+
+	for i, node := range nodes {
+		se += weights[i] * float64(node.se)
+		sn += weights[i] * float64(node.sn)
+		sg += weights[i] * float64(node.sg)
+	}
+
+	nearest := 0
+	switch {
+	case t >= 0.5 && u < 0.5:
+		nearest = 1
+	case t >= 0.5 && u >= 0.5:
+		nearest = 2
+	case t < 0.5 && u >= 0.5:
+		nearest = 3
+	}
+	datum = nodes[nearest].datum
+
+	return
+}
+
+// Transform the ETRS89 easting, northing and ellipsoidal height into the OSGB36 easting, northing and the
+// height in the returned height datum. Returns cartconvert.ErrRange if the point lies outside the grid or
+// outside OSGM15, where the height datum is HeightDatumNone and the height can not be transformed.
+func (ostn *OSTN15) ETRS89ToOSGB36(easting, northing, height float64) (e, n, h float64, datum HeightDatum, err error) {
+
+	se, sn, sg, datum, err := ostn.Shift(easting, northing)
+	if err != nil {
+		return 0, 0, 0, HeightDatumNone, err
+	}
+	if datum == HeightDatumNone {
+		return 0, 0, 0, HeightDatumNone, cartconvert.ErrRange
+	}
+
+	return easting + se, northing + sn, height - sg, datum, nil
+}
+
+// Transform the OSGB36 easting, northing and the height of the local height datum into the ETRS89 easting,
+// northing and ellipsoidal height. The ETRS89 position is found iteratively, so the result is the exact
+// inverse of ETRS89ToOSGB36. Returns cartconvert.ErrRange if the point lies outside the grid or outside
+// OSGM15, where the height datum is HeightDatumNone and the height can not be transformed.
+func (ostn *OSTN15) OSGB36ToETRS89(easting, northing, height float64) (e, n, h float64, datum HeightDatum, err error) {
+
+	var se, sn, sg float64
+	e, n = easting, northing
+
+	for i := 0; i < 20; i++ {
+		if se, sn, sg, datum, err = ostn.Shift(e, n); err != nil {
+			return 0, 0, 0, HeightDatumNone, err
+		}
+
+		nexte, nextn := easting-se, northing-sn
+		converged := math.Abs(nexte-e) < 0.0001 && math.Abs(nextn-n) < 0.0001
+		e, n = nexte, nextn
+		if converged {
+			break
+		}
+	}
+
+	if datum == HeightDatumNone {
+		return 0, 0, 0, HeightDatumNone, cartconvert.ErrRange
+	}
+	return e, n, height + sg, datum, nil
+}
+
+// Transform an ETRS89 latitude / longitude coordinate with ellipsoidal height into a OSGB36 coordinate, whose
+// RelHeight refers to the returned height datum. Returns cartconvert.ErrRange if the coordinate lies outside
+// the grid or outside OSGM15.
+func (ostn *OSTN15) ETRS89LatLongToOSGB36(gc *cartconvert.PolarCoord) (*OSGB36Coord, HeightDatum, error) {
+
+	gp := cartconvert.DefaultTMAlgorithm.DirectTransverseMercator(
+		&cartconvert.PolarCoord{Latitude: gc.Latitude, Longitude: gc.Longitude, El: cartconvert.GRS80Ellipsoid},
+		49,
+		-2,
+		0.9996012717,
+		400000,
+		-100000)
+
+	e, n, h, datum, err := ostn.ETRS89ToOSGB36(gp.X, gp.Y, gc.Height)
+	if err != nil {
+		return nil, HeightDatumNone, err
+	}
+
+	coord, err := GridRefNumToLet(uint(e+0.5), uint(n+0.5), h, OSGB36_Max)
+	return coord, datum, err
+}
+
+// Transform a OSGB36 coordinate, whose RelHeight refers to the local height datum, into an ETRS89 latitude /
+// longitude coordinate with ellipsoidal height. Returns cartconvert.ErrRange if the coordinate lies outside
+// the grid or outside OSGM15.
+func (ostn *OSTN15) OSGB36ToETRS89LatLong(coord *OSGB36Coord) (*cartconvert.PolarCoord, HeightDatum, error) {
+
+	easting, northing := OSGB36ZoneToRefCoords(coord)
+
+	e, n, h, datum, err := ostn.OSGB36ToETRS89(float64(easting), float64(northing), coord.RelHeight)
+	if err != nil {
+		return nil, HeightDatumNone, err
+	}
+
+	gc := cartconvert.DefaultTMAlgorithm.InverseTransverseMercator(
+		&cartconvert.GeoPoint{Y: n, X: e, El: cartconvert.GRS80Ellipsoid},
+		49,
+		-2,
+		0.9996012717,
+		400000,
+		-100000)
+	gc.Height = h
+
+	return gc, datum, nil
+}
+
+// Returns the OSGM15 geoid height at the ETRS89 coordinate pc. Implements cartconvert.Geoid, the height refers
+// to the local height datum of the nearest grid node. Returns cartconvert.ErrRange if pc lies outside the grid
+// or outside OSGM15.
+func (ostn *OSTN15) Undulation(pc *cartconvert.PolarCoord) (float64, error) {
+
+	gp := cartconvert.DefaultTMAlgorithm.DirectTransverseMercator(
+		&cartconvert.PolarCoord{Latitude: pc.Latitude, Longitude: pc.Longitude, El: cartconvert.GRS80Ellipsoid},
+		49,
+		-2,
+		0.9996012717,
+		400000,
+		-100000)
+
+	_, _, sg, datum, err := ostn.Shift(gp.X, gp.Y)
+	if err != nil {
+		return 0, err
+	}
+	if datum == HeightDatumNone {
+		return 0, cartconvert.ErrRange
+	}
+	return sg, nil
+}
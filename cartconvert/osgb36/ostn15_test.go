@@ -0,0 +1,176 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert/osgb36 package
+package osgb36
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"strings"
+	"testing"
+)
+
+// ## LoadOSTN15
+func TestLoadOSTN15(t *testing.T) {
+
+	ostn, err := LoadOSTN15File("testdata/ostn15.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ostn.Len() != 9 {
+		t.Errorf("LoadOSTN15File: Expected 9 nodes, got %d", ostn.Len())
+	}
+
+	for _, in := range []string{
+		"219363,650000,312000,104.176,-75.119,44.000",
+		"219363,650500,312000,104.176,-75.119,44.000,1",
+		"219363,650000,312000,x,-75.119,44.000,1",
+		"219363,650000,312000,104.176,-75.119,44.000,256",
+		"0,701000,312000,104.176,-75.119,44.000,1",
+	} {
+		if _, err := LoadOSTN15(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadOSTN15: Expected an error for %q", in)
+		}
+	}
+}
+
+// ## OSTN15.Shift
+type ostn15ShiftTest struct {
+	easting, northing float64
+	se, sn, sg        float64
+	datum             HeightDatum
+	err               error
+}
+
+var ostn15ShiftTests = []ostn15ShiftTest{
+	// grid node
+	{651000, 313000, 104.208, -75.094, 44.75, HeightDatumODN, nil},
+	// center of the cell
+	{650500, 312500, 104.192, -75.10675, 44.375, HeightDatumODN, nil},
+	// nearest node outside OSGM15
+	{650200, 312300, 104.1828, -75.11134, 44.175, HeightDatumNone, nil},
+	// outside the grid
+	{649999, 312000, 0, 0, 0, HeightDatumNone, cartconvert.ErrRange},
+	{652000, 313000, 0, 0, 0, HeightDatumNone, cartconvert.ErrRange},
+	{-1, 312000, 0, 0, 0, HeightDatumNone, cartconvert.ErrRange},
+	{700500, 1250500, 0, 0, 0, HeightDatumNone, cartconvert.ErrRange},
+}
+
+func TestOSTN15Shift(t *testing.T) {
+
+	ostn, err := LoadOSTN15File("testdata/ostn15.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cnt, test := range ostn15ShiftTests {
+		se, sn, sg, datum, err := ostn.Shift(test.easting, test.northing)
+		if err != test.err || datum != test.datum ||
+			fmt.Sprintf("%.4f %.4f %.4f", se, sn, sg) != fmt.Sprintf("%.4f %.4f %.4f", test.se, test.sn, test.sg) {
+			t.Errorf("OSTN15.Shift [%d]: Expected %f %f %f %s %v, got %f %f %f %s %v", cnt, test.se, test.sn, test.sg, test.datum, test.err, se, sn, sg, datum, err)
+		}
+	}
+}
+
+// ## OSTN15.ETRS89ToOSGB36, OSTN15.OSGB36ToETRS89
+func TestOSTN15(t *testing.T) {
+
+	ostn, err := LoadOSTN15File("testdata/ostn15.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, n, h, datum, err := ostn.ETRS89ToOSGB36(651234.5, 312876.5, 100)
+	if err != nil || datum != HeightDatumODN || math.Abs(h-(100-44.836375)) > 0.0001 {
+		t.Errorf("OSTN15.ETRS89ToOSGB36: Expected height %f in %s, got %f in %s (%v)", 100-44.836375, HeightDatumODN, h, datum, err)
+	}
+
+	e, n, h, datum, err = ostn.OSGB36ToETRS89(e, n, h)
+	if err != nil || datum != HeightDatumODN || math.Abs(e-651234.5) > 0.0001 || math.Abs(n-312876.5) > 0.0001 || math.Abs(h-100) > 0.0001 {
+		t.Errorf("OSTN15.OSGB36ToETRS89: Expected 651234.5 312876.5 100, got %f %f %f (%v)", e, n, h, err)
+	}
+
+	if _, _, h, datum, err := ostn.ETRS89ToOSGB36(650200, 312300, 100); err != cartconvert.ErrRange {
+		t.Errorf("OSTN15.ETRS89ToOSGB36: Expected %s outside OSGM15, got %f in %s (%v)", cartconvert.ErrRange, h, datum, err)
+	}
+	if _, _, h, datum, err := ostn.OSGB36ToETRS89(650200+104.1828, 312300-75.11134, 50); err != cartconvert.ErrRange {
+		t.Errorf("OSTN15.OSGB36ToETRS89: Expected %s outside OSGM15, got %f in %s (%v)", cartconvert.ErrRange, h, datum, err)
+	}
+
+	if _, _, _, _, err := ostn.OSGB36ToETRS89(600000, 300000, 0); err != cartconvert.ErrRange {
+		t.Errorf("OSTN15.OSGB36ToETRS89: Expected %s outside the grid, got %v", cartconvert.ErrRange, err)
+	}
+}
+
+// ## DefaultOSTN15
+func TestDefaultOSTN15(t *testing.T) {
+
+	ostn, err := LoadOSTN15File("testdata/ostn15.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the ETRS89 point E 651307.003 N 313255.686 with an ellipsoidal height of 108.05m
+	gc := cartconvert.DefaultTMAlgorithm.InverseTransverseMercator(
+		&cartconvert.GeoPoint{X: 651307.003, Y: 313255.686, El: cartconvert.GRS80Ellipsoid},
+		49, -2, 0.9996012717, 400000, -100000)
+	gc.Height = 108.05
+
+	helmert, err := WGS84LatLongToOSGB36(&cartconvert.PolarCoord{Latitude: gc.Latitude, Longitude: gc.Longitude})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	DefaultOSTN15 = ostn
+	defer func() { DefaultOSTN15 = nil }()
+
+	// the synthetic grid reproduces the helmert transformation
+	out, err := WGS84LatLongToOSGB36(gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !osgb36fuzzyequal(helmert, out) || out.String() != "TG5141113181" || fmt.Sprintf("%.2f", out.RelHeight) != "63.08" {
+		t.Errorf("WGS84LatLongToOSGB36: Expected %s at 63.08m, got %s at %fm", helmert, out, out.RelHeight)
+	}
+
+	if h, err := cartconvert.OrthometricHeight(ostn, gc); err != nil || fmt.Sprintf("%.2f", h) != "63.08" {
+		t.Errorf("OrthometricHeight: Expected 63.08m, got %fm (%v)", h, err)
+	}
+
+	back := OSGB36ToWGS84LatLong(out)
+	if math.Abs(back.Latitude-gc.Latitude) > 1e-5 || math.Abs(back.Longitude-gc.Longitude) > 1e-5 || math.Abs(back.Height-gc.Height) > 0.01 || back.El != cartconvert.WGS84Ellipsoid {
+		t.Errorf("OSGB36ToWGS84LatLong: Expected %s at %fm, got %s at %fm", gc, gc.Height, back, back.Height)
+	}
+
+	if _, method, datum, err := WGS84LatLongToOSGB36Shift(gc); err != nil || method != cartconvert.ShiftGrid || datum != HeightDatumODN {
+		t.Errorf("WGS84LatLongToOSGB36Shift: Expected %s in %s, got %s in %s (%v)", cartconvert.ShiftGrid, HeightDatumODN, method, datum, err)
+	}
+	if _, method, datum := OSGB36ToWGS84LatLongShift(out); method != cartconvert.ShiftGrid || datum != HeightDatumODN {
+		t.Errorf("OSGB36ToWGS84LatLongShift: Expected %s in %s, got %s in %s", cartconvert.ShiftGrid, HeightDatumODN, method, datum)
+	}
+
+	// outside OSGM15, transformed by the helmert parameters with the height above the Airy ellipsoid
+	none := cartconvert.DefaultTMAlgorithm.InverseTransverseMercator(
+		&cartconvert.GeoPoint{X: 650200, Y: 312300, El: cartconvert.GRS80Ellipsoid},
+		49, -2, 0.9996012717, 400000, -100000)
+	none.Height = 100
+
+	ellipsoidal, err := cartconvert.TransformDatum(&cartconvert.PolarCoord{Latitude: none.Latitude, Longitude: none.Longitude, Height: 100, El: cartconvert.WGS84Ellipsoid}, cartconvert.WGS84Datum, cartconvert.OSGB36Datum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, method, datum, err := WGS84LatLongToOSGB36Shift(none); err != nil || method != cartconvert.ShiftFallback || datum != HeightDatumNone || math.Abs(out.RelHeight-ellipsoidal.Height) > 0.001 {
+		t.Errorf("WGS84LatLongToOSGB36Shift: Expected %s in %s at %fm, got %v in %s (%v)", cartconvert.ShiftFallback, HeightDatumNone, ellipsoidal.Height, out, datum, err)
+	}
+
+	// outside the grid, transformed by the helmert parameters
+	for cnt, test := range wGS84LatLongToOSGB36Tests {
+		out, method, _, err := WGS84LatLongToOSGB36Shift(test.in)
+		if err != nil || method != cartconvert.ShiftFallback || !osgb36fuzzyequal(test.out, out) {
+			t.Errorf("WGS84LatLongToOSGB36Shift [%d]: Expected %s by %s, got %s by %s (%v)", cnt, test.out, cartconvert.ShiftFallback, out, method, err)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ## Open Location Code (Plus Codes)
+
+// The following functions deal with Open Location Codes as described in
+// https://github.com/google/open-location-code/blob/main/docs/specification.md
+
+// Codeset of Open Location Codes
+const PlusCodeAlphabet = "23456789CFGHJMPQRVWX"
+
+const (
+	plusCodeSeparator         = '+'
+	plusCodeSeparatorPosition = 8
+	plusCodePadding           = '0'
+	plusCodePairLength        = 10
+	plusCodeMaxLength         = 15
+	plusCodeBase              = 20
+	plusCodeGridRows          = 5
+	plusCodeGridColumns       = 4
+
+	// Precision of the pair section and of the complete code as the inverse of one degree
+	plusCodePairPrecision     = 8000
+	plusCodeFinalLatPrecision = plusCodePairPrecision * 3125 // 5^5 grid rows
+	plusCodeFinalLngPrecision = plusCodePairPrecision * 1024 // 4^5 grid columns
+)
+
+// The code length of the registered plus code coordinate system when converting latitude and
+// longitude. A length of 10 identifies an area of about 14 by 14 meters.
+const DefaultPlusCodeLength = 10
+
+// Position of the separator and of the padding within code; -1 if code is no
+// syntactically valid plus code
+func plusCodeStructure(code string) (sep, pad int) {
+
+	sep = strings.IndexByte(code, plusCodeSeparator)
+	if sep < 0 || sep != strings.LastIndexByte(code, plusCodeSeparator) || sep > plusCodeSeparatorPosition || sep%2 == 1 {
+		return -1, -1
+	}
+
+	// a single digit following the separator is not allowed
+	if len(code)-sep-1 == 1 {
+		return -1, -1
+	}
+
+	pad = strings.IndexByte(code, plusCodePadding)
+	if pad >= 0 {
+		// padding is only allowed for full codes, in pairs up to the separator
+		// and may not be followed by any digits
+		if sep < plusCodeSeparatorPosition || pad == 0 || pad%2 == 1 ||
+			strings.Trim(code[pad:sep], string(plusCodePadding)) != "" || sep != len(code)-1 {
+			return -1, -1
+		}
+	}
+
+	for i := 0; i < len(code); i++ {
+		if i == sep || (pad >= 0 && i >= pad && i < sep) {
+			continue
+		}
+		if strings.IndexByte(PlusCodeAlphabet, code[i]) < 0 {
+			return -1, -1
+		}
+	}
+
+	if sep == 0 && len(code) == 1 {
+		return -1, -1
+	}
+	return
+}
+
+// Returns true if code is a full plus code
+func IsFullPlusCode(code string) bool {
+	code = strings.ToUpper(code)
+	if sep, _ := plusCodeStructure(code); sep != plusCodeSeparatorPosition {
+		return false
+	}
+	// the first latitude digit may not exceed 180°, the first longitude digit not 360°
+	return strings.IndexByte(PlusCodeAlphabet, code[0])*plusCodeBase < 180 &&
+		strings.IndexByte(PlusCodeAlphabet, code[1])*plusCodeBase < 360
+}
+
+// Returns true if code is a short plus code which requires a reference location to be recovered
+func IsShortPlusCode(code string) bool {
+	sep, _ := plusCodeStructure(strings.ToUpper(code))
+	return sep >= 0 && sep < plusCodeSeparatorPosition
+}
+
+// Truncate val to the cell containing it. As in the reference implementation, val is rounded to six decimals
+// first, so floating point noise like 0.9999999999 of a cell does not move the point into the cell below.
+func plusCodeTruncate(val float64) int64 {
+	return int64(math.Floor(math.Round(val*1e6) / 1e6))
+}
+
+// Return the plus code of length codelength of a latitude & longitude bearing point. Latitudes
+// are clipped to ±90°, longitudes wrapped around.
+//
+// Returns ErrRange if codelength is not one of 2, 4, 6, 8 or 10 to 15
+func LatLongToPlusCode(pc *PolarCoord, codelength int) (string, error) {
+
+	if codelength < 2 || codelength > plusCodeMaxLength || (codelength < plusCodePairLength && codelength%2 == 1) {
+		return "", ErrRange
+	}
+
+	lat := math.Max(-90, math.Min(90, pc.Latitude))
+	latval := plusCodeTruncate((lat + 90) * plusCodeFinalLatPrecision)
+	// the north pole is encoded as the cell below it
+	if latval >= 180*plusCodeFinalLatPrecision {
+		latval = 180*plusCodeFinalLatPrecision - 1
+	}
+
+	lngval := plusCodeTruncate((pc.Longitude+180)*plusCodeFinalLngPrecision) % (360 * plusCodeFinalLngPrecision)
+	if lngval < 0 {
+		lngval += 360 * plusCodeFinalLngPrecision
+	}
+
+	var digits [plusCodeMaxLength]byte
+
+	// grid section, last digit first
+	for i := plusCodeMaxLength - 1; i >= plusCodePairLength; i-- {
+		row := latval % plusCodeGridRows
+		col := lngval % plusCodeGridColumns
+		digits[i] = PlusCodeAlphabet[row*plusCodeGridColumns+col]
+		latval /= plusCodeGridRows
+		lngval /= plusCodeGridColumns
+	}
+
+	// pair section, last pair first
+	for i := plusCodePairLength - 2; i >= 0; i -= 2 {
+		digits[i] = PlusCodeAlphabet[latval%plusCodeBase]
+		digits[i+1] = PlusCodeAlphabet[lngval%plusCodeBase]
+		latval /= plusCodeBase
+		lngval /= plusCodeBase
+	}
+
+	code := string(digits[:codelength])
+	if codelength < plusCodeSeparatorPosition {
+		return code + strings.Repeat(string(plusCodePadding), plusCodeSeparatorPosition-codelength) + string(plusCodeSeparator), nil
+	}
+	return code[:plusCodeSeparatorPosition] + string(plusCodeSeparator) + code[plusCodeSeparatorPosition:], nil
+}
+
+// Return the area of a full plus code as a bounding box.
+// If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+//
+// Returns ErrSyntax if code is not a full plus code
+func PlusCodeToBoundingBox(code string, el *Ellipsoid) (*BoundingBox, error) {
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !IsFullPlusCode(code) {
+		return nil, CartographyError{Coord: code, Err: ErrSyntax}
+	}
+
+	digits := strings.Replace(code, string(plusCodeSeparator), "", 1)
+	digits = strings.TrimRight(digits, string(plusCodePadding))
+	if len(digits) > plusCodeMaxLength {
+		digits = digits[:plusCodeMaxLength]
+	}
+
+	// all values are integers of the finest resolution, converted to degrees at the end
+	var latval, lngval int64
+	latplace := int64(plusCodeFinalLatPrecision / plusCodePairPrecision * plusCodeBase * plusCodeBase * plusCodeBase * plusCodeBase)
+	lngplace := int64(plusCodeFinalLngPrecision / plusCodePairPrecision * plusCodeBase * plusCodeBase * plusCodeBase * plusCodeBase)
+
+	pairs := len(digits)
+	if pairs > plusCodePairLength {
+		pairs = plusCodePairLength
+	}
+
+	for i := 0; i < pairs; i += 2 {
+		latval += int64(strings.IndexByte(PlusCodeAlphabet, digits[i])) * latplace
+		lngval += int64(strings.IndexByte(PlusCodeAlphabet, digits[i+1])) * lngplace
+		if i < pairs-2 {
+			latplace /= plusCodeBase
+			lngplace /= plusCodeBase
+		}
+	}
+
+	if len(digits) > plusCodePairLength {
+		latplace /= plusCodeGridRows
+		lngplace /= plusCodeGridColumns
+		for i := plusCodePairLength; i < len(digits); i++ {
+			digit := int64(strings.IndexByte(PlusCodeAlphabet, digits[i]))
+			latval += digit / plusCodeGridColumns * latplace
+			lngval += digit % plusCodeGridColumns * lngplace
+			if i < len(digits)-1 {
+				latplace /= plusCodeGridRows
+				lngplace /= plusCodeGridColumns
+			}
+		}
+	}
+
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+
+	return &BoundingBox{
+		South: float64(latval)/plusCodeFinalLatPrecision - 90,
+		West:  float64(lngval)/plusCodeFinalLngPrecision - 180,
+		North: math.Min(90, float64(latval+latplace)/plusCodeFinalLatPrecision-90),
+		East:  float64(lngval+lngplace)/plusCodeFinalLngPrecision - 180,
+		El:    el,
+	}, nil
+}
+
+// Return latitude & longitude of the centre of the area of a full plus code.
+// If the reference ellipsoid is nil, the default Ellipsoid will be returned.
+//
+// Returns ErrSyntax if code is not a full plus code
+func PlusCodeToLatLong(code string, el *Ellipsoid) (*PolarCoord, error) {
+
+	bb, err := PlusCodeToBoundingBox(code, el)
+	if err != nil {
+		return nil, err
+	}
+	return &PolarCoord{Latitude: (bb.South + bb.North) / 2, Longitude: (bb.West + bb.East) / 2, El: bb.El}, nil
+}
+
+// Latitude clipped and longitude wrapped as used by plus codes
+func plusCodeReference(ref *PolarCoord) (lat, long float64) {
+	return math.Max(-90, math.Min(90, ref.Latitude)), geoHashLongitude(ref.Longitude)
+}
+
+// Shortens the full plus code by removing as many leading digits as possible such that the
+// code can be recovered using a location close to the reference location ref. Four, six or eight
+// digits get removed. If the reference location is too far away, the full code is returned.
+//
+// Returns ErrSyntax if code is not a full plus code and ErrRange if code is padded.
+func ShortenPlusCode(code string, ref *PolarCoord) (string, error) {
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !IsFullPlusCode(code) {
+		return "", CartographyError{Coord: code, Err: ErrSyntax}
+	}
+	if strings.IndexByte(code, plusCodePadding) >= 0 {
+		return "", CartographyError{Coord: code, Err: ErrRange}
+	}
+
+	center, err := PlusCodeToLatLong(code, nil)
+	if err != nil {
+		return "", err
+	}
+
+	lat, long := plusCodeReference(ref)
+	distance := math.Max(math.Abs(center.Latitude-lat), math.Abs(center.Longitude-long))
+
+	// remove 8, 6 or 4 digits if the reference is within 0.3 of the resolution of the remaining pairs,
+	// leaving a safety margin to the maximum of 0.5
+	for removed := plusCodeSeparatorPosition; removed >= 4; removed -= 2 {
+		if distance < 0.3*math.Pow(plusCodeBase, 2-float64(removed/2)) {
+			return code[removed:], nil
+		}
+	}
+	return code, nil
+}
+
+// Recovers the full plus code from the short code using the reference location ref. The
+// nearest matching code to the reference location is returned. Full codes are returned unchanged.
+//
+// Returns ErrSyntax if code is neither a short nor a full plus code.
+func RecoverPlusCode(code string, ref *PolarCoord) (string, error) {
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if IsFullPlusCode(code) {
+		return code, nil
+	}
+	if !IsShortPlusCode(code) {
+		return "", CartographyError{Coord: code, Err: ErrSyntax}
+	}
+
+	lat, long := plusCodeReference(ref)
+
+	missing := plusCodeSeparatorPosition - strings.IndexByte(code, plusCodeSeparator)
+	resolution := math.Pow(plusCodeBase, 2-float64(missing/2))
+	half := resolution / 2
+
+	// take the missing leading digits from the reference location
+	prefix, err := LatLongToPlusCode(&PolarCoord{Latitude: lat, Longitude: long}, plusCodePairLength)
+	if err != nil {
+		return "", err
+	}
+
+	candidate := prefix[:missing] + code
+	center, err := PlusCodeToLatLong(candidate, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// the candidate may be more than half the resolution away from the reference
+	// location, in which case the adjacent area is closer
+	clat, clong := center.Latitude, center.Longitude
+	switch {
+	case lat+half < clat && clat-resolution >= -90:
+		clat -= resolution
+	case lat-half > clat && clat+resolution <= 90:
+		clat += resolution
+	}
+	switch {
+	case long+half < clong:
+		clong -= resolution
+	case long-half > clong:
+		clong += resolution
+	}
+
+	return LatLongToPlusCode(&PolarCoord{Latitude: clat, Longitude: clong}, len(candidate)-1)
+}
+
+// A plus code literal
+type plusCode string
+
+func (pc plusCode) String() string { return string(pc) }
+
+// Full plus codes as a CoordinateSystem
+type plusCodeSystem struct {
+	name   string
+	length int
+}
+
+// Returns a plus code coordinate system named name, to be registered by RegisterCoordinateSystem.
+// FromLatLong returns codes of length length, see LatLongToPlusCode.
+func NewPlusCodeCoordinateSystem(name string, length int) CoordinateSystem {
+	return plusCodeSystem{name: name, length: length}
+}
+
+func (ps plusCodeSystem) Name() string     { return ps.name }
+func (plusCodeSystem) Description() string { return "Open Location Code (Plus Code)" }
+
+// Parses a full plus code. Short codes require a reference location, see RecoverPlusCode
+func (plusCodeSystem) Parse(coord string) (fmt.Stringer, error) {
+	code := strings.ToUpper(strings.TrimSpace(coord))
+	if !IsFullPlusCode(code) {
+		return nil, CartographyError{Coord: coord, Err: ErrSyntax}
+	}
+	return plusCode(code), nil
+}
+
+func (plusCodeSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(plusCode); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (plusCodeSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	code, ok := coord.(plusCode)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return PlusCodeToLatLong(string(code), nil)
+}
+
+func (ps plusCodeSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	code, err := LatLongToPlusCode(pc, ps.length)
+	if err != nil {
+		return nil, err
+	}
+	return plusCode(code), nil
+}
+
+func (plusCodeSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return WGS84CRS, nil
+}
+
+func init() {
+	RegisterCoordinateSystem(NewPlusCodeCoordinateSystem("pluscode", DefaultPlusCodeLength))
+}
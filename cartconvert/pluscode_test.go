@@ -0,0 +1,282 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// ## LatLongToPlusCode
+type latLongToPlusCodeTest struct {
+	in     *PolarCoord
+	length int
+	out    string
+}
+
+// Test data taken from the Open Location Code test data set encoding.csv
+var latLongToPlusCodeTests = []latLongToPlusCodeTest{
+	{&PolarCoord{Latitude: 20.375, Longitude: 2.775}, 6, "7FG49Q00+"},
+	{&PolarCoord{Latitude: 20.3700625, Longitude: 2.7821875}, 10, "7FG49QCJ+2V"},
+	{&PolarCoord{Latitude: 20.3701125, Longitude: 2.782234375}, 11, "7FG49QCJ+2VX"},
+	{&PolarCoord{Latitude: 20.3701135, Longitude: 2.78223535156}, 13, "7FG49QCJ+2VXGJ"},
+	{&PolarCoord{Latitude: 47.0000625, Longitude: 8.0000625}, 10, "8FVC2222+22"},
+	{&PolarCoord{Latitude: -41.2730625, Longitude: 174.7859375}, 10, "4VCPPQGP+Q9"},
+	{&PolarCoord{Latitude: 0.5, Longitude: -179.5}, 4, "62G20000+"},
+	{&PolarCoord{Latitude: -89.5, Longitude: -179.5}, 4, "22220000+"},
+	{&PolarCoord{Latitude: -89.9999375, Longitude: -179.9999375}, 10, "22222222+22"},
+	{&PolarCoord{Latitude: 0.5, Longitude: 179.5}, 4, "6VGX0000+"},
+	{&PolarCoord{Latitude: 1, Longitude: 1}, 11, "6FH32222+222"},
+	{&PolarCoord{Latitude: 90, Longitude: 1}, 4, "CFX30000+"},
+	{&PolarCoord{Latitude: 92, Longitude: 1}, 4, "CFX30000+"},
+	{&PolarCoord{Latitude: 90, Longitude: 1}, 10, "CFX3X2X2+X2"},
+	{&PolarCoord{Latitude: 1, Longitude: 180}, 4, "62H20000+"},
+	{&PolarCoord{Latitude: 1, Longitude: 181}, 4, "62H30000+"},
+	// cell boundaries: a point belongs to the cell at its south west
+	{&PolarCoord{Latitude: 47, Longitude: 8}, 10, "8FVC2222+22"},
+	{&PolarCoord{Latitude: 46.99999999, Longitude: 8.00001}, 10, "8FRCX2X2+X2"},
+	// floating point noise just below the boundary of the cell
+	{&PolarCoord{Latitude: 20.3700625, Longitude: 2.7821874999}, 10, "7FG49QCJ+2V"},
+}
+
+func TestLatLongToPlusCode(t *testing.T) {
+	for cnt, test := range latLongToPlusCodeTests {
+		out, err := LatLongToPlusCode(test.in, test.length)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.out != out {
+			t.Errorf("LatLongToPlusCode [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+
+	// the area of the code contains the point
+	for _, pc := range []*PolarCoord{{Latitude: 46.99999999, Longitude: 8.00001}, {Latitude: 47, Longitude: 8}, {Latitude: -33.9, Longitude: 18.4}} {
+		code, _ := LatLongToPlusCode(pc, 10)
+		bb, err := PlusCodeToBoundingBox(code, nil)
+		if err != nil || pc.Latitude < bb.South-1e-9 || pc.Latitude >= bb.North || pc.Longitude < bb.West-1e-9 || pc.Longitude >= bb.East {
+			t.Errorf("LatLongToPlusCode: expected %s to contain %s, got %v (%v)", code, pc, bb, err)
+		}
+	}
+
+	for _, length := range []int{0, 1, 3, 9, 16} {
+		if _, err := LatLongToPlusCode(&PolarCoord{}, length); err != ErrRange {
+			t.Errorf("LatLongToPlusCode: expected %s for length %d, got %v", ErrRange, length, err)
+		}
+	}
+}
+
+// ## PlusCodeToBoundingBox
+type plusCodeToBoundingBoxTest struct {
+	in  string
+	out *BoundingBox
+}
+
+var plusCodeToBoundingBoxTests = []plusCodeToBoundingBoxTest{
+	{"7FG49Q00+", &BoundingBox{South: 20.35, West: 2.75, North: 20.4, East: 2.8}},
+	{"7fg49qcj+2v", &BoundingBox{South: 20.37, West: 2.782125, North: 20.370125, East: 2.78225}},
+	{"7FG49QCJ+2VX", &BoundingBox{South: 20.3701, West: 2.78221875, North: 20.370125, East: 2.7822500}},
+	{"CFX30000+", &BoundingBox{South: 89, West: 1, North: 90, East: 2}},
+	{"CFX3X2X2+X2", &BoundingBox{South: 89.999875, West: 1, North: 90, East: 1.000125}},
+}
+
+func TestPlusCodeToBoundingBox(t *testing.T) {
+	for cnt, test := range plusCodeToBoundingBoxTests {
+		out, err := PlusCodeToBoundingBox(test.in, nil)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !boundingboxequal(test.out, out) {
+			t.Errorf("PlusCodeToBoundingBox [%d]: expected %s, got %s", cnt, test.out, out)
+		}
+	}
+
+	for _, code := range []string{"", "+", "7FG49QCJ+2", "7FG49QCJ2V", "7FG49Q0J+", "7FG4900+", "7FG49Q00+2V", "9QCJ+2V", "7FG49QCJ+2A", "WFG49QCJ+2V", "7FG4+9QCJ+2V"} {
+		if _, err := PlusCodeToBoundingBox(code, nil); err == nil {
+			t.Errorf("PlusCodeToBoundingBox: expected an error for %q", code)
+		}
+	}
+}
+
+// ## IsFullPlusCode, IsShortPlusCode
+func TestPlusCodeValidity(t *testing.T) {
+	tests := []struct {
+		code        string
+		full, short bool
+	}{
+		{"8FWC2345+G6", true, false},
+		{"8FWC2345+G6G", true, false},
+		{"8fwc2345+", true, false},
+		{"8FWCX400+", true, false},
+		{"WC2345+G6g", false, true},
+		{"2345+G6", false, true},
+		{"45+G6", false, true},
+		{"+G6", false, true},
+		{"G+", false, false},
+		{"8FWC2345+G", false, false},
+		{"8FWC2_45+G6", false, false},
+		{"8FWC2η45+G6", false, false},
+		{"8FWC2345+G6+", false, false},
+		{"8FWC2300+G6", false, false},
+		{"WC2300+G6g", false, false},
+		{"WC2345+G", false, false},
+	}
+
+	for _, test := range tests {
+		if full := IsFullPlusCode(test.code); full != test.full {
+			t.Errorf("IsFullPlusCode: expected %t for %s, got %t", test.full, test.code, full)
+		}
+		if short := IsShortPlusCode(test.code); short != test.short {
+			t.Errorf("IsShortPlusCode: expected %t for %s, got %t", test.short, test.code, short)
+		}
+	}
+}
+
+// ## ShortenPlusCode, RecoverPlusCode
+type shortPlusCodeTest struct {
+	full  string
+	ref   *PolarCoord
+	short string
+}
+
+// Test data taken from the Open Location Code test data set shortCodeTests.csv
+var shortPlusCodeTests = []shortPlusCodeTest{
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3701125, Longitude: -1.217765625}, "+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3708675, Longitude: -1.217765625}, "CJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3693575, Longitude: -1.217765625}, "CJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3701125, Longitude: -1.218520625}, "CJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3701125, Longitude: -1.217010625}, "CJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3852125, Longitude: -1.217765625}, "9QCJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3550125, Longitude: -1.217765625}, "9QCJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3701125, Longitude: -1.232865625}, "9QCJ+2VX"},
+	{"9C3W9QCJ+2VX", &PolarCoord{Latitude: 51.3701125, Longitude: -1.202665625}, "9QCJ+2VX"},
+	{"8FJFW222+", &PolarCoord{Latitude: 42.899, Longitude: 9.012}, "22+"},
+	{"796RXG22+", &PolarCoord{Latitude: 14.95125, Longitude: -23.5001}, "22+"},
+	{"8FVC9G8F+6X", &PolarCoord{Latitude: 47.4, Longitude: 8.6}, "9G8F+6X"},
+	// the first pair is never removed, whatever the distance of the reference
+	{"8FVC9G8F+6X", &PolarCoord{Latitude: 47.9, Longitude: 8.6}, "8FVC9G8F+6X"},
+	{"8FVC9G8F+6X", &PolarCoord{Latitude: 48.4, Longitude: 8.6}, "8FVC9G8F+6X"},
+	{"8FVC9G8F+6X", &PolarCoord{Latitude: 47.4, Longitude: 14.5}, "8FVC9G8F+6X"},
+}
+
+func TestShortenPlusCode(t *testing.T) {
+	for cnt, test := range shortPlusCodeTests {
+		out, err := ShortenPlusCode(test.full, test.ref)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.short != out {
+			t.Errorf("ShortenPlusCode [%d]: expected %s, got %s", cnt, test.short, out)
+		}
+	}
+
+	if out, err := ShortenPlusCode("9C3W9QCJ+2VX", &PolarCoord{Latitude: -30, Longitude: 100}); err != nil || out != "9C3W9QCJ+2VX" {
+		t.Errorf("ShortenPlusCode: expected the full code for a distant reference, got %s (%v)", out, err)
+	}
+
+	if _, err := ShortenPlusCode("9C3W9Q00+", &PolarCoord{Latitude: 51.37, Longitude: -1.21}); err == nil {
+		t.Errorf("ShortenPlusCode: expected an error for a padded code")
+	}
+}
+
+func TestRecoverPlusCode(t *testing.T) {
+	for cnt, test := range shortPlusCodeTests {
+		out, err := RecoverPlusCode(test.short, test.ref)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.full != out {
+			t.Errorf("RecoverPlusCode [%d]: expected %s, got %s", cnt, test.full, out)
+		}
+	}
+
+	// the nearest matching area lies across the north pole limit or the antimeridian
+	recovertests := []struct {
+		short string
+		ref   *PolarCoord
+		full  string
+	}{
+		{"XXXXXX+XX", &PolarCoord{Latitude: -81.0, Longitude: 0.0}, "2CXXXXXX+XX"},
+		{"2222+22", &PolarCoord{Latitude: 89.6, Longitude: 0.0}, "CFX22222+22"},
+		{"2222+22", &PolarCoord{Latitude: 1.0, Longitude: 179.9}, "62H22222+22"},
+		{"8FVC9G8F+6X", &PolarCoord{Latitude: 0, Longitude: 0}, "8FVC9G8F+6X"},
+	}
+
+	for cnt, test := range recovertests {
+		out, err := RecoverPlusCode(test.short, test.ref)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.full != out {
+			t.Errorf("RecoverPlusCode [%d]: expected %s, got %s", cnt, test.full, out)
+		}
+	}
+
+	if _, err := RecoverPlusCode("9QCJ+2", &PolarCoord{}); err == nil {
+		t.Errorf("RecoverPlusCode: expected an error for an invalid code")
+	}
+}
+
+// ## Round trip
+func TestPlusCodeRoundTrip(t *testing.T) {
+	for _, in := range []*PolarCoord{
+		{Latitude: 48.208333, Longitude: 16.372778},
+		{Latitude: -33.856784, Longitude: 151.215297},
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 64.146582, Longitude: -21.942635},
+	} {
+		code, err := LatLongToPlusCode(in, DefaultPlusCodeLength)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		bb, err := PlusCodeToBoundingBox(code, nil)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !bb.Contains(in) {
+			t.Errorf("PlusCode round trip: %s of %s does not contain %s", bb, code, in)
+		}
+	}
+}
+
+// ## NewPlusCodeCoordinateSystem
+func TestPlusCodeCoordinateSystem(t *testing.T) {
+	test := latLongToPlusCodeTests[2]
+
+	cs := NewPlusCodeCoordinateSystem("pluscode11", test.length)
+	if out, err := cs.FromLatLong(test.in); err != nil || cs.Name() != "pluscode11" || out.String() != test.out {
+		t.Errorf("NewPlusCodeCoordinateSystem: Expected pluscode11 %s, got %s %v (%v)", test.out, cs.Name(), out, err)
+	}
+
+	if _, err := NewPlusCodeCoordinateSystem("pluscode", 3).FromLatLong(test.in); err != ErrRange {
+		t.Errorf("NewPlusCodeCoordinateSystem: Expected %s for length 3, got %v", ErrRange, err)
+	}
+
+	// the registered coordinate system converts at the default length
+	if cs, ok := LookupCoordinateSystem("pluscode"); !ok {
+		t.Error("LookupCoordinateSystem: pluscode not registered")
+	} else if out, err := cs.FromLatLong(test.in); err != nil || len(out.String()) != DefaultPlusCodeLength+1 {
+		t.Errorf("pluscode: Expected %d digits, got %v (%v)", DefaultPlusCodeLength, out, err)
+	}
+}
@@ -0,0 +1,655 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ## PROJ strings
+
+// PROJ describes coordinate reference systems by a list of +key=value parameters, eg.
+// "+proj=tmerc +lat_0=0 +lon_0=10.333 +k=1 +x_0=150000 +y_0=-5000000 +ellps=bessel +towgs84=...". ParseProj4
+// configures the projections and datums of this package by such a definition, so a national grid can be
+// supported without writing a package of its own.
+
+// A parameter of a PROJ string, at index of the string
+type proj4Parameter struct {
+	key, value string
+	index      int
+	used       bool
+}
+
+// The parameter as written in the PROJ string
+func (p *proj4Parameter) String() string {
+	if p.value == "" {
+		return "+" + p.key
+	}
+	return "+" + p.key + "=" + p.value
+}
+
+// The error err located at the parameter
+func (p *proj4Parameter) error(err error) error {
+	val, _ := strconv.ParseFloat(p.value, 64)
+	return CartographyError{Coord: p.String(), Val: val, Index: p.index, Err: err}
+}
+
+type proj4Parser struct {
+	def    string
+	params []*proj4Parameter
+}
+
+// Split the PROJ string into its parameters. A parameter may be given only once.
+func newProj4Parser(def string) (*proj4Parser, error) {
+
+	ps := &proj4Parser{def: def}
+
+	offset := 0
+	for _, field := range strings.Fields(def) {
+		index := strings.Index(def[offset:], field) + offset
+		offset = index + len(field)
+
+		p := &proj4Parameter{key: strings.TrimPrefix(field, "+"), index: index}
+		if eq := strings.Index(p.key, "="); eq != -1 {
+			p.key, p.value = p.key[:eq], p.key[eq+len("="):]
+			if p.value == "" {
+				return nil, CartographyError{Coord: field, Index: index, Err: ErrSyntax}
+			}
+		}
+		if p.key == "" || ps.find(p.key) != nil {
+			return nil, CartographyError{Coord: field, Index: index, Err: ErrSyntax}
+		}
+		ps.params = append(ps.params, p)
+	}
+	return ps, nil
+}
+
+// The parameter key or nil, if not given
+func (ps *proj4Parser) find(key string) *proj4Parameter {
+	for _, p := range ps.params {
+		if p.key == key {
+			return p
+		}
+	}
+	return nil
+}
+
+// The parameter key, which is marked as understood, or nil if not given
+func (ps *proj4Parser) lookup(key string) *proj4Parameter {
+	p := ps.find(key)
+	if p != nil {
+		p.used = true
+	}
+	return p
+}
+
+// The value of the parameter key, or def if not given
+func (ps *proj4Parser) float(key string, def float64) (float64, error) {
+	p := ps.lookup(key)
+	if p == nil {
+		return def, nil
+	}
+	val, err := strconv.ParseFloat(p.value, 64)
+	if err != nil {
+		return 0, p.error(ErrSyntax)
+	}
+	return val, nil
+}
+
+// The latitude of the parameter key within ±90°, or def if not given
+func (ps *proj4Parser) latitude(key string, def float64) (float64, error) {
+	lat, err := ps.float(key, def)
+	if err == nil && !(math.Abs(lat) <= 90) {
+		err = ps.find(key).error(ErrRange)
+	}
+	return lat, err
+}
+
+// The value of the parameter key, which must be def if given
+func (ps *proj4Parser) fixed(key string, def float64) error {
+	val, err := ps.float(key, def)
+	if err == nil && val != def {
+		err = ps.find(key).error(ErrUnsupported)
+	}
+	return err
+}
+
+// The values of the parameter key as a comma separated list
+func (ps *proj4Parser) floats(key string) ([]float64, error) {
+	p := ps.lookup(key)
+	if p == nil {
+		return nil, nil
+	}
+	var vals []float64
+	for _, field := range strings.Split(p.value, ",") {
+		val, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, p.error(ErrSyntax)
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// Ellipsoids of this package by their name in PROJ
+var proj4Ellipsoids = []struct {
+	name string
+	el   *Ellipsoid
+}{
+	{"WGS84", WGS84Ellipsoid},
+	{"GRS80", GRS80Ellipsoid},
+	{"bessel", Bessel1841Ellipsoid},
+	{"airy", Airy1830Ellipsoid},
+	{"mod_airy", AiryModifiedEllipsoid},
+	{"clrk66", Clarke1866Ellipsoid},
+	{"clrk80ign", Clarke1880IGNEllipsoid},
+	{"intl", International1924Ellipsoid},
+	{"krass", Krassowsky1940Ellipsoid},
+}
+
+// Datums by their name in PROJ. The transformations into WGS84 of the catalogue replace those of PROJ.
+var proj4Datums = map[string]*Datum{
+	"WGS84":         WGS84Datum,
+	"potsdam":       DHDNDatum,
+	"hermannskogel": MGIDatum,
+	"ire65":         Ireland65Datum,
+	"OSGB36":        OSGB36Datum,
+}
+
+// The ellipsoid given by +ellps, +R or +a with one of +b, +rf and +f, or nil
+func (ps *proj4Parser) ellipsoid() (*Ellipsoid, *proj4Parameter, error) {
+
+	if p := ps.lookup("ellps"); p != nil {
+		for _, pe := range proj4Ellipsoids {
+			if pe.name == p.value {
+				return pe.el, p, nil
+			}
+		}
+		return nil, nil, p.error(ErrUnsupported)
+	}
+
+	key := "a"
+	if ps.find("R") != nil {
+		key = "R"
+	}
+	p := ps.find(key)
+	if p == nil {
+		return nil, nil, nil
+	}
+	a, err := ps.float(key, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a <= 0 {
+		return nil, nil, p.error(ErrRange)
+	}
+
+	invf := 0.0
+	if key == "a" {
+		switch {
+		case ps.find("b") != nil:
+			b, err := ps.float("b", 0)
+			if err != nil {
+				return nil, nil, err
+			}
+			if b <= 0 || b > a {
+				return nil, nil, ps.find("b").error(ErrRange)
+			}
+			if b != a {
+				invf = a / (a - b)
+			}
+		case ps.find("rf") != nil:
+			if invf, err = ps.float("rf", 0); err != nil {
+				return nil, nil, err
+			}
+			// a sphere has no inverse flattening
+			if !(invf > 1) {
+				return nil, nil, ps.find("rf").error(ErrRange)
+			}
+		case ps.find("f") != nil:
+			f, err := ps.float("f", 0)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !(f >= 0 && f < 1) {
+				return nil, nil, ps.find("f").error(ErrRange)
+			}
+			if f != 0 {
+				invf = 1 / f
+			}
+		}
+	}
+	return wktEllipsoid("unknown", a, invf), p, nil
+}
+
+// The prime meridian given by +pm, either by its name or its longitude east of Greenwich in degrees
+func (ps *proj4Parser) primeMeridian() (*PrimeMeridian, error) {
+	p := ps.lookup("pm")
+	if p == nil {
+		return GreenwichMeridian, nil
+	}
+	if pm, ok := LookupPrimeMeridian(p.value); ok {
+		return pm, nil
+	}
+	long, err := strconv.ParseFloat(p.value, 64)
+	if err != nil {
+		return nil, p.error(ErrUnsupported)
+	}
+	return wktPrimeMeridian(p.value, long), nil
+}
+
+// The datum given by +datum, the ellipsoid, +pm and +towgs84. Datums of the catalogue are recognized by their
+// ellipsoid, prime meridian and transformation into WGS84. Without ellipsoid, the datum is WGS84.
+func (ps *proj4Parser) datum() (*Datum, error) {
+
+	pm, err := ps.primeMeridian()
+	if err != nil {
+		return nil, err
+	}
+
+	var towgs84 *Helmert
+	if p := ps.find("towgs84"); p != nil {
+		params, err := ps.floats("towgs84")
+		if err != nil {
+			return nil, err
+		}
+		switch len(params) {
+		case 3:
+			towgs84 = NewHelmertTransformer(params[0], params[1], params[2], 0, 0, 0, 0, "")
+		case 7:
+			towgs84 = NewHelmertTransformer(params[0], params[1], params[2], params[6], params[3], params[4], params[5], "")
+		default:
+			return nil, p.error(ErrSyntax)
+		}
+	}
+
+	if p := ps.lookup("nadgrids"); p != nil {
+		return nil, p.error(ErrUnsupported)
+	}
+
+	el, ep, err := ps.ellipsoid()
+	if err != nil {
+		return nil, err
+	}
+
+	if p := ps.lookup("datum"); p != nil {
+		d, ok := proj4Datums[p.value]
+		if !ok {
+			if d, ok = LookupDatum(p.value); !ok {
+				return nil, p.error(ErrUnsupported)
+			}
+		}
+		if el != nil && !ellipsoidsEqual(el, d.El) {
+			return nil, ep.error(ErrUnsupported)
+		}
+		return wktDatum(d.Name, "", d.El, pm, towgs84), nil
+	}
+
+	if el == nil {
+		el = WGS84Ellipsoid
+	}
+	return proj4Datum(el, pm, towgs84), nil
+}
+
+// The datum of the ellipsoid el and the prime meridian pm, which is transformed into WGS84 by towgs84. Returns
+// a datum of the catalogue if it agrees, preferring the ones of the very ellipsoid el.
+func proj4Datum(el *Ellipsoid, pm *PrimeMeridian, towgs84 *Helmert) *Datum {
+
+	if towgs84 == nil {
+		if pm == GreenwichMeridian && (el == WGS84Ellipsoid || el == GRS80Ellipsoid) {
+			return ellipsoidDatum(el)
+		}
+		return NewDatum("Unknown based on "+el.CommonName+" ellipsoid", el, pm, nil)
+	}
+
+	var closest *Datum
+	for _, names := range wktDatumNames {
+		d := names.datum
+		if hp, ok := d.towgs84(); ok && d.PrimeMeridian == pm && ellipsoidsEqual(d.El, el) && helmertEqual(hp, towgs84) {
+			if d.El == el {
+				return d
+			}
+			if closest == nil {
+				closest = d
+			}
+		}
+	}
+	if closest != nil {
+		return closest
+	}
+	return NewDatum("Unknown based on "+el.CommonName+" ellipsoid", el, pm, NewGeocentricTransformer(towgs84, el, WGS84Ellipsoid))
+}
+
+// The parameters of the natural origin, +lat_0, +lon_0, +k or +k_0, +x_0 and +y_0
+func (ps *proj4Parser) naturalOrigin(p *Projection) (err error) {
+	if p.LatO, err = ps.latitude("lat_0", 0); err != nil {
+		return
+	}
+	if p.LongO, err = ps.float("lon_0", 0); err != nil {
+		return
+	}
+	scale := "k_0"
+	if ps.find(scale) == nil {
+		scale = "k"
+	}
+	if p.Scale, err = ps.float(scale, 1); err != nil {
+		return
+	}
+	if !(p.Scale > 0) {
+		return ps.find(scale).error(ErrRange)
+	}
+	if p.FE, err = ps.float("x_0", 0); err != nil {
+		return
+	}
+	p.FN, err = ps.float("y_0", 0)
+	return
+}
+
+// The projection of the PROJ string, nil for geographic coordinates
+func (ps *proj4Parser) projection() (*Projection, error) {
+
+	proj := ps.lookup("proj")
+	if proj == nil {
+		return nil, CartographyError{Coord: "+proj", Index: len(ps.def), Err: ErrSyntax}
+	}
+
+	p := &Projection{Scale: 1}
+
+	switch proj.value {
+	case "longlat", "latlong", "lonlat", "latlon":
+		return nil, nil
+
+	case "tmerc":
+		p.Method = TransverseMercator
+		return p, ps.naturalOrigin(p)
+
+	// the extended transverse mercator of Poder and Engsager is a Krüger series
+	case "etmerc":
+		p.Method, p.Algorithm = TransverseMercator, TMKrueger
+		return p, ps.naturalOrigin(p)
+
+	case "utm":
+		zp := ps.lookup("zone")
+		if zp == nil {
+			return nil, CartographyError{Coord: "+zone", Index: len(ps.def), Err: ErrSyntax}
+		}
+		zone, err := strconv.Atoi(zp.value)
+		if err != nil {
+			return nil, zp.error(ErrSyntax)
+		}
+		if zone < 1 || zone > 60 {
+			return nil, zp.error(ErrRange)
+		}
+		p.Method, p.LongO, p.Scale, p.FE = TransverseMercator, float64(zone-1)*6-180+3, 0.9996, 500000
+		if ps.lookup("south") != nil {
+			p.FN = 10000000
+		}
+		return p, nil
+
+	case "ups":
+		p.Method, p.LatO, p.Scale, p.FE, p.FN = PolarStereographic, 90, 0.994, 2000000, 2000000
+		if ps.lookup("south") != nil {
+			p.LatO = -90
+		}
+		return p, nil
+
+	case "stere":
+		p.Method = PolarStereographic
+		if err := ps.naturalOrigin(p); err != nil {
+			return nil, err
+		}
+		if lat0 := ps.find("lat_0"); lat0 == nil {
+			return nil, CartographyError{Coord: "+lat_0", Index: len(ps.def), Err: ErrSyntax}
+		} else if p.LatO != 90 && p.LatO != -90 {
+			return nil, lat0.error(ErrUnsupported)
+		}
+		// variant A has the standard parallel at the pole
+		return p, ps.fixed("lat_ts", p.LatO)
+
+	case "sterea":
+		p.Method = ObliqueStereographic
+		return p, ps.naturalOrigin(p)
+
+	case "somerc":
+		p.Method = SwissObliqueMercator
+		return p, ps.naturalOrigin(p)
+
+	case "lcc":
+		if ps.find("lat_1") == nil {
+			return nil, CartographyError{Coord: "+lat_1", Index: len(ps.def), Err: ErrSyntax}
+		}
+		lat1, err := ps.latitude("lat_1", 0)
+		if err != nil {
+			return nil, err
+		}
+		if p.Lat2, err = ps.latitude("lat_2", lat1); err != nil {
+			return nil, err
+		}
+
+		// standard parallels at a pole or, for a single one, at the equator yield no cone
+		if lat1 == 0 && p.Lat2 == 0 || math.Abs(lat1) == 90 {
+			return nil, ps.find("lat_1").error(ErrRange)
+		}
+		if math.Abs(p.Lat2) == 90 || p.Lat2 == -lat1 {
+			return nil, ps.find("lat_2").error(ErrRange)
+		}
+
+		if p.Lat2 == lat1 {
+			// one standard parallel, which is the latitude of the natural origin
+			p.Method = LambertConformalConic1SP
+			if err := ps.naturalOrigin(p); err != nil {
+				return nil, err
+			}
+			if ps.find("lat_0") != nil && p.LatO != lat1 {
+				return nil, ps.find("lat_0").error(ErrUnsupported)
+			}
+			p.LatO, p.Lat2 = lat1, 0
+			return p, nil
+		}
+
+		p.Method, p.Lat1 = LambertConformalConic2SP, lat1
+		if err := ps.naturalOrigin(p); err != nil {
+			return nil, err
+		}
+		if p.Scale != 1 {
+			// the scale is fixed by the standard parallels
+			if ps.find("k_0") != nil {
+				return nil, ps.find("k_0").error(ErrUnsupported)
+			}
+			return nil, ps.find("k").error(ErrUnsupported)
+		}
+		return p, nil
+
+	case "webmerc":
+		p.Method = WebMercator
+		for _, key := range []string{"lat_0", "lon_0", "x_0", "y_0"} {
+			if err := ps.fixed(key, 0); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+
+	case "merc":
+		// the web mercator as written by GDAL, the ellipsoidal mercator projection is not supported
+		a, b := ps.find("a"), ps.find("b")
+		if r := ps.find("R"); r != nil {
+			a, b = r, r
+		}
+		if a == nil || b == nil || a.value != "6378137" || b.value != "6378137" {
+			return nil, proj.error(ErrUnsupported)
+		}
+		a.used, b.used = true, true
+		p.Method = WebMercator
+		for _, key := range []string{"lat_ts", "lon_0", "x_0", "y_0"} {
+			if err := ps.fixed(key, 0); err != nil {
+				return nil, err
+			}
+		}
+		if err := ps.fixed("k", 1); err != nil {
+			return nil, err
+		}
+		if nadgrids := ps.find("nadgrids"); nadgrids != nil && nadgrids.value == "@null" {
+			nadgrids.used = true
+		}
+		return p, nil
+	}
+	return nil, proj.error(ErrUnsupported)
+}
+
+// Parameters of PROJ strings, which do not affect coordinates
+var proj4Ignored = []string{"no_defs", "wktext"}
+
+// ParseProj4 parses the PROJ string def into a coordinate reference system. Supported are the projections
+// tmerc, etmerc, utm, lcc, sterea, somerc, stere and ups on their natural origin, merc and webmerc as used by
+// Web Mercator and geographic longlat coordinates. The datum is given by +datum or by the ellipsoid (+ellps,
+// +R or +a with one of +b, +rf and +f), +pm and the helmert parameters +towgs84 in position vector convention.
+// Datums of the catalogue are recognized, otherwise the datum is named "Unknown based on ... ellipsoid"; A
+// datum without +towgs84 is assumed to coincide with WGS84. The CRS is named by +title, otherwise "unknown".
+//
+// Returns a CartographyError locating the parameter: ErrSyntax for a malformed or missing parameter, ErrRange
+// for a value out of range and ErrUnsupported for a parameter or value not supported by this package, eg.
+// +nadgrids or +units=ft.
+func ParseProj4(def string) (*CRS, error) {
+
+	ps, err := newProj4Parser(def)
+	if err != nil {
+		return nil, err
+	}
+
+	if p := ps.lookup("init"); p != nil {
+		return nil, p.error(ErrUnsupported)
+	}
+
+	projection, err := ps.projection()
+	if err != nil {
+		return nil, err
+	}
+
+	var datum *Datum
+	if projection != nil && projection.Method == WebMercator {
+		// web mercator refers to WGS84, even if projected from a sphere
+		datum = WGS84Datum
+		if proj := ps.find("proj"); proj.value == "webmerc" {
+			if datum, err = ps.datum(); err != nil {
+				return nil, err
+			}
+			if datum != WGS84Datum {
+				return nil, proj.error(ErrUnsupported)
+			}
+		}
+	} else if datum, err = ps.datum(); err != nil {
+		return nil, err
+	}
+
+	crs := &CRS{Name: "unknown", Datum: datum, Projection: projection}
+	if p := ps.lookup("title"); p != nil {
+		crs.Name = p.value
+	}
+
+	if p := ps.lookup("type"); p != nil && p.value != "crs" {
+		return nil, p.error(ErrUnsupported)
+	}
+	if p := ps.lookup("axis"); p != nil && p.value != "enu" {
+		return nil, p.error(ErrUnsupported)
+	}
+	if projection != nil {
+		if p := ps.lookup("units"); p != nil && p.value != "m" {
+			return nil, p.error(ErrUnsupported)
+		}
+		if err := ps.fixed("to_meter", 1); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range proj4Ignored {
+		ps.lookup(key)
+	}
+
+	for _, p := range ps.params {
+		if !p.used {
+			return nil, p.error(ErrUnsupported)
+		}
+	}
+	return crs, nil
+}
+
+// The parameters of the natural origin as a PROJ string
+func (p *Projection) proj4NaturalOrigin(scale string) string {
+	return " +lat_0=" + wktFloat(p.LatO) + " +lon_0=" + wktFloat(p.LongO) + " +" + scale + "=" + wktFloat(p.Scale) +
+		" +x_0=" + wktFloat(p.FE) + " +y_0=" + wktFloat(p.FN)
+}
+
+// The ellipsoid, prime meridian and transformation into WGS84 of the datum as a PROJ string
+func (d *Datum) proj4() string {
+
+	if d == WGS84Datum {
+		return " +datum=WGS84"
+	}
+
+	def := ""
+	for _, pe := range proj4Ellipsoids {
+		if pe.el == d.El {
+			def = " +ellps=" + pe.name
+			break
+		}
+	}
+	if def == "" {
+		def = " +a=" + wktFloat(d.El.a) + " +b=" + wktFloat(d.El.b)
+	}
+
+	if hp, ok := d.wktToWGS84(); ok {
+		def += " +towgs84=" + strings.Join([]string{wktFloat(hp.Dx), wktFloat(hp.Dy), wktFloat(hp.Dz),
+			wktFloat(hp.Rx), wktFloat(hp.Ry), wktFloat(hp.Rz), wktFloat(hp.DM)}, ",")
+	}
+
+	if pm := d.PrimeMeridian; pm != nil && pm != GreenwichMeridian {
+		if _, ok := LookupPrimeMeridian(pm.Name); ok {
+			def += " +pm=" + strings.ToLower(pm.Name)
+		} else {
+			def += " +pm=" + wktFloat(pm.Longitude)
+		}
+	}
+	return def
+}
+
+// Returns the coordinate reference system as PROJ string. The transformation of the datum into WGS84 is given
+// by +towgs84 in position vector convention, if it can be expressed by helmert parameters.
+//
+// Returns ErrUnsupported if the parameters can not be projected by the projection method.
+func (crs *CRS) Proj4() (string, error) {
+
+	p := crs.Projection
+	if p == nil {
+		return "+proj=longlat" + crs.Datum.proj4() + " +no_defs +type=crs", nil
+	}
+	if err := p.check(); err != nil {
+		return "", err
+	}
+
+	var def string
+	switch p.Method {
+	case TransverseMercator:
+		proj := "tmerc"
+		if p.Algorithm == TMKrueger {
+			proj = "etmerc"
+		}
+		def = "+proj=" + proj + p.proj4NaturalOrigin("k")
+	case LambertConformalConic1SP:
+		def = "+proj=lcc +lat_1=" + wktFloat(p.LatO) + p.proj4NaturalOrigin("k_0")
+	case LambertConformalConic2SP:
+		def = "+proj=lcc +lat_0=" + wktFloat(p.LatO) + " +lon_0=" + wktFloat(p.LongO) + " +lat_1=" + wktFloat(p.Lat1) +
+			" +lat_2=" + wktFloat(p.Lat2) + " +x_0=" + wktFloat(p.FE) + " +y_0=" + wktFloat(p.FN)
+	case ObliqueStereographic:
+		def = "+proj=sterea" + p.proj4NaturalOrigin("k")
+	case SwissObliqueMercator:
+		def = "+proj=somerc" + p.proj4NaturalOrigin("k_0")
+	case PolarStereographic:
+		def = "+proj=stere" + p.proj4NaturalOrigin("k")
+	default:
+		return wktWebMercatorExtension + " +type=crs", nil
+	}
+	return def + crs.Datum.proj4() + " +units=m +no_defs +type=crs", nil
+}
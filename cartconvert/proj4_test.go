@@ -0,0 +1,269 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"math"
+	"testing"
+)
+
+// ## ParseProj4
+type parseProj4Test struct {
+	in         string
+	name       string
+	datum      *Datum
+	projection *Projection
+}
+
+// PROJ strings of EPSG as written by PROJ
+var parseProj4Tests = []parseProj4Test{
+	{ // EPSG:27700
+		"+proj=tmerc +lat_0=49 +lon_0=-2 +k=0.9996012717 +x_0=400000 +y_0=-100000 +ellps=airy +towgs84=446.448,-125.157,542.06,0.15,0.247,0.842,-20.489 +units=m +no_defs +type=crs",
+		"unknown",
+		OSGB36Datum,
+		&Projection{Method: TransverseMercator, LatO: 49, LongO: -2, Scale: 0.9996012717, FE: 400000, FN: -100000},
+	},
+	{ // EPSG:31467
+		"+proj=tmerc +lat_0=0 +lon_0=9 +k=1 +x_0=3500000 +y_0=0 +ellps=bessel +towgs84=598.1,73.7,418.2,0.202,0.045,-2.455,6.7 +units=m +no_defs",
+		"unknown",
+		DHDNDatum,
+		&Projection{Method: TransverseMercator, LongO: 9, Scale: 1, FE: 3500000},
+	},
+	{ // the Krüger series of PROJ
+		"+proj=etmerc +lat_0=0 +lon_0=15 +k=0.9996 +x_0=500000 +y_0=0 +datum=WGS84",
+		"unknown",
+		WGS84Datum,
+		&Projection{Method: TransverseMercator, LongO: 15, Scale: 0.9996, FE: 500000, Algorithm: TMKrueger},
+	},
+	{
+		"  +proj=utm   +zone=33 +south +datum=WGS84 +title=Antarctica",
+		"Antarctica",
+		WGS84Datum,
+		&Projection{Method: TransverseMercator, LongO: 15, Scale: 0.9996, FE: 500000, FN: 10000000},
+	},
+	{ // EPSG:2056
+		"+proj=somerc +lat_0=46.9524055555556 +lon_0=7.43958333333333 +k_0=1 +x_0=2600000 +y_0=1200000 +ellps=bessel +towgs84=674.374,15.056,405.346,0,0,0,0 +units=m +no_defs",
+		"unknown",
+		CH1903PlusDatum,
+		&Projection{Method: SwissObliqueMercator, LatO: 46.9524055555556, LongO: 7.43958333333333, Scale: 1, FE: 2600000, FN: 1200000},
+	},
+	{ // EPSG:28992 by the transformation of the catalogue
+		"+proj=sterea +lat_0=52.1561605555556 +lon_0=5.38763888888889 +k=0.9999079 +x_0=155000 +y_0=463000 +ellps=bessel +towgs84=593.16,26.15,478.54 +units=m +no_defs",
+		"unknown",
+		AmersfoortDatum,
+		&Projection{Method: ObliqueStereographic, LatO: 52.1561605555556, LongO: 5.38763888888889, Scale: 0.9999079, FE: 155000, FN: 463000},
+	},
+	{ // EPSG:27572
+		"+proj=lcc +lat_1=46.8 +lat_0=46.8 +lon_0=0 +k_0=0.99987742 +x_0=600000 +y_0=2200000 +a=6378249.2 +b=6356515 +towgs84=-168,-60,320,0,0,0,0 +pm=paris +units=m +no_defs",
+		"unknown",
+		NTFParisDatum,
+		&Projection{Method: LambertConformalConic1SP, LatO: 46.8, Scale: 0.99987742, FE: 600000, FN: 2200000},
+	},
+	{ // EPSG:31287
+		"+proj=lcc +lat_0=47.5 +lon_0=13.3333333333333 +lat_1=49 +lat_2=46 +x_0=400000 +y_0=400000 +datum=hermannskogel +units=m +no_defs",
+		"unknown",
+		MGIDatum,
+		&Projection{Method: LambertConformalConic2SP, LatO: 47.5, LongO: 13.3333333333333, Lat1: 49, Lat2: 46, Scale: 1, FE: 400000, FN: 400000},
+	},
+	{ // EPSG:3857
+		"+proj=merc +a=6378137 +b=6378137 +lat_ts=0 +lon_0=0 +x_0=0 +y_0=0 +k=1 +units=m +nadgrids=@null +wktext +no_defs +type=crs",
+		"unknown",
+		WGS84Datum,
+		&Projection{Method: WebMercator, Scale: 1},
+	},
+	{
+		"+proj=webmerc +datum=WGS84",
+		"unknown",
+		WGS84Datum,
+		&Projection{Method: WebMercator, Scale: 1},
+	},
+	{
+		"+proj=stere +lat_0=90 +lat_ts=90 +lon_0=0 +k=0.994 +x_0=2000000 +y_0=2000000 +datum=WGS84 +units=m +no_defs",
+		"unknown",
+		WGS84Datum,
+		&Projection{Method: PolarStereographic, LatO: 90, Scale: 0.994, FE: 2000000, FN: 2000000},
+	},
+	{
+		"+proj=ups +south +ellps=WGS84",
+		"unknown",
+		WGS84Datum,
+		&Projection{Method: PolarStereographic, LatO: -90, Scale: 0.994, FE: 2000000, FN: 2000000},
+	},
+	{
+		"+proj=longlat +ellps=GRS80 +no_defs",
+		"unknown",
+		ETRS89Datum,
+		nil,
+	},
+	{
+		"proj=longlat datum=hermannskogel pm=ferro",
+		"unknown",
+		MGIFerroDatum,
+		nil,
+	},
+}
+
+func TestParseProj4(t *testing.T) {
+	for index, test := range parseProj4Tests {
+		crs, err := ParseProj4(test.in)
+		if err != nil {
+			t.Errorf("ParseProj4 [%d]: %s", index, err)
+			continue
+		}
+		if crs.Name != test.name || crs.Datum != test.datum || !projectionequal(crs.Projection, test.projection) {
+			t.Errorf("ParseProj4 [%d]: expected %s on %s by %v, got %s on %s by %v", index, test.name, test.datum, test.projection, crs, crs.Datum, crs.Projection)
+		}
+	}
+}
+
+// Datums which are not in the catalogue
+func TestParseProj4Datum(t *testing.T) {
+
+	// Gauß-Krüger M28 on MGI with the transformation of PROJ
+	crs, err := ParseProj4("+proj=tmerc +lat_0=0 +lon_0=10.3333333333333 +k=1 +x_0=150000 +y_0=-5000000 +ellps=bessel +towgs84=577.326,90.129,463.919,5.137,1.474,5.297,2.4232 +units=m +no_defs")
+	if err != nil || crs.Datum != MGIDatum {
+		t.Fatalf("ParseProj4: expected the datum %s, got %v (%v)", MGIDatum, crs, err)
+	}
+
+	// Gauß-Krüger projection of the latitude and longitude transformed by the parameters
+	in := &PolarCoord{Latitude: 47.439212, Longitude: 10.7, El: WGS84Ellipsoid}
+	pt, err := crs.FromWGS84(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewHelmertTransformer(577.326, 90.129, 463.919, 2.4232, 5.137, 1.474, 5.297, "")
+	gc := NewGeocentricTransformer(expected, Bessel1841Ellipsoid, WGS84Ellipsoid).InverseTransformPolar(in)
+	if gp := DefaultTMAlgorithm.DirectTransverseMercator(gc, 0, 10+1.0/3, 1, 150000, -5000000); math.Hypot(gp.X-pt.X, gp.Y-pt.Y) > 0.01 {
+		t.Errorf("CRS.FromWGS84: expected %v, got %v", gp, pt)
+	}
+
+	// a transformation which is not in the catalogue
+	crs, err = ParseProj4("+proj=longlat +ellps=bessel +towgs84=426.9,142.6,460.1,4.91,4.49,-12.42,17.1")
+	expected = NewHelmertTransformer(426.9, 142.6, 460.1, 17.1, 4.91, 4.49, -12.42, "")
+	if hp, ok := crs.Datum.towgs84(); err != nil || crs.Datum.Name != "Unknown based on Bessel1841 ellipsoid" || crs.Datum.El != Bessel1841Ellipsoid || !ok || !helmertEqual(expected, hp) {
+		t.Errorf("ParseProj4: expected a datum transformed by %s, got %v by %v (%v)", expected, crs, hp, err)
+	}
+
+	// datums without transformation coincide with WGS84
+	crs, err = ParseProj4("+proj=longlat +a=6371000 +pm=-17.6666666666667")
+	if err != nil || crs.Datum.El.a != 6371000 || crs.Datum.El.b != 6371000 || crs.Datum.PrimeMeridian != FerroMeridian || crs.Datum.ToWGS84 != nil {
+		t.Errorf("ParseProj4: expected a sphere east of Ferro, got %v (%v)", crs, err)
+	}
+
+	crs, err = ParseProj4("+proj=longlat +ellps=intl")
+	if err != nil || crs.Datum.Name != "Unknown based on International1924 ellipsoid" || crs.Datum.ToWGS84 != nil {
+		t.Errorf("ParseProj4: expected a datum on the international ellipsoid, got %v (%v)", crs, err)
+	}
+}
+
+// ## ParseProj4 errors
+type parseProj4ErrorTest struct {
+	in    string
+	coord string
+	index int
+	err   error
+}
+
+var parseProj4ErrorTests = []parseProj4ErrorTest{
+	{"", "+proj", 0, ErrSyntax},
+	{"+ellps=bessel", "+proj", 13, ErrSyntax},
+	{"+proj=tmerc +lat_0=4x", "+lat_0=4x", 12, ErrSyntax},
+	{"+proj=tmerc +lat_0", "+lat_0", 12, ErrSyntax},
+	{"+proj=tmerc +lat_0=", "+lat_0=", 12, ErrSyntax},
+	{"+proj=tmerc +k=1 +k=1", "+k=1", 17, ErrSyntax},
+	{"+proj=tmerc + +k=1", "+", 12, ErrSyntax},
+	{"+proj=tmerc +ellps=bessel +towgs84=1,2", "+towgs84=1,2", 26, ErrSyntax},
+	{"+proj=utm", "+zone", 9, ErrSyntax},
+	{"+proj=utm +zone=61", "+zone=61", 10, ErrRange},
+	{"+proj=longlat +a=6378137 +rf=0.5", "+rf=0.5", 25, ErrRange},
+	{"+proj=longlat +a=6378137 +rf=0", "+rf=0", 25, ErrRange},
+	{"+proj=longlat +a=6378137 +f=1", "+f=1", 25, ErrRange},
+	{"+proj=tmerc +lat_0=91", "+lat_0=91", 12, ErrRange},
+	{"+proj=tmerc +k=0", "+k=0", 12, ErrRange},
+	{"+proj=stere +lat_0=90 +k_0=-1", "+k_0=-1", 22, ErrRange},
+	{"+proj=lcc +lat_1=0 +lat_2=0", "+lat_1=0", 10, ErrRange},
+	{"+proj=lcc +lat_1=30 +lat_2=-30", "+lat_2=-30", 20, ErrRange},
+	{"+proj=lcc +lat_1=45 +lat_2=90", "+lat_2=90", 20, ErrRange},
+	{"+proj=lcc +lat_1=-95 +lat_2=45", "+lat_1=-95", 10, ErrRange},
+	{"+init=epsg:31467", "+init=epsg:31467", 0, ErrUnsupported},
+	{"+proj=krovak +lat_0=49.5", "+proj=krovak", 0, ErrUnsupported},
+	{"+proj=merc +ellps=WGS84", "+proj=merc", 0, ErrUnsupported},
+	{"+proj=merc +a=6378137 +b=6378137 +lon_0=10", "+lon_0=10", 33, ErrUnsupported},
+	{"+proj=tmerc +lat_1=45 +lon_0=9", "+lat_1=45", 12, ErrUnsupported},
+	{"+proj=tmerc +ellps=clrk80", "+ellps=clrk80", 12, ErrUnsupported},
+	{"+proj=tmerc +ellps=bessel +nadgrids=BETA2007.gsb", "+nadgrids=BETA2007.gsb", 26, ErrUnsupported},
+	{"+proj=tmerc +datum=NAD27", "+datum=NAD27", 12, ErrUnsupported},
+	{"+proj=tmerc +datum=potsdam +ellps=intl", "+ellps=intl", 27, ErrUnsupported},
+	{"+proj=longlat +pm=lisbon", "+pm=lisbon", 14, ErrUnsupported},
+	{"+proj=tmerc +units=us-ft", "+units=us-ft", 12, ErrUnsupported},
+	{"+proj=tmerc +to_meter=0.3048", "+to_meter=0.3048", 12, ErrUnsupported},
+	{"+proj=tmerc +axis=neu", "+axis=neu", 12, ErrUnsupported},
+	{"+proj=stere +lat_0=45", "+lat_0=45", 12, ErrUnsupported},
+	{"+proj=stere +lat_0=90 +lat_ts=70", "+lat_ts=70", 22, ErrUnsupported},
+	{"+proj=lcc +lat_1=46 +lat_0=45", "+lat_0=45", 20, ErrUnsupported},
+	{"+proj=lcc +lat_1=46 +lat_2=49 +k_0=0.9999", "+k_0=0.9999", 30, ErrUnsupported},
+	{"+proj=longlat +geoidgrids=egm96_15.gtx", "+geoidgrids=egm96_15.gtx", 14, ErrUnsupported},
+}
+
+func TestParseProj4Error(t *testing.T) {
+	for index, test := range parseProj4ErrorTests {
+		_, err := ParseProj4(test.in)
+		ce, ok := err.(CartographyError)
+		if !ok || ce.Err != test.err || ce.Coord != test.coord || ce.Index != test.index {
+			t.Errorf("ParseProj4 [%d]: expected %s at %q (%d), got %v (%d)", index, test.err, test.coord, test.index, err, ce.Index)
+		}
+	}
+
+	if _, err := ParseProj4("+proj=stere +lat_0=45"); err.(CartographyError).Val != 45 {
+		t.Errorf("ParseProj4: expected the value 45, got %v", err)
+	}
+}
+
+// ## Proj4
+func TestProj4(t *testing.T) {
+	for index, crs := range wktCRSTests {
+		def, err := crs.Proj4()
+		if err != nil {
+			t.Errorf("Proj4 [%d]: %s", index, err)
+			continue
+		}
+
+		out, err := ParseProj4(def)
+		if err != nil {
+			t.Errorf("ParseProj4 [%d]: %s in %s", index, err, def)
+			continue
+		}
+		if !projectionequal(out.Projection, crs.Projection) {
+			t.Errorf("ParseProj4 [%d]: expected %v, got %v", index, crs.Projection, out.Projection)
+		}
+
+		// datums of the catalogue are recognized, others are reconstructed
+		if _, ok := wktDatums[wktAlias(crs.Datum.Name)]; ok && out.Datum != crs.Datum {
+			t.Errorf("ParseProj4 [%d]: expected the datum %s, got %s in %s", index, crs.Datum, out.Datum, def)
+		}
+		expected, _ := crs.Datum.towgs84()
+		if hp, _ := out.Datum.towgs84(); out.Datum.El != crs.Datum.El || out.Datum.PrimeMeridian != crs.Datum.PrimeMeridian || !helmertEqual(expected, hp) {
+			t.Errorf("ParseProj4 [%d]: expected the datum %s by %s, got %s by %s", index, crs.Datum, expected, out.Datum, hp)
+		}
+	}
+
+	// the algorithm of the transverse mercator
+	for _, alg := range []TMAlgorithm{TMRedfearn, TMKrueger} {
+		crs := &CRS{Name: "unknown", Datum: WGS84Datum, Projection: &Projection{Method: TransverseMercator, LongO: 15, Scale: 0.9996, FE: 500000, Algorithm: alg}}
+		def, err := crs.Proj4()
+		if err != nil {
+			t.Errorf("Proj4 [%s]: %s", alg, err)
+			continue
+		}
+		if out, err := ParseProj4(def); err != nil || !projectionequal(out.Projection, crs.Projection) {
+			t.Errorf("ParseProj4 [%s]: expected %v, got %v (%v) in %s", alg, crs.Projection, out, err, def)
+		}
+	}
+
+	if def, _ := WGS84CRS.Proj4(); def != "+proj=longlat +datum=WGS84 +no_defs +type=crs" {
+		t.Errorf("Proj4: expected +proj=longlat +datum=WGS84 +no_defs +type=crs, got %s", def)
+	}
+}
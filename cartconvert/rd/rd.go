@@ -0,0 +1,206 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// This package provides a series of functions to deal with
+// conversion and transformations of coordinates in the Dutch Rijksdriehoeksstelsel (RD).
+//
+// RD coordinates are an oblique stereographic projection of the Amersfoort datum on the Bessel
+// ellipsoid. The datum shift to WGS84 uses a three parameter translation, which results in an
+// accuracy of about one meter. For accuracy within 1cm the RDNAPTRANS-Transformation has to be applied.
+//
+// References:
+//
+// [NL]: http://nl.wikipedia.org/wiki/Rijksdriehoeksco%C3%B6rdinaten
+package rd
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"strconv"
+	"strings"
+)
+
+// A coordinate of the RD grid is specified by easting (x) and northing (y). RelHeight is the ellipsoidal
+// height above the Bessel ellipsoid of the Amersfoort datum.
+type RDCoord struct {
+	Easting, Northing, RelHeight float64
+	El                           *cartconvert.Ellipsoid
+	Datum                        *cartconvert.Datum
+}
+
+var coordliterals = []string{"x:", " y:"}
+
+// Parameters of the oblique stereographic projection of the RD grid
+const (
+	rdLatO  = 52 + 9.0/60 + 22.178/3600
+	rdLongO = 5 + 23.0/60 + 15.5/3600
+	rdScale = 0.9999079
+	rdFE    = 155000
+	rdFN    = 463000
+)
+
+// Canonical representation of a RDCoord-value
+func (rc *RDCoord) String() (fs string) {
+
+	var next float64
+
+	if rc == nil {
+		return
+	}
+	for i := 0; i < 2; i++ {
+		fs += coordliterals[i]
+		switch i {
+		case 0:
+			next = rc.Easting
+		case 1:
+			next = rc.Northing
+		}
+
+		tmp := fmt.Sprintf("%f", next)
+		n := len(tmp)
+		for n > 0 && tmp[n-1] == '0' {
+			n--
+		}
+		if n > 0 && tmp[n-1] == '.' {
+			n--
+		}
+		fs = fs + tmp[:n]
+	}
+	return
+}
+
+// Parses a string representation of a RD coordinate into a struct holding a RDCoord coordinate value.
+// The coordinate is either given as "x:155000 y:463000", the literals in any order and case, or as
+// easting and northing separated by blanks, eg. "155000 463000".
+// The reference ellipsoid of the RD datum is always the Bessel ellipsoid.
+//
+// returns a cartconvert.CartographyError of cartconvert.ErrSyntax if format is not understood
+func ARDToStruct(coord string) (*RDCoord, error) {
+
+	fields := strings.Fields(strings.ToUpper(coord))
+	if len(fields) != 2 {
+		return nil, cartconvert.CartographyError{Coord: coord, Err: cartconvert.ErrSyntax}
+	}
+
+	var values [2]string
+	var seen [2]bool
+
+	for i, field := range fields {
+		index := i
+		switch {
+		case strings.HasPrefix(field, "X:"):
+			index = 0
+			field = field[2:]
+		case strings.HasPrefix(field, "Y:"):
+			index = 1
+			field = field[2:]
+		}
+
+		if seen[index] {
+			return nil, cartconvert.CartographyError{Coord: fields[i], Err: cartconvert.ErrSyntax}
+		}
+		seen[index] = true
+		values[index] = field
+	}
+
+	easting, err := strconv.ParseFloat(values[0], 64)
+	if err != nil {
+		return nil, cartconvert.CartographyError{Coord: values[0], Err: cartconvert.ErrSyntax}
+	}
+
+	northing, err := strconv.ParseFloat(values[1], 64)
+	if err != nil {
+		return nil, cartconvert.CartographyError{Coord: values[1], Err: cartconvert.ErrSyntax}
+	}
+
+	return &RDCoord{Easting: easting, Northing: northing, El: cartconvert.Bessel1841Ellipsoid, Datum: cartconvert.AmersfoortDatum}, nil
+}
+
+// Transform a RD coordinate value to a WGS84 based latitude and longitude coordinate.
+func RDToWGS84LatLong(coord *RDCoord) (*cartconvert.PolarCoord, error) {
+
+	gc := cartconvert.InverseObliqueStereographic(
+		&cartconvert.GeoPoint{Y: coord.Northing, X: coord.Easting, H: coord.RelHeight, El: cartconvert.Bessel1841Ellipsoid},
+		rdLatO,
+		rdLongO,
+		rdScale,
+		rdFE,
+		rdFN)
+
+	return cartconvert.TransformDatum(gc, cartconvert.AmersfoortDatum, cartconvert.WGS84Datum)
+}
+
+// Transform a latitude / longitude coordinate datum into a RD coordinate.
+//
+// Important: The reference ellipsoid of the originating coordinate system will be assumed
+// to be the WGS84Ellipsoid, regardless of the actually set reference ellipsoid.
+func WGS84LatLongToRD(gcin *cartconvert.PolarCoord) (*RDCoord, error) {
+
+	// This sets the Ellipsoid to WGS84 on a copy, regardless of the actual value set
+	gc := *gcin
+	gc.El = cartconvert.WGS84Ellipsoid
+
+	polar, err := cartconvert.TransformDatum(&gc, cartconvert.WGS84Datum, cartconvert.AmersfoortDatum)
+	if err != nil {
+		return nil, err
+	}
+
+	gp := cartconvert.DirectObliqueStereographic(
+		polar,
+		rdLatO,
+		rdLongO,
+		rdScale,
+		rdFE,
+		rdFN)
+
+	return &RDCoord{Northing: gp.Y, Easting: gp.X, RelHeight: gp.H, El: gp.El, Datum: cartconvert.AmersfoortDatum}, nil
+}
+
+func NewRDCoord(Easting, Northing, RelHeight float64) *RDCoord {
+	return &RDCoord{Easting: Easting, Northing: Northing, RelHeight: RelHeight, El: cartconvert.Bessel1841Ellipsoid, Datum: cartconvert.AmersfoortDatum}
+}
+
+// The coordinate reference system of the RD grid on the datum Amersfoort
+var RDCRS = &cartconvert.CRS{
+	Name:       "Amersfoort / RD New",
+	Datum:      cartconvert.AmersfoortDatum,
+	Projection: &cartconvert.Projection{Method: cartconvert.ObliqueStereographic, LatO: rdLatO, LongO: rdLongO, Scale: rdScale, FE: rdFE, FN: rdFN},
+}
+
+// The Dutch RD grid as a cartconvert.CoordinateSystem
+type rdSystem struct{}
+
+func (rdSystem) Name() string        { return "rd" }
+func (rdSystem) Description() string { return "NL:Rijksdriehoeksstelsel" }
+
+func (rdSystem) Parse(coord string) (fmt.Stringer, error) {
+	return ARDToStruct(coord)
+}
+
+func (rdSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*RDCoord); !ok {
+		return "", cartconvert.ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (rdSystem) ToLatLong(coord fmt.Stringer) (*cartconvert.PolarCoord, error) {
+	rdcoord, ok := coord.(*RDCoord)
+	if !ok {
+		return nil, cartconvert.ErrCoordType
+	}
+	return RDToWGS84LatLong(rdcoord)
+}
+
+func (rdSystem) FromLatLong(pc *cartconvert.PolarCoord) (fmt.Stringer, error) {
+	return WGS84LatLongToRD(pc)
+}
+
+func (rdSystem) CRS(coord fmt.Stringer) (*cartconvert.CRS, error) {
+	return RDCRS, nil
+}
+
+func init() {
+	cartconvert.RegisterCoordinateSystem(rdSystem{})
+}
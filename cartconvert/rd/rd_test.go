@@ -0,0 +1,219 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert/rd package
+package rd
+
+import (
+	"fmt"
+	"github.com/the42/cartconvert/cartconvert"
+	"math"
+	"testing"
+)
+
+type rdCoordRepresentation struct {
+	in  RDCoord
+	out string
+}
+
+var rdCoordRepresentationTest = []rdCoordRepresentation{
+	{
+		RDCoord{Easting: 155000, Northing: 463000}, "x:155000 y:463000",
+	},
+	{
+		RDCoord{Easting: 121687.5, Northing: 487484.25}, "x:121687.5 y:487484.25",
+	},
+}
+
+func TestRDCoordRepresentation(t *testing.T) {
+	for cnt, test := range rdCoordRepresentationTest {
+		out := test.in.String()
+
+		if test.out != out {
+			t.Errorf("TestRDCoordRepresentation [%d]: Expected: %s, got: %s", cnt, test.out, out)
+		}
+	}
+}
+
+// ## ARDToStruct
+type aRDToStructretparam struct {
+	coord *RDCoord
+	err   error
+}
+
+func (val *aRDToStructretparam) String() (fs string) {
+
+	if val.coord != nil {
+		fs = val.coord.String()
+	}
+
+	if val.err != nil {
+		fs += " " + val.err.Error()
+	}
+	return
+}
+
+type aRDToStruct struct {
+	in  string
+	out aRDToStructretparam
+}
+
+var aRDToStructTests = []aRDToStruct{
+	{
+		in: "x:155000 y:463000", out: aRDToStructretparam{coord: &RDCoord{Easting: 155000, Northing: 463000}, err: nil},
+	},
+	{
+		in: " Y:463000  X:155000.5", out: aRDToStructretparam{coord: &RDCoord{Easting: 155000.5, Northing: 463000}, err: nil},
+	},
+	{
+		in: "121687 487484", out: aRDToStructretparam{coord: &RDCoord{Easting: 121687, Northing: 487484}, err: nil},
+	},
+	{
+		in: "x:155000 x:463000", out: aRDToStructretparam{coord: nil, err: cartconvert.CartographyError{Coord: "X:463000", Err: cartconvert.ErrSyntax}},
+	},
+	{
+		in: "x:155000 N:463000", out: aRDToStructretparam{coord: nil, err: cartconvert.CartographyError{Coord: "N:463000", Err: cartconvert.ErrSyntax}},
+	},
+	{
+		in: "155000", out: aRDToStructretparam{coord: nil, err: cartconvert.CartographyError{Coord: "155000", Err: cartconvert.ErrSyntax}},
+	},
+}
+
+func ardtostructequal(coord1, coord2 aRDToStructretparam) bool {
+	if coord1.coord != nil && coord2.coord != nil {
+		return coord1.coord.String() == coord2.coord.String()
+	}
+	return coord1.err == coord2.err
+}
+
+func TestARDToStruct(t *testing.T) {
+	for cnt, test := range aRDToStructTests {
+
+		out, erro := ARDToStruct(test.in)
+		retval := aRDToStructretparam{coord: out, err: erro}
+
+		if !ardtostructequal(test.out, retval) {
+			t.Errorf("ARDToStruct [%d]: expected %v, got %v", cnt, test.out, retval)
+		}
+	}
+}
+
+// ## RDToWGS84LatLong
+type rdToWGS84LatLongTest struct {
+	in  *RDCoord
+	out *cartconvert.PolarCoord
+}
+
+// The Onze Lieve Vrouwetoren in Amersfoort, origin of the RD grid, as given by RDNAPTRANS
+var rdToWGS84LatLongTests = []rdToWGS84LatLongTest{
+	{
+		NewRDCoord(155000, 463000, 0),
+		&cartconvert.PolarCoord{Latitude: 52.15517440, Longitude: 5.38720621},
+	},
+}
+
+// equal within about one meter
+func latlongequal(pcp1, pcp2 *cartconvert.PolarCoord) bool {
+	pp1s := fmt.Sprintf("%.5f %.5f", pcp1.Latitude, pcp1.Longitude)
+	pp2s := fmt.Sprintf("%.5f %.5f", pcp2.Latitude, pcp2.Longitude)
+
+	return pp1s == pp2s
+}
+
+func TestRDToWGS84LatLong(t *testing.T) {
+	for cnt, test := range rdToWGS84LatLongTests {
+
+		out, err := RDToWGS84LatLong(test.in)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !latlongequal(test.out, out) {
+			t.Errorf("RDToWGS84LatLong [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+	}
+}
+
+// ## WGS84LatLongToRD
+type wGS84LatLongToRDTest struct {
+	in  *cartconvert.PolarCoord
+	out *RDCoord
+}
+
+var wGS84LatLongToRDTests = []wGS84LatLongToRDTest{
+	{
+		&cartconvert.PolarCoord{Latitude: 52.15517440, Longitude: 5.38720621},
+		NewRDCoord(155000, 463000, 0),
+	},
+}
+
+func rdcoordfuzzyequal(c1, c2 *RDCoord) bool {
+	return math.Hypot(c1.Easting-c2.Easting, c1.Northing-c2.Northing) < 1.0
+}
+
+func TestWGS84LatLongToRD(t *testing.T) {
+	for cnt, test := range wGS84LatLongToRDTests {
+		out, err := WGS84LatLongToRD(test.in)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !rdcoordfuzzyequal(test.out, out) {
+			t.Errorf("WGS84LatLongToRD [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+
+		// the input is left unmodified
+		if test.in.El != nil {
+			t.Errorf("WGS84LatLongToRD [%d]: Expected the input without an ellipsoid, got %v", cnt, test.in.El)
+		}
+	}
+}
+
+// ## Round trip
+func TestRDRoundTrip(t *testing.T) {
+	for _, in := range []*RDCoord{
+		NewRDCoord(121687, 487484, 0),
+		NewRDCoord(233883, 582065, 0),
+		NewRDCoord(13000, 381000, 0),
+	} {
+		gc, err := RDToWGS84LatLong(in)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		out, err := WGS84LatLongToRD(gc)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if math.Hypot(in.Easting-out.Easting, in.Northing-out.Northing) > 0.001 {
+			t.Errorf("RD round trip: Expected %s, got %s", in, out)
+		}
+	}
+}
+
+// ## 3D round trip
+func TestRDHeight(t *testing.T) {
+
+	in := &cartconvert.PolarCoord{Latitude: 52.155, Longitude: 5.387, Height: 47.5, El: cartconvert.WGS84Ellipsoid}
+
+	coord, err := WGS84LatLongToRD(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the height above the Bessel ellipsoid differs by the datum shift
+	if math.Abs(coord.RelHeight-in.Height) < 1 {
+		t.Errorf("WGS84LatLongToRD: Expected the height to be shifted, got %f", coord.RelHeight)
+	}
+
+	out, err := RDToWGS84LatLong(coord)
+	if err != nil || !latlongequal(in, out) || math.Abs(out.Height-in.Height) > 0.001 {
+		t.Errorf("RDToWGS84LatLong: Expected %s at %fm, got %s at %fm (%v)", in, in.Height, out, out.Height, err)
+	}
+}
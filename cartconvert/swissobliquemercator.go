@@ -0,0 +1,118 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+)
+
+// ## Swiss Oblique Mercator Projection
+
+// Parameters of the conformal sphere of the Swiss oblique mercator double projection for the
+// latitude of origin latO in radians: the radius of the sphere r, the exponent alpha, the
+// latitude of origin on the sphere bO and the integration constant k
+func swissObliqueMercatorParameters(el *Ellipsoid, latO float64) (r, alpha, bO, k float64) {
+	e2 := (el.a*el.a - el.b*el.b) / (el.a * el.a)
+	e := math.Sqrt(e2)
+
+	sinlatO := math.Sin(latO)
+	coslatO := math.Cos(latO)
+
+	r = el.a * math.Sqrt(1-e2) / (1 - e2*sinlatO*sinlatO)
+	alpha = math.Sqrt(1 + e2/(1-e2)*coslatO*coslatO*coslatO*coslatO)
+	bO = math.Asin(sinlatO / alpha)
+	k = math.Log(math.Tan(math.Pi/4+bO/2)) - alpha*math.Log(math.Tan(math.Pi/4+latO/2)) +
+		alpha*e/2*math.Log((1+e*sinlatO)/(1-e*sinlatO))
+	return
+}
+
+// Direct Swiss oblique mercator projection: Conformal projection of the ellipsoid onto a sphere, which
+// is projected onto a cylinder touching the sphere along the great circle through the origin perpendicular
+// to the meridian of origin. Input parameters:
+//
+//	gc *PolarCoord: Latitude and Longitude or point to be projected; in decimal degrees
+//	latO, longO: Latitude and longitude of the origin in decimal degrees
+//	scale: Projection scaling at the origin; Dimensionless, typically 1 or little bellow
+//	fe, fn: False easting and northing respectively in meters
+//
+// Taken from "Formeln und Konstanten für die Berechnung der Schweizerischen schiefachsigen
+// Zylinderprojektion und der Transformation zwischen Koordinatensystemen", swisstopo.
+// Identical to the Hotine oblique mercator (variant B) with an azimuth and rectified grid angle of 90°
+func DirectSwissObliqueMercator(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
+
+	var pt GeoPoint
+
+	el := gc.El
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+	r, alpha, bO, k := swissObliqueMercatorParameters(el, degtorad(latO))
+	r *= scale
+
+	lat := degtorad(gc.Latitude)
+	esin := e * math.Sin(lat)
+
+	// latitude and longitude on the sphere
+	s := alpha*math.Log(math.Tan(math.Pi/4+lat/2)) - alpha*e/2*math.Log((1+esin)/(1-esin)) + k
+	b := 2 * (math.Atan(math.Exp(s)) - math.Pi/4)
+	l := alpha * degtorad(gc.Longitude-longO)
+
+	// latitude and longitude relative to the pseudo equator through the origin
+	lbar := math.Atan(math.Sin(l) / (math.Sin(bO)*math.Tan(b) + math.Cos(bO)*math.Cos(l)))
+	bbar := math.Asin(math.Cos(bO)*math.Sin(b) - math.Sin(bO)*math.Cos(b)*math.Cos(l))
+
+	pt.X = fe + r*lbar
+	pt.Y = fn + r/2*math.Log((1+math.Sin(bbar))/(1-math.Sin(bbar)))
+
+	pt.El = el
+	pt.H = gc.Height
+
+	return &pt
+}
+
+// Inverse Swiss oblique mercator projection: Projection of a cylinder touching the conformal sphere along
+// the great circle through the origin back onto the ellipsoid. Input parameters:
+//
+//	pt *GeoPoint: Easting(X) and Northing(Y) of map point to be projected; in meters
+//	latO, longO: Latitude and longitude of the origin in decimal degrees
+//	scale: Projection scaling at the origin; Dimensionless, typically 1 or little bellow
+//	fe, fn: False easting and northing respectively in meters
+//
+// Taken from "Formeln und Konstanten für die Berechnung der Schweizerischen schiefachsigen
+// Zylinderprojektion und der Transformation zwischen Koordinatensystemen", swisstopo
+func InverseSwissObliqueMercator(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
+
+	var gc PolarCoord
+
+	el := pt.El
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+	r, alpha, bO, k := swissObliqueMercatorParameters(el, degtorad(latO))
+	r *= scale
+
+	// latitude and longitude relative to the pseudo equator through the origin
+	lbar := (pt.X - fe) / r
+	bbar := 2 * (math.Atan(math.Exp((pt.Y-fn)/r)) - math.Pi/4)
+
+	// latitude and longitude on the sphere
+	b := math.Asin(math.Cos(bO)*math.Sin(bbar) + math.Sin(bO)*math.Cos(bbar)*math.Cos(lbar))
+	l := math.Atan(math.Sin(lbar) / (math.Cos(bO)*math.Cos(lbar) - math.Sin(bO)*math.Tan(bbar)))
+
+	lat := b
+	for i := 0; i < 20; i++ {
+		s := (math.Log(math.Tan(math.Pi/4+b/2))-k)/alpha + e*math.Log(math.Tan(math.Pi/4+math.Asin(e*math.Sin(lat))/2))
+		latn := 2*math.Atan(math.Exp(s)) - math.Pi/2
+		if math.Abs(latn-lat) < 1e-12 {
+			lat = latn
+			break
+		}
+		lat = latn
+	}
+
+	gc.Latitude = radtodeg(lat)
+	gc.Longitude = longO + radtodeg(l/alpha)
+
+	gc.El = el
+	gc.Height = pt.H
+
+	return &gc
+}
@@ -0,0 +1,50 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// ## Swiss Oblique Mercator
+// CH1903 / LV03; cross checked against the Hotine oblique mercator (variant B) of OGP Publication 373-7-2
+const (
+	lv03LatO  = 46 + 57.0/60 + 8.66/3600
+	lv03LongO = 7 + 26.0/60 + 22.5/3600
+)
+
+var swissObliqueMercatorTests = []directtransversemercatorTest{
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: lv03LatO, Longitude: lv03LongO, El: Bessel1841Ellipsoid}, lv03LatO, lv03LongO, 1, 600000, 200000},
+		&GeoPoint{X: 600000, Y: 200000},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 46.5, Longitude: 8.5, El: Bessel1841Ellipsoid}, lv03LatO, lv03LongO, 1, 600000, 200000},
+		&GeoPoint{X: 681390.49, Y: 150263.64},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 47.518605, Longitude: 9.437422, El: Bessel1841Ellipsoid}, lv03LatO, lv03LongO, 1, 600000, 200000},
+		&GeoPoint{X: 750444.02, Y: 264858.33},
+	},
+	{
+		directtransversemercatorParam{&PolarCoord{Latitude: 45.8, Longitude: 6, El: Bessel1841Ellipsoid}, lv03LatO, lv03LongO, 1, 2600000, 1200000},
+		&GeoPoint{X: 2488081.43, Y: 1072933.53},
+	},
+}
+
+func TestSwissObliqueMercator(t *testing.T) {
+	for cnt, test := range swissObliqueMercatorTests {
+		out := DirectSwissObliqueMercator(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !geopointcmequal(test.out, out) {
+			t.Errorf("DirectSwissObliqueMercator [%d]: Expected %v, got %v", cnt, test.out, out)
+		}
+
+		pc := InverseSwissObliqueMercator(out, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !latlongmmequal(test.in.pc, pc) {
+			t.Errorf("InverseSwissObliqueMercator [%d]: Expected %s, got %s", cnt, test.in.pc, pc)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ## Polar Stereographic Projection
+
+// Factor of the polar stereographic projection which only depends on the eccentricity e
+func polarStereographicFactor(e float64) float64 {
+	return math.Sqrt(math.Pow(1+e, 1+e) * math.Pow(1-e, 1-e))
+}
+
+// Direct polar stereographic projection: Projection of an ellipsoid onto a plane touching
+// the ellipsoid at one of the poles. Input parameters:
+//
+//	gc *PolarCoord: Latitude and Longitude or point to be projected; in decimal degrees
+//	latO: 90 for the projection onto the north pole, -90 for the south pole
+//	longO: Longitude of origin, the meridian pointing towards grid north; in decimal degrees
+//	fe, fn: False easting and northing respectively in meters
+//	scale: Projection scaling at the pole; Dimensionless, typically 1 or little bellow
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Polar Stereographic (Variant A), pp. 67 - 70
+func DirectPolarStereographic(gc *PolarCoord, latO, longO, scale, fe, fn float64) *GeoPoint {
+
+	var pt GeoPoint
+
+	el := gc.El
+
+	e := math.Sqrt((el.a*el.a - el.b*el.b) / (el.a * el.a))
+
+	lat := degtorad(gc.Latitude)
+	dlong := degtorad(gc.Longitude - longO)
+
+	// the south pole case is the mirrored north pole case
+	if latO < 0 {
+		lat = -lat
+	}
+
+	esin := e * math.Sin(lat)
+	t := math.Tan(math.Pi/4-lat/2) / math.Pow((1-esin)/(1+esin), e/2)
+	rho := 2 * el.a * scale * t / polarStereographicFactor(e)
+
+	pt.X = fe + rho*math.Sin(dlong)
+	if latO < 0 {
+		pt.Y = fn + rho*math.Cos(dlong)
+	} else {
+		pt.Y = fn - rho*math.Cos(dlong)
+	}
+
+	pt.El = el
+	pt.H = gc.Height
+
+	return &pt
+}
+
+// Inverse polar stereographic projection: Projection of a plane touching the ellipsoid at one
+// of the poles back onto the ellipsoid. Input parameters:
+//
+//	pt *GeoPoint: Easting(X) and Northing(Y) of map point to be projected; in meters
+//	latO: 90 for the projection onto the north pole, -90 for the south pole
+//	longO: Longitude of origin, the meridian pointing towards grid north; in decimal degrees
+//	fe, fn: False easting and northing respectively in meters
+//	scale: Projection scaling at the pole; Dimensionless, typically 1 or little bellow
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Polar Stereographic (Variant A), pp. 67 - 70
+func InversePolarStereographic(pt *GeoPoint, latO, longO, scale, fe, fn float64) *PolarCoord {
+
+	var gc PolarCoord
+
+	el := pt.El
+
+	esq := (el.a*el.a - el.b*el.b) / (el.a * el.a)
+	e := math.Sqrt(esq)
+
+	dx := pt.X - fe
+	dy := pt.Y - fn
+
+	rho := math.Hypot(dx, dy)
+	t := rho * polarStereographicFactor(e) / (2 * el.a * scale)
+
+	chi := math.Pi/2 - 2*math.Atan(t)
+
+	e4 := esq * esq
+	e6 := e4 * esq
+	e8 := e6 * esq
+
+	lat := chi +
+		(esq/2+5*e4/24+e6/12+13*e8/360)*math.Sin(2*chi) +
+		(7*e4/48+29*e6/240+811*e8/11520)*math.Sin(4*chi) +
+		(7*e6/120+81*e8/1120)*math.Sin(6*chi) +
+		(4279*e8/161280)*math.Sin(8*chi)
+
+	var dlong float64
+	if latO < 0 {
+		lat = -lat
+		dlong = math.Atan2(dx, dy)
+	} else {
+		dlong = math.Atan2(dx, -dy)
+	}
+
+	gc.Latitude = radtodeg(lat)
+	gc.Longitude = longO + radtodeg(dlong)
+
+	gc.El = el
+	gc.Height = pt.H
+
+	return &gc
+}
+
+// ## UPS coordinate functions for parsing and conversion
+
+// A UPS coordinate defined by Northing, Easting and the zone A, B (south pole) or Y, Z (north pole).
+// The reference ellipsoid is typically the GRS80Ellipsoid or the WGS84Ellipsoid
+type UPSCoord struct {
+	Northing, Easting float64
+	Zone              string
+	El                *Ellipsoid
+}
+
+// UPS covers the polar regions, overlapping the UTM latitude limits by half a degree
+const (
+	upsNorthLimit = 83.5
+	upsSouthLimit = -79.5
+)
+
+// Canonical representation of a UPS coordinate
+func (ups *UPSCoord) String() string {
+	return fmt.Sprintf("%s %.0f %.0f", ups.Zone, ups.Easting, ups.Northing)
+}
+
+// This function parses a string UPS coordinate literal of the format
+//
+//	"ZONE EASTING NORTHING"
+//
+// Zone is one of A, B (south pole) or Y, Z (north pole). Easting and northing are specified as decimal meters.
+// If the reference ellipsoid is nil, the DefaultEllipsoid is assumed.
+//
+// returns a CartographyError of ErrSyntax if format is not understood
+func AUPSToStruct(upscoord string, el *Ellipsoid) (*UPSCoord, error) {
+
+	fields := strings.Fields(strings.ToUpper(upscoord))
+	if len(fields) != 3 {
+		return nil, CartographyError{Coord: upscoord, Err: ErrSyntax}
+	}
+
+	switch fields[0] {
+	case "A", "B", "Y", "Z":
+	default:
+		return nil, CartographyError{Coord: fields[0], Err: ErrSyntax}
+	}
+
+	east, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, CartographyError{Coord: fields[1], Err: ErrSyntax}
+	}
+
+	north, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, CartographyError{Coord: fields[2], Err: ErrSyntax}
+	}
+
+	if el == nil {
+		el = DefaultEllipsoid
+	}
+	return &UPSCoord{Northing: north, Easting: east, Zone: fields[0], El: el}, nil
+}
+
+// Convert from UPS 2D projection to 3D polar. If the UPS coordinates do not contain a
+// reference ellipsoid, the DefaultEllipsoid is assumed and copied to the resulting polar coordinates.
+//
+// Returns ErrRange if the zone is not one of A, B, Y, Z
+func UPSToLatLong(coord *UPSCoord) (*PolarCoord, error) {
+
+	var latO float64
+
+	switch coord.Zone {
+	case "A", "B":
+		latO = -90
+	case "Y", "Z":
+		latO = 90
+	default:
+		return nil, ErrRange
+	}
+
+	pt := &GeoPoint{X: coord.Easting, Y: coord.Northing, El: coord.El}
+	if pt.El == nil {
+		pt.El = DefaultEllipsoid
+	}
+
+	return InversePolarStereographic(pt, latO, 0, 0.994, 2000000, 2000000), nil
+}
+
+// Convert from 3D polar to UPS 2D projection. If the polar coordinates do not contain a
+// reference ellipsoid, the DefaultEllipsoid is assumed and copied to the resulting UPS coordinates.
+//
+// Returns ErrRange if the latitude is neither north of 83.5°N nor south of 79.5°S
+func LatLongToUPS(gcin *PolarCoord) (*UPSCoord, error) {
+
+	var ups UPSCoord
+	var latO float64
+
+	gc := *gcin // Make a copy as we might set the ellipsoid and we will not alter the input values
+
+	switch {
+	case gc.Latitude >= upsNorthLimit:
+		latO = 90
+		ups.Zone = "Z"
+		if gc.Longitude < 0 {
+			ups.Zone = "Y"
+		}
+	case gc.Latitude <= upsSouthLimit:
+		latO = -90
+		ups.Zone = "B"
+		if gc.Longitude < 0 {
+			ups.Zone = "A"
+		}
+	default:
+		return nil, ErrRange
+	}
+
+	if gc.El == nil {
+		gc.El = DefaultEllipsoid
+	}
+
+	pt := DirectPolarStereographic(&gc, latO, 0, 0.994, 2000000, 2000000)
+
+	ups.Easting = pt.X
+	ups.Northing = pt.Y
+	ups.El = pt.El
+
+	return &ups, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ## DirectPolarStereographic
+type directpolarstereographicTest struct {
+	in  directtransversemercatorParam
+	out *GeoPoint
+}
+
+var directpolarstereographicTests = []directpolarstereographicTest{
+	{
+		// OGP Publication 373-7-2, Polar Stereographic (Variant A) example
+		directtransversemercatorParam{
+			&PolarCoord{Latitude: 73, Longitude: 44, El: WGS84Ellipsoid},
+			90,
+			0,
+			0.994,
+			2000000,
+			2000000,
+		},
+		&GeoPoint{X: 3320416.7471, Y: 632668.4317},
+	},
+}
+
+func geopointcmequal(gp1, gp2 *GeoPoint) bool {
+	gp1s := fmt.Sprintf("%.2f %.2f", gp1.X, gp1.Y)
+	gp2s := fmt.Sprintf("%.2f %.2f", gp2.X, gp2.Y)
+
+	return gp1s == gp2s
+}
+
+func TestDirectPolarStereographic(t *testing.T) {
+	for cnt, test := range directpolarstereographicTests {
+		out := DirectPolarStereographic(test.in.pc, test.in.lat0, test.in.long0, test.in.scale, test.in.fe, test.in.fn)
+		if !geopointcmequal(test.out, out) {
+			t.Errorf("DirectPolarStereographic [%d]: Expected %v, got %v", cnt, test.out, out)
+		}
+	}
+}
+
+// ## InversePolarStereographic
+func TestInversePolarStereographic(t *testing.T) {
+	pt := &GeoPoint{X: 3320416.75, Y: 632668.43, El: WGS84Ellipsoid}
+	out := InversePolarStereographic(pt, 90, 0, 0.994, 2000000, 2000000)
+	if !latlongequal(&PolarCoord{Latitude: 73, Longitude: 44}, out) {
+		t.Errorf("InversePolarStereographic: Expected 73 44, got %s", out)
+	}
+}
+
+// ## AUPSToStruct
+type aUPSToStructTest struct {
+	in  string
+	out *UPSCoord
+}
+
+var aUPSToStructTests = []aUPSToStructTest{
+	{" Z 3320417  632668 ", &UPSCoord{Zone: "Z", Easting: 3320417, Northing: 632668}},
+	{"a 1234567.5 2345678", &UPSCoord{Zone: "A", Easting: 1234567.5, Northing: 2345678}},
+}
+
+func upsequal(ups1, ups2 *UPSCoord) bool {
+	u1 := fmt.Sprintf("%s %f %f", ups1.Zone, ups1.Easting, ups1.Northing)
+	u2 := fmt.Sprintf("%s %f %f", ups2.Zone, ups2.Easting, ups2.Northing)
+
+	return u1 == u2
+}
+
+func TestAUPSToStruct(t *testing.T) {
+	for cnt, test := range aUPSToStructTests {
+		out, err := AUPSToStruct(test.in, nil)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !upsequal(test.out, out) {
+			t.Errorf("AUPSToStruct [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+	}
+}
+
+func TestAUPSToStructError(t *testing.T) {
+	for _, in := range []string{"33U 1234 1234", "Z 1234", "Z abc 1234", "Z 1234 abc"} {
+		_, err := AUPSToStruct(in, nil)
+		if cerr, ok := err.(CartographyError); !ok || cerr.Err != ErrSyntax {
+			t.Errorf("AUPSToStruct: expected %s for %q, got %v", ErrSyntax, in, err)
+		}
+	}
+}
+
+// ## LatLongToUPS, UPSToLatLong
+type latLongToUPSTest struct {
+	in   *PolarCoord
+	zone string
+}
+
+var latLongToUPSTests = []latLongToUPSTest{
+	{&PolarCoord{Latitude: 73, Longitude: 44}, ""},
+	{&PolarCoord{Latitude: 84.5, Longitude: 44}, "Z"},
+	{&PolarCoord{Latitude: 87.25, Longitude: -120.5}, "Y"},
+	{&PolarCoord{Latitude: -80.5, Longitude: 10}, "B"},
+	{&PolarCoord{Latitude: -85.75, Longitude: -60.25}, "A"},
+}
+
+func TestLatLongToUPS(t *testing.T) {
+	for cnt, test := range latLongToUPSTests {
+		out, err := LatLongToUPS(test.in)
+
+		if test.zone == "" {
+			if err != ErrRange {
+				t.Errorf("LatLongToUPS [%d]: expected %s, got %v", cnt, ErrRange, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if out.Zone != test.zone {
+			t.Errorf("LatLongToUPS [%d]: expected zone %s, got %s", cnt, test.zone, out.Zone)
+		}
+
+		pc, err := UPSToLatLong(out)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !latlongequal(test.in, pc) {
+			t.Errorf("UPSToLatLong [%d]: expected %s, got %s", cnt, test.in, pc)
+		}
+	}
+}
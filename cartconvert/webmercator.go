@@ -0,0 +1,379 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ## Web Mercator Projection
+
+// Web Mercator is limited to latitudes which map the world onto a square
+const webMercatorMaxLatitude = 85.051128779806589
+
+// Direct Web Mercator projection (EPSG:3857) as used by Google Maps, Bing Maps and OpenStreetMap:
+// Projection of latitude and longitude onto the surface of a cylinder, treating the WGS84 coordinates
+// as if they were on a sphere of the radius of the WGS84 semi-major axis. Latitudes are clamped to ±85.0511°.
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Popular Visualisation Pseudo Mercator, pp. 40 - 41
+func DirectWebMercator(gc *PolarCoord) *GeoPoint {
+
+	a := WGS84Ellipsoid.a
+	lat := math.Max(-webMercatorMaxLatitude, math.Min(webMercatorMaxLatitude, gc.Latitude))
+
+	return &GeoPoint{
+		X:  a * degtorad(gc.Longitude),
+		Y:  a * math.Log(math.Tan(math.Pi/4+degtorad(lat)/2)),
+		H:  gc.Height,
+		El: WGS84Ellipsoid,
+	}
+}
+
+// Inverse Web Mercator projection (EPSG:3857). Easting(X) and Northing(Y) are given in meters, the resulting
+// latitude and longitude refer to the WGS84Ellipsoid.
+//
+// Taken from "OGP Publication 373-7-2 – Surveying and Positioning Guidance Note number 7, part 2 – November 2010",
+// Popular Visualisation Pseudo Mercator, pp. 40 - 41
+func InverseWebMercator(pt *GeoPoint) *PolarCoord {
+
+	a := WGS84Ellipsoid.a
+
+	return &PolarCoord{
+		Latitude:  radtodeg(math.Pi/2 - 2*math.Atan(math.Exp(-pt.Y/a))),
+		Longitude: radtodeg(pt.X / a),
+		Height:    pt.H,
+		El:        WGS84Ellipsoid,
+	}
+}
+
+// ## Slippy map tiles and quadkeys
+
+// The largest supported zoom level of a tile
+const MaxTileZoom = 30
+
+// The zoom level of the registered tile and quadkey coordinate systems when converting latitude and
+// longitude
+const DefaultTileZoom = 18
+
+// A tile of the slippy map tile scheme of OpenStreetMap and Google Maps. At zoom level Zoom the world
+// is divided into 2^Zoom by 2^Zoom tiles, X counts from west to east and Y from north to south.
+type Tile struct {
+	X, Y, Zoom uint
+}
+
+// Canonical representation of a tile as used in tile URLs, "zoom/x/y"
+func (tile *Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", tile.Zoom, tile.X, tile.Y)
+}
+
+func (tile *Tile) valid() bool {
+	return tile.Zoom <= MaxTileZoom && tile.X < 1<<tile.Zoom && tile.Y < 1<<tile.Zoom
+}
+
+// This function parses a string tile literal of the format
+//
+//	"ZOOM/X/Y"
+//
+// returns ErrSyntax if format is not understood
+// returns ErrRange if zoom, x or y are out of range
+func ATileToStruct(tilecoord string) (*Tile, error) {
+
+	fields := strings.Split(removeblank(strings.TrimSpace(tilecoord)), "/")
+	if len(fields) != 3 {
+		return nil, CartographyError{Coord: tilecoord, Err: ErrSyntax}
+	}
+
+	var values [3]uint
+	for i, field := range fields {
+		val, err := strconv.ParseUint(field, 10, 0)
+		if err != nil {
+			return nil, CartographyError{Coord: field, Err: ErrSyntax}
+		}
+		values[i] = uint(val)
+	}
+
+	tile := &Tile{Zoom: values[0], X: values[1], Y: values[2]}
+	if !tile.valid() {
+		return nil, CartographyError{Coord: tilecoord, Err: ErrRange}
+	}
+	return tile, nil
+}
+
+// Returns the tile of zoom level zoom containing the point pc.
+//
+// Returns ErrRange if the zoom level is larger than MaxTileZoom or the latitude exceeds the Web Mercator limits of ±85.0511°
+func LatLongToTile(pc *PolarCoord, zoom uint) (*Tile, error) {
+
+	if zoom > MaxTileZoom || math.Abs(pc.Latitude) > webMercatorMaxLatitude {
+		return nil, ErrRange
+	}
+
+	long := pc.Longitude
+	if long < -180 || long > 180 {
+		long = geoHashLongitude(long)
+	}
+
+	n := math.Exp2(float64(zoom))
+	pt := DirectWebMercator(&PolarCoord{Latitude: pc.Latitude, Longitude: long})
+	circumference := 2 * math.Pi * WGS84Ellipsoid.a
+
+	clamp := func(val float64) uint {
+		return uint(math.Max(0, math.Min(n-1, math.Floor(val))))
+	}
+
+	return &Tile{
+		X:    clamp((pt.X/circumference + 0.5) * n),
+		Y:    clamp((0.5 - pt.Y/circumference) * n),
+		Zoom: zoom,
+	}, nil
+}
+
+// Returns the area covered by the tile, relative to the WGS84Ellipsoid.
+//
+// Returns ErrRange if the tile does not exist
+func TileToBoundingBox(tile *Tile) (*BoundingBox, error) {
+
+	if !tile.valid() {
+		return nil, ErrRange
+	}
+
+	n := math.Exp2(float64(tile.Zoom))
+	circumference := 2 * math.Pi * WGS84Ellipsoid.a
+
+	corner := func(x, y float64) *PolarCoord {
+		return InverseWebMercator(&GeoPoint{X: (x/n - 0.5) * circumference, Y: (0.5 - y/n) * circumference})
+	}
+
+	nw := corner(float64(tile.X), float64(tile.Y))
+	se := corner(float64(tile.X+1), float64(tile.Y+1))
+
+	return &BoundingBox{South: se.Latitude, West: nw.Longitude, North: nw.Latitude, East: se.Longitude, El: WGS84Ellipsoid}, nil
+}
+
+// Returns the centre of the tile, relative to the WGS84Ellipsoid.
+//
+// Returns ErrRange if the tile does not exist
+func TileToLatLong(tile *Tile) (*PolarCoord, error) {
+
+	if !tile.valid() {
+		return nil, ErrRange
+	}
+
+	n := math.Exp2(float64(tile.Zoom))
+	circumference := 2 * math.Pi * WGS84Ellipsoid.a
+
+	return InverseWebMercator(&GeoPoint{
+		X: ((float64(tile.X)+0.5)/n - 0.5) * circumference,
+		Y: (0.5 - (float64(tile.Y)+0.5)/n) * circumference,
+	}), nil
+}
+
+// Returns the quadkey of a tile as used by Bing Maps. The quadkey has one digit per zoom level,
+// tiles of zoom level 0 have the empty quadkey.
+func TileToQuadKey(tile *Tile) string {
+
+	quadkey := make([]byte, tile.Zoom)
+	for i := uint(0); i < tile.Zoom; i++ {
+		mask := uint(1) << (tile.Zoom - 1 - i)
+		digit := byte('0')
+		if tile.X&mask != 0 {
+			digit++
+		}
+		if tile.Y&mask != 0 {
+			digit += 2
+		}
+		quadkey[i] = digit
+	}
+	return string(quadkey)
+}
+
+// Returns the tile designated by a Bing Maps quadkey.
+//
+// returns ErrSyntax if the quadkey contains other digits than 0 - 3
+// returns ErrRange if the quadkey is longer than MaxTileZoom
+func QuadKeyToTile(quadkey string) (*Tile, error) {
+
+	quadkey = strings.TrimSpace(quadkey)
+	if len(quadkey) > MaxTileZoom {
+		return nil, CartographyError{Coord: quadkey, Err: ErrRange}
+	}
+
+	tile := &Tile{Zoom: uint(len(quadkey))}
+	for i, digit := range quadkey {
+		if digit < '0' || digit > '3' {
+			return nil, CartographyError{Coord: quadkey, Index: i, Err: ErrSyntax}
+		}
+		tile.X <<= 1
+		tile.Y <<= 1
+		tile.X |= uint(digit-'0') & 1
+		tile.Y |= uint(digit-'0') >> 1
+	}
+	return tile, nil
+}
+
+// Web Mercator coordinates as a CoordinateSystem
+type webMercatorPoint GeoPoint
+
+func (pt *webMercatorPoint) String() string {
+	return fmt.Sprintf("%.2f %.2f", pt.X, pt.Y)
+}
+
+type webMercatorSystem struct{}
+
+func (webMercatorSystem) Name() string        { return "webmercator" }
+func (webMercatorSystem) Description() string { return "Web Mercator (EPSG:3857)" }
+
+// Parses a literal of the form "EASTING NORTHING" in meters
+func (webMercatorSystem) Parse(coord string) (fmt.Stringer, error) {
+
+	fields := strings.Fields(coord)
+	if len(fields) != 2 {
+		return nil, CartographyError{Coord: coord, Err: ErrSyntax}
+	}
+
+	var values [2]float64
+	for i, field := range fields {
+		val, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, CartographyError{Coord: coord, Err: ErrSyntax}
+		}
+		values[i] = val
+	}
+	return &webMercatorPoint{X: values[0], Y: values[1], El: WGS84Ellipsoid}, nil
+}
+
+func (webMercatorSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*webMercatorPoint); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (webMercatorSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	pt, ok := coord.(*webMercatorPoint)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return InverseWebMercator((*GeoPoint)(pt)), nil
+}
+
+func (webMercatorSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	return (*webMercatorPoint)(DirectWebMercator(pc)), nil
+}
+
+func (webMercatorSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return webMercatorCRS, nil
+}
+
+// Slippy map tiles as a CoordinateSystem
+type tileSystem struct {
+	name string
+	zoom uint
+}
+
+// Returns a slippy map tile coordinate system named name, to be registered by RegisterCoordinateSystem.
+// FromLatLong returns tiles of zoom level zoom.
+func NewTileCoordinateSystem(name string, zoom uint) CoordinateSystem {
+	return tileSystem{name: name, zoom: zoom}
+}
+
+func (ts tileSystem) Name() string     { return ts.name }
+func (tileSystem) Description() string { return "Slippy map tile z/x/y" }
+
+func (tileSystem) Parse(coord string) (fmt.Stringer, error) {
+	return ATileToStruct(coord)
+}
+
+func (tileSystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(*Tile); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (tileSystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	tile, ok := coord.(*Tile)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	return TileToLatLong(tile)
+}
+
+func (ts tileSystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	return LatLongToTile(pc, ts.zoom)
+}
+
+func (tileSystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return webMercatorCRS, nil
+}
+
+// A quadkey literal
+type quadKey string
+
+func (qk quadKey) String() string { return string(qk) }
+
+// Bing Maps quadkeys as a CoordinateSystem
+type quadKeySystem struct {
+	name string
+	zoom uint
+}
+
+// Returns a Bing Maps quadkey coordinate system named name, to be registered by RegisterCoordinateSystem.
+// FromLatLong returns quadkeys of zoom level zoom, ie. of zoom digits.
+func NewQuadKeyCoordinateSystem(name string, zoom uint) CoordinateSystem {
+	return quadKeySystem{name: name, zoom: zoom}
+}
+
+func (qs quadKeySystem) Name() string     { return qs.name }
+func (quadKeySystem) Description() string { return "Bing Maps quadkey" }
+
+func (quadKeySystem) Parse(coord string) (fmt.Stringer, error) {
+	tile, err := QuadKeyToTile(coord)
+	if err != nil {
+		return nil, err
+	}
+	return quadKey(TileToQuadKey(tile)), nil
+}
+
+func (quadKeySystem) Format(coord fmt.Stringer) (string, error) {
+	if _, ok := coord.(quadKey); !ok {
+		return "", ErrCoordType
+	}
+	return coord.String(), nil
+}
+
+func (quadKeySystem) ToLatLong(coord fmt.Stringer) (*PolarCoord, error) {
+	qk, ok := coord.(quadKey)
+	if !ok {
+		return nil, ErrCoordType
+	}
+	tile, err := QuadKeyToTile(string(qk))
+	if err != nil {
+		return nil, err
+	}
+	return TileToLatLong(tile)
+}
+
+func (qs quadKeySystem) FromLatLong(pc *PolarCoord) (fmt.Stringer, error) {
+	tile, err := LatLongToTile(pc, qs.zoom)
+	if err != nil {
+		return nil, err
+	}
+	return quadKey(TileToQuadKey(tile)), nil
+}
+
+func (quadKeySystem) CRS(coord fmt.Stringer) (*CRS, error) {
+	return webMercatorCRS, nil
+}
+
+func init() {
+	RegisterCoordinateSystem(webMercatorSystem{})
+	RegisterCoordinateSystem(NewTileCoordinateSystem("tile", DefaultTileZoom))
+	RegisterCoordinateSystem(NewQuadKeyCoordinateSystem("quadkey", DefaultTileZoom))
+}
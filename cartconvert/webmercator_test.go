@@ -0,0 +1,194 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"testing"
+)
+
+// ## DirectWebMercator, InverseWebMercator
+// OGP Publication 373-7-2, Popular Visualisation Pseudo Mercator
+func TestWebMercator(t *testing.T) {
+	pc := &PolarCoord{Latitude: 24 + 22.0/60 + 54.433/3600, Longitude: -(100 + 20.0/60)}
+	expected := &GeoPoint{X: -11169055.58, Y: 2800000.00}
+
+	pt := DirectWebMercator(pc)
+	if !geopointcmequal(expected, pt) {
+		t.Errorf("DirectWebMercator: Expected %v, got %v", expected, pt)
+	}
+
+	if out := InverseWebMercator(pt); !latlongmmequal(pc, out) {
+		t.Errorf("InverseWebMercator: Expected %s, got %s", pc, out)
+	}
+
+	if pt = DirectWebMercator(&PolarCoord{Latitude: 90}); !geopointcmequal(&GeoPoint{X: 0, Y: 20037508.34}, pt) {
+		t.Errorf("DirectWebMercator: Expected the northern limit, got %v", pt)
+	}
+}
+
+// ## LatLongToTile
+type latLongToTileTest struct {
+	in   *PolarCoord
+	zoom uint
+	out  string
+}
+
+var latLongToTileTests = []latLongToTileTest{
+	{&PolarCoord{Latitude: 51.51202, Longitude: 0.02435}, 17, "17/65544/43582"},
+	{&PolarCoord{Latitude: 48.2082, Longitude: 16.3738}, 0, "0/0/0"},
+	{&PolarCoord{Latitude: 48.2082, Longitude: 16.3738}, 1, "1/1/0"},
+	{&PolarCoord{Latitude: -85.05, Longitude: 180}, 2, "2/3/3"},
+	{&PolarCoord{Latitude: 85.05, Longitude: -180}, 2, "2/0/0"},
+}
+
+func TestLatLongToTile(t *testing.T) {
+	for cnt, test := range latLongToTileTests {
+		out, err := LatLongToTile(test.in, test.zoom)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if out.String() != test.out {
+			t.Errorf("LatLongToTile [%d]: Expected %s, got %s", cnt, test.out, out)
+		}
+
+		bb, err := TileToBoundingBox(out)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !bb.Contains(test.in) {
+			t.Errorf("TileToBoundingBox [%d]: %s does not contain %s", cnt, bb, test.in)
+		}
+	}
+
+	for _, zoom := range []uint{MaxTileZoom + 1} {
+		if _, err := LatLongToTile(&PolarCoord{}, zoom); err != ErrRange {
+			t.Errorf("LatLongToTile: Expected %s for zoom %d, got %v", ErrRange, zoom, err)
+		}
+	}
+
+	if _, err := LatLongToTile(&PolarCoord{Latitude: 86}, 3); err != ErrRange {
+		t.Errorf("LatLongToTile: Expected %s, got %v", ErrRange, err)
+	}
+}
+
+// ## TileToBoundingBox
+func TestTileToBoundingBox(t *testing.T) {
+	bb, err := TileToBoundingBox(&Tile{X: 1, Y: 0, Zoom: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := (&BoundingBox{South: 0, West: 0, North: webMercatorMaxLatitude, East: 180}); !boundingboxequal(expected, bb) {
+		t.Errorf("TileToBoundingBox: Expected %s, got %s", expected, bb)
+	}
+
+	if _, err = TileToBoundingBox(&Tile{X: 2, Y: 0, Zoom: 1}); err != ErrRange {
+		t.Errorf("TileToBoundingBox: Expected %s, got %v", ErrRange, err)
+	}
+}
+
+// ## ATileToStruct
+func TestATileToStruct(t *testing.T) {
+	tile, err := ATileToStruct(" 17/65544/43582 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *tile != (Tile{X: 65544, Y: 43582, Zoom: 17}) {
+		t.Errorf("ATileToStruct: Expected 17/65544/43582, got %s", tile)
+	}
+
+	for _, in := range []string{"17/65544", "a/1/1", "1/2/0", "31/0/0"} {
+		if _, err := ATileToStruct(in); err == nil {
+			t.Errorf("ATileToStruct: Expected error for %q", in)
+		}
+	}
+}
+
+// ## TileToQuadKey, QuadKeyToTile
+type quadKeyTest struct {
+	tile    Tile
+	quadkey string
+}
+
+var quadKeyTests = []quadKeyTest{
+	{Tile{X: 3, Y: 5, Zoom: 3}, "213"},
+	{Tile{X: 0, Y: 0, Zoom: 0}, ""},
+	{Tile{X: 35210, Y: 21493, Zoom: 16}, "1202102332221212"},
+}
+
+func TestQuadKey(t *testing.T) {
+	for cnt, test := range quadKeyTests {
+		if out := TileToQuadKey(&test.tile); out != test.quadkey {
+			t.Errorf("TileToQuadKey [%d]: Expected %s, got %s", cnt, test.quadkey, out)
+		}
+
+		tile, err := QuadKeyToTile(test.quadkey)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if *tile != test.tile {
+			t.Errorf("QuadKeyToTile [%d]: Expected %s, got %s", cnt, &test.tile, tile)
+		}
+	}
+
+	if _, err := QuadKeyToTile("1204"); err == nil {
+		t.Error("QuadKeyToTile: Expected error for 1204")
+	}
+}
+
+// ## NewTileCoordinateSystem, NewQuadKeyCoordinateSystem
+func TestTileCoordinateSystems(t *testing.T) {
+	in := latLongToTileTests[0].in
+
+	tiles := NewTileCoordinateSystem("tile17", 17)
+	if out, err := tiles.FromLatLong(in); err != nil || tiles.Name() != "tile17" || out.String() != "17/65544/43582" {
+		t.Errorf("NewTileCoordinateSystem: Expected tile17 17/65544/43582, got %s %v (%v)", tiles.Name(), out, err)
+	}
+
+	quadkeys := NewQuadKeyCoordinateSystem("quadkey17", 17)
+	if out, err := quadkeys.FromLatLong(in); err != nil || quadkeys.Name() != "quadkey17" || out.String() != TileToQuadKey(&Tile{X: 65544, Y: 43582, Zoom: 17}) {
+		t.Errorf("NewQuadKeyCoordinateSystem: Expected quadkey17 of 17/65544/43582, got %s %v (%v)", quadkeys.Name(), out, err)
+	}
+
+	for _, cs := range []CoordinateSystem{NewTileCoordinateSystem("tile", MaxTileZoom+1), NewQuadKeyCoordinateSystem("quadkey", MaxTileZoom+1)} {
+		if _, err := cs.FromLatLong(in); err != ErrRange {
+			t.Errorf("%s: Expected %s for zoom %d, got %v", cs.Name(), ErrRange, MaxTileZoom+1, err)
+		}
+	}
+
+	// the registered coordinate systems convert at the default zoom level
+	if cs, ok := LookupCoordinateSystem("tile"); !ok {
+		t.Error("LookupCoordinateSystem: tile not registered")
+	} else if out, err := cs.FromLatLong(in); err != nil || out.(*Tile).Zoom != DefaultTileZoom {
+		t.Errorf("tile: Expected zoom %d, got %v (%v)", DefaultTileZoom, out, err)
+	}
+
+	if cs, ok := LookupCoordinateSystem("quadkey"); !ok {
+		t.Error("LookupCoordinateSystem: quadkey not registered")
+	} else if out, err := cs.FromLatLong(in); err != nil || len(out.String()) != DefaultTileZoom {
+		t.Errorf("quadkey: Expected %d digits, got %v (%v)", DefaultTileZoom, out, err)
+	}
+}
+
+// ## Web Mercator literals
+func TestWebMercatorParse(t *testing.T) {
+	cs, _ := LookupCoordinateSystem("webmercator")
+
+	for _, in := range []string{"-11169055.58", "-11169055.58 2800000.00 0", "-11169055.58m 2800000.00", "-11169055.58 north"} {
+		_, err := cs.Parse(in)
+		if cerr, ok := err.(CartographyError); !ok || cerr.Err != ErrSyntax {
+			t.Errorf("webmercator.Parse: Expected %s for %q, got %v", ErrSyntax, in, err)
+		}
+	}
+}
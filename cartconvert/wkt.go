@@ -0,0 +1,999 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+package cartconvert
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ## Well known text (WKT) of coordinate reference systems
+
+// Well known text describes coordinate reference systems as nested keywords with bracketed arguments, eg.
+// GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],...]. WKT 1 is defined by
+// "OGC 01-009 – Coordinate Transformation Services", as written by GDAL and PostGIS; WKT 2 by
+// "OGC 18-010 – Geographic information – Well-known text representation of coordinate reference systems",
+// as written by PROJ and QGIS.
+
+// A node of well known text: KEYWORD[arg, ...]. Quoted strings, numbers and enumerations are kept as
+// args, nested keywords as children, both in the order of the text.
+type wktNode struct {
+	keyword  string
+	args     []string
+	children []*wktNode
+}
+
+type wktParser struct {
+	wkt string
+	pos int
+}
+
+// A syntax error at the current position
+func (p *wktParser) error() error {
+	end := p.pos + 32
+	if end > len(p.wkt) {
+		end = len(p.wkt)
+	}
+	return CartographyError{Coord: p.wkt[p.pos:end], Index: p.pos, Err: ErrSyntax}
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.wkt) && strings.IndexByte(" \t\r\n", p.wkt[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// Parses a quoted string starting at the current position. A quote within the string is doubled.
+func (p *wktParser) quoted() (string, error) {
+	var s []byte
+	for p.pos++; p.pos < len(p.wkt); p.pos++ {
+		c := p.wkt[p.pos]
+		if c == '"' {
+			if p.pos+1 < len(p.wkt) && p.wkt[p.pos+1] == '"' {
+				p.pos++
+			} else {
+				p.pos++
+				return string(s), nil
+			}
+		}
+		s = append(s, c)
+	}
+	return "", p.error()
+}
+
+// Parses KEYWORD[arg, ...] starting at the current position. Arguments may also be enclosed in parentheses.
+func (p *wktParser) node() (*wktNode, error) {
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.wkt) && strings.IndexByte(",[]() \t\r\n\"", p.wkt[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.error()
+	}
+	n := &wktNode{keyword: strings.ToUpper(p.wkt[start:p.pos])}
+
+	p.skipSpace()
+	if p.pos >= len(p.wkt) || (p.wkt[p.pos] != '[' && p.wkt[p.pos] != '(') {
+		return nil, p.error()
+	}
+	closing := byte(']')
+	if p.wkt[p.pos] == '(' {
+		closing = ')'
+	}
+	p.pos++
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.wkt) {
+			return nil, p.error()
+		}
+
+		if p.wkt[p.pos] == '"' {
+			arg, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			n.args = append(n.args, arg)
+		} else {
+			start := p.pos
+			for p.pos < len(p.wkt) && strings.IndexByte(",[]() \t\r\n\"", p.wkt[p.pos]) < 0 {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.error()
+			}
+			arg := p.wkt[start:p.pos]
+
+			p.skipSpace()
+			if p.pos < len(p.wkt) && (p.wkt[p.pos] == '[' || p.wkt[p.pos] == '(') {
+				p.pos = start
+				child, err := p.node()
+				if err != nil {
+					return nil, err
+				}
+				n.children = append(n.children, child)
+			} else {
+				n.args = append(n.args, arg)
+			}
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.wkt) {
+			return nil, p.error()
+		}
+		switch p.wkt[p.pos] {
+		case ',':
+			p.pos++
+		case closing:
+			p.pos++
+			return n, nil
+		default:
+			return nil, p.error()
+		}
+	}
+}
+
+// The first child of n with one of the keywords, or nil
+func (n *wktNode) child(keywords ...string) *wktNode {
+	for _, child := range n.children {
+		for _, keyword := range keywords {
+			if child.keyword == keyword {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// The name of a node, its first argument
+func (n *wktNode) name() string {
+	if len(n.args) == 0 {
+		return ""
+	}
+	return n.args[0]
+}
+
+// The i-th argument of n as a number
+func (n *wktNode) float(i int) (float64, error) {
+	if i >= len(n.args) {
+		return 0, CartographyError{Coord: n.keyword, Err: ErrSyntax}
+	}
+	val, err := strconv.ParseFloat(n.args[i], 64)
+	if err != nil {
+		return 0, CartographyError{Coord: n.args[i], Err: ErrSyntax}
+	}
+	return val, nil
+}
+
+// The EPSG code of the ID or AUTHORITY of n, or 0
+func (n *wktNode) epsg() int {
+	id := n.child("ID", "AUTHORITY")
+	if id == nil || len(id.args) < 2 || !strings.EqualFold(id.args[0], "EPSG") {
+		return 0
+	}
+	code, _ := strconv.Atoi(id.args[1])
+	return code
+}
+
+// Units of the values of WKT
+type wktUnit byte
+
+const (
+	wktDegree wktUnit = iota
+	wktMetre
+	wktUnity
+	wktArcSecond
+	wktPPM
+)
+
+// Factor of the unit to the SI unit
+func (u wktUnit) factor() float64 {
+	switch u {
+	case wktDegree:
+		return math.Pi / 180
+	case wktArcSecond:
+		return math.Pi / 648000
+	case wktPPM:
+		return 1e-6
+	}
+	return 1
+}
+
+// The unit as WKT 2
+func (u wktUnit) wkt2() string {
+	switch u {
+	case wktDegree:
+		return `ANGLEUNIT["degree",0.0174532925199433]`
+	case wktArcSecond:
+		return `ANGLEUNIT["arc-second",4.84813681109536E-06]`
+	case wktPPM:
+		return `SCALEUNIT["parts per million",1E-06]`
+	case wktUnity:
+		return `SCALEUNIT["unity",1]`
+	}
+	return `LENGTHUNIT["metre",1]`
+}
+
+// Reports whether the unit node n, if any, equals the unit u
+func (n *wktNode) isUnit(u wktUnit) (bool, error) {
+	if n == nil {
+		return true, nil
+	}
+	factor, err := n.float(1)
+	if err != nil {
+		return false, err
+	}
+	return math.Abs(factor-u.factor()) <= 1e-12*u.factor(), nil
+}
+
+// The value of the node, its second argument, converted from the unit given by a child of n into the unit u.
+// Values without unit are assumed to be given in the unit node fallback or, if nil, in u.
+func (n *wktNode) value(u wktUnit, fallback *wktNode) (float64, error) {
+	val, err := n.float(1)
+	if err != nil {
+		return 0, err
+	}
+	unit := n.child("UNIT", "ANGLEUNIT", "LENGTHUNIT", "SCALEUNIT")
+	if unit == nil {
+		unit = fallback
+	}
+	if same, err := unit.isUnit(u); err != nil || same {
+		return val, err
+	}
+	factor, _ := unit.float(1)
+	return val * factor / u.factor(), nil
+}
+
+// Format a number of WKT
+func wktFloat(val float64) string {
+	if val == 0 {
+		// no negative zero
+		val = 0
+	}
+	return strings.ToUpper(strconv.FormatFloat(val, 'g', 15, 64))
+}
+
+// Quote a string of WKT
+func wktQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+// Normalizes the name of an object of WKT, which differ in case and punctuation, eg. "OSGB_1936" and "OSGB 1936"
+func wktAlias(name string) string {
+	var alias []byte
+	for _, c := range []byte(strings.ToLower(name)) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' {
+			alias = append(alias, c)
+		}
+	}
+	return string(alias)
+}
+
+// ## Ellipsoids, prime meridians and datums
+
+// Names and the inverse flattening of the ellipsoids of this package as given by EPSG
+var wktEllipsoids = []struct {
+	el   *Ellipsoid
+	name string
+	invf float64
+}{
+	{WGS84Ellipsoid, "WGS 84", 298.257223563},
+	{GRS80Ellipsoid, "GRS 1980", 298.257222101},
+	{Bessel1841Ellipsoid, "Bessel 1841", 299.1528128},
+	{Bessel1841MGIEllipsoid, "Bessel 1841", 299.1528128},
+	{Airy1830Ellipsoid, "Airy 1830", 299.3249646},
+	{AiryModifiedEllipsoid, "Airy Modified 1849", 299.3249646},
+	{Clarke1866Ellipsoid, "Clarke 1866", 294.978698213898},
+	{Clarke1880IGNEllipsoid, "Clarke 1880 (IGN)", 293.466021293627},
+	{International1924Ellipsoid, "International 1924", 297},
+	{Krassowsky1940Ellipsoid, "Krassowsky 1940", 298.3},
+}
+
+// Reports whether the ellipsoids agree to within a centimeter
+func ellipsoidsEqual(el1, el2 *Ellipsoid) bool {
+	return math.Abs(el1.a-el2.a) < 1e-3 && math.Abs(el1.b-el2.b) < 1e-2
+}
+
+// The ellipsoid of the semi-major axis a and the inverse flattening invf, which is 0 for a sphere. Returns the
+// closest ellipsoid of this package, or a new one named name.
+func wktEllipsoid(name string, a, invf float64) *Ellipsoid {
+
+	b := a
+	if invf != 0 {
+		b = a - a/invf
+	}
+	el := NewEllipsoid(a, b, name)
+
+	var closest *Ellipsoid
+	for _, we := range wktEllipsoids {
+		if ellipsoidsEqual(we.el, el) && (closest == nil || math.Abs(we.el.b-b) < math.Abs(closest.b-b)) {
+			closest = we.el
+		}
+	}
+	if closest != nil {
+		return closest
+	}
+	return el
+}
+
+// SPHEROID of WKT 1 or ELLIPSOID of WKT 2
+func wktSpheroid(el *Ellipsoid, keyword, unit string) string {
+
+	name, invf := el.CommonName, 0.0
+	if el.a != el.b {
+		invf = el.a / (el.a - el.b)
+	}
+	for _, we := range wktEllipsoids {
+		if we.el == el {
+			name, invf = we.name, we.invf
+			break
+		}
+	}
+	return keyword + "[" + wktQuote(name) + "," + wktFloat(el.a) + "," + wktFloat(invf) + unit + "]"
+}
+
+// The prime meridian named name at long degrees east of Greenwich; Prime meridians of this package are
+// recognized by their longitude.
+func wktPrimeMeridian(name string, long float64) *PrimeMeridian {
+	for _, pm := range []*PrimeMeridian{GreenwichMeridian, FerroMeridian, ParisMeridian} {
+		if math.Abs(pm.Longitude-long) < 1e-8 {
+			return pm
+		}
+	}
+	return &PrimeMeridian{Name: name, Longitude: long}
+}
+
+// Names of the datums of the catalogue in WKT: the name of the geographic CRS and of the datum in WKT 1
+// and WKT 2, following EPSG
+var wktDatumNames = []struct {
+	datum            *Datum
+	geog, wkt1, wkt2 string
+}{
+	{WGS84Datum, "WGS 84", "WGS_1984", "World Geodetic System 1984"},
+	{ETRS89Datum, "ETRS89", "European_Terrestrial_Reference_System_1989", "European Terrestrial Reference System 1989"},
+	{RGF93Datum, "RGF93", "Reseau_Geodesique_Francais_1993", "Reseau Geodesique Francais 1993"},
+	{MGIDatum, "MGI", "Militar_Geographische_Institut", "Militar-Geographische Institut"},
+	{MGIFerroDatum, "MGI (Ferro)", "Militar_Geographische_Institut_Ferro", "Militar-Geographische Institut (Ferro)"},
+	{DHDNDatum, "DHDN", "Deutsches_Hauptdreiecksnetz", "Deutsches Hauptdreiecksnetz"},
+	{SJTSKDatum, "S-JTSK", "System_Jednotne_Trigonometricke_Site_Katastralni", "System of the Unified Trigonometrical Cadastral Network"},
+	{SJTSKFerroDatum, "S-JTSK (Ferro)", "System_Jednotne_Trigonometricke_Site_Katastralni_Ferro", "System of the Unified Trigonometrical Cadastral Network (Ferro)"},
+	{NTFDatum, "NTF", "Nouvelle_Triangulation_Francaise", "Nouvelle Triangulation Francaise"},
+	{NTFParisDatum, "NTF (Paris)", "Nouvelle_Triangulation_Francaise_Paris", "Nouvelle Triangulation Francaise (Paris)"},
+	{CH1903PlusDatum, "CH1903+", "CH1903+", "CH1903+"},
+	// after CH1903+, which PROJ strings of the same transformation denote
+	{CH1903Datum, "CH1903", "CH1903", "CH1903"},
+	{AmersfoortDatum, "Amersfoort", "Amersfoort", "Amersfoort"},
+	{OSGB36Datum, "OSGB36", "OSGB_1936", "Ordnance Survey of Great Britain 1936"},
+	{Ireland65Datum, "TM65", "TM65", "TM65"},
+	{ED50Datum, "ED50", "European_Datum_1950", "European Datum 1950"},
+	{Pulkovo1942Datum, "Pulkovo 1942", "Pulkovo_1942", "Pulkovo 1942"},
+}
+
+// Datums of the catalogue by the normalized names of the datum and the geographic CRS
+var wktDatums = map[string]*Datum{
+	// spelling of GDAL
+	"militargeographischeinstitute": MGIDatum,
+	"ireland1965":                   Ireland65Datum,
+}
+
+func init() {
+	for _, names := range wktDatumNames {
+		for _, name := range []string{names.datum.Name, names.geog, names.wkt1, names.wkt2} {
+			wktDatums[wktAlias(name)] = names.datum
+		}
+	}
+}
+
+// Names of the datum in WKT
+func wktNames(d *Datum) (geog, wkt1, wkt2 string) {
+	for _, names := range wktDatumNames {
+		if names.datum == d {
+			return names.geog, names.wkt1, names.wkt2
+		}
+	}
+	return d.Name, strings.Replace(d.Name, " ", "_", -1), d.Name
+}
+
+// The datum of the WKT named name, of the geographic CRS geog, the ellipsoid el, the prime meridian pm and the
+// transformation into WGS84 towgs84, which might be nil. Datums of the catalogue are recognized by their name,
+// if their ellipsoid and transformation into WGS84 agree. Other datums without transformation into WGS84 are
+// assumed to coincide with WGS84.
+func wktDatum(name, geog string, el *Ellipsoid, pm *PrimeMeridian, towgs84 *Helmert) *Datum {
+
+	d, ok := wktDatums[wktAlias(strings.TrimPrefix(name, "D_"))]
+	if !ok {
+		d, ok = wktDatums[wktAlias(geog)]
+	}
+
+	if ok && ellipsoidsEqual(d.El, el) {
+		if d.PrimeMeridian != pm {
+			// the catalogue datum with the prime meridian of the WKT, eg. MGI (Ferro) for the datum of MGI
+			// and the prime meridian Ferro
+			pmd := NewDatum(d.Name+" ("+pm.Name+")", d.El, pm, d.ToWGS84)
+			for _, names := range wktDatumNames {
+				if c := names.datum; c.El == d.El && sameTransformer(c.ToWGS84, d.ToWGS84) && c.PrimeMeridian == pm {
+					pmd = c
+				}
+			}
+			d = pmd
+		}
+
+		if towgs84 == nil {
+			return d
+		}
+		if h, ok := d.towgs84(); ok && helmertEqual(h, towgs84) {
+			return d
+		}
+	}
+
+	if towgs84 == nil {
+		return NewDatum(name, el, pm, nil)
+	}
+	return NewDatum(name, el, pm, NewGeocentricTransformer(towgs84, el, WGS84Ellipsoid))
+}
+
+// Reports whether the parameters of the helmert transformations agree to the accuracy of published
+// parameters; Published parameters of the inverse transformation are often just negated instead of exactly
+// inverted, which differs by a few centimeters.
+func helmertEqual(h1, h2 *Helmert) bool {
+	p1, p2 := h1.PositionVector(), h2.PositionVector()
+	return math.Abs(p1.Dx-p2.Dx) < 0.1 && math.Abs(p1.Dy-p2.Dy) < 0.1 && math.Abs(p1.Dz-p2.Dz) < 0.1 &&
+		math.Abs(p1.Rx-p2.Rx) < 0.01 && math.Abs(p1.Ry-p2.Ry) < 0.01 && math.Abs(p1.Rz-p2.Rz) < 0.01 &&
+		math.Abs(p1.DM-p2.DM) < 0.01
+}
+
+// The datum of the geographic CRS n. The helmert parameters towgs84 replace the TOWGS84 clause, if not nil.
+// A datum ensemble of WKT 2, like WGS 84 as written by PROJ, stands for its datum.
+func (n *wktNode) datum(towgs84 *Helmert) (*Datum, error) {
+
+	dn := n.child("DATUM", "GEODETICDATUM", "TRF", "ENSEMBLE")
+	if dn == nil {
+		return nil, CartographyError{Coord: n.keyword, Err: ErrSyntax}
+	}
+
+	en := dn.child("SPHEROID", "ELLIPSOID")
+	if en == nil {
+		return nil, CartographyError{Coord: dn.keyword, Err: ErrSyntax}
+	}
+	a, err := en.value(wktMetre, nil)
+	if err != nil {
+		return nil, err
+	}
+	invf, err := en.float(2)
+	if err != nil {
+		return nil, err
+	}
+	el := wktEllipsoid(en.name(), a, invf)
+
+	pm := GreenwichMeridian
+	if pn := n.child("PRIMEM", "PRIMEMERIDIAN"); pn != nil {
+		long, err := pn.value(wktDegree, nil)
+		if err != nil {
+			return nil, err
+		}
+		pm = wktPrimeMeridian(pn.name(), long)
+	}
+
+	if tn := dn.child("TOWGS84"); tn != nil && towgs84 == nil {
+		if len(tn.args) != 3 && len(tn.args) != 7 {
+			return nil, CartographyError{Coord: tn.keyword, Err: ErrSyntax}
+		}
+		var params [7]float64
+		for i := range tn.args {
+			if params[i], err = tn.float(i); err != nil {
+				return nil, err
+			}
+		}
+		towgs84 = NewHelmertTransformer(params[0], params[1], params[2], params[6], params[3], params[4], params[5], dn.name())
+	}
+
+	return wktDatum(dn.name(), n.name(), el, pm, towgs84), nil
+}
+
+// ## Projection methods
+
+// A parameter of a projection method by its name in WKT 1 and WKT 2 and its EPSG code. The parameter sets
+// the field of Projection returned by field or, if nil, is fixed to the value fixed.
+type wktParameter struct {
+	wkt1, wkt2 string
+	epsg       int
+	unit       wktUnit
+	field      func(p *Projection) *float64
+	fixed      float64
+}
+
+func wktLatO(p *Projection) *float64  { return &p.LatO }
+func wktLongO(p *Projection) *float64 { return &p.LongO }
+func wktLat1(p *Projection) *float64  { return &p.Lat1 }
+func wktLat2(p *Projection) *float64  { return &p.Lat2 }
+func wktScale(p *Projection) *float64 { return &p.Scale }
+func wktFE(p *Projection) *float64    { return &p.FE }
+func wktFN(p *Projection) *float64    { return &p.FN }
+
+var wktNaturalOrigin = []wktParameter{
+	{"latitude_of_origin", "Latitude of natural origin", 8801, wktDegree, wktLatO, 0},
+	{"central_meridian", "Longitude of natural origin", 8802, wktDegree, wktLongO, 0},
+	{"scale_factor", "Scale factor at natural origin", 8805, wktUnity, wktScale, 0},
+	{"false_easting", "False easting", 8806, wktMetre, wktFE, 0},
+	{"false_northing", "False northing", 8807, wktMetre, wktFN, 0},
+}
+
+// A projection method by its name in WKT 1 and WKT 2, its EPSG code, further names and its parameters
+type wktMethod struct {
+	method     ProjectionMethod
+	wkt1, wkt2 string
+	epsg       int
+	aliases    []string
+	parameters []wktParameter
+}
+
+var wktMethods = []*wktMethod{
+	{TransverseMercator, "Transverse_Mercator", "Transverse Mercator", 9807, []string{"Gauss_Kruger"}, wktNaturalOrigin},
+	{LambertConformalConic1SP, "Lambert_Conformal_Conic_1SP", "Lambert Conic Conformal (1SP)", 9801, nil, wktNaturalOrigin},
+	{LambertConformalConic2SP, "Lambert_Conformal_Conic_2SP", "Lambert Conic Conformal (2SP)", 9802, nil, []wktParameter{
+		{"latitude_of_origin", "Latitude of false origin", 8821, wktDegree, wktLatO, 0},
+		{"central_meridian", "Longitude of false origin", 8822, wktDegree, wktLongO, 0},
+		{"standard_parallel_1", "Latitude of 1st standard parallel", 8823, wktDegree, wktLat1, 0},
+		{"standard_parallel_2", "Latitude of 2nd standard parallel", 8824, wktDegree, wktLat2, 0},
+		{"false_easting", "Easting at false origin", 8826, wktMetre, wktFE, 0},
+		{"false_northing", "Northing at false origin", 8827, wktMetre, wktFN, 0},
+	}},
+	{ObliqueStereographic, "Oblique_Stereographic", "Oblique Stereographic", 9809, []string{"Double_Stereographic"}, wktNaturalOrigin},
+	{SwissObliqueMercator, "Hotine_Oblique_Mercator_Azimuth_Center", "Hotine Oblique Mercator (variant B)", 9815,
+		[]string{"Hotine_Oblique_Mercator_Variant_B", "Swiss_Oblique_Cylindrical"}, []wktParameter{
+			{"latitude_of_center", "Latitude of projection centre", 8811, wktDegree, wktLatO, 0},
+			{"longitude_of_center", "Longitude of projection centre", 8812, wktDegree, wktLongO, 0},
+			{"azimuth", "Azimuth of initial line", 8813, wktDegree, nil, 90},
+			{"rectified_grid_angle", "Angle from Rectified to Skew Grid", 8814, wktDegree, nil, 90},
+			{"scale_factor", "Scale factor on initial line", 8815, wktUnity, wktScale, 0},
+			{"false_easting", "Easting at projection centre", 8816, wktMetre, wktFE, 0},
+			{"false_northing", "Northing at projection centre", 8817, wktMetre, wktFN, 0},
+		}},
+	{PolarStereographic, "Polar_Stereographic", "Polar Stereographic (variant A)", 9810, nil, wktNaturalOrigin},
+	// WKT 1 has no name for web mercator, GDAL writes Mercator_1SP on a sphere by an EXTENSION of PROJ
+	{WebMercator, "Mercator_1SP", "Popular Visualisation Pseudo Mercator", 1024, []string{"Popular_Visualisation_Pseudo_Mercator"}, []wktParameter{
+		{"", "Latitude of natural origin", 8801, wktDegree, wktLatO, 0},
+		{"central_meridian", "Longitude of natural origin", 8802, wktDegree, wktLongO, 0},
+		{"scale_factor", "", 0, wktUnity, wktScale, 0},
+		{"false_easting", "False easting", 8806, wktMetre, wktFE, 0},
+		{"false_northing", "False northing", 8807, wktMetre, wktFN, 0},
+	}},
+}
+
+// The PROJ definition of web mercator as written by GDAL
+const wktWebMercatorExtension = "+proj=merc +a=6378137 +b=6378137 +lat_ts=0 +lon_0=0 +x_0=0 +y_0=0 +k=1 +units=m +nadgrids=@null +wktext +no_defs"
+
+// Reports whether the WKT name or the EPSG code code denote the object
+func wktMatches(name string, code int, epsg int, names ...string) bool {
+	if code != 0 && code == epsg {
+		return true
+	}
+	alias := wktAlias(name)
+	for _, n := range names {
+		if n != "" && wktAlias(n) == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// The projection method of the PROJECTION or METHOD node n
+func wktLookupMethod(n *wktNode) (*wktMethod, error) {
+	for _, m := range wktMethods {
+		if wktMatches(n.name(), n.epsg(), m.epsg, append([]string{m.wkt1, m.wkt2}, m.aliases...)...) {
+			return m, nil
+		}
+	}
+	return nil, CartographyError{Coord: n.name(), Err: ErrUnsupported}
+}
+
+// Set the projection parameter of the PARAMETER node n, whose angles without unit are given in the unit node
+// angular or, if nil, in degrees
+func (m *wktMethod) set(p *Projection, n *wktNode, angular *wktNode) error {
+	for _, param := range m.parameters {
+		if !wktMatches(n.name(), n.epsg(), param.epsg, param.wkt1, param.wkt2) {
+			continue
+		}
+
+		var fallback *wktNode
+		if param.unit == wktDegree {
+			fallback = angular
+		}
+		val, err := n.value(param.unit, fallback)
+		if err != nil {
+			return err
+		}
+		if param.field == nil {
+			if math.Abs(val-param.fixed) > 1e-9 {
+				return CartographyError{Coord: n.name(), Val: val, Err: ErrUnsupported}
+			}
+			return nil
+		}
+		*param.field(p) = val
+		return nil
+	}
+	return CartographyError{Coord: n.name(), Err: ErrUnsupported}
+}
+
+// The projection of the PROJECTION or METHOD node pn and the PARAMETER children of n, whose angles without
+// unit are given in the unit node angular or, if nil, in degrees
+func (n *wktNode) projection(pn, angular *wktNode) (*Projection, error) {
+
+	m, err := wktLookupMethod(pn)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Projection{Method: m.method, Scale: 1}
+	for _, child := range n.children {
+		if child.keyword == "PARAMETER" {
+			if err := m.set(p, child, angular); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := p.check(); err != nil {
+		return nil, CartographyError{Coord: pn.name(), Err: err}
+	}
+	return p, nil
+}
+
+// Returns ErrUnsupported if one of the unit nodes of n, including those of its axes, differs from the unit u
+func (n *wktNode) checkUnit(u wktUnit, keywords ...string) error {
+	nodes := []*wktNode{n}
+	for _, child := range n.children {
+		if child.keyword == "AXIS" {
+			nodes = append(nodes, child)
+		}
+	}
+	for _, node := range nodes {
+		unit := node.child(keywords...)
+		if same, err := unit.isUnit(u); err != nil {
+			return err
+		} else if !same {
+			return CartographyError{Coord: unit.name(), Err: ErrUnsupported}
+		}
+	}
+	return nil
+}
+
+// ## Parsing
+
+// Parse a coordinate reference system from well known text. Supported are geographic and projected
+// coordinate reference systems of WKT 1 (GEOGCS, PROJCS) and WKT 2 (GEOGCRS, GEODCRS, PROJCRS), the latter
+// also bound to WGS84 by a BOUNDCRS. Projected coordinates have to be given in meters, geographic
+// coordinates in degrees. As written by GDAL, the prime meridian of WKT 1 is given in degrees, the
+// parameters of the projection in the angular unit of the GEOGCS.
+//
+// Datums of the catalogue are recognized by their EPSG names, if the ellipsoid and the transformation into
+// WGS84 agree. Other datums are transformed into WGS84 by their TOWGS84 clause or the abridged
+// transformation of the BOUNDCRS, or else assumed to coincide with WGS84.
+//
+// Returns a CartographyError of ErrSyntax, if the text is not well formed, or of ErrUnsupported, naming the
+// projection method, parameter or unit which is not supported by this package.
+func ParseWKT(wkt string) (*CRS, error) {
+
+	p := &wktParser{wkt: wkt}
+	n, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos != len(p.wkt) {
+		return nil, p.error()
+	}
+	return n.crs(nil)
+}
+
+// The coordinate reference system of n, whose datum is transformed into WGS84 by towgs84 if not nil
+func (n *wktNode) crs(towgs84 *Helmert) (*CRS, error) {
+
+	switch n.keyword {
+	case "GEOGCS", "GEOGCRS", "GEOGRAPHICCRS", "GEODCRS", "GEODETICCRS":
+		if cs := n.child("CS"); cs != nil && !strings.EqualFold(cs.name(), "ellipsoidal") {
+			return nil, CartographyError{Coord: cs.name(), Err: ErrUnsupported}
+		}
+		if err := n.checkUnit(wktDegree, "UNIT", "ANGLEUNIT"); err != nil {
+			return nil, err
+		}
+		d, err := n.datum(towgs84)
+		if err != nil {
+			return nil, err
+		}
+		return &CRS{Name: n.name(), Datum: d}, nil
+
+	case "PROJCS":
+		gn := n.child("GEOGCS")
+		pn := n.child("PROJECTION")
+		if gn == nil || pn == nil {
+			return nil, CartographyError{Coord: n.keyword, Err: ErrSyntax}
+		}
+		if err := n.checkUnit(wktMetre, "UNIT"); err != nil {
+			return nil, err
+		}
+		d, err := gn.datum(towgs84)
+		if err != nil {
+			return nil, err
+		}
+		p, err := n.projection(pn, gn.child("UNIT"))
+		if err != nil {
+			return nil, err
+		}
+		// Mercator_1SP denotes web mercator only on the sphere of the PROJ extension
+		if ext := n.child("EXTENSION"); p.Method == WebMercator && wktAlias(pn.name()) == wktAlias("Mercator_1SP") &&
+			(ext == nil || !strings.Contains(ext.name()+" "+strings.Join(ext.args, " "), "+a=6378137 +b=6378137")) {
+			return nil, CartographyError{Coord: pn.name(), Err: ErrUnsupported}
+		}
+		return &CRS{Name: n.name(), Datum: d, Projection: p}, nil
+
+	case "PROJCRS", "PROJECTEDCRS":
+		gn := n.child("BASEGEOGCRS", "BASEGEODCRS")
+		cn := n.child("CONVERSION", "DERIVINGCONVERSION")
+		if gn == nil || cn == nil || cn.child("METHOD", "PROJECTION") == nil {
+			return nil, CartographyError{Coord: n.keyword, Err: ErrSyntax}
+		}
+		if err := n.checkUnit(wktMetre, "UNIT", "LENGTHUNIT"); err != nil {
+			return nil, err
+		}
+		d, err := gn.datum(towgs84)
+		if err != nil {
+			return nil, err
+		}
+		p, err := cn.projection(cn.child("METHOD", "PROJECTION"), nil)
+		if err != nil {
+			return nil, err
+		}
+		return &CRS{Name: n.name(), Datum: d, Projection: p}, nil
+
+	case "BOUNDCRS":
+		source, target := n.child("SOURCECRS"), n.child("TARGETCRS")
+		tn := n.child("ABRIDGEDTRANSFORMATION")
+		if source == nil || target == nil || tn == nil || len(source.children) != 1 || len(target.children) != 1 {
+			return nil, CartographyError{Coord: n.keyword, Err: ErrSyntax}
+		}
+		wgs84, err := target.children[0].crs(nil)
+		if err != nil {
+			return nil, err
+		}
+		if wgs84.Datum.El != WGS84Ellipsoid || wgs84.Datum.ToWGS84 != nil {
+			return nil, CartographyError{Coord: wgs84.Name, Err: ErrUnsupported}
+		}
+		hp, err := tn.helmert()
+		if err != nil {
+			return nil, err
+		}
+		return source.children[0].crs(hp)
+	}
+
+	return nil, CartographyError{Coord: n.keyword, Err: ErrUnsupported}
+}
+
+// The parameters of the helmert transformation and the geocentric translation in WKT 2 by their names
+// and EPSG codes
+var wktHelmertParameters = []struct {
+	name  string
+	epsg  int
+	unit  wktUnit
+	field func(hp *Helmert) *float64
+}{
+	{"X-axis translation", 8605, wktMetre, func(hp *Helmert) *float64 { return &hp.Dx }},
+	{"Y-axis translation", 8606, wktMetre, func(hp *Helmert) *float64 { return &hp.Dy }},
+	{"Z-axis translation", 8607, wktMetre, func(hp *Helmert) *float64 { return &hp.Dz }},
+	{"X-axis rotation", 8608, wktArcSecond, func(hp *Helmert) *float64 { return &hp.Rx }},
+	{"Y-axis rotation", 8609, wktArcSecond, func(hp *Helmert) *float64 { return &hp.Ry }},
+	{"Z-axis rotation", 8610, wktArcSecond, func(hp *Helmert) *float64 { return &hp.Rz }},
+	{"Scale difference", 8611, wktPPM, func(hp *Helmert) *float64 { return &hp.DM }},
+}
+
+// The helmert transformation of the ABRIDGEDTRANSFORMATION n: a geocentric translation (EPSG methods 9603
+// and 1031), or a helmert transformation in position vector (9606, 1033) or coordinate frame convention
+// (9607, 1032)
+func (n *wktNode) helmert() (*Helmert, error) {
+
+	mn := n.child("METHOD")
+	if mn == nil {
+		return nil, CartographyError{Coord: n.keyword, Err: ErrSyntax}
+	}
+
+	hp := &Helmert{Datum: n.name()}
+	method, code := wktAlias(mn.name()), mn.epsg()
+	switch {
+	case code == 9603 || code == 1031 || code == 0 && strings.HasPrefix(method, "geocentrictranslations"):
+	case code == 9606 || code == 1033 || code == 0 && strings.HasPrefix(method, "positionvector"):
+	case code == 9607 || code == 1032 || code == 0 && strings.HasPrefix(method, "coordinateframe"):
+		hp.Convention = CoordinateFrame
+	default:
+		return nil, CartographyError{Coord: mn.name(), Err: ErrUnsupported}
+	}
+
+L:
+	for _, child := range n.children {
+		if child.keyword != "PARAMETER" {
+			continue
+		}
+		for _, param := range wktHelmertParameters {
+			if wktMatches(child.name(), child.epsg(), param.epsg, param.name) {
+				val, err := child.value(param.unit, nil)
+				if err != nil {
+					return nil, err
+				}
+				*param.field(hp) = val
+				continue L
+			}
+		}
+		return nil, CartographyError{Coord: child.name(), Err: ErrUnsupported}
+	}
+
+	return hp.PositionVector(), nil
+}
+
+// ## Writing
+
+// The helmert parameters of the datum to publish in WKT. Omitted for datums on the WGS84 ellipsoid which
+// coincide with WGS84 and for datums whose transformation into WGS84 can not be expressed by helmert
+// parameters.
+func (d *Datum) wktToWGS84() (*Helmert, bool) {
+	if d.ToWGS84 == nil && d.El == WGS84Ellipsoid {
+		return nil, false
+	}
+	return d.towgs84()
+}
+
+// GEOGCS of WKT 1 for the datum
+func wktGeogCS(d *Datum) string {
+
+	geog, name, _ := wktNames(d)
+
+	var towgs84 string
+	if hp, ok := d.wktToWGS84(); ok {
+		towgs84 = "," + hp.WellKnownString()
+	}
+
+	pm := d.PrimeMeridian
+	if pm == nil {
+		pm = GreenwichMeridian
+	}
+
+	return "GEOGCS[" + wktQuote(geog) +
+		",DATUM[" + wktQuote(name) + "," + wktSpheroid(d.El, "SPHEROID", "") + towgs84 + "]" +
+		",PRIMEM[" + wktQuote(pm.Name) + "," + wktFloat(pm.Longitude) + "]" +
+		`,UNIT["degree",0.0174532925199433],AXIS["Latitude",NORTH],AXIS["Longitude",EAST]]`
+}
+
+// The projection method of p
+func (p *Projection) wktMethod() (*wktMethod, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	for _, m := range wktMethods {
+		if m.method == p.Method {
+			return m, nil
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+// The value of the parameter of p
+func (param *wktParameter) value(p *Projection) float64 {
+	if param.field == nil {
+		return param.fixed
+	}
+	return *param.field(p)
+}
+
+// Returns the coordinate reference system as WKT 1, as read by GDAL, QGIS and PostGIS. The transformation of
+// the datum into WGS84 is given by TOWGS84 in position vector convention, if it can be expressed by helmert
+// parameters.
+//
+// Returns ErrUnsupported if the parameters can not be projected by the projection method.
+func (crs *CRS) WKT() (string, error) {
+
+	if crs.Projection == nil {
+		return wktGeogCS(crs.Datum), nil
+	}
+
+	m, err := crs.Projection.wktMethod()
+	if err != nil {
+		return "", err
+	}
+
+	wkt := "PROJCS[" + wktQuote(crs.Name) + "," + wktGeogCS(crs.Datum) + ",PROJECTION[" + wktQuote(m.wkt1) + "]"
+	for _, param := range m.parameters {
+		if param.wkt1 != "" {
+			wkt += ",PARAMETER[" + wktQuote(param.wkt1) + "," + wktFloat(param.value(crs.Projection)) + "]"
+		}
+	}
+	wkt += `,UNIT["metre",1],AXIS["Easting",EAST],AXIS["Northing",NORTH]`
+	if m.method == WebMercator {
+		wkt += `,EXTENSION["PROJ4",` + wktQuote(wktWebMercatorExtension) + "]"
+	}
+	return wkt + "]", nil
+}
+
+// DATUM and PRIMEM of WKT 2 for the datum
+func wkt2Datum(d *Datum) string {
+
+	_, _, name := wktNames(d)
+
+	pm := d.PrimeMeridian
+	if pm == nil {
+		pm = GreenwichMeridian
+	}
+
+	return "DATUM[" + wktQuote(name) + "," + wktSpheroid(d.El, "ELLIPSOID", ","+wktMetre.wkt2()) + "]" +
+		",PRIMEM[" + wktQuote(pm.Name) + "," + wktFloat(pm.Longitude) + "," + wktDegree.wkt2() + "]"
+}
+
+// GEOGCRS of WKT 2 named name for the datum
+func wkt2GeogCRS(name string, d *Datum) string {
+	return "GEOGCRS[" + wktQuote(name) + "," + wkt2Datum(d) + ",CS[ellipsoidal,2]" +
+		`,AXIS["geodetic latitude (Lat)",north,ORDER[1],` + wktDegree.wkt2() + "]" +
+		`,AXIS["geodetic longitude (Lon)",east,ORDER[2],` + wktDegree.wkt2() + "]]"
+}
+
+// ID of WKT 2 of the EPSG code
+func wkt2ID(code int) string {
+	return `ID["EPSG",` + strconv.Itoa(code) + "]"
+}
+
+// Returns the coordinate reference system as WKT 2, as read by PROJ and QGIS. A CRS whose datum is
+// transformed into WGS84 by helmert parameters is bound to WGS84 by a BOUNDCRS.
+//
+// Returns ErrUnsupported if the parameters can not be projected by the projection method.
+func (crs *CRS) WKT2() (string, error) {
+
+	var wkt string
+
+	if crs.Projection == nil {
+		wkt = wkt2GeogCRS(crs.Name, crs.Datum)
+	} else {
+		m, err := crs.Projection.wktMethod()
+		if err != nil {
+			return "", err
+		}
+
+		// the conversion is named like the projected CRS without its base, eg. "UTM zone 33N" of "WGS 84 / UTM zone 33N"
+		conversion := crs.Name
+		if i := strings.LastIndex(conversion, " / "); i >= 0 {
+			conversion = conversion[i+len(" / "):]
+		}
+
+		geog, _, _ := wktNames(crs.Datum)
+		wkt = "PROJCRS[" + wktQuote(crs.Name) + ",BASEGEOGCRS[" + wktQuote(geog) + "," + wkt2Datum(crs.Datum) + "]" +
+			",CONVERSION[" + wktQuote(conversion) + ",METHOD[" + wktQuote(m.wkt2) + "," + wkt2ID(m.epsg) + "]"
+		for _, param := range m.parameters {
+			if param.wkt2 != "" {
+				wkt += ",PARAMETER[" + wktQuote(param.wkt2) + "," + wktFloat(param.value(crs.Projection)) + "," +
+					param.unit.wkt2() + "," + wkt2ID(param.epsg) + "]"
+			}
+		}
+		wkt += "],CS[Cartesian,2]" +
+			`,AXIS["easting (E)",east,ORDER[1],` + wktMetre.wkt2() + "]" +
+			`,AXIS["northing (N)",north,ORDER[2],` + wktMetre.wkt2() + "]]"
+	}
+
+	hp, ok := crs.Datum.wktToWGS84()
+	if !ok {
+		return wkt, nil
+	}
+
+	geog, _, _ := wktNames(crs.Datum)
+	wkt = "BOUNDCRS[SOURCECRS[" + wkt + "],TARGETCRS[" + wkt2GeogCRS(WGS84CRS.Name, WGS84Datum) + "]" +
+		",ABRIDGEDTRANSFORMATION[" + wktQuote(geog+" to WGS 84")
+	params := wktHelmertParameters
+	if hp.DM == 0 && hp.Rx == 0 && hp.Ry == 0 && hp.Rz == 0 {
+		wkt += `,METHOD["Geocentric translations (geog2D domain)",` + wkt2ID(9603) + "]"
+		params = params[:3]
+	} else {
+		wkt += `,METHOD["Position Vector transformation (geog2D domain)",` + wkt2ID(9606) + "]"
+	}
+	for _, param := range params {
+		wkt += ",PARAMETER[" + wktQuote(param.name) + "," + wktFloat(*param.field(hp)) + "," + param.unit.wkt2() + "," + wkt2ID(param.epsg) + "]"
+	}
+	return wkt + "]]", nil
+}
@@ -0,0 +1,336 @@
+// Copyright 2011,2012 Johann Höchtl. All rights reserved.
+// Use of this source code is governed by a Modified BSD License
+// that can be found in the LICENSE file.
+
+// Automated tests for the cartconvert package
+package cartconvert
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func projectionequal(p1, p2 *Projection) bool {
+	if p1 == nil || p2 == nil {
+		return p1 == p2
+	}
+	for _, d := range []float64{p1.LatO - p2.LatO, p1.LongO - p2.LongO, p1.Lat1 - p2.Lat1, p1.Lat2 - p2.Lat2, p1.Scale - p2.Scale, p1.FE - p2.FE, p1.FN - p2.FN} {
+		if math.Abs(d) > 1e-9 {
+			return false
+		}
+	}
+	return p1.Method == p2.Method && p1.Algorithm == p2.Algorithm
+}
+
+// ## ParseWKT
+type parseWKTTest struct {
+	in         string
+	name       string
+	datum      *Datum
+	projection *Projection
+}
+
+var parseWKTTests = []parseWKTTest{
+	{ // WKT 1 of EPSG:27700 as written by GDAL, the published parameters of OSGB36 are negated instead of inverted
+		`PROJCS["OSGB 1936 / British National Grid",GEOGCS["OSGB 1936",DATUM["OSGB_1936",SPHEROID["Airy 1830",6377563.396,299.3249646,AUTHORITY["EPSG","7001"]],
+		TOWGS84[446.448,-125.157,542.06,0.15,0.247,0.842,-20.489],AUTHORITY["EPSG","6277"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],
+		UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4277"]],PROJECTION["Transverse_Mercator"],
+		PARAMETER["latitude_of_origin",49],PARAMETER["central_meridian",-2],PARAMETER["scale_factor",0.9996012717],
+		PARAMETER["false_easting",400000],PARAMETER["false_northing",-100000],UNIT["metre",1,AUTHORITY["EPSG","9001"]],
+		AXIS["Easting",EAST],AXIS["Northing",NORTH],AUTHORITY["EPSG","27700"]]`,
+		"OSGB 1936 / British National Grid",
+		OSGB36Datum,
+		&Projection{Method: TransverseMercator, LatO: 49, LongO: -2, Scale: 0.9996012717, FE: 400000, FN: -100000},
+	},
+	{ // projection parameters in grads of the GEOGCS and the prime meridian in degrees
+		`PROJCS["NTF (Paris) / Lambert zone II",GEOGCS["NTF (Paris)",DATUM["Nouvelle_Triangulation_Francaise_Paris",
+		SPHEROID["Clarke 1880 (IGN)",6378249.2,293.4660212936269],TOWGS84[-168,-60,320,0,0,0,0]],PRIMEM["Paris",2.33722917],
+		UNIT["grad",0.01570796326794897]],PROJECTION["Lambert_Conformal_Conic_1SP"],PARAMETER["latitude_of_origin",52],
+		PARAMETER["central_meridian",0],PARAMETER["scale_factor",0.99987742],PARAMETER["false_easting",600000],
+		PARAMETER["false_northing",2200000],UNIT["metre",1],AXIS["X",EAST],AXIS["Y",NORTH]]`,
+		"NTF (Paris) / Lambert zone II",
+		NTFParisDatum,
+		&Projection{Method: LambertConformalConic1SP, LatO: 46.8, Scale: 0.99987742, FE: 600000, FN: 2200000},
+	},
+	{ // a datum with prime meridian, which is not in the catalogue
+		`GEOGCS["MGI (Ferro)",DATUM["Militar_Geographische_Institute",SPHEROID["Bessel 1841",6377397.155,299.1528128]],
+		PRIMEM["Ferro",-17.66666666666667],UNIT["degree",0.0174532925199433]]`,
+		"MGI (Ferro)",
+		MGIFerroDatum,
+		nil,
+	},
+	{ // WKT 2 of EPSG:32633 as written by PROJ
+		`PROJCRS["WGS 84 / UTM zone 33N",
+		    BASEGEOGCRS["WGS 84",
+		        DATUM["World Geodetic System 1984",
+		            ELLIPSOID["WGS 84",6378137,298.257223563,LENGTHUNIT["metre",1]]],
+		        PRIMEM["Greenwich",0,ANGLEUNIT["degree",0.0174532925199433]],
+		        ID["EPSG",4326]],
+		    CONVERSION["UTM zone 33N",
+		        METHOD["Transverse Mercator",ID["EPSG",9807]],
+		        PARAMETER["Latitude of natural origin",0,ANGLEUNIT["degree",0.0174532925199433],ID["EPSG",8801]],
+		        PARAMETER["Longitude of natural origin",15,ANGLEUNIT["degree",0.0174532925199433],ID["EPSG",8802]],
+		        PARAMETER["Scale factor at natural origin",0.9996,SCALEUNIT["unity",1],ID["EPSG",8805]],
+		        PARAMETER["False easting",500000,LENGTHUNIT["metre",1],ID["EPSG",8806]],
+		        PARAMETER["False northing",0,LENGTHUNIT["metre",1],ID["EPSG",8807]]],
+		    CS[Cartesian,2],
+		        AXIS["(E)",east,ORDER[1],LENGTHUNIT["metre",1]],
+		        AXIS["(N)",north,ORDER[2],LENGTHUNIT["metre",1]],
+		    ID["EPSG",32633]]`,
+		"WGS 84 / UTM zone 33N",
+		WGS84Datum,
+		&Projection{Method: TransverseMercator, LongO: 15, Scale: 0.9996, FE: 500000},
+	},
+	{ // WKT 2 of EPSG:4326 as written by PROJ 9, the datum ensemble stands for WGS84
+		`GEOGCRS["WGS 84",
+		    ENSEMBLE["World Geodetic System 1984 ensemble",
+		        MEMBER["World Geodetic System 1984 (Transit)"],
+		        MEMBER["World Geodetic System 1984 (G730)"],
+		        MEMBER["World Geodetic System 1984 (G873)"],
+		        MEMBER["World Geodetic System 1984 (G1150)"],
+		        MEMBER["World Geodetic System 1984 (G1674)"],
+		        MEMBER["World Geodetic System 1984 (G1762)"],
+		        MEMBER["World Geodetic System 1984 (G2139)"],
+		        MEMBER["World Geodetic System 1984 (G2296)"],
+		        ELLIPSOID["WGS 84",6378137,298.257223563,
+		            LENGTHUNIT["metre",1]],
+		        ENSEMBLEACCURACY[2.0]],
+		    PRIMEM["Greenwich",0,
+		        ANGLEUNIT["degree",0.0174532925199433]],
+		    CS[ellipsoidal,2],
+		        AXIS["geodetic latitude (Lat)",north,
+		            ORDER[1],
+		            ANGLEUNIT["degree",0.0174532925199433]],
+		        AXIS["geodetic longitude (Lon)",east,
+		            ORDER[2],
+		            ANGLEUNIT["degree",0.0174532925199433]],
+		    USAGE[
+		        SCOPE["Horizontal component of 3D system."],
+		        AREA["World."],
+		        BBOX[-90,-180,90,180]],
+		    ID["EPSG",4326]]`,
+		"WGS 84",
+		WGS84Datum,
+		nil,
+	},
+	{ // WKT 2 of EPSG:3857 as written by PROJ 9
+		`PROJCRS["WGS 84 / Pseudo-Mercator",
+		    BASEGEOGCRS["WGS 84",
+		        ENSEMBLE["World Geodetic System 1984 ensemble",
+		            MEMBER["World Geodetic System 1984 (Transit)"],
+		            MEMBER["World Geodetic System 1984 (G730)"],
+		            MEMBER["World Geodetic System 1984 (G873)"],
+		            MEMBER["World Geodetic System 1984 (G1150)"],
+		            MEMBER["World Geodetic System 1984 (G1674)"],
+		            MEMBER["World Geodetic System 1984 (G1762)"],
+		            MEMBER["World Geodetic System 1984 (G2139)"],
+		            MEMBER["World Geodetic System 1984 (G2296)"],
+		            ELLIPSOID["WGS 84",6378137,298.257223563,
+		                LENGTHUNIT["metre",1]],
+		            ENSEMBLEACCURACY[2.0]],
+		        PRIMEM["Greenwich",0,
+		            ANGLEUNIT["degree",0.0174532925199433]],
+		        ID["EPSG",4326]],
+		    CONVERSION["Popular Visualisation Pseudo-Mercator",
+		        METHOD["Popular Visualisation Pseudo Mercator",
+		            ID["EPSG",1024]],
+		        PARAMETER["Latitude of natural origin",0,
+		            ANGLEUNIT["degree",0.0174532925199433],
+		            ID["EPSG",8801]],
+		        PARAMETER["Longitude of natural origin",0,
+		            ANGLEUNIT["degree",0.0174532925199433],
+		            ID["EPSG",8802]],
+		        PARAMETER["False easting",0,
+		            LENGTHUNIT["metre",1],
+		            ID["EPSG",8806]],
+		        PARAMETER["False northing",0,
+		            LENGTHUNIT["metre",1],
+		            ID["EPSG",8807]]],
+		    CS[Cartesian,2],
+		        AXIS["easting (X)",east,
+		            ORDER[1],
+		            LENGTHUNIT["metre",1]],
+		        AXIS["northing (Y)",north,
+		            ORDER[2],
+		            LENGTHUNIT["metre",1]],
+		    USAGE[
+		        SCOPE["Web mapping and visualisation."],
+		        AREA["World between 85.06°S and 85.06°N."],
+		        BBOX[-85.06,-180,85.06,180]],
+		    ID["EPSG",3857]]`,
+		"WGS 84 / Pseudo-Mercator",
+		WGS84Datum,
+		&Projection{Method: WebMercator, Scale: 1},
+	},
+	{ // WKT 2 bound to WGS84, parameters recognized by their EPSG code, angles in radians
+		`BOUNDCRS[SOURCECRS[PROJCRS["Amersfoort / RD New",BASEGEODCRS["Amersfoort",DATUM["Amersfoort",
+		ELLIPSOID["Bessel 1841",6377397.155,299.1528128]]],CONVERSION["RD New",METHOD["Oblique Stereographic"],
+		PARAMETER["lat_0",0.9102967268932393,ANGLEUNIT["radian",1],ID["EPSG",8801]],
+		PARAMETER["lon_0",0.09403203751960007,ANGLEUNIT["radian",1],ID["EPSG",8802]],
+		PARAMETER["k",0.9999079,ID["EPSG",8805]],PARAMETER["x_0",155000,ID["EPSG",8806]],PARAMETER["y_0",463000,ID["EPSG",8807]]],
+		CS[Cartesian,2],AXIS["easting",east],AXIS["northing",north],LENGTHUNIT["metre",1]]],
+		TARGETCRS[GEOGCRS["WGS 84",DATUM["World Geodetic System 1984",ELLIPSOID["WGS 84",6378137,298.257223563]],CS[ellipsoidal,2]]],
+		ABRIDGEDTRANSFORMATION["Amersfoort to WGS 84",METHOD["Geocentric translations (geog2D domain)",ID["EPSG",9603]],
+		PARAMETER["X-axis translation",593.16],PARAMETER["Y-axis translation",26.15],PARAMETER["Z-axis translation",478.54]]]`,
+		"Amersfoort / RD New",
+		AmersfoortDatum,
+		&Projection{Method: ObliqueStereographic, LatO: 52 + 9.0/60 + 22.178/3600, LongO: 5 + 23.0/60 + 15.5/3600, Scale: 0.9999079, FE: 155000, FN: 463000},
+	},
+}
+
+func TestParseWKT(t *testing.T) {
+	for index, test := range parseWKTTests {
+		crs, err := ParseWKT(test.in)
+		if err != nil {
+			t.Errorf("ParseWKT [%d]: %s", index, err)
+			continue
+		}
+		if crs.Name != test.name || crs.Datum != test.datum || !projectionequal(crs.Projection, test.projection) {
+			t.Errorf("ParseWKT [%d]: expected %s on %s by %v, got %s on %s by %v", index, test.name, test.datum, test.projection, crs, crs.Datum, crs.Projection)
+		}
+	}
+}
+
+// Datums which are not in the catalogue
+func TestParseWKTDatum(t *testing.T) {
+
+	// Gauss-Krüger zone 3 on DHDN with a transformation differing from the catalogue
+	crs, err := ParseWKT(`PROJCS["DHDN / 3-degree Gauss-Kruger zone 3",GEOGCS["DHDN",DATUM["Deutsches_Hauptdreiecksnetz",
+		SPHEROID["Bessel 1841",6377397.155,299.1528128],TOWGS84[582,105,414,1.04,0.35,-3.08,8.3]],PRIMEM["Greenwich",0],
+		UNIT["degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],PARAMETER["latitude_of_origin",0],
+		PARAMETER["central_meridian",9],PARAMETER["scale_factor",1],PARAMETER["false_easting",3500000],
+		PARAMETER["false_northing",0],UNIT["metre",1]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewHelmertTransformer(582, 105, 414, 8.3, 1.04, 0.35, -3.08, "")
+	if hp, ok := crs.Datum.towgs84(); crs.Datum == DHDNDatum || crs.Datum.El != Bessel1841Ellipsoid || !ok || !helmertEqual(expected, hp) {
+		t.Errorf("ParseWKT: expected a datum transformed by %s, got %s by %v", expected, crs.Datum, hp)
+	}
+
+	// an unknown datum on the GRS80 ellipsoid without transformation coincides with WGS84
+	crs, err = ParseWKT(`GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]],
+		PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]]`)
+	if err != nil || crs.Datum.Name != "North_American_Datum_1983" || crs.Datum.El != GRS80Ellipsoid || crs.Datum.ToWGS84 != nil {
+		t.Errorf("ParseWKT: expected North_American_Datum_1983 on GRS80, got %v (%v)", crs, err)
+	}
+
+	// an unknown ellipsoid
+	crs, err = ParseWKT(`GEOGCRS["Sphere",DATUM["Sphere",ELLIPSOID["Sphere",6371000,0,LENGTHUNIT["metre",1]]],CS[ellipsoidal,2]]`)
+	if err != nil || crs.Datum.El.a != 6371000 || crs.Datum.El.b != 6371000 || crs.Datum.El.CommonName != "Sphere" {
+		t.Errorf("ParseWKT: expected a sphere, got %v (%v)", crs, err)
+	}
+}
+
+// ## ParseWKT errors
+type parseWKTErrorTest struct {
+	in    string
+	coord string
+	err   error
+}
+
+var parseWKTErrorTests = []parseWKTErrorTest{
+	{`GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]`, "", ErrSyntax},
+	{`GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]] x`, "x", ErrSyntax},
+	{`GEOGCS["WGS 84,DATUM["WGS_1984"]]`, "", ErrSyntax},
+	{`GEOGCS["WGS 84",DATUM["WGS_1984"]]`, "DATUM", ErrSyntax},
+	{`GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,a]]]`, "a", ErrSyntax},
+	{`GEOCCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]]`, "GEOCCS", ErrUnsupported},
+	{`GEOGCS["NTF (Paris)",DATUM["NTF",SPHEROID["Clarke 1880 (IGN)",6378249.2,293.466021293627]],UNIT["grad",0.015707963267949]]`, "grad", ErrUnsupported},
+	{`PROJCS["Krovak",GEOGCS["S-JTSK",DATUM["S-JTSK",SPHEROID["Bessel 1841",6377397.155,299.1528128]]],PROJECTION["Krovak"]]`, "Krovak", ErrUnsupported},
+	{`PROJCS["Mercator",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Mercator_1SP"]]`, "Mercator_1SP", ErrUnsupported},
+	{`PROJCS["TM",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Transverse_Mercator"],PARAMETER["standard_parallel_1",10]]`, "standard_parallel_1", ErrUnsupported},
+	{`PROJCS["TM",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Transverse_Mercator"],UNIT["US survey foot",0.304800609601219]]`, "US survey foot", ErrUnsupported},
+	{`PROJCS["HOM",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Hotine_Oblique_Mercator_Azimuth_Center"],PARAMETER["azimuth",45]]`, "azimuth", ErrUnsupported},
+	{`PROJCS["UPS",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Polar_Stereographic"],PARAMETER["latitude_of_origin",70]]`, "Polar_Stereographic", ErrUnsupported},
+	{`BOUNDCRS[SOURCECRS[GEOGCRS["ED50",DATUM["European Datum 1950",ELLIPSOID["International 1924",6378388,297]]]],
+		TARGETCRS[GEOGCRS["WGS 84",DATUM["World Geodetic System 1984",ELLIPSOID["WGS 84",6378137,298.257223563]]]],
+		ABRIDGEDTRANSFORMATION["ED50 to WGS 84",METHOD["NTv2",ID["EPSG",9615]]]]`, "NTv2", ErrUnsupported},
+}
+
+func TestParseWKTError(t *testing.T) {
+	for index, test := range parseWKTErrorTests {
+		_, err := ParseWKT(test.in)
+		ce, ok := err.(CartographyError)
+		if !ok || ce.Err != test.err || (test.coord != "" && ce.Coord != test.coord) {
+			t.Errorf("ParseWKT [%d]: expected %s at %q, got %v", index, test.err, test.coord, err)
+		}
+	}
+}
+
+// ## WKT, WKT2
+var wktCRSTests = []*CRS{
+	WGS84CRS,
+	webMercatorCRS,
+	{Name: "ETRS89", Datum: ETRS89Datum},
+	{Name: "ED50 / UTM zone 32N", Datum: ED50Datum, Projection: &Projection{Method: TransverseMercator, LongO: 9, Scale: 0.9996, FE: 500000}},
+	{Name: "NTF (Paris) / Lambert zone II", Datum: NTFParisDatum, Projection: &Projection{Method: LambertConformalConic1SP, LatO: 46.8, Scale: 0.99987742, FE: 600000, FN: 2200000}},
+	{Name: "MGI / Austria Lambert", Datum: MGIDatum, Projection: &Projection{Method: LambertConformalConic2SP, LatO: 47.5, LongO: 13 + 20.0/60, Lat1: 49, Lat2: 46, Scale: 1, FE: 400000, FN: 400000}},
+	{Name: "CH1903+ / LV95", Datum: CH1903PlusDatum, Projection: &Projection{Method: SwissObliqueMercator, LatO: 46 + 57.0/60 + 8.66/3600, LongO: 7 + 26.0/60 + 22.5/3600, Scale: 1, FE: 2600000, FN: 1200000}},
+	{Name: "WGS 84 / UPS South (E,N)", Datum: WGS84Datum, Projection: &Projection{Method: PolarStereographic, LatO: -90, Scale: 0.994, FE: 2000000, FN: 2000000}},
+	{Name: "Custom", Datum: NewHelmertDatum("Custom datum", Krassowsky1940Ellipsoid, NewHelmertTransformer(1, 2, 3, 4, 5, 6, 7, "")), Projection: &Projection{Method: ObliqueStereographic, LatO: 45, LongO: 25, Scale: 0.99975, FE: 500000, FN: 500000}},
+}
+
+func TestWKT(t *testing.T) {
+	for index, crs := range wktCRSTests {
+		wkt1, err1 := crs.WKT()
+		wkt2, err2 := crs.WKT2()
+		if err1 != nil || err2 != nil {
+			t.Errorf("WKT [%d]: %v, %v", index, err1, err2)
+			continue
+		}
+
+		for _, wkt := range []string{wkt1, wkt2} {
+			out, err := ParseWKT(wkt)
+			if err != nil {
+				t.Errorf("ParseWKT [%d]: %s in %s", index, err, wkt)
+				continue
+			}
+			if out.Name != crs.Name || !projectionequal(out.Projection, crs.Projection) {
+				t.Errorf("ParseWKT [%d]: expected %s by %v, got %s by %v", index, crs, crs.Projection, out, out.Projection)
+			}
+
+			// datums of the catalogue are recognized, others are reconstructed
+			if _, ok := wktDatums[wktAlias(crs.Datum.Name)]; ok && out.Datum != crs.Datum {
+				t.Errorf("ParseWKT [%d]: expected the datum %s, got %s", index, crs.Datum, out.Datum)
+			}
+			expected, _ := crs.Datum.towgs84()
+			if hp, _ := out.Datum.towgs84(); out.Datum.El != crs.Datum.El || out.Datum.PrimeMeridian != crs.Datum.PrimeMeridian || !helmertEqual(expected, hp) {
+				t.Errorf("ParseWKT [%d]: expected the datum %s by %s, got %s by %s", index, crs.Datum, expected, out.Datum, hp)
+			}
+		}
+	}
+}
+
+// The WKT of the catalogue datums recognized by GDAL and PROJ
+func TestWKTText(t *testing.T) {
+
+	crs := &CRS{Name: "MGI (Ferro) / M28", Datum: MGIFerroDatum, Projection: &Projection{Method: TransverseMercator, LongO: 28, Scale: 1, FE: 150000, FN: -5000000}}
+
+	wkt1, _ := crs.WKT()
+	for _, fragment := range []string{`PROJCS["MGI (Ferro) / M28",GEOGCS["MGI (Ferro)",DATUM["Militar_Geographische_Institut_Ferro",SPHEROID["Bessel 1841",6377397.155,299.1528128],TOWGS84[`,
+		`PRIMEM["Ferro",-17.6666666666667]`, `PROJECTION["Transverse_Mercator"]`, `PARAMETER["central_meridian",28]`, `PARAMETER["false_northing",-5000000]`} {
+		if !strings.Contains(wkt1, fragment) {
+			t.Errorf("WKT: expected %s in %s", fragment, wkt1)
+		}
+	}
+
+	wkt2, _ := crs.WKT2()
+	for _, fragment := range []string{`BOUNDCRS[SOURCECRS[PROJCRS["MGI (Ferro) / M28",BASEGEOGCRS["MGI (Ferro)",DATUM["Militar-Geographische Institut (Ferro)"`,
+		`CONVERSION["M28",METHOD["Transverse Mercator",ID["EPSG",9807]]`, `PARAMETER["Longitude of natural origin",28,ANGLEUNIT["degree",0.0174532925199433],ID["EPSG",8802]]`,
+		`ABRIDGEDTRANSFORMATION["MGI (Ferro) to WGS 84",METHOD["Position Vector transformation (geog2D domain)",ID["EPSG",9606]]`} {
+		if !strings.Contains(wkt2, fragment) {
+			t.Errorf("WKT2: expected %s in %s", fragment, wkt2)
+		}
+	}
+
+	// WGS84 is not bound to itself
+	if wkt2, _ := WGS84CRS.WKT2(); !strings.HasPrefix(wkt2, `GEOGCRS["WGS 84",`) {
+		t.Errorf("WKT2: expected a GEOGCRS, got %s", wkt2)
+	}
+
+	if _, err := (&CRS{Name: "Mercator", Datum: WGS84Datum, Projection: &Projection{Method: WebMercator, Scale: 1, FE: 100}}).WKT(); err != ErrUnsupported {
+		t.Errorf("WKT: expected %s, got %v", ErrUnsupported, err)
+	}
+}
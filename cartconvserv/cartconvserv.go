@@ -12,7 +12,11 @@ import (
 	"fmt"
 	"github.com/the42/cartconvert/cartconvert"
 	"github.com/the42/cartconvert/cartconvert/bmn"
+	"github.com/the42/cartconvert/cartconvert/irishgrid"
+	_ "github.com/the42/cartconvert/cartconvert/lambert"
+	_ "github.com/the42/cartconvert/cartconvert/lv03p"
 	"github.com/the42/cartconvert/cartconvert/osgb36"
+	_ "github.com/the42/cartconvert/cartconvert/rd"
 	"html/template"
 	"log"
 	"net/http"
@@ -38,9 +42,14 @@ const (
 	OFlatlongdeg   = "latlongdeg"
 	OFlatlongcomma = "latlongcomma"
 	OFgeohash      = "geohash"
+	OFpluscode     = "pluscode"
+	OFmaidenhead   = "maidenhead"
+	OFgars         = "gars"
 	OFUTM          = "utm"
 	OFBMN          = "bmn"
 	OFOSGB         = "osgb"
+	OFIrishGrid    = "irishgrid"
+	OFTile         = "tile"
 )
 
 // Interface type for transparent XML / JSON Encoding
@@ -79,6 +88,18 @@ type (
 		GeoHash string
 	}
 
+	PlusCode struct {
+		PlusCode string
+	}
+
+	Maidenhead struct {
+		Maidenhead string
+	}
+
+	GARS struct {
+		GARS string
+	}
+
 	UTMCoord struct {
 		UTMCoord  *cartconvert.UTMCoord // MIND: UTMCoord is named, because XML and JSON serialization behave differently. An unnamed struct element will NOT be serialized by the XML encoder
 		UTMString string
@@ -93,41 +114,80 @@ type (
 		OSGB36Coord  *osgb36.OSGB36Coord // MIND: OSGB36Coord is named, because XML and JSON serialization behave differently. An unnamed struct element will NOT be serialized by the XML encoder
 		OSGB36String string
 	}
+
+	IrishGrid struct {
+		IrishGridCoord  *irishgrid.IrishGridCoord // MIND: IrishGridCoord is named, because XML and JSON serialization behave differently. An unnamed struct element will NOT be serialized by the XML encoder
+		IrishGridString string
+	}
+
+	Tile struct {
+		Tile        *cartconvert.Tile // MIND: Tile is named, because XML and JSON serialization behave differently. An unnamed struct element will NOT be serialized by the XML encoder
+		TileString  string
+		QuadKey     string
+		BoundingBox *cartconvert.BoundingBox
+	}
+
+	// Serialization of every coordinate system without a dedicated payload
+	Coordinate struct {
+		Coord       interface{} // MIND: Coord is named, because XML and JSON serialization behave differently. An unnamed struct element will NOT be serialized by the XML encoder
+		CoordString string
+	}
 )
 
 // serialize gets called by the respective handler methods to perform the serialization in the requested output representation
 func serialize(latlong *cartconvert.PolarCoord, oformat string) (interface{}, error) {
-	var serializestruct interface{}
-	var err error
 
-	switch oformat {
-	case OFlatlongdeg:
-		lat, long := cartconvert.LatLongToString(latlong, cartconvert.LLFdms)
-		serializestruct = &LatLong{Lat: lat, Long: long, Fmt: cartconvert.LLFdms.String(), LatLongString: latlong.String()}
-	case OFlatlongcomma:
-		lat, long := cartconvert.LatLongToString(latlong, cartconvert.LLFdeg)
-		serializestruct = &LatLong{Lat: lat, Long: long, Fmt: cartconvert.LLFdeg.String(), LatLongString: latlong.String()}
-	case OFgeohash:
-		serializestruct = &GeoHash{GeoHash: cartconvert.LatLongToGeoHash(latlong)}
-	case OFUTM:
-		utm := cartconvert.LatLongToUTM(latlong)
-		serializestruct = &UTMCoord{UTMCoord: utm, UTMString: utm.String()}
-	case OFBMN:
-		var bmnval *bmn.BMNCoord
-		bmnval, err = bmn.WGS84LatLongToBMN(latlong, bmn.BMNZoneDet)
-		if err == nil {
-			serializestruct = &BMN{BMNCoord: bmnval, BMNString: bmnval.String()}
+	cs, ok := cartconvert.LookupCoordinateSystem(oformat)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported output format: '%s'", oformat)
+	}
+
+	coord, err := cs.FromLatLong(latlong)
+	if err != nil {
+		return nil, err
+	}
+
+	coordstring, err := cs.Format(coord)
+	if err != nil {
+		return nil, err
+	}
+
+	// the output formats which predate the coordinate system registry keep their serialization
+	switch val := coord.(type) {
+	case *cartconvert.PolarCoord:
+		format := cartconvert.LLFdeg
+		if cs.Name() == OFlatlongdeg {
+			format = cartconvert.LLFdms
 		}
-	case OFOSGB:
-		var osgb36val *osgb36.OSGB36Coord
-		osgb36val, err = osgb36.WGS84LatLongToOSGB36(latlong)
-		if err == nil {
-			serializestruct = &OSGB36{OSGB36Coord: osgb36val, OSGB36String: osgb36val.String()}
+		lat, long := cartconvert.LatLongToString(val, format)
+		return &LatLong{Lat: lat, Long: long, Fmt: format.String(), LatLongString: val.String()}, nil
+	case *cartconvert.UTMCoord:
+		return &UTMCoord{UTMCoord: val, UTMString: coordstring}, nil
+	case *bmn.BMNCoord:
+		return &BMN{BMNCoord: val, BMNString: coordstring}, nil
+	case *osgb36.OSGB36Coord:
+		return &OSGB36{OSGB36Coord: val, OSGB36String: coordstring}, nil
+	case *irishgrid.IrishGridCoord:
+		return &IrishGrid{IrishGridCoord: val, IrishGridString: coordstring}, nil
+	case *cartconvert.Tile:
+		bb, err := cartconvert.TileToBoundingBox(val)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		err = fmt.Errorf("Unsupported output format: '%s'", oformat)
+		return &Tile{Tile: val, TileString: coordstring, QuadKey: cartconvert.TileToQuadKey(val), BoundingBox: bb}, nil
+	}
+
+	switch cs.Name() {
+	case OFgeohash:
+		return &GeoHash{GeoHash: coordstring}, nil
+	case OFpluscode:
+		return &PlusCode{PlusCode: coordstring}, nil
+	case OFmaidenhead:
+		return &Maidenhead{Maidenhead: coordstring}, nil
+	case OFgars:
+		return &GARS{GARS: coordstring}, nil
 	}
-	return serializestruct, err
+	return &Coordinate{Coord: coord, CoordString: coordstring}, nil
 }
 
 func getfirstValueFromURLParameters(params []URLParameter, key string) (retval string) {
@@ -148,8 +208,18 @@ func latlongHandler(request *GEOConvertRequest, latlongstrval, oformat string) (
 		return nil, fmt.Errorf("Latlong doesn't accept an input value. Use the parameters 'lat' and 'long' instead")
 	}
 
-	slat := getfirstValueFromURLParameters(request.Parameters, "lat")
-	slong := getfirstValueFromURLParameters(request.Parameters, "long")
+	latlong, err := latlongFromURLParameters(request.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	return serialize(latlong, oformat)
+}
+
+// Parse the URL parameters 'lat' and 'long' as latitude and longitude
+func latlongFromURLParameters(params []URLParameter) (*cartconvert.PolarCoord, error) {
+
+	slat := getfirstValueFromURLParameters(params, "lat")
+	slong := getfirstValueFromURLParameters(params, "long")
 
 	var lat, long float64
 	var err error
@@ -170,54 +240,69 @@ func latlongHandler(request *GEOConvertRequest, latlongstrval, oformat string) (
 		}
 	}
 
-	latlong := &cartconvert.PolarCoord{Latitude: lat, Longitude: long, El: cartconvert.DefaultEllipsoid}
-	return serialize(latlong, oformat)
+	return &cartconvert.PolarCoord{Latitude: lat, Longitude: long, El: cartconvert.DefaultEllipsoid}, nil
 }
 
-func geohashHandler(request *GEOConvertRequest, geohashstrval, oformat string) (interface{}, error) {
-	var latlong *cartconvert.PolarCoord
-	var err error
-	if latlong, err = cartconvert.GeoHashToLatLong(geohashstrval, nil); err != nil {
-		return nil, err
+// A tile has to be passed as the parameters 'z', 'x' and 'y' as the value can not contain slashes
+func tileHandler(request *GEOConvertRequest, tilestrval, oformat string) (interface{}, error) {
+
+	if len(tilestrval) > 0 {
+		return nil, fmt.Errorf("Tile doesn't accept an input value. Use the parameters 'z', 'x' and 'y' instead")
 	}
-	return serialize(latlong, oformat)
-}
 
-func utmHandler(req *GEOConvertRequest, utmstrval, oformat string) (interface{}, error) {
-	var utmval *cartconvert.UTMCoord
-	var err error
-	if utmval, err = cartconvert.AUTMToStruct(utmstrval, nil); err != nil {
-		return nil, err
+	z := getfirstValueFromURLParameters(request.Parameters, "z")
+	x := getfirstValueFromURLParameters(request.Parameters, "x")
+	y := getfirstValueFromURLParameters(request.Parameters, "y")
+
+	tile, err := cartconvert.ATileToStruct(z + "/" + x + "/" + y)
+	if err != nil {
+		return nil, fmt.Errorf("Not a tile: '%s/%s/%s'", z, x, y)
 	}
 
-	var latlong *cartconvert.PolarCoord
-	if latlong, err = cartconvert.UTMToLatLong(utmval); err != nil {
+	latlong, err := cartconvert.TileToLatLong(tile)
+	if err != nil {
 		return nil, err
 	}
 	return serialize(latlong, oformat)
 }
 
-func bmnHandler(req *GEOConvertRequest, bmnstrval, oformat string) (interface{}, error) {
-	var bmnval *bmn.BMNCoord
-	var err error
-	if bmnval, err = bmn.ABMNToStruct(bmnstrval); err != nil {
-		return nil, err
+// A short plus code gets recovered relative to the reference location passed as the parameters 'lat' and 'long'
+func pluscodeHandler(request *GEOConvertRequest, pluscodestrval, oformat string) (interface{}, error) {
+
+	code := pluscodestrval
+	if cartconvert.IsShortPlusCode(code) {
+		ref, err := latlongFromURLParameters(request.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("A short plus code requires the reference location as parameters 'lat' and 'long': %s", err)
+		}
+
+		if code, err = cartconvert.RecoverPlusCode(code, ref); err != nil {
+			return nil, err
+		}
 	}
 
-	var latlong *cartconvert.PolarCoord
-	if latlong, err = bmn.BMNToWGS84LatLong(bmnval); err != nil {
+	latlong, err := cartconvert.PlusCodeToLatLong(code, nil)
+	if err != nil {
 		return nil, err
 	}
 	return serialize(latlong, oformat)
 }
 
-func osgbHandler(req *GEOConvertRequest, osgb36strval, oformat string) (interface{}, error) {
-	var osgb36val *osgb36.OSGB36Coord
-	var err error
-	if osgb36val, err = osgb36.AOSGB36ToStruct(osgb36strval, osgb36.OSGB36Leave); err != nil {
-		return nil, err
+// coordSystemHandler returns a restful method which parses the value as a coordinate of
+// the coordinate system cs
+func coordSystemHandler(cs cartconvert.CoordinateSystem) restHandler {
+	return func(req *GEOConvertRequest, coordstrval, oformat string) (interface{}, error) {
+		coord, err := cs.Parse(coordstrval)
+		if err != nil {
+			return nil, err
+		}
+
+		var latlong *cartconvert.PolarCoord
+		if latlong, err = cs.ToLatLong(coord); err != nil {
+			return nil, err
+		}
+		return serialize(latlong, oformat)
 	}
-	return serialize(osgb36.OSGB36ToWGS84LatLong(osgb36val), oformat)
 }
 
 // closure of the restful methods
@@ -350,15 +435,25 @@ type httphandlerfunc struct {
 	docstring string
 }
 
+// Every registered coordinate system is accessible by its name. Latitude and longitude
+// as well as tiles get passed as parameters, thus latlong and tile require a dedicated handler.
+// Short plus codes additionally require a reference location
 var httphandlerfuncs = map[string]httphandlerfunc{
-	"/latlong": {"/latlong", latlongHandler, "Latitude, Longitude"},
-	"/geohash": {"/geohash", geohashHandler, "Geohash"},
-	"/utm":     {"/utm", utmHandler, "UTM"},
-	"/bmn":     {"/bmn", bmnHandler, "AT:Bundesmeldenetz"},
-	"/osgb":    {"/osgb", osgbHandler, "UK:OSGB36"},
+	"/latlong":  {"/latlong", latlongHandler, "Latitude, Longitude"},
+	"/tile":     {"/tile", tileHandler, "Slippy map tile z/x/y"},
+	"/pluscode": {"/pluscode", pluscodeHandler, "Open Location Code (Plus Code)"},
 }
 
 func init() {
+	for _, name := range cartconvert.CoordinateSystems() {
+		method := "/" + name
+		if _, ok := httphandlerfuncs[method]; ok {
+			continue
+		}
+		cs, _ := cartconvert.LookupCoordinateSystem(name)
+		httphandlerfuncs[method] = httphandlerfunc{method, coordSystemHandler(cs), cs.Description()}
+	}
+
 	apirootLink = conf_apiroot()
 	http.HandleFunc(apirootLink+"/", apiHandler)
 
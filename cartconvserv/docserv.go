@@ -13,6 +13,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"runtime/debug"
 	"strconv"
@@ -20,14 +21,16 @@ import (
 
 // These constants specifiy the directory in which the documentation files are saved
 const (
-	docdefaultsetup = "default.tpl"
-	docmainTemplate = "index.tpl" // The main documentation file. Other filenames are created from the requested API documentation
-	doctemplateroot = templateroot + "doc/"
+	docdefaultsetup   = "default.tpl"
+	docmainTemplate   = "index.tpl"  // The main documentation file. Other filenames are created from the requested API documentation
+	docsystemTemplate = "system.tpl" // Documentation of API methods which come without a documentation file of their own
+	doctemplateroot   = templateroot + "doc/"
 )
 
 // defines the layout of a documentation page and is used by html/template
 type docPageLayout struct {
 	ConcreteHeading  string // Heading for detailed documentation pages
+	Method           string // API method of detailed documentation pages
 	APIRoot, DocRoot string // APIRoot is used for inline examples
 	Navigation       []Link // Keeps an url and the text for a href
 }
@@ -57,7 +60,13 @@ func docHandler(w http.ResponseWriter, req *http.Request) {
 	} else {
 		// else load the specific help template. The filename is constructed from the API function
 		filename = doctemplateroot + base + ".tpl"
-		docPage.ConcreteHeading = httphandlerfuncs[base].docstring
+		docPage.ConcreteHeading = httphandlerfuncs["/"+base].docstring
+		docPage.Method = base
+
+		// coordinate systems without a documentation file of their own get a generic one
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			filename = doctemplateroot + docsystemTemplate
+		}
 	}
 
 	tpl, err := template.ParseFiles(filename, doctemplateroot+docdefaultsetup)
@@ -0,0 +1,13 @@
+{{define "Back"}}..{{end}}{{define "Payload"}}
+  <header>
+    <h1><a href=".">Documentation for {{.ConcreteHeading}}</a></h1>
+  </header>
+  <h2>Usage</h2>
+  <p>
+    Convert a coordinate value from {{.ConcreteHeading}} by calling <code>{{.APIRoot}}/{{.Method}}/&lt;VALUE&gt;.[xml|json]?outputformat=&lt;FORMAT&gt;</code>.
+    Every coordinate system listed below is also a valid output format.
+  </p>
+  <h2>{{.ConcreteHeading}} API Documentation</h2>
+  <p><a href="https://github.com/the42/cartconvert/blob/master/cartconvserv/README.md">Documentation on Github</a> (authorative developer source)
+  </p>
+  {{end}}
@@ -8,8 +8,11 @@
 // The target reference ellipsoid is always the WGS84Ellipsoid
 //
 // Usage of ./conv
-//  -of="deg": specify output format. Possible values are:  dms  geohash  utc  deg
+//  -if="osgb36": specify input format
+//  -of="deg": specify output format
 //
+// Input and output formats are the names of the coordinate systems registered with the
+// cartconvert package, eg. bmn, osgb36, utm, geohash, deg or dms. Run "conv -h" for the full list.
 package main
 
 import (
@@ -17,56 +20,65 @@ import (
 	"flag"
 	"fmt"
 	"github.com/the42/cartconvert/cartconvert"
-	"github.com/the42/cartconvert/cartconvert/bmn"
-	"github.com/the42/cartconvert/cartconvert/osgb36"
+	_ "github.com/the42/cartconvert/cartconvert/bmn"
+	_ "github.com/the42/cartconvert/cartconvert/irishgrid"
+	_ "github.com/the42/cartconvert/cartconvert/lambert"
+	_ "github.com/the42/cartconvert/cartconvert/lv03p"
+	_ "github.com/the42/cartconvert/cartconvert/osgb36"
+	_ "github.com/the42/cartconvert/cartconvert/rd"
 	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
-type displayformat byte
+// Format names of conv which predate the coordinate system registry of cartconvert
+var formatAliases = map[string]string{"deg": "latlongcomma", "dms": "latlongdeg", "osgb36": "osgb"}
 
-const (
-	offmtunknown displayformat = iota
-	ofdeg
-	ofdms
-	ofutm
-	ofgeohash
-)
-
-type inputformat byte
-
-const (
-	ifbmn = iota
-	ifosgb36
-)
+// Returns the names of all registered coordinate systems and aliases thereof
+func formatNames() string {
+	names := cartconvert.CoordinateSystems()
+	for alias := range formatAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
 
-var ofOptions = map[string]displayformat{"deg": ofdeg, "dms": ofdms, "utm": ofutm, "geohash": ofgeohash}
-var ifOptions = map[string]inputformat{"bmn": ifbmn, "osgb36": ifosgb36}
+// Look up a coordinate system by its name or by an alias thereof
+func lookupFormat(name string) (cartconvert.CoordinateSystem, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := formatAliases[name]; ok {
+		name = alias
+	}
+	return cartconvert.LookupCoordinateSystem(name)
+}
 
 func main() {
 
 	var ofcmdlinespec, ifcmdlinespec string
-	var of displayformat
-	var ifm inputformat
 	var lines uint
-	var instring, outstring, ofparamvalues, ifparamvalues string
-	var pc *cartconvert.PolarCoord
-
-	for key, _ := range ofOptions {
-		ofparamvalues += fmt.Sprintf(" %s ", key)
-	}
+	var instring, outstring string
 
-	for key, _ := range ifOptions {
-		ifparamvalues += fmt.Sprintf(" %s ", key)
-	}
+	paramvalues := formatNames()
 
-	flag.StringVar(&ofcmdlinespec, "of", "deg", "specify output format. Possible values are: "+ofparamvalues)
-	flag.StringVar(&ifcmdlinespec, "if", "osgb36", "specify input format. Possible values are: "+ifparamvalues)
+	flag.StringVar(&ofcmdlinespec, "of", "deg", "specify output format. Possible values are: "+paramvalues)
+	flag.StringVar(&ifcmdlinespec, "if", "osgb36", "specify input format. Possible values are: "+paramvalues)
 	flag.Parse()
 
-	of = ofOptions[strings.ToLower(ofcmdlinespec)]
-	ifm = ifOptions[strings.ToLower(ifcmdlinespec)]
+	of, ok := lookupFormat(ofcmdlinespec)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Unrecognized output specifier")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ifm, ok := lookupFormat(ifcmdlinespec)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Unrecognized input specifier")
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	reader := bufio.NewReaderSize(os.Stdin, 100)
 	longline := false
@@ -95,47 +107,25 @@ func main() {
 			continue
 		}
 
-		switch ifm {
-		case ifbmn:
-
-			bmncoord, err := bmn.ABMNToStruct(instring)
-
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "BMN: error on line %d: %s\n", lines, err)
-				continue
-			}
-			pc, err = bmn.BMNToWGS84LatLong(bmncoord)
-
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "BMN: error on line %d: %s (BMN does not return a lat/long bearing)\n", lines, err)
-				continue
-			}
-		case ifosgb36:
-			osgb36coord, err := osgb36.AOSGB36ToStruct(instring, osgb36.OSGB36Auto)
-
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "OSGB36: error on line %d: %s\n", lines, err)
-				continue
-			}
-			pc = osgb36.OSGB36ToWGS84LatLong(osgb36coord)
+		coord, err := ifm.Parse(instring)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: error on line %d: %s\n", ifm.Description(), lines, err)
+			continue
 		}
 
-		switch of {
-		case ofdeg:
-			lat, long := cartconvert.LatLongToString(pc, cartconvert.LLFdeg)
-			outstring = lat + ", " + long
-		case ofdms:
-			outstring = pc.String()
-		case ofutm:
-			outstring = cartconvert.LatLongToUTM(pc).String()
-		case ofgeohash:
-			outstring = cartconvert.LatLongToGeoHash(pc)
-		default:
-			fmt.Fprintln(os.Stderr, "Unrecognized output specifier")
-			flag.Usage()
-			fmt.Fprintf(os.Stderr, "possible values are: [%s]\n", ofparamvalues)
-			fmt.Fprintln(os.Stderr, "]")
-			os.Exit(2)
+		pc, err := ifm.ToLatLong(coord)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: error on line %d: %s (%s does not return a lat/long bearing)\n", ifm.Description(), lines, err, ifm.Name())
+			continue
+		}
+
+		coord, err = of.FromLatLong(pc)
+		if err == nil {
+			outstring, err = of.Format(coord)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: error on line %d: %s\n", of.Description(), lines, err)
+			continue
 		}
 		fmt.Fprintf(os.Stdout, "%s\n", outstring)
 	}